@@ -0,0 +1,27 @@
+// Command realtime-schema writes the JSON Schema of the realtime messages,
+// for the frontend to validate what it receives against.
+package main
+
+import (
+	"family-potluck/backend/internal/realtime"
+	"flag"
+	"log"
+	"os"
+)
+
+func main() {
+	out := flag.String("o", "", "file to write the schema to (default stdout)")
+	flag.Parse()
+
+	schema, err := realtime.Schema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(schema)
+		return
+	}
+	if err := os.WriteFile(*out, schema, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"family-potluck/backend/internal/database"
 	"family-potluck/backend/internal/handlers"
+	"family-potluck/backend/internal/scheduler"
 	"family-potluck/backend/internal/websocket"
 	"fmt"
 	"log"
@@ -10,6 +12,9 @@ import (
 	"os"
 	"strings"
 	"time"
+	// Events are scheduled in their own time zones, which slim images
+	// don't have the database for
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
 )
@@ -27,60 +32,111 @@ func main() {
 	defer dbService.Close()
 
 	hub := websocket.NewHub()
+	hub.Config = websocket.ConfigFromEnv()
+	// With more than one backend instance, messages must go through Redis so
+	// that clients connected to any instance see them
+	if os.Getenv("REALTIME_BROKER") == "redis" {
+		hub.Broker = websocket.NewRedisBroker(os.Getenv("REDIS_ADDR"), os.Getenv("REDIS_PASSWORD"), os.Getenv("REDIS_CHANNEL"))
+	}
+	// Keep the replay history in Mongo so clients can resume across restarts
+	if os.Getenv("REALTIME_PERSIST_HISTORY") == "true" {
+		hub.Store = dbService
+	}
 	go hub.Run()
 
 	server := handlers.NewServer(dbService, hub)
 
+	// Finish events once they're over. With more than one backend instance,
+	// the one holding the job's lease in Mongo does it
+	jobs := scheduler.New(dbService)
+	jobs.Add(scheduler.Job{
+		Name:     "complete-ended-events",
+		Interval: scheduler.IntervalFromEnv("SCHEDULER_INTERVAL", 5*time.Minute),
+		Run:      server.CompleteEndedEvents,
+	})
+	go jobs.Run(context.Background())
+
 	mux := http.NewServeMux()
+	auth := func(next http.HandlerFunc) http.Handler {
+		return requireAuth(server, next)
+	}
 
-	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		hub.ServeWs(w, r)
-	})
+	hub.CheckOrigin = func(r *http.Request) bool {
+		return isAllowedOrigin(r.Header.Get("Origin"))
+	}
+	mux.HandleFunc("GET /ws", server.ServeWs)
+	mux.Handle("GET /ws/metrics", auth(server.GetRealtimeMetrics))
 
-	mux.HandleFunc("POST /auth/google", server.GoogleLogin)
+	mux.HandleFunc("GET /auth/providers", server.GetAuthProviders)
+	mux.HandleFunc("POST /auth/{provider}", server.Login)
 	mux.HandleFunc("POST /auth/logout", server.Logout)
-	mux.HandleFunc("GET /auth/me", server.GetMe)
-	mux.HandleFunc("POST /groups", server.CreateGroup)
-	mux.HandleFunc("POST /groups/leave", server.LeaveGroup)
-	mux.HandleFunc("POST /groups/join-by-code", server.JoinGroupByCode)
+	mux.HandleFunc("POST /auth/refresh", server.RefreshSession)
+	mux.Handle("GET /auth/me", auth(server.GetMe))
+	mux.Handle("POST /auth/identities/{provider}", auth(server.LinkIdentity))
+	mux.Handle("POST /auth/merge/{provider}", auth(server.MergeAccount))
+	mux.Handle("GET /auth/sessions", auth(server.GetSessions))
+	mux.Handle("DELETE /auth/sessions", auth(server.RevokeAllSessions))
+	mux.Handle("DELETE /auth/sessions/{id}", auth(server.RevokeSession))
+	mux.Handle("GET /auth/tokens", auth(server.GetAPITokens))
+	mux.Handle("POST /auth/tokens", auth(server.CreateAPIToken))
+	mux.Handle("DELETE /auth/tokens/{id}", auth(server.DeleteAPIToken))
+	mux.Handle("POST /auth/calendar", auth(server.CreateCalendarToken))
+	mux.Handle("DELETE /auth/calendar", auth(server.DeleteCalendarToken))
+	mux.HandleFunc("GET /calendar/{token}/events.ics", server.GetUserCalendar)
+	mux.HandleFunc("GET /calendar/{token}/groups/{file}", server.GetGroupCalendar)
+	mux.Handle("POST /groups", auth(server.CreateGroup))
+	mux.Handle("POST /groups/leave", auth(server.LeaveGroup))
+	mux.Handle("POST /groups/join-by-code", auth(server.JoinGroupByCode))
 	mux.HandleFunc("GET /groups/code/{code}", server.GetGroupByCode)
-	mux.HandleFunc("DELETE /groups/{id}", server.DeleteGroup)
-	mux.HandleFunc("PATCH /groups/{id}", server.UpdateGroup)
-	mux.HandleFunc("GET /groups/{id}", server.GetGroup)
-	mux.HandleFunc("GET /groups", server.GetGroups)
-	mux.HandleFunc("GET /families", server.GetFamilyMember)
-	mux.HandleFunc("PATCH /families/{id}", server.UpdateFamilyMember)
-	mux.HandleFunc("POST /events", server.CreateEvent)
-	mux.HandleFunc("POST /events/{id}/finish", server.FinishEvent)
-	mux.HandleFunc("POST /events/{id}/skip", server.SkipEvent)
-	mux.HandleFunc("DELETE /events/{id}", server.DeleteEvent)
-	mux.HandleFunc("GET /events/{id}", server.GetEvent)
-	mux.HandleFunc("PATCH /events/{id}", server.UpdateEvent)
-	mux.HandleFunc("GET /events/stats/{id}", server.GetEventStats)
-	mux.HandleFunc("GET /events", server.GetEvents)
-	mux.HandleFunc("GET /events/user", server.GetUserEvents)
-	mux.HandleFunc("POST /events/join-by-code", server.JoinEventByCode)
+	mux.Handle("DELETE /groups/{id}", auth(server.DeleteGroup))
+	mux.Handle("PATCH /groups/{id}", auth(server.UpdateGroup))
+	mux.Handle("GET /groups/{id}", auth(server.GetGroup))
+	mux.Handle("GET /groups/{id}/{sub}", subresources(map[string]http.Handler{
+		"stream": auth(server.StreamGroup),
+	}))
+	mux.Handle("GET /groups", auth(server.GetGroups))
+	mux.Handle("GET /families", auth(server.GetFamilyMember))
+	mux.Handle("PATCH /families/{id}", auth(server.UpdateFamilyMember))
+	mux.Handle("POST /events", auth(server.CreateEvent))
+	mux.Handle("POST /events/import", auth(server.ImportEvents))
+	mux.Handle("POST /events/{id}/finish", auth(server.FinishEvent))
+	mux.Handle("POST /events/{id}/skip", auth(server.SkipEvent))
+	mux.Handle("POST /events/{id}/cancel", auth(server.CancelEvent))
+	mux.Handle("POST /events/{id}/uncancel", auth(server.UncancelEvent))
+	mux.Handle("DELETE /events/{id}", auth(server.DeleteEvent))
+	mux.Handle("GET /events/{id}", auth(server.GetEvent))
+	mux.Handle("PATCH /events/{id}", auth(server.UpdateEvent))
+	mux.Handle("GET /events/stats/{id}", auth(server.GetEventStats))
+	mux.Handle("GET /events/{id}/{sub}", subresources(map[string]http.Handler{
+		"stream":      auth(server.StreamEvent),
+		"presence":    auth(server.GetEventPresence),
+		"occurrences": auth(server.GetEventOccurrences),
+		"hosts":       auth(server.GetEventHosts),
+	}))
+	mux.Handle("GET /events", auth(server.GetEvents))
+	mux.Handle("GET /events/user", auth(server.GetUserEvents))
+	mux.Handle("POST /events/join-by-code", auth(server.JoinEventByCode))
 	mux.HandleFunc("GET /events/code/{code}", server.GetEventByCode)
-	mux.HandleFunc("POST /rsvps", server.RSVPEvent)
-	mux.HandleFunc("GET /rsvps", server.GetRSVPs)
-	mux.HandleFunc("GET /groups/members", server.GetGroupMembers)
-	mux.HandleFunc("POST /dishes", server.AddDish)
-	mux.HandleFunc("GET /dishes", server.GetDishes)
-	mux.HandleFunc("POST /dishes/{id}/pledge", server.PledgeDish)
-	mux.HandleFunc("POST /dishes/{id}/unpledge", server.UnpledgeDish)
-	mux.HandleFunc("DELETE /dishes/{id}", server.DeleteDish)
-	mux.HandleFunc("POST /swaps", server.CreateSwapRequest)
-	mux.HandleFunc("PATCH /swaps/{id}", server.UpdateSwapRequest)
-	mux.HandleFunc("GET /swaps", server.GetSwapRequests)
-	mux.HandleFunc("POST /chat/messages", server.SendChatMessage)
-	mux.HandleFunc("GET /chat/messages", server.GetChatMessages)
-	mux.HandleFunc("POST /households", server.CreateHousehold)
-	mux.HandleFunc("POST /households/join", server.JoinHousehold)
-	mux.HandleFunc("POST /households/add-member", server.AddMemberToHousehold)
-	mux.HandleFunc("GET /households/{id}", server.GetHousehold)
-	mux.HandleFunc("DELETE /households/{id}", server.DeleteHousehold)
-	mux.HandleFunc("PATCH /households/{id}", server.UpdateHousehold)
-	mux.HandleFunc("POST /households/remove-member", server.RemoveMemberFromHousehold)
+	mux.Handle("POST /rsvps", auth(server.RSVPEvent))
+	mux.Handle("GET /rsvps", auth(server.GetRSVPs))
+	mux.Handle("GET /groups/members", auth(server.GetGroupMembers))
+	mux.Handle("POST /dishes", auth(server.AddDish))
+	mux.Handle("GET /dishes", auth(server.GetDishes))
+	mux.Handle("POST /dishes/{id}/pledge", auth(server.PledgeDish))
+	mux.Handle("POST /dishes/{id}/unpledge", auth(server.UnpledgeDish))
+	mux.Handle("DELETE /dishes/{id}", auth(server.DeleteDish))
+	mux.Handle("POST /swaps", auth(server.CreateSwapRequest))
+	mux.Handle("PATCH /swaps/{id}", auth(server.UpdateSwapRequest))
+	mux.Handle("GET /swaps", auth(server.GetSwapRequests))
+	mux.Handle("POST /chat/messages", auth(server.SendChatMessage))
+	mux.Handle("GET /chat/messages", auth(server.GetChatMessages))
+	mux.Handle("POST /households", auth(server.CreateHousehold))
+	mux.Handle("POST /households/join", auth(server.JoinHousehold))
+	mux.Handle("POST /households/add-member", auth(server.AddMemberToHousehold))
+	mux.Handle("GET /households/{id}", auth(server.GetHousehold))
+	mux.Handle("DELETE /households/{id}", auth(server.DeleteHousehold))
+	mux.Handle("PATCH /households/{id}", auth(server.UpdateHousehold))
+	mux.Handle("POST /households/remove-member", auth(server.RemoveMemberFromHousehold))
 	mux.HandleFunc("GET /health", server.HealthHandler)
 	mux.HandleFunc("GET /version", server.GetVersion)
 
@@ -97,27 +153,63 @@ func main() {
 	}
 }
 
-func enableCORS(next http.Handler) http.Handler {
+// requireAuth authenticates the request from the token cookie or an
+// Authorization: Bearer header, checks API token scopes against the matched
+// route, and stores the caller in the request context.
+func requireAuth(server *handlers.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := server.Authenticate(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !principal.Allows(r.Pattern) {
+			http.Error(w, "API token does not have the required scope", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(handlers.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// subresources serves "GET /<collection>/{id}/{sub}" by the name in {sub}. The
+// mux rejects a route like "GET /events/{id}/stream" as ambiguous next to
+// "GET /events/stats/{id}"; a single {sub} route doesn't conflict, because the
+// literal routes are more specific and still take precedence.
+func subresources(routes map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next, ok := routes[r.PathValue("sub")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isAllowedOrigin reports whether origin is listed in ALLOWED_ORIGINS (or
+// ALLOWED_ORIGINS is "*"). Requests without an Origin header are not from a
+// browser and are allowed.
+func isAllowedOrigin(origin string) bool {
 	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
-	origins := strings.Split(allowedOrigins, ",")
-	for i, o := range origins {
-		origins[i] = strings.TrimSpace(o)
+	if origin == "" || allowedOrigins == "*" {
+		return true
 	}
+	origins := strings.Split(allowedOrigins, ",")
 	origins = append(origins, "https://gather.ramjin.com")
+	for _, o := range origins {
+		if strings.TrimSpace(o) == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func enableCORS(next http.Handler) http.Handler {
+	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
-		isAllowed := false
-		if allowedOrigins == "*" {
-			isAllowed = true
-		} else {
-			for _, o := range origins {
-				if o == origin {
-					isAllowed = true
-					break
-				}
-			}
-		}
+		isAllowed := isAllowedOrigin(origin)
 
 		if isAllowed && origin != "" {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
@@ -126,7 +218,7 @@ func enableCORS(next http.Handler) http.Handler {
 		}
 
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// Security Headers
@@ -0,0 +1,93 @@
+package authz
+
+import (
+	"context"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Action names something a FamilyMember may attempt on a Resource.
+type Action string
+
+const (
+	EditEvent       Action = "event:edit"
+	DeleteEvent     Action = "event:delete"
+	DeleteDish      Action = "dish:delete"
+	ManageGroup     Action = "group:manage"
+	ManageHousehold Action = "household:manage"
+	PostChatMessage Action = "chat:post"
+	ViewGroup       Action = "group:view"
+	ViewEvent       Action = "event:view"
+)
+
+// Resource is the object an Action is performed on. Only the fields relevant
+// to the action need to be set.
+type Resource struct {
+	Event       *models.Event
+	Dish        *models.Dish
+	GroupID     primitive.ObjectID
+	HouseholdID primitive.ObjectID
+}
+
+func (r Resource) groupID() primitive.ObjectID {
+	if r.Event != nil {
+		return r.Event.GroupID
+	}
+	return r.GroupID
+}
+
+type Authorizer struct {
+	DB database.Service
+}
+
+func New(db database.Service) *Authorizer {
+	return &Authorizer{DB: db}
+}
+
+// Can reports whether actor may perform action on res. An error is returned
+// only when a lookup the decision depends on fails.
+func (a *Authorizer) Can(ctx context.Context, actor *models.FamilyMember, action Action, res Resource) (bool, error) {
+	rule, ok := policies[action]
+	if !ok || actor == nil {
+		return false, nil
+	}
+	return rule(&evaluation{ctx: ctx, db: a.DB, actor: actor, res: res})
+}
+
+func (a *Authorizer) CanEditEvent(ctx context.Context, actor *models.FamilyMember, event *models.Event) (bool, error) {
+	return a.Can(ctx, actor, EditEvent, Resource{Event: event})
+}
+
+func (a *Authorizer) CanDeleteEvent(ctx context.Context, actor *models.FamilyMember, event *models.Event) (bool, error) {
+	return a.Can(ctx, actor, DeleteEvent, Resource{Event: event})
+}
+
+func (a *Authorizer) CanDeleteDish(ctx context.Context, actor *models.FamilyMember, dish *models.Dish, event *models.Event) (bool, error) {
+	return a.Can(ctx, actor, DeleteDish, Resource{Event: event, Dish: dish})
+}
+
+func (a *Authorizer) CanManageGroup(ctx context.Context, actor *models.FamilyMember, groupID primitive.ObjectID) (bool, error) {
+	return a.Can(ctx, actor, ManageGroup, Resource{GroupID: groupID})
+}
+
+// CanManageHousehold allows household members, or admins of groupID when it is
+// provided and someone in the household belongs to it, to change the
+// household.
+func (a *Authorizer) CanManageHousehold(ctx context.Context, actor *models.FamilyMember, householdID, groupID primitive.ObjectID) (bool, error) {
+	return a.Can(ctx, actor, ManageHousehold, Resource{HouseholdID: householdID, GroupID: groupID})
+}
+
+func (a *Authorizer) CanPostChatMessage(ctx context.Context, actor *models.FamilyMember, event *models.Event) (bool, error) {
+	return a.Can(ctx, actor, PostChatMessage, Resource{Event: event})
+}
+
+func (a *Authorizer) CanViewGroup(ctx context.Context, actor *models.FamilyMember, groupID primitive.ObjectID) (bool, error) {
+	return a.Can(ctx, actor, ViewGroup, Resource{GroupID: groupID})
+}
+
+// CanViewEvent allows members of the event's group and its invited guests.
+func (a *Authorizer) CanViewEvent(ctx context.Context, actor *models.FamilyMember, event *models.Event) (bool, error) {
+	return a.Can(ctx, actor, ViewEvent, Resource{Event: event})
+}
@@ -0,0 +1,192 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestAuthorizer(group *models.Group, members map[primitive.ObjectID]*models.FamilyMember, households map[primitive.ObjectID]*models.Household) *Authorizer {
+	mockDB := &database.MockService{}
+	mockDB.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		if group == nil || group.ID != id {
+			return nil, database.ErrNoDocuments
+		}
+		return group, nil
+	}
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		if m, ok := members[id]; ok {
+			return m, nil
+		}
+		return nil, database.ErrNoDocuments
+	}
+	mockDB.GetFamilyMembersByIDsFunc = func(ctx context.Context, ids []primitive.ObjectID) ([]models.FamilyMember, error) {
+		found := []models.FamilyMember{}
+		for _, id := range ids {
+			if m, ok := members[id]; ok {
+				found = append(found, *m)
+			}
+		}
+		return found, nil
+	}
+	mockDB.GetHouseholdFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Household, error) {
+		if h, ok := households[id]; ok {
+			return h, nil
+		}
+		return nil, database.ErrNoDocuments
+	}
+	return New(mockDB)
+}
+
+func TestEventPolicies(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	householdID := primitive.NewObjectID()
+
+	admin := &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}}
+	host := &models.FamilyMember{ID: primitive.NewObjectID(), HouseholdID: &householdID, GroupIDs: []primitive.ObjectID{groupID}}
+	housemate := &models.FamilyMember{ID: primitive.NewObjectID(), HouseholdID: &householdID, GroupIDs: []primitive.ObjectID{groupID}}
+	member := &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}}
+	outsider := &models.FamilyMember{ID: primitive.NewObjectID()}
+	guest := &models.FamilyMember{ID: primitive.NewObjectID()}
+
+	group := &models.Group{ID: groupID, AdminIDs: []primitive.ObjectID{admin.ID}}
+	members := map[primitive.ObjectID]*models.FamilyMember{host.ID: host}
+	a := newTestAuthorizer(group, members, nil)
+
+	oneOff := &models.Event{ID: primitive.NewObjectID(), GroupID: groupID, HostID: host.ID, GuestIDs: []primitive.ObjectID{guest.ID}}
+	recurring := &models.Event{ID: primitive.NewObjectID(), GroupID: groupID, HostID: host.ID, Recurrence: "Weekly"}
+
+	tests := []struct {
+		name   string
+		action Action
+		actor  *models.FamilyMember
+		event  *models.Event
+		want   bool
+	}{
+		{"admin edits", EditEvent, admin, oneOff, true},
+		{"host edits", EditEvent, host, oneOff, true},
+		{"housemate edits", EditEvent, housemate, recurring, true},
+		{"member cannot edit", EditEvent, member, oneOff, false},
+		{"admin deletes recurring", DeleteEvent, admin, recurring, true},
+		{"host deletes one-off", DeleteEvent, host, oneOff, true},
+		{"housemate deletes one-off", DeleteEvent, housemate, oneOff, true},
+		{"host cannot delete recurring", DeleteEvent, host, recurring, false},
+		{"housemate cannot delete recurring", DeleteEvent, housemate, recurring, false},
+		{"member cannot delete", DeleteEvent, member, oneOff, false},
+		{"member chats", PostChatMessage, member, oneOff, true},
+		{"outsider cannot chat", PostChatMessage, outsider, oneOff, false},
+		{"member views", ViewEvent, member, oneOff, true},
+		{"guest views", ViewEvent, guest, oneOff, true},
+		{"guest views only invited event", ViewEvent, guest, recurring, false},
+		{"outsider cannot view", ViewEvent, outsider, oneOff, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Can(context.Background(), tt.actor, tt.action, Resource{Event: tt.event})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Can(%s) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanDeleteDish(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	host := &models.FamilyMember{ID: primitive.NewObjectID()}
+	bringer := &models.FamilyMember{ID: primitive.NewObjectID()}
+	member := &models.FamilyMember{ID: primitive.NewObjectID()}
+
+	a := newTestAuthorizer(&models.Group{ID: groupID}, map[primitive.ObjectID]*models.FamilyMember{host.ID: host}, nil)
+	event := &models.Event{ID: primitive.NewObjectID(), GroupID: groupID, HostID: host.ID}
+
+	tests := []struct {
+		name  string
+		actor *models.FamilyMember
+		dish  *models.Dish
+		want  bool
+	}{
+		{"bringer", bringer, &models.Dish{BringerID: &bringer.ID}, true},
+		{"host", host, &models.Dish{BringerID: &bringer.ID}, true},
+		{"other member", member, &models.Dish{BringerID: &bringer.ID}, false},
+		{"unclaimed dish", member, &models.Dish{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.CanDeleteDish(context.Background(), tt.actor, tt.dish, event)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CanDeleteDish() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanManageHousehold(t *testing.T) {
+	groupID := primitive.NewObjectID()
+	householdID := primitive.NewObjectID()
+	otherHouseholdID := primitive.NewObjectID()
+	admin := &models.FamilyMember{ID: primitive.NewObjectID()}
+	resident := &models.FamilyMember{ID: primitive.NewObjectID(), HouseholdID: &householdID, GroupIDs: []primitive.ObjectID{groupID}}
+	listedResident := &models.FamilyMember{ID: primitive.NewObjectID()}
+	stranger := &models.FamilyMember{ID: primitive.NewObjectID()}
+	otherResident := &models.FamilyMember{ID: primitive.NewObjectID(), HouseholdID: &otherHouseholdID, GroupIDs: []primitive.ObjectID{primitive.NewObjectID()}}
+
+	households := map[primitive.ObjectID]*models.Household{
+		householdID:      {ID: householdID, MemberIDs: []primitive.ObjectID{resident.ID, listedResident.ID}},
+		otherHouseholdID: {ID: otherHouseholdID, MemberIDs: []primitive.ObjectID{otherResident.ID}},
+	}
+	members := map[primitive.ObjectID]*models.FamilyMember{resident.ID: resident, listedResident.ID: listedResident, otherResident.ID: otherResident}
+	a := newTestAuthorizer(&models.Group{ID: groupID, AdminIDs: []primitive.ObjectID{admin.ID}}, members, households)
+
+	tests := []struct {
+		name        string
+		actor       *models.FamilyMember
+		householdID primitive.ObjectID
+		groupID     primitive.ObjectID
+		want        bool
+	}{
+		{"resident", resident, householdID, primitive.NilObjectID, true},
+		{"listed in member_ids", listedResident, householdID, primitive.NilObjectID, true},
+		{"admin of the household's group", admin, householdID, groupID, true},
+		{"admin without group", admin, householdID, primitive.NilObjectID, false},
+		{"admin of an unrelated group", admin, otherHouseholdID, groupID, false},
+		{"admin of a missing household", admin, primitive.NewObjectID(), groupID, false},
+		{"stranger", stranger, householdID, groupID, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.CanManageHousehold(context.Background(), tt.actor, tt.householdID, tt.groupID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CanManageHousehold() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCan_GroupLookupError(t *testing.T) {
+	mockDB := &database.MockService{}
+	mockDB.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		return nil, errors.New("db down")
+	}
+	a := New(mockDB)
+
+	_, err := a.CanManageGroup(context.Background(), &models.FamilyMember{ID: primitive.NewObjectID()}, primitive.NewObjectID())
+	if err == nil {
+		t.Error("expected lookup error to be returned")
+	}
+}
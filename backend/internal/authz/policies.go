@@ -0,0 +1,190 @@
+package authz
+
+import (
+	"context"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+)
+
+// policies is the single place where authorization rules are defined.
+var policies = map[Action]rule{
+	EditEvent: anyOf(isEventHost, isGroupAdmin, inHostHousehold),
+	// Recurring events can only be deleted by admins
+	DeleteEvent:     anyOf(isGroupAdmin, allOf(isNotRecurring, anyOf(isEventHost, inHostHousehold))),
+	DeleteDish:      anyOf(isDishBringer, isEventHost, isGroupAdmin, inHostHousehold),
+	ManageGroup:     isGroupAdmin,
+	ManageHousehold: anyOf(isHouseholdMember, allOf(isGroupAdmin, isHouseholdInGroup)),
+	PostChatMessage: isGroupMember,
+	ViewGroup:       isGroupMember,
+	ViewEvent:       anyOf(isGroupMember, isEventGuest),
+}
+
+type rule func(e *evaluation) (bool, error)
+
+// evaluation carries one authorization decision and caches the lookups made
+// while evaluating its rules.
+type evaluation struct {
+	ctx   context.Context
+	db    database.Service
+	actor *models.FamilyMember
+	res   Resource
+
+	group     *models.Group
+	household *models.Household
+}
+
+func (e *evaluation) loadGroup() (*models.Group, error) {
+	if e.group == nil {
+		group, err := e.db.GetGroup(e.ctx, e.res.groupID())
+		if err != nil {
+			return nil, err
+		}
+		e.group = group
+	}
+	return e.group, nil
+}
+
+func (e *evaluation) loadHousehold() (*models.Household, error) {
+	if e.household == nil {
+		household, err := e.db.GetHousehold(e.ctx, e.res.HouseholdID)
+		if err != nil {
+			return nil, err
+		}
+		e.household = household
+	}
+	return e.household, nil
+}
+
+func anyOf(rules ...rule) rule {
+	return func(e *evaluation) (bool, error) {
+		for _, r := range rules {
+			ok, err := r(e)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+func allOf(rules ...rule) rule {
+	return func(e *evaluation) (bool, error) {
+		for _, r := range rules {
+			ok, err := r(e)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+func isGroupAdmin(e *evaluation) (bool, error) {
+	if e.res.groupID().IsZero() {
+		return false, nil
+	}
+	group, err := e.loadGroup()
+	if err != nil {
+		return false, err
+	}
+	for _, id := range group.AdminIDs {
+		if id == e.actor.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isGroupMember(e *evaluation) (bool, error) {
+	groupID := e.res.groupID()
+	for _, id := range e.actor.GroupIDs {
+		if id == groupID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isEventHost(e *evaluation) (bool, error) {
+	return e.res.Event != nil && e.res.Event.HostID == e.actor.ID, nil
+}
+
+func isEventGuest(e *evaluation) (bool, error) {
+	if e.res.Event == nil {
+		return false, nil
+	}
+	for _, id := range e.res.Event.GuestIDs {
+		if id == e.actor.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func inHostHousehold(e *evaluation) (bool, error) {
+	if e.res.Event == nil || e.actor.HouseholdID == nil {
+		return false, nil
+	}
+	host, err := e.db.GetFamilyMemberByID(e.ctx, e.res.Event.HostID)
+	if err != nil {
+		// A missing host simply has no household to share
+		return false, nil
+	}
+	return host.HouseholdID != nil && *host.HouseholdID == *e.actor.HouseholdID, nil
+}
+
+func isNotRecurring(e *evaluation) (bool, error) {
+	return e.res.Event != nil && e.res.Event.Recurrence == "", nil
+}
+
+func isDishBringer(e *evaluation) (bool, error) {
+	return e.res.Dish != nil && e.res.Dish.BringerID != nil && *e.res.Dish.BringerID == e.actor.ID, nil
+}
+
+func isHouseholdMember(e *evaluation) (bool, error) {
+	if e.res.HouseholdID.IsZero() {
+		return false, nil
+	}
+	if e.actor.HouseholdID != nil && *e.actor.HouseholdID == e.res.HouseholdID {
+		return true, nil
+	}
+	household, err := e.loadHousehold()
+	if err != nil {
+		return false, nil
+	}
+	for _, id := range household.MemberIDs {
+		if id == e.actor.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isHouseholdInGroup reports whether someone in the household belongs to the
+// group, so that group's admins can't reach households outside it.
+func isHouseholdInGroup(e *evaluation) (bool, error) {
+	groupID := e.res.groupID()
+	if e.res.HouseholdID.IsZero() || groupID.IsZero() {
+		return false, nil
+	}
+	household, err := e.loadHousehold()
+	if err != nil || len(household.MemberIDs) == 0 {
+		// A missing household has no one in the group
+		return false, nil
+	}
+	residents, err := e.db.GetFamilyMembersByIDs(e.ctx, household.MemberIDs)
+	if err != nil {
+		return false, err
+	}
+	for _, resident := range residents {
+		for _, id := range resident.GroupIDs {
+			if id == groupID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,59 @@
+package authz
+
+import "strings"
+
+// Personal API tokens are limited by scopes of the form "<resource>:read" and
+// "<resource>:write", where the resource is the first path segment of a route.
+// GET routes need the read scope and everything else the write scope; write
+// implies read.
+var scopeResources = []string{"events", "dishes", "rsvps", "chat", "swaps", "groups", "households", "families"}
+
+// Scopes lists every scope a token may be granted.
+func Scopes() []string {
+	scopes := make([]string, 0, len(scopeResources)*2)
+	for _, resource := range scopeResources {
+		scopes = append(scopes, resource+":read", resource+":write")
+	}
+	return scopes
+}
+
+func ValidScope(scope string) bool {
+	for _, s := range Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredScope returns the scope needed to call the route registered as
+// pattern, e.g. "POST /dishes/{id}/pledge" needs "dishes:write". ok is false for
+// routes API tokens may never call, such as everything under /auth.
+func RequiredScope(pattern string) (scope string, ok bool) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+	resource, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	for _, r := range scopeResources {
+		if r == resource {
+			if method == "GET" {
+				return resource + ":read", true
+			}
+			return resource + ":write", true
+		}
+	}
+	return "", false
+}
+
+// HasScope reports whether the granted scopes satisfy required.
+func HasScope(granted []string, required string) bool {
+	resource, level, _ := strings.Cut(required, ":")
+	for _, g := range granted {
+		if g == required || (level == "read" && g == resource+":write") {
+			return true
+		}
+	}
+	return false
+}
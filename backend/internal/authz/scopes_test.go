@@ -0,0 +1,50 @@
+package authz
+
+import "testing"
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		wantOK  bool
+	}{
+		{"GET /events/{id}", "events:read", true},
+		{"PATCH /events/{id}", "events:write", true},
+		{"POST /dishes/{id}/pledge", "dishes:write", true},
+		{"GET /chat/messages", "chat:read", true},
+		{"GET /auth/me", "", false},
+		{"DELETE /auth/tokens/{id}", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, ok := RequiredScope(tt.pattern)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("RequiredScope(%q) = %q, %v; want %q, %v", tt.pattern, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"exact", []string{"events:read"}, "events:read", true},
+		{"write implies read", []string{"dishes:write"}, "dishes:read", true},
+		{"read does not imply write", []string{"dishes:read"}, "dishes:write", false},
+		{"other resource", []string{"events:write"}, "dishes:read", false},
+		{"none", nil, "events:read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasScope(tt.granted, tt.required); got != tt.want {
+				t.Errorf("HasScope(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
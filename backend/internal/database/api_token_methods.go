@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"family-potluck/backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *service) CreateAPIToken(ctx context.Context, token *models.APIToken) error {
+	_, err := s.db.Collection("api_tokens").InsertOne(ctx, token)
+	return err
+}
+
+func (s *service) GetAPITokenByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	err := s.db.Collection("api_tokens").FindOne(ctx, bson.M{"token_hash": hash}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *service) GetAPITokensByFamilyMemberID(ctx context.Context, familyMemberID primitive.ObjectID) ([]models.APIToken, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := s.db.Collection("api_tokens").Find(ctx, bson.M{"family_id": familyMemberID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var tokens []models.APIToken
+	if err = cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// DeleteAPIToken deletes the token only if it belongs to familyMemberID and
+// reports whether anything was deleted.
+func (s *service) DeleteAPIToken(ctx context.Context, id, familyMemberID primitive.ObjectID) (bool, error) {
+	result, err := s.db.Collection("api_tokens").DeleteOne(ctx, bson.M{"_id": id, "family_id": familyMemberID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount == 1, nil
+}
+
+func (s *service) TouchAPIToken(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error {
+	_, err := s.db.Collection("api_tokens").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": usedAt}})
+	return err
+}
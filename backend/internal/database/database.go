@@ -23,11 +23,14 @@ type Service interface {
 	// FamilyMembers
 	GetFamilyMemberByEmail(ctx context.Context, email string) (*models.FamilyMember, error)
 	GetFamilyMemberByID(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error)
+	GetFamilyMemberByIdentity(ctx context.Context, provider, subject string) (*models.FamilyMember, error)
+	GetFamilyMemberByCalendarToken(ctx context.Context, hash string) (*models.FamilyMember, error)
 	CreateFamilyMember(ctx context.Context, familyMember *models.FamilyMember) error
 	UpdateFamilyMember(ctx context.Context, id primitive.ObjectID, update bson.M) error
 	GetFamilyMembersByGroupID(ctx context.Context, groupID primitive.ObjectID) ([]models.FamilyMember, error)
 	GetFamilyMembersByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.FamilyMember, error)
 	RemoveGroupIDFromAllFamilyMembers(ctx context.Context, groupID primitive.ObjectID) error
+	MergeFamilyMembers(ctx context.Context, sourceID, targetID primitive.ObjectID) error
 
 	// Groups
 	CountGroupsByName(ctx context.Context, name string) (int64, error)
@@ -45,8 +48,19 @@ type Service interface {
 	GetEventsByGroupID(ctx context.Context, groupID primitive.ObjectID, includeCompleted bool) ([]models.Event, error)
 	GetEventsByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Event, error)
 	UpdateEvent(ctx context.Context, id primitive.ObjectID, update bson.M) error
+	CompleteEvent(ctx context.Context, id primitive.ObjectID) (bool, error)
 	DeleteEvent(ctx context.Context, id primitive.ObjectID) error
 	GetCompletedEventsByRecurrenceID(ctx context.Context, recurrenceID primitive.ObjectID) ([]models.Event, error)
+	GetEventsByRecurrenceID(ctx context.Context, recurrenceID primitive.ObjectID) ([]models.Event, error)
+	GetUnfinishedEventsBefore(ctx context.Context, before time.Time) ([]models.Event, error)
+
+	// Series
+	CreateSeries(ctx context.Context, series *models.Series) error
+	GetSeries(ctx context.Context, id primitive.ObjectID) (*models.Series, error)
+	GetSeriesByGroupID(ctx context.Context, groupID primitive.ObjectID) ([]models.Series, error)
+	UpdateSeries(ctx context.Context, id primitive.ObjectID, update bson.M) error
+	DeleteSeries(ctx context.Context, id primitive.ObjectID) error
+	CreateOccurrence(ctx context.Context, event *models.Event) (bool, error)
 
 	// Dishes
 	CreateDish(ctx context.Context, dish *models.Dish) error
@@ -75,6 +89,29 @@ type Service interface {
 	UpdateHousehold(ctx context.Context, id primitive.ObjectID, update bson.M) error
 	DeleteHousehold(ctx context.Context, id primitive.ObjectID) error
 	RemoveMemberFromHousehold(ctx context.Context, householdID, familyMemberID primitive.ObjectID) error
+
+	// Sessions
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSessionByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	GetSessionByRefreshTokenHash(ctx context.Context, hash string) (*models.Session, error)
+	GetActiveSessionsByFamilyMemberID(ctx context.Context, familyMemberID primitive.ObjectID) ([]models.Session, error)
+	RotateSessionRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	RevokeSession(ctx context.Context, id primitive.ObjectID) error
+	RevokeSessionsByFamilyMemberID(ctx context.Context, familyMemberID primitive.ObjectID) error
+
+	// API tokens
+	CreateAPIToken(ctx context.Context, token *models.APIToken) error
+	GetAPITokenByHash(ctx context.Context, hash string) (*models.APIToken, error)
+	GetAPITokensByFamilyMemberID(ctx context.Context, familyMemberID primitive.ObjectID) ([]models.APIToken, error)
+	DeleteAPIToken(ctx context.Context, id, familyMemberID primitive.ObjectID) (bool, error)
+	TouchAPIToken(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error
+
+	// Realtime log
+	AppendRealtimeMessage(ctx context.Context, msg *models.RealtimeMessage, keep int) error
+	GetRecentRealtimeMessages(ctx context.Context, topic string, limit int) ([]models.RealtimeMessage, error)
+
+	// Leases
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
 }
 
 type service struct {
@@ -149,6 +186,32 @@ func (s *service) GetFamilyMemberByID(ctx context.Context, id primitive.ObjectID
 	return &familyMember, nil
 }
 
+// GetFamilyMemberByIdentity finds the member a provider subject is linked to.
+// Members created before identities were tracked are matched on google_id.
+func (s *service) GetFamilyMemberByIdentity(ctx context.Context, provider, subject string) (*models.FamilyMember, error) {
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}}
+	if provider == "google" {
+		filter = bson.M{"$or": []bson.M{filter, {"google_id": subject}}}
+	}
+	var familyMember models.FamilyMember
+	err := s.db.Collection("families").FindOne(ctx, filter).Decode(&familyMember)
+	if err != nil {
+		return nil, err
+	}
+	return &familyMember, nil
+}
+
+// GetFamilyMemberByCalendarToken finds the member whose calendar feed secret
+// hashes to hash.
+func (s *service) GetFamilyMemberByCalendarToken(ctx context.Context, hash string) (*models.FamilyMember, error) {
+	var familyMember models.FamilyMember
+	err := s.db.Collection("families").FindOne(ctx, bson.M{"calendar_token_hash": hash}).Decode(&familyMember)
+	if err != nil {
+		return nil, err
+	}
+	return &familyMember, nil
+}
+
 func (s *service) CreateFamilyMember(ctx context.Context, familyMember *models.FamilyMember) error {
 	_, err := s.db.Collection("families").InsertOne(ctx, familyMember)
 	return err
@@ -306,6 +369,26 @@ func (s *service) GetEventsByGroupID(ctx context.Context, groupID primitive.Obje
 	return events, nil
 }
 
+// GetUnfinishedEventsBefore returns the events starting before the given time
+// that haven't been completed or cancelled, oldest first.
+func (s *service) GetUnfinishedEventsBefore(ctx context.Context, before time.Time) ([]models.Event, error) {
+	filter := bson.M{
+		"date":   bson.M{"$lt": before},
+		"status": bson.M{"$nin": []string{"completed", "cancelled"}},
+	}
+	opts := options.Find().SetSort(bson.M{"date": 1})
+	cursor, err := s.db.Collection("events").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var events []models.Event
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
 func (s *service) GetEventsByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Event, error) {
 	filter := bson.M{
 		"guest_ids": userID,
@@ -328,6 +411,18 @@ func (s *service) UpdateEvent(ctx context.Context, id primitive.ObjectID, update
 	return err
 }
 
+// CompleteEvent marks the event completed unless it already is or was
+// cancelled, reporting whether it did. Only the caller that completes it
+// should roll its series forward.
+func (s *service) CompleteEvent(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": id, "status": bson.M{"$nin": []string{"completed", "cancelled"}}}
+	res, err := s.db.Collection("events").UpdateOne(ctx, filter, bson.M{"$set": bson.M{"status": "completed"}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
 func (s *service) DeleteEvent(ctx context.Context, id primitive.ObjectID) error {
 	// Delete related data first
 	filter := bson.M{"event_id": id}
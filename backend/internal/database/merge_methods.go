@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MergeFamilyMembers folds the duplicate account sourceID into targetID and
+// deletes it. Every step is idempotent, so a merge interrupted part-way can be
+// safely retried.
+func (s *service) MergeFamilyMembers(ctx context.Context, sourceID, targetID primitive.ObjectID) error {
+	source, err := s.GetFamilyMemberByID(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	target, err := s.GetFamilyMemberByID(ctx, targetID)
+	if err != nil {
+		return err
+	}
+
+	// Profile: groups and identities are unioned; the survivor keeps its own
+	// household unless it has none.
+	update := bson.M{}
+	addToSet := bson.M{}
+	if len(source.GroupIDs) > 0 {
+		addToSet["group_ids"] = bson.M{"$each": source.GroupIDs}
+	}
+	if len(source.Identities) > 0 {
+		addToSet["identities"] = bson.M{"$each": source.Identities}
+	}
+	if len(addToSet) > 0 {
+		update["$addToSet"] = addToSet
+	}
+	if target.HouseholdID == nil && source.HouseholdID != nil {
+		update["$set"] = bson.M{"household_id": source.HouseholdID}
+	}
+	if len(update) > 0 {
+		if _, err := s.db.Collection("families").UpdateOne(ctx, bson.M{"_id": targetID}, update); err != nil {
+			return err
+		}
+	}
+
+	// Membership arrays on other documents
+	for _, ref := range []struct{ collection, field string }{
+		{"groups", "admin_ids"},
+		{"groups", "host_opt_out_ids"},
+		{"events", "guest_ids"},
+	} {
+		if err := s.replaceInArray(ctx, ref.collection, ref.field, sourceID, targetID); err != nil {
+			return err
+		}
+	}
+	if err := s.replaceInOrder(ctx, "groups", "host_order", sourceID, targetID); err != nil {
+		return err
+	}
+	if target.HouseholdID == nil {
+		err = s.replaceInArray(ctx, "households", "member_ids", sourceID, targetID)
+	} else {
+		_, err = s.db.Collection("households").UpdateMany(ctx, bson.M{"member_ids": sourceID}, bson.M{"$pull": bson.M{"member_ids": sourceID}})
+	}
+	if err != nil {
+		return err
+	}
+
+	// RSVPs: where both accounts answered for the same event, the survivor's
+	// answer wins.
+	targetEventIDs, err := s.db.Collection("rsvps").Distinct(ctx, "event_id", bson.M{"family_id": targetID})
+	if err != nil {
+		return err
+	}
+	if len(targetEventIDs) > 0 {
+		if _, err := s.db.Collection("rsvps").DeleteMany(ctx, bson.M{"family_id": sourceID, "event_id": bson.M{"$in": targetEventIDs}}); err != nil {
+			return err
+		}
+	}
+
+	// Single-valued references
+	for _, ref := range []struct {
+		collection, field string
+		extra             bson.M
+	}{
+		{"rsvps", "family_id", nil},
+		{"dishes", "bringer_id", nil},
+		{"chat", "family_id", bson.M{"family_name": target.Name}},
+		{"events", "host_id", nil},
+		{"swaps", "requesting_family_id", nil},
+		{"swaps", "target_family_id", nil},
+	} {
+		set := bson.M{ref.field: targetID}
+		for k, v := range ref.extra {
+			set[k] = v
+		}
+		if _, err := s.db.Collection(ref.collection).UpdateMany(ctx, bson.M{ref.field: sourceID}, bson.M{"$set": set}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.db.Collection("sessions").UpdateMany(
+		ctx,
+		bson.M{"family_id": sourceID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	); err != nil {
+		return err
+	}
+
+	// Tokens were granted by the duplicate account; the owner can mint new ones
+	if _, err := s.db.Collection("api_tokens").DeleteMany(ctx, bson.M{"family_id": sourceID}); err != nil {
+		return err
+	}
+
+	_, err = s.db.Collection("families").DeleteOne(ctx, bson.M{"_id": sourceID})
+	return err
+}
+
+// replaceInArray swaps from for to in an array field without duplicating to
+// where both were already present.
+func (s *service) replaceInArray(ctx context.Context, collection, field string, from, to primitive.ObjectID) error {
+	coll := s.db.Collection(collection)
+	if _, err := coll.UpdateMany(ctx, bson.M{field: from}, bson.M{"$addToSet": bson.M{field: to}}); err != nil {
+		return err
+	}
+	_, err := coll.UpdateMany(ctx, bson.M{field: from}, bson.M{"$pull": bson.M{field: from}})
+	return err
+}
+
+// replaceInOrder swaps from for to in an ordered array field, keeping from's
+// place. Where to was already present it keeps its own place instead.
+func (s *service) replaceInOrder(ctx context.Context, collection, field string, from, to primitive.ObjectID) error {
+	coll := s.db.Collection(collection)
+	if _, err := coll.UpdateMany(ctx, bson.M{field: bson.M{"$all": bson.A{from, to}}}, bson.M{"$pull": bson.M{field: from}}); err != nil {
+		return err
+	}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"m": from}}})
+	_, err := coll.UpdateMany(ctx, bson.M{field: from}, bson.M{"$set": bson.M{field + ".$[m]": to}}, opts)
+	return err
+}
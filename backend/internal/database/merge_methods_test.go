@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"family-potluck/backend/internal/models"
+	"os"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testService connects to the MongoDB at MONGODB_TEST_URI, using a database
+// of its own that is dropped when the test ends.
+func testService(t *testing.T) *service {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	db := client.Database("familypotluck_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return &service{db: db}
+}
+
+func TestMergeFamilyMembers_HostRotation(t *testing.T) {
+	s := testService(t)
+	ctx := context.Background()
+
+	source := models.FamilyMember{ID: primitive.NewObjectID(), Name: "Duplicate"}
+	target := models.FamilyMember{ID: primitive.NewObjectID(), Name: "Aunt May"}
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	groups := []models.Group{
+		{ID: primitive.NewObjectID(), HostOrder: []primitive.ObjectID{a, source.ID, b}, HostOptOutIDs: []primitive.ObjectID{source.ID}},
+		{ID: primitive.NewObjectID(), HostOrder: []primitive.ObjectID{target.ID, a, source.ID}, HostOptOutIDs: []primitive.ObjectID{source.ID, target.ID}},
+	}
+	for _, f := range []models.FamilyMember{source, target} {
+		if _, err := s.db.Collection("families").InsertOne(ctx, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, g := range groups {
+		if _, err := s.db.Collection("groups").InsertOne(ctx, g); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.MergeFamilyMembers(ctx, source.ID, target.ID); err != nil {
+		t.Fatalf("MergeFamilyMembers returned an error: %v", err)
+	}
+
+	want := []struct{ order, optOuts []primitive.ObjectID }{
+		{[]primitive.ObjectID{a, target.ID, b}, []primitive.ObjectID{target.ID}},
+		{[]primitive.ObjectID{target.ID, a}, []primitive.ObjectID{target.ID}},
+	}
+	for i, g := range groups {
+		var got models.Group
+		if err := s.db.Collection("groups").FindOne(ctx, bson.M{"_id": g.ID}).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.HostOrder, want[i].order) {
+			t.Errorf("group %d: expected host order %v, got %v", i, want[i].order, got.HostOrder)
+		}
+		if !reflect.DeepEqual(got.HostOptOutIDs, want[i].optOuts) {
+			t.Errorf("group %d: expected opt-outs %v, got %v", i, want[i].optOuts, got.HostOptOutIDs)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"family-potluck/backend/internal/models"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -33,8 +34,17 @@ type MockService struct {
 	GetEventsByGroupIDFunc                func(ctx context.Context, groupID primitive.ObjectID, includeCompleted bool) ([]models.Event, error)
 	GetEventsByUserIDFunc                 func(ctx context.Context, userID primitive.ObjectID) ([]models.Event, error)
 	UpdateEventFunc                       func(ctx context.Context, id primitive.ObjectID, update bson.M) error
+	CompleteEventFunc                     func(ctx context.Context, id primitive.ObjectID) (bool, error)
 	DeleteEventFunc                       func(ctx context.Context, id primitive.ObjectID) error
 	GetCompletedEventsByRecurrenceIDFunc  func(ctx context.Context, recurrenceID primitive.ObjectID) ([]models.Event, error)
+	GetEventsByRecurrenceIDFunc           func(ctx context.Context, recurrenceID primitive.ObjectID) ([]models.Event, error)
+	GetUnfinishedEventsBeforeFunc         func(ctx context.Context, before time.Time) ([]models.Event, error)
+	CreateSeriesFunc                      func(ctx context.Context, series *models.Series) error
+	GetSeriesFunc                         func(ctx context.Context, id primitive.ObjectID) (*models.Series, error)
+	GetSeriesByGroupIDFunc                func(ctx context.Context, groupID primitive.ObjectID) ([]models.Series, error)
+	UpdateSeriesFunc                      func(ctx context.Context, id primitive.ObjectID, update bson.M) error
+	CreateOccurrenceFunc                  func(ctx context.Context, event *models.Event) (bool, error)
+	DeleteSeriesFunc                      func(ctx context.Context, id primitive.ObjectID) error
 	CreateDishFunc                        func(ctx context.Context, dish *models.Dish) error
 	GetDishesByEventIDFunc                func(ctx context.Context, eventID primitive.ObjectID) ([]models.Dish, error)
 	GetDishByIDFunc                       func(ctx context.Context, id primitive.ObjectID) (*models.Dish, error)
@@ -53,6 +63,24 @@ type MockService struct {
 	UpdateHouseholdFunc                   func(ctx context.Context, id primitive.ObjectID, update bson.M) error
 	DeleteHouseholdFunc                   func(ctx context.Context, id primitive.ObjectID) error
 	RemoveMemberFromHouseholdFunc         func(ctx context.Context, householdID, familyID primitive.ObjectID) error
+	GetFamilyMemberByIdentityFunc         func(ctx context.Context, provider, subject string) (*models.FamilyMember, error)
+	GetFamilyMemberByCalendarTokenFunc    func(ctx context.Context, hash string) (*models.FamilyMember, error)
+	MergeFamilyMembersFunc                func(ctx context.Context, sourceID, targetID primitive.ObjectID) error
+	CreateSessionFunc                     func(ctx context.Context, session *models.Session) error
+	GetSessionByIDFunc                    func(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	GetSessionByRefreshTokenHashFunc      func(ctx context.Context, hash string) (*models.Session, error)
+	GetActiveSessionsByFamilyMemberIDFunc func(ctx context.Context, familyMemberID primitive.ObjectID) ([]models.Session, error)
+	RotateSessionRefreshTokenFunc         func(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	RevokeSessionFunc                     func(ctx context.Context, id primitive.ObjectID) error
+	RevokeSessionsByFamilyMemberIDFunc    func(ctx context.Context, familyMemberID primitive.ObjectID) error
+	CreateAPITokenFunc                    func(ctx context.Context, token *models.APIToken) error
+	GetAPITokenByHashFunc                 func(ctx context.Context, hash string) (*models.APIToken, error)
+	GetAPITokensByFamilyMemberIDFunc      func(ctx context.Context, familyMemberID primitive.ObjectID) ([]models.APIToken, error)
+	DeleteAPITokenFunc                    func(ctx context.Context, id, familyMemberID primitive.ObjectID) (bool, error)
+	TouchAPITokenFunc                     func(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error
+	AppendRealtimeMessageFunc             func(ctx context.Context, msg *models.RealtimeMessage, keep int) error
+	GetRecentRealtimeMessagesFunc         func(ctx context.Context, topic string, limit int) ([]models.RealtimeMessage, error)
+	AcquireLeaseFunc                      func(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
 }
 
 func (m *MockService) Health() map[string]string { return m.HealthFunc() }
@@ -120,12 +148,39 @@ func (m *MockService) GetEventsByUserID(ctx context.Context, userID primitive.Ob
 func (m *MockService) UpdateEvent(ctx context.Context, id primitive.ObjectID, update bson.M) error {
 	return m.UpdateEventFunc(ctx, id, update)
 }
+func (m *MockService) CompleteEvent(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	return m.CompleteEventFunc(ctx, id)
+}
 func (m *MockService) DeleteEvent(ctx context.Context, id primitive.ObjectID) error {
 	return m.DeleteEventFunc(ctx, id)
 }
 func (m *MockService) GetCompletedEventsByRecurrenceID(ctx context.Context, recurrenceID primitive.ObjectID) ([]models.Event, error) {
 	return m.GetCompletedEventsByRecurrenceIDFunc(ctx, recurrenceID)
 }
+func (m *MockService) GetEventsByRecurrenceID(ctx context.Context, recurrenceID primitive.ObjectID) ([]models.Event, error) {
+	return m.GetEventsByRecurrenceIDFunc(ctx, recurrenceID)
+}
+func (m *MockService) GetUnfinishedEventsBefore(ctx context.Context, before time.Time) ([]models.Event, error) {
+	return m.GetUnfinishedEventsBeforeFunc(ctx, before)
+}
+func (m *MockService) CreateSeries(ctx context.Context, series *models.Series) error {
+	return m.CreateSeriesFunc(ctx, series)
+}
+func (m *MockService) GetSeries(ctx context.Context, id primitive.ObjectID) (*models.Series, error) {
+	return m.GetSeriesFunc(ctx, id)
+}
+func (m *MockService) GetSeriesByGroupID(ctx context.Context, groupID primitive.ObjectID) ([]models.Series, error) {
+	return m.GetSeriesByGroupIDFunc(ctx, groupID)
+}
+func (m *MockService) UpdateSeries(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	return m.UpdateSeriesFunc(ctx, id, update)
+}
+func (m *MockService) CreateOccurrence(ctx context.Context, event *models.Event) (bool, error) {
+	return m.CreateOccurrenceFunc(ctx, event)
+}
+func (m *MockService) DeleteSeries(ctx context.Context, id primitive.ObjectID) error {
+	return m.DeleteSeriesFunc(ctx, id)
+}
 func (m *MockService) CreateDish(ctx context.Context, dish *models.Dish) error {
 	return m.CreateDishFunc(ctx, dish)
 }
@@ -180,3 +235,57 @@ func (m *MockService) DeleteHousehold(ctx context.Context, id primitive.ObjectID
 func (m *MockService) RemoveMemberFromHousehold(ctx context.Context, householdID, familyID primitive.ObjectID) error {
 	return m.RemoveMemberFromHouseholdFunc(ctx, householdID, familyID)
 }
+func (m *MockService) CreateSession(ctx context.Context, session *models.Session) error {
+	return m.CreateSessionFunc(ctx, session)
+}
+func (m *MockService) GetSessionByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	return m.GetSessionByIDFunc(ctx, id)
+}
+func (m *MockService) GetSessionByRefreshTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	return m.GetSessionByRefreshTokenHashFunc(ctx, hash)
+}
+func (m *MockService) GetActiveSessionsByFamilyMemberID(ctx context.Context, familyMemberID primitive.ObjectID) ([]models.Session, error) {
+	return m.GetActiveSessionsByFamilyMemberIDFunc(ctx, familyMemberID)
+}
+func (m *MockService) RotateSessionRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	return m.RotateSessionRefreshTokenFunc(ctx, id, oldHash, newHash, expiresAt)
+}
+func (m *MockService) RevokeSession(ctx context.Context, id primitive.ObjectID) error {
+	return m.RevokeSessionFunc(ctx, id)
+}
+func (m *MockService) RevokeSessionsByFamilyMemberID(ctx context.Context, familyMemberID primitive.ObjectID) error {
+	return m.RevokeSessionsByFamilyMemberIDFunc(ctx, familyMemberID)
+}
+func (m *MockService) GetFamilyMemberByIdentity(ctx context.Context, provider, subject string) (*models.FamilyMember, error) {
+	return m.GetFamilyMemberByIdentityFunc(ctx, provider, subject)
+}
+func (m *MockService) GetFamilyMemberByCalendarToken(ctx context.Context, hash string) (*models.FamilyMember, error) {
+	return m.GetFamilyMemberByCalendarTokenFunc(ctx, hash)
+}
+func (m *MockService) MergeFamilyMembers(ctx context.Context, sourceID, targetID primitive.ObjectID) error {
+	return m.MergeFamilyMembersFunc(ctx, sourceID, targetID)
+}
+func (m *MockService) CreateAPIToken(ctx context.Context, token *models.APIToken) error {
+	return m.CreateAPITokenFunc(ctx, token)
+}
+func (m *MockService) GetAPITokenByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	return m.GetAPITokenByHashFunc(ctx, hash)
+}
+func (m *MockService) GetAPITokensByFamilyMemberID(ctx context.Context, familyMemberID primitive.ObjectID) ([]models.APIToken, error) {
+	return m.GetAPITokensByFamilyMemberIDFunc(ctx, familyMemberID)
+}
+func (m *MockService) DeleteAPIToken(ctx context.Context, id, familyMemberID primitive.ObjectID) (bool, error) {
+	return m.DeleteAPITokenFunc(ctx, id, familyMemberID)
+}
+func (m *MockService) TouchAPIToken(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error {
+	return m.TouchAPITokenFunc(ctx, id, usedAt)
+}
+func (m *MockService) AppendRealtimeMessage(ctx context.Context, msg *models.RealtimeMessage, keep int) error {
+	return m.AppendRealtimeMessageFunc(ctx, msg, keep)
+}
+func (m *MockService) GetRecentRealtimeMessages(ctx context.Context, topic string, limit int) ([]models.RealtimeMessage, error) {
+	return m.GetRecentRealtimeMessagesFunc(ctx, topic, limit)
+}
+func (m *MockService) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	return m.AcquireLeaseFunc(ctx, name, holder, ttl)
+}
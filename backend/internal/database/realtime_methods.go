@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"family-potluck/backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AppendRealtimeMessage stores msg and drops the entries of its topic that
+// fall outside the most recent keep.
+func (s *service) AppendRealtimeMessage(ctx context.Context, msg *models.RealtimeMessage, keep int) error {
+	if _, err := s.db.Collection("realtime_log").InsertOne(ctx, msg); err != nil {
+		return err
+	}
+	if msg.Seq <= uint64(keep) {
+		return nil
+	}
+	_, err := s.db.Collection("realtime_log").DeleteMany(ctx, bson.M{
+		"topic": msg.Topic,
+		"seq":   bson.M{"$lte": msg.Seq - uint64(keep)},
+	})
+	return err
+}
+
+// GetRecentRealtimeMessages returns up to limit of the newest messages for
+// topic, oldest first.
+func (s *service) GetRecentRealtimeMessages(ctx context.Context, topic string, limit int) ([]models.RealtimeMessage, error) {
+	opts := options.Find().SetSort(bson.M{"seq": -1}).SetLimit(int64(limit))
+	cursor, err := s.db.Collection("realtime_log").Find(ctx, bson.M{"topic": topic}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var messages []models.RealtimeMessage
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"family-potluck/backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *service) CreateSeries(ctx context.Context, series *models.Series) error {
+	_, err := s.db.Collection("series").InsertOne(ctx, series)
+	return err
+}
+
+func (s *service) GetSeries(ctx context.Context, id primitive.ObjectID) (*models.Series, error) {
+	var series models.Series
+	err := s.db.Collection("series").FindOne(ctx, bson.M{"_id": id}).Decode(&series)
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (s *service) UpdateSeries(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	_, err := s.db.Collection("series").UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (s *service) DeleteSeries(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.db.Collection("series").DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (s *service) GetSeriesByGroupID(ctx context.Context, groupID primitive.ObjectID) ([]models.Series, error) {
+	cursor, err := s.db.Collection("series").Find(ctx, bson.M{"group_id": groupID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var series []models.Series
+	if err = cursor.All(ctx, &series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// CreateOccurrence inserts event, an occurrence of a series, unless the series
+// already has one in the same slot, reporting whether it was inserted. There is
+// no unique index on the slot, since moving a series shifts its occurrences
+// through each other's slots, so two callers inserting the same occurrence at
+// the same moment can both add it. CompleteEvent and the scheduler's lease
+// keep the callers that fill a series apart.
+func (s *service) CreateOccurrence(ctx context.Context, event *models.Event) (bool, error) {
+	filter := bson.M{
+		"recurrence_id": event.RecurrenceID,
+		"$or":           []bson.M{{"date": event.Date}, {"original_date": event.Date}},
+	}
+	res, err := s.db.Collection("events").UpdateOne(ctx, filter, bson.M{"$setOnInsert": event}, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+// GetEventsByRecurrenceID returns every occurrence of a series, whatever its
+// status, in date order.
+func (s *service) GetEventsByRecurrenceID(ctx context.Context, recurrenceID primitive.ObjectID) ([]models.Event, error) {
+	opts := options.Find().SetSort(bson.M{"date": 1})
+	cursor, err := s.db.Collection("events").Find(ctx, bson.M{"recurrence_id": recurrenceID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var events []models.Event
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
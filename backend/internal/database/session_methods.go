@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"family-potluck/backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *service) CreateSession(ctx context.Context, session *models.Session) error {
+	_, err := s.db.Collection("sessions").InsertOne(ctx, session)
+	return err
+}
+
+func (s *service) GetSessionByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	var session models.Session
+	err := s.db.Collection("sessions").FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetSessionByRefreshTokenHash matches the current or the previous refresh
+// token so that reuse of a rotated token can be detected.
+func (s *service) GetSessionByRefreshTokenHash(ctx context.Context, hash string) (*models.Session, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"refresh_token_hash": hash},
+		{"previous_refresh_token_hash": hash},
+	}}
+	var session models.Session
+	err := s.db.Collection("sessions").FindOne(ctx, filter).Decode(&session)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *service) GetActiveSessionsByFamilyMemberID(ctx context.Context, familyMemberID primitive.ObjectID) ([]models.Session, error) {
+	filter := bson.M{
+		"family_id":  familyMemberID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.M{"last_used_at": -1})
+	cursor, err := s.db.Collection("sessions").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var sessions []models.Session
+	if err = cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RotateSessionRefreshToken swaps the refresh token hash only if oldHash is
+// still current, so two concurrent refreshes cannot both succeed.
+func (s *service) RotateSessionRefreshToken(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	result, err := s.db.Collection("sessions").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "refresh_token_hash": oldHash, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"refresh_token_hash":          newHash,
+			"previous_refresh_token_hash": oldHash,
+			"last_used_at":                time.Now(),
+			"expires_at":                  expiresAt,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (s *service) RevokeSession(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.db.Collection("sessions").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	return err
+}
+
+func (s *service) RevokeSessionsByFamilyMemberID(ctx context.Context, familyMemberID primitive.ObjectID) error {
+	_, err := s.db.Collection("sessions").UpdateMany(
+		ctx,
+		bson.M{"family_id": familyMemberID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	return err
+}
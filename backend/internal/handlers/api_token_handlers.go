@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/authz"
+	"family-potluck/backend/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// API tokens carry a recognisable prefix so Authenticate can tell them apart
+// from session JWTs, and so leaked tokens are easy to spot.
+const apiTokenPrefix = "fp_"
+
+// lastUsedResolution limits how often last_used_at is written for a busy token.
+const lastUsedResolution = time.Minute
+
+func (s *Server) authenticateAPIToken(ctx context.Context, tokenStr string) (*Principal, error) {
+	apiToken, err := s.DB.GetAPITokenByHash(ctx, hashToken(tokenStr))
+	if err != nil {
+		return nil, errUnauthenticated
+	}
+	now := time.Now()
+	if apiToken.IsExpired(now) {
+		return nil, errUnauthenticated
+	}
+
+	familyMember, err := s.DB.GetFamilyMemberByID(ctx, apiToken.FamilyMemberID)
+	if err != nil {
+		return nil, errUnauthenticated
+	}
+
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) >= lastUsedResolution {
+		if err := s.DB.TouchAPIToken(ctx, apiToken.ID, now); err == nil {
+			apiToken.LastUsedAt = &now
+		}
+	}
+	return &Principal{FamilyMember: familyMember, APIToken: apiToken}, nil
+}
+
+func (s *Server) GetAPITokens(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := s.DB.GetAPITokensByFamilyMemberID(context.Background(), actor.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tokens == nil {
+		tokens = []models.APIToken{}
+	}
+
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// CreateAPIToken mints a token for the current FamilyMember. The plaintext
+// token is only ever returned in this response.
+func (s *Server) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "Token name is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Scopes) == 0 {
+		http.Error(w, "At least one scope is required", http.StatusBadRequest)
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !authz.ValidScope(scope) {
+			http.Error(w, "Invalid scope: "+scope, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		http.Error(w, "expires_in_days must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	secret, err := newRandomToken()
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	plaintext := apiTokenPrefix + secret
+
+	apiToken := models.APIToken{
+		ID:             primitive.NewObjectID(),
+		FamilyMemberID: actor.ID,
+		Name:           req.Name,
+		Prefix:         plaintext[:len(apiTokenPrefix)+6],
+		TokenHash:      hashToken(plaintext),
+		Scopes:         req.Scopes,
+		CreatedAt:      time.Now(),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := apiToken.CreatedAt.AddDate(0, 0, req.ExpiresInDays)
+		apiToken.ExpiresAt = &expiresAt
+	}
+
+	if err := s.DB.CreateAPIToken(context.Background(), &apiToken); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		models.APIToken
+		Token string `json:"token"`
+	}{apiToken, plaintext})
+}
+
+func (s *Server) DeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid token id", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := s.DB.DeleteAPIToken(context.Background(), id, actor.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Token deleted"))
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreateAPIToken(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	familyID := primitive.NewObjectID()
+	var stored *models.APIToken
+	mockDB.CreateAPITokenFunc = func(ctx context.Context, token *models.APIToken) error {
+		stored = token
+		return nil
+	}
+
+	body := `{"name":"event script","scopes":["events:write","dishes:write"],"expires_in_days":30}`
+	req, _ := http.NewRequest("POST", "/auth/tokens", bytes.NewBufferString(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID})
+	rr := httptest.NewRecorder()
+
+	server.CreateAPIToken(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	var resp struct {
+		Token  string   `json:"token"`
+		Prefix string   `json:"prefix"`
+		Scopes []string `json:"scopes"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if !strings.HasPrefix(resp.Token, apiTokenPrefix) || !strings.HasPrefix(resp.Token, resp.Prefix) {
+		t.Errorf("unexpected token %q with prefix %q", resp.Token, resp.Prefix)
+	}
+	if stored.TokenHash != hashToken(resp.Token) || stored.TokenHash == resp.Token {
+		t.Error("expected only the token hash to be stored")
+	}
+	if stored.FamilyMemberID != familyID || stored.ExpiresAt == nil {
+		t.Errorf("unexpected stored token: %+v", stored)
+	}
+}
+
+func TestCreateAPIToken_InvalidScope(t *testing.T) {
+	server := NewServer(&database.MockService{}, nil)
+
+	req, _ := http.NewRequest("POST", "/auth/tokens", bytes.NewBufferString(`{"name":"x","scopes":["everything"]}`))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID()})
+	rr := httptest.NewRecorder()
+
+	server.CreateAPIToken(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestDeleteAPIToken_NotOwned(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	mockDB.DeleteAPITokenFunc = func(ctx context.Context, id, familyMemberID primitive.ObjectID) (bool, error) {
+		return false, nil
+	}
+
+	tokenID := primitive.NewObjectID()
+	req, _ := http.NewRequest("DELETE", "/auth/tokens/"+tokenID.Hex(), nil)
+	req.SetPathValue("id", tokenID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID()})
+	rr := httptest.NewRecorder()
+
+	server.DeleteAPIToken(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+}
+
+func TestAuthenticate_APIToken(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	familyID := primitive.NewObjectID()
+	plaintext := apiTokenPrefix + "secret"
+	expired := time.Now().Add(-time.Hour)
+	tokens := map[string]*models.APIToken{
+		hashToken(plaintext):                  {ID: primitive.NewObjectID(), FamilyMemberID: familyID, Scopes: []string{"events:read"}},
+		hashToken(apiTokenPrefix + "expired"): {ID: primitive.NewObjectID(), FamilyMemberID: familyID, ExpiresAt: &expired},
+	}
+	mockDB.GetAPITokenByHashFunc = func(ctx context.Context, hash string) (*models.APIToken, error) {
+		if token, ok := tokens[hash]; ok {
+			return token, nil
+		}
+		return nil, database.ErrNoDocuments
+	}
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: id}, nil
+	}
+	touched := false
+	mockDB.TouchAPITokenFunc = func(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error {
+		touched = true
+		return nil
+	}
+
+	req, _ := http.NewRequest("GET", "/events", nil)
+	req.Header.Set("Authorization", "Bearer "+plaintext)
+	principal, err := server.Authenticate(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if principal.FamilyMember.ID != familyID || principal.APIToken == nil {
+		t.Errorf("unexpected principal: %+v", principal)
+	}
+	if !touched {
+		t.Error("expected last_used_at to be recorded")
+	}
+	if !principal.Allows("GET /events/{id}") {
+		t.Error("expected events:read to allow GET /events/{id}")
+	}
+	if principal.Allows("POST /events") || principal.Allows("GET /auth/tokens") {
+		t.Error("expected events:read to deny writes and /auth routes")
+	}
+
+	req, _ = http.NewRequest("GET", "/events", nil)
+	req.Header.Set("Authorization", "Bearer "+apiTokenPrefix+"expired")
+	if _, err := server.Authenticate(req); err == nil {
+		t.Error("expected an expired token to be rejected")
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"family-potluck/backend/internal/authz"
+	"family-potluck/backend/internal/models"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type contextKey string
+
+const (
+	familyMemberContextKey contextKey = "familyMember"
+	sessionContextKey      contextKey = "session"
+)
+
+// WithFamilyMember returns a copy of ctx carrying the authenticated FamilyMember.
+func WithFamilyMember(ctx context.Context, familyMember *models.FamilyMember) context.Context {
+	return context.WithValue(ctx, familyMemberContextKey, familyMember)
+}
+
+// FamilyMemberFromContext returns the FamilyMember stored by the auth middleware.
+func FamilyMemberFromContext(ctx context.Context) (*models.FamilyMember, bool) {
+	familyMember, ok := ctx.Value(familyMemberContextKey).(*models.FamilyMember)
+	return familyMember, ok && familyMember != nil
+}
+
+// WithSession returns a copy of ctx carrying the session the request was
+// authenticated with.
+func WithSession(ctx context.Context, session *models.Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey, session)
+}
+
+// SessionFromContext returns the Session stored by the auth middleware.
+func SessionFromContext(ctx context.Context) (*models.Session, bool) {
+	session, ok := ctx.Value(sessionContextKey).(*models.Session)
+	return session, ok && session != nil
+}
+
+// Principal is the authenticated caller: a FamilyMember acting through either
+// a browser session or a personal API token.
+type Principal struct {
+	FamilyMember *models.FamilyMember
+	Session      *models.Session
+	APIToken     *models.APIToken
+}
+
+// Allows reports whether the principal may call the route registered as
+// pattern. Sessions may call any route; API tokens need the route's scope.
+func (p *Principal) Allows(pattern string) bool {
+	if p.APIToken == nil {
+		return true
+	}
+	scope, ok := authz.RequiredScope(pattern)
+	return ok && authz.HasScope(p.APIToken.Scopes, scope)
+}
+
+// WithPrincipal returns a copy of ctx carrying the principal's FamilyMember
+// and, for browser requests, its Session.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	ctx = WithFamilyMember(ctx, p.FamilyMember)
+	if p.Session != nil {
+		ctx = WithSession(ctx, p.Session)
+	}
+	return ctx
+}
+
+// currentMember returns the authenticated FamilyMember, writing a 401 if the
+// request did not pass through the auth middleware.
+func currentMember(w http.ResponseWriter, r *http.Request) (*models.FamilyMember, bool) {
+	familyMember, ok := FamilyMemberFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return familyMember, true
+}
+
+// matchesActor rejects a client-supplied member ID that differs from the
+// authenticated member. An omitted (zero) ID is accepted.
+func matchesActor(w http.ResponseWriter, actor *models.FamilyMember, claimed primitive.ObjectID, field string) bool {
+	if claimed.IsZero() || claimed == actor.ID {
+		return true
+	}
+	http.Error(w, field+" does not match the authenticated user", http.StatusForbidden)
+	return false
+}
+
+// queryActor validates an optional member ID query parameter against the
+// authenticated member.
+func queryActor(w http.ResponseWriter, r *http.Request, actor *models.FamilyMember, param string) bool {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return true
+	}
+	claimed, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		http.Error(w, "Invalid "+param, http.StatusBadRequest)
+		return false
+	}
+	return matchesActor(w, actor, claimed, param)
+}
@@ -3,9 +3,12 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"family-potluck/backend/internal/identity"
 	"family-potluck/backend/internal/models"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -22,15 +25,28 @@ func getJWTKey() []byte {
 	return []byte(key)
 }
 
+var (
+	errUnauthenticated  = errors.New("unauthenticated")
+	errIdentityConflict = errors.New("an account with this email already exists; sign in with a linked provider to link this one")
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID string) (string, error) {
-	expirationTime := time.Now().Add(72 * time.Hour)
+// GenerateToken issues a short-lived access token bound to a session.
+func GenerateToken(userID, sessionID string) (string, error) {
+	expirationTime := time.Now().Add(accessTokenTTL)
 	claims := &Claims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
@@ -39,7 +55,7 @@ func GenerateToken(userID string) (string, error) {
 	return token.SignedString(getJWTKey())
 }
 
-func setTokenCookie(w http.ResponseWriter, token string) {
+func setCookie(w http.ResponseWriter, name, value, path string, expires time.Time) {
 	isProduction := os.Getenv("APP_ENV") == "production"
 	sameSite := http.SameSiteLaxMode
 	if isProduction {
@@ -47,140 +63,241 @@ func setTokenCookie(w http.ResponseWriter, token string) {
 	}
 
 	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    token,
-		Expires:  time.Now().Add(72 * time.Hour),
+		Name:     name,
+		Value:    value,
+		Expires:  expires,
 		HttpOnly: true,
-		Path:     "/",
+		Path:     path,
 		SameSite: sameSite,
 		Secure:   isProduction,
 	})
 }
 
-func (s *Server) GoogleLogin(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		IDToken string `json:"id_token"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+func setTokenCookie(w http.ResponseWriter, token string) {
+	setCookie(w, "token", token, "/", time.Now().Add(accessTokenTTL))
+}
+
+// The refresh cookie is only sent to /auth so it never travels with ordinary
+// API requests.
+func setRefreshCookie(w http.ResponseWriter, token string, expires time.Time) {
+	setCookie(w, "refresh_token", token, "/auth", expires)
+}
+
+func clearAuthCookies(w http.ResponseWriter) {
+	setCookie(w, "token", "", "/", time.Unix(0, 0))
+	setCookie(w, "refresh_token", "", "/auth", time.Unix(0, 0))
+}
+
+// Login signs in through the identity provider named in the path, e.g.
+// POST /auth/google with {"id_token": ...}.
+func (s *Server) Login(w http.ResponseWriter, r *http.Request) {
+	ident, ok := s.verifyCredentials(w, r)
+	if !ok {
 		return
 	}
 
 	ctx := context.Background()
-	// Ideally, validate the audience (your Client ID)
-	payload, err := s.TokenValidator.Validate(ctx, req.IDToken, os.Getenv("GOOGLE_CLIENT_ID"))
-	if err != nil {
-		http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+	familyMember, created, err := s.upsertFamilyMember(ctx, ident)
+	if err == errIdentityConflict {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	email := payload.Claims["email"].(string)
-	name := payload.Claims["name"].(string)
-	picture := payload.Claims["picture"].(string)
-	sub := payload.Subject // Google ID
+	if err := s.startSession(w, r, familyMember); err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(familyMember.ToSafe())
+}
 
-	familyMember, err := s.DB.GetFamilyMemberByEmail(ctx, email)
+// upsertFamilyMember resolves a verified identity to a FamilyMember, creating
+// one on first sign-in. Every provider converges here. The provider subject is
+// matched first so that an email change at the provider keeps the account;
+// email is only used to link a new identity when the provider verified it.
+func (s *Server) upsertFamilyMember(ctx context.Context, ident *identity.Identity) (*models.FamilyMember, bool, error) {
+	familyMember, err := s.DB.GetFamilyMemberByIdentity(ctx, ident.Provider, ident.Subject)
+	if err == nil {
+		return familyMember, false, s.linkIdentity(ctx, familyMember, ident)
+	} else if err != mongo.ErrNoDocuments {
+		return nil, false, err
+	}
 
+	familyMember, err = s.DB.GetFamilyMemberByEmail(ctx, ident.Email)
 	if err == mongo.ErrNoDocuments {
 		// Create new user
 		newFamilyMember := models.FamilyMember{
-			ID:       primitive.NewObjectID(),
-			Name:     name,
-			Email:    email,
-			GoogleID: sub,
-			Picture:  picture,
+			ID:         primitive.NewObjectID(),
+			Name:       ident.Name,
+			Email:      ident.Email,
+			Picture:    ident.Picture,
+			Identities: []models.LinkedIdentity{newLinkedIdentity(ident)},
 		}
-		err = s.DB.CreateFamilyMember(ctx, &newFamilyMember)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+		if ident.Provider == "google" {
+			newFamilyMember.GoogleID = ident.Subject
 		}
-		familyMember = &newFamilyMember
-		w.WriteHeader(http.StatusCreated)
-	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	} else {
-		// Update existing user info if needed
-		update := bson.M{
-			"$set": bson.M{
-				"name":      name,
-				"picture":   picture,
-				"google_id": sub,
-			},
+		if err := s.DB.CreateFamilyMember(ctx, &newFamilyMember); err != nil {
+			return nil, false, err
 		}
-		err = s.DB.UpdateFamilyMember(ctx, familyMember.ID, update)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+		return &newFamilyMember, true, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	if !ident.EmailVerified {
+		return nil, false, errIdentityConflict
+	}
+	return familyMember, false, s.linkIdentity(ctx, familyMember, ident)
+}
+
+func newLinkedIdentity(ident *identity.Identity) models.LinkedIdentity {
+	return models.LinkedIdentity{
+		Provider: ident.Provider,
+		Subject:  ident.Subject,
+		Email:    ident.Email,
+		LinkedAt: time.Now(),
+	}
+}
+
+// linkIdentity records ident on familyMember, or refreshes the email seen for
+// it. Name and picture are only filled in when the member has none, so a
+// provider never overwrites what the member chose.
+func (s *Server) linkIdentity(ctx context.Context, familyMember *models.FamilyMember, ident *identity.Identity) error {
+	identities := make([]models.LinkedIdentity, 0, len(familyMember.Identities)+1)
+	found := false
+	for _, li := range familyMember.Identities {
+		if li.Provider == ident.Provider && li.Subject == ident.Subject {
+			li.Email = ident.Email
+			found = true
 		}
+		identities = append(identities, li)
+	}
+	if !found {
+		identities = append(identities, newLinkedIdentity(ident))
 	}
 
-	token, err := GenerateToken(familyMember.ID.Hex())
-	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
-		return
+	set := bson.M{"identities": identities}
+	familyMember.Identities = identities
+	if familyMember.Name == "" && ident.Name != "" {
+		set["name"] = ident.Name
+		familyMember.Name = ident.Name
+	}
+	if familyMember.Picture == "" && ident.Picture != "" {
+		set["picture"] = ident.Picture
+		familyMember.Picture = ident.Picture
 	}
-	setTokenCookie(w, token)
+	if ident.Provider == "google" && familyMember.GoogleID == "" {
+		set["google_id"] = ident.Subject
+		familyMember.GoogleID = ident.Subject
+	}
+	return s.DB.UpdateFamilyMember(ctx, familyMember.ID, bson.M{"$set": set})
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(familyMember.ToSafe())
+// GetAuthProviders lists the enabled identity providers so the login page can
+// offer them. The dev provider also exposes its seeded users.
+func (s *Server) GetAuthProviders(w http.ResponseWriter, r *http.Request) {
+	type providerInfo struct {
+		Name  string             `json:"name"`
+		Users []identity.DevUser `json:"users,omitempty"`
+	}
+
+	providers := []providerInfo{}
+	for _, name := range s.Identity.Names() {
+		info := providerInfo{Name: name}
+		if p, ok := s.Identity.Get(name); ok {
+			if dev, ok := p.(*identity.DevProvider); ok {
+				info.Users = dev.Users()
+			}
+		}
+		providers = append(providers, info)
+	}
+
+	json.NewEncoder(w).Encode(providers)
 }
 
+// Logout revokes the session identified by the refresh cookie, if any, and
+// clears both auth cookies. It works even when the access token has expired.
 func (s *Server) Logout(w http.ResponseWriter, r *http.Request) {
-	isProduction := os.Getenv("APP_ENV") == "production"
-	sameSite := http.SameSiteLaxMode
-	if isProduction {
-		sameSite = http.SameSiteNoneMode
+	if c, err := r.Cookie("refresh_token"); err == nil && c.Value != "" {
+		session, err := s.DB.GetSessionByRefreshTokenHash(context.Background(), hashToken(c.Value))
+		if err == nil {
+			s.DB.RevokeSession(context.Background(), session.ID)
+		}
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    "",
-		Expires:  time.Unix(0, 0),
-		HttpOnly: true,
-		Path:     "/",
-		SameSite: sameSite,
-		Secure:   isProduction,
-	})
+	clearAuthCookies(w)
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Logged out"))
 }
 
 func (s *Server) GetMe(w http.ResponseWriter, r *http.Request) {
-	c, err := r.Cookie("token")
-	if err != nil {
-		if err == http.ErrNoCookie {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-		http.Error(w, "Bad Request", http.StatusBadRequest)
+	familyMember, ok := FamilyMemberFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	tokenStr := c.Value
-	claims := &Claims{}
+	json.NewEncoder(w).Encode(familyMember.ToSafe())
+}
+
+// tokenFromRequest returns the JWT carried by the request, preferring an
+// Authorization: Bearer header over the token cookie.
+func tokenFromRequest(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if c, err := r.Cookie("token"); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// Authenticate identifies the caller from a personal API token or from an
+// access token whose session has not been revoked.
+func (s *Server) Authenticate(r *http.Request) (*Principal, error) {
+	tokenStr := tokenFromRequest(r)
+	if tokenStr == "" {
+		return nil, errUnauthenticated
+	}
+	if strings.HasPrefix(tokenStr, apiTokenPrefix) {
+		return s.authenticateAPIToken(r.Context(), tokenStr)
+	}
 
+	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
 		return getJWTKey(), nil
-	})
-
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil || !token.Valid {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
+		return nil, errUnauthenticated
 	}
 
 	userID, err := primitive.ObjectIDFromHex(claims.UserID)
 	if err != nil {
-		http.Error(w, "Invalid token data", http.StatusUnauthorized)
-		return
+		return nil, errUnauthenticated
 	}
-
-	familyMember, err := s.DB.GetFamilyMemberByID(context.Background(), userID)
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusUnauthorized)
-		return
+		return nil, errUnauthenticated
 	}
 
-	json.NewEncoder(w).Encode(familyMember.ToSafe())
+	session, err := s.DB.GetSessionByID(r.Context(), sessionID)
+	if err != nil || session.FamilyMemberID != userID || !session.IsActive(time.Now()) {
+		return nil, errUnauthenticated
+	}
+
+	familyMember, err := s.DB.GetFamilyMemberByID(r.Context(), userID)
+	if err != nil {
+		return nil, errUnauthenticated
+	}
+	return &Principal{FamilyMember: familyMember, Session: session}, nil
 }
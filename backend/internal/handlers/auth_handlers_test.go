@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/identity"
 	"family-potluck/backend/internal/models"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"google.golang.org/api/idtoken"
 )
@@ -22,6 +25,11 @@ func (m *MockTokenValidator) Validate(ctx context.Context, idToken string, audie
 	return m.ValidateFunc(ctx, idToken, audience)
 }
 
+// withFamilyMember attaches an authenticated FamilyMember to req, as the auth middleware would.
+func withFamilyMember(req *http.Request, familyMember *models.FamilyMember) *http.Request {
+	return req.WithContext(WithFamilyMember(req.Context(), familyMember))
+}
+
 func TestLogout(t *testing.T) {
 	server := NewServer(nil, nil)
 	req, _ := http.NewRequest("POST", "/auth/logout", nil)
@@ -68,7 +76,7 @@ func TestGoogleLogin_NewUser(t *testing.T) {
 	mockDB := &database.MockService{}
 	mockValidator := &MockTokenValidator{}
 	server := NewServer(mockDB, nil)
-	server.TokenValidator = mockValidator
+	server.Identity = identity.NewRegistry(identity.NewGoogleProvider(mockValidator, ""))
 
 	familyID := primitive.NewObjectID()
 	email := "test@example.com"
@@ -84,12 +92,23 @@ func TestGoogleLogin_NewUser(t *testing.T) {
 		}, nil
 	}
 
+	mockDB.GetFamilyMemberByIdentityFunc = func(ctx context.Context, provider, subject string) (*models.FamilyMember, error) {
+		return nil, database.ErrNoDocuments
+	}
 	mockDB.GetFamilyMemberByEmailFunc = func(ctx context.Context, e string) (*models.FamilyMember, error) {
 		return nil, database.ErrNoDocuments // Assuming you have this or use mongo.ErrNoDocuments
 	}
 
+	var created *models.FamilyMember
 	mockDB.CreateFamilyMemberFunc = func(ctx context.Context, f *models.FamilyMember) error {
 		f.ID = familyID
+		created = f
+		return nil
+	}
+
+	var createdSession *models.Session
+	mockDB.CreateSessionFunc = func(ctx context.Context, session *models.Session) error {
+		createdSession = session
 		return nil
 	}
 
@@ -99,9 +118,10 @@ func TestGoogleLogin_NewUser(t *testing.T) {
 	body, _ := json.Marshal(loginReq)
 
 	req, _ := http.NewRequest("POST", "/auth/google", bytes.NewBuffer(body))
+	req.SetPathValue("provider", "google")
 	rr := httptest.NewRecorder()
 
-	server.GoogleLogin(rr, req)
+	server.Login(rr, req)
 
 	if status := rr.Code; status != http.StatusCreated {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
@@ -112,4 +132,261 @@ func TestGoogleLogin_NewUser(t *testing.T) {
 	if resp.Email != email {
 		t.Errorf("expected email %v, got %v", email, resp.Email)
 	}
+
+	if len(created.Identities) != 1 || created.Identities[0].Provider != "google" || created.Identities[0].Subject != "google-id-123" {
+		t.Errorf("expected the google identity to be linked, got %+v", created.Identities)
+	}
+
+	if createdSession == nil || createdSession.FamilyMemberID != familyID {
+		t.Fatal("expected a session to be created for the new user")
+	}
+	cookies := map[string]string{}
+	for _, c := range rr.Result().Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	if cookies["token"] == "" || cookies["refresh_token"] == "" {
+		t.Errorf("expected token and refresh_token cookies, got %v", cookies)
+	}
+	if hashToken(cookies["refresh_token"]) != createdSession.RefreshTokenHash {
+		t.Error("stored refresh token hash does not match the issued cookie")
+	}
+}
+
+func TestGetMe_Authenticated(t *testing.T) {
+	server := NewServer(nil, nil)
+	familyID := primitive.NewObjectID()
+
+	req, _ := http.NewRequest("GET", "/auth/me", nil)
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID, Email: "test@example.com"})
+	rr := httptest.NewRecorder()
+
+	server.GetMe(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var resp models.SafeFamilyMember
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.ID != familyID {
+		t.Errorf("expected family ID %v, got %v", familyID, resp.ID)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	familyID := primitive.NewObjectID()
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		if id != familyID {
+			return nil, database.ErrNoDocuments
+		}
+		return &models.FamilyMember{ID: id}, nil
+	}
+
+	sessionID := primitive.NewObjectID()
+	revokedID := primitive.NewObjectID()
+	revokedAt := time.Now()
+	sessions := map[primitive.ObjectID]*models.Session{
+		sessionID: {ID: sessionID, FamilyMemberID: familyID, ExpiresAt: time.Now().Add(time.Hour)},
+		revokedID: {ID: revokedID, FamilyMemberID: familyID, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt},
+	}
+	mockDB.GetSessionByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+		if session, ok := sessions[id]; ok {
+			return session, nil
+		}
+		return nil, database.ErrNoDocuments
+	}
+
+	token, err := GenerateToken(familyID.Hex(), sessionID.Hex())
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	unknownToken, _ := GenerateToken(primitive.NewObjectID().Hex(), sessionID.Hex())
+	revokedToken, _ := GenerateToken(familyID.Hex(), revokedID.Hex())
+	noSessionToken, _ := GenerateToken(familyID.Hex(), primitive.NewObjectID().Hex())
+
+	tests := []struct {
+		name    string
+		prepare func(req *http.Request)
+		wantErr bool
+	}{
+		{"cookie", func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "token", Value: token}) }, false},
+		{"bearer header", func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }, false},
+		{"missing token", func(req *http.Request) {}, true},
+		{"malformed token", func(req *http.Request) { req.Header.Set("Authorization", "Bearer not-a-jwt") }, true},
+		{"unknown user", func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "token", Value: unknownToken}) }, true},
+		{"revoked session", func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "token", Value: revokedToken}) }, true},
+		{"unknown session", func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "token", Value: noSessionToken}) }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/auth/me", nil)
+			tt.prepare(req)
+
+			principal, err := server.Authenticate(req)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected authentication to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if principal.FamilyMember.ID != familyID {
+				t.Errorf("expected family ID %v, got %v", familyID, principal.FamilyMember.ID)
+			}
+			if principal.Session.ID != sessionID {
+				t.Errorf("expected session ID %v, got %v", sessionID, principal.Session.ID)
+			}
+		})
+	}
+}
+
+func TestLogin_DevProviderExistingUser(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+	server.Identity = identity.NewRegistry(identity.NewDevProvider([]identity.DevUser{{Email: "alice@example.com", Name: "Alice"}}))
+
+	existing := &models.FamilyMember{ID: primitive.NewObjectID(), Email: "alice@example.com", GoogleID: "google-id-123"}
+	mockDB.GetFamilyMemberByIdentityFunc = func(ctx context.Context, provider, subject string) (*models.FamilyMember, error) {
+		return nil, database.ErrNoDocuments
+	}
+	mockDB.GetFamilyMemberByEmailFunc = func(ctx context.Context, email string) (*models.FamilyMember, error) {
+		return existing, nil
+	}
+	var update bson.M
+	mockDB.UpdateFamilyMemberFunc = func(ctx context.Context, id primitive.ObjectID, u bson.M) error {
+		update = u
+		return nil
+	}
+	mockDB.CreateSessionFunc = func(ctx context.Context, session *models.Session) error {
+		return nil
+	}
+
+	req, _ := http.NewRequest("POST", "/auth/dev", bytes.NewBufferString(`{"email":"alice@example.com"}`))
+	req.SetPathValue("provider", "dev")
+	rr := httptest.NewRecorder()
+
+	server.Login(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	set := update["$set"].(bson.M)
+	if _, ok := set["google_id"]; ok {
+		t.Error("dev sign-in must not overwrite google_id")
+	}
+	if set["name"] != "Alice" {
+		t.Errorf("expected missing name to be filled in, got %v", set["name"])
+	}
+	identities := set["identities"].([]models.LinkedIdentity)
+	if len(identities) != 1 || identities[0].Provider != "dev" {
+		t.Errorf("expected the dev identity to be linked, got %+v", identities)
+	}
+}
+
+func TestLogin_MatchesSubjectAfterEmailChange(t *testing.T) {
+	mockDB := &database.MockService{}
+	mockValidator := &MockTokenValidator{}
+	server := NewServer(mockDB, nil)
+	server.Identity = identity.NewRegistry(identity.NewGoogleProvider(mockValidator, ""))
+
+	mockValidator.ValidateFunc = func(ctx context.Context, idToken string, audience string) (*idtoken.Payload, error) {
+		return &idtoken.Payload{
+			Claims:  map[string]interface{}{"email": "new@example.com", "name": "Provider Name", "email_verified": true},
+			Subject: "google-id-123",
+		}, nil
+	}
+
+	existing := &models.FamilyMember{
+		ID:         primitive.NewObjectID(),
+		Name:       "Chosen Name",
+		Email:      "old@example.com",
+		Identities: []models.LinkedIdentity{{Provider: "google", Subject: "google-id-123", Email: "old@example.com"}},
+	}
+	mockDB.GetFamilyMemberByIdentityFunc = func(ctx context.Context, provider, subject string) (*models.FamilyMember, error) {
+		if provider == "google" && subject == "google-id-123" {
+			return existing, nil
+		}
+		return nil, database.ErrNoDocuments
+	}
+	mockDB.GetFamilyMemberByEmailFunc = func(ctx context.Context, email string) (*models.FamilyMember, error) {
+		t.Error("email lookup should not be needed when the subject matches")
+		return nil, database.ErrNoDocuments
+	}
+	var update bson.M
+	mockDB.UpdateFamilyMemberFunc = func(ctx context.Context, id primitive.ObjectID, u bson.M) error {
+		update = u
+		return nil
+	}
+	mockDB.CreateSessionFunc = func(ctx context.Context, session *models.Session) error {
+		return nil
+	}
+
+	req, _ := http.NewRequest("POST", "/auth/google", bytes.NewBufferString(`{"id_token":"fake-token"}`))
+	req.SetPathValue("provider", "google")
+	rr := httptest.NewRecorder()
+
+	server.Login(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	set := update["$set"].(bson.M)
+	if _, ok := set["name"]; ok {
+		t.Error("sign-in must not overwrite the member's chosen name")
+	}
+	identities := set["identities"].([]models.LinkedIdentity)
+	if len(identities) != 1 || identities[0].Email != "new@example.com" {
+		t.Errorf("expected the linked identity's email to be refreshed, got %+v", identities)
+	}
+}
+
+func TestLogin_UnverifiedEmailConflict(t *testing.T) {
+	mockDB := &database.MockService{}
+	mockValidator := &MockTokenValidator{}
+	server := NewServer(mockDB, nil)
+	server.Identity = identity.NewRegistry(identity.NewGoogleProvider(mockValidator, ""))
+
+	mockValidator.ValidateFunc = func(ctx context.Context, idToken string, audience string) (*idtoken.Payload, error) {
+		return &idtoken.Payload{
+			Claims:  map[string]interface{}{"email": "taken@example.com", "email_verified": false},
+			Subject: "someone-else",
+		}, nil
+	}
+	mockDB.GetFamilyMemberByIdentityFunc = func(ctx context.Context, provider, subject string) (*models.FamilyMember, error) {
+		return nil, database.ErrNoDocuments
+	}
+	mockDB.GetFamilyMemberByEmailFunc = func(ctx context.Context, email string) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: primitive.NewObjectID(), Email: email}, nil
+	}
+
+	req, _ := http.NewRequest("POST", "/auth/google", bytes.NewBufferString(`{"id_token":"fake-token"}`))
+	req.SetPathValue("provider", "google")
+	rr := httptest.NewRecorder()
+
+	server.Login(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+	}
+}
+
+func TestLogin_UnknownProvider(t *testing.T) {
+	server := NewServer(nil, nil)
+	server.Identity = identity.NewRegistry()
+
+	req, _ := http.NewRequest("POST", "/auth/dev", bytes.NewBufferString(`{"email":"alice@example.com"}`))
+	req.SetPathValue("provider", "dev")
+	rr := httptest.NewRecorder()
+
+	server.Login(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
 }
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// defaultHostPreview is how many occurrences GetEventHosts looks ahead by
+	// default.
+	defaultHostPreview = 6
+	// maxHostPreview caps how far ahead GetEventHosts will look.
+	maxHostPreview = 52
+)
+
+// checkUnavailability accepts stretches of YYYY-MM-DD days that don't end
+// before they start.
+func checkUnavailability(unavailable []models.Unavailability) error {
+	for _, u := range unavailable {
+		start, err := time.Parse(time.DateOnly, u.Start)
+		if err != nil {
+			return fmt.Errorf("invalid start date %q", u.Start)
+		}
+		end, err := time.Parse(time.DateOnly, u.End)
+		if err != nil {
+			return fmt.Errorf("invalid end date %q", u.End)
+		}
+		if end.Before(start) {
+			return fmt.Errorf("unavailability ending %s starts after it ends", u.End)
+		}
+	}
+	return nil
+}
+
+// hostPreview is who hosts one upcoming occurrence of a series. EventID is
+// nil for occurrences that aren't scheduled yet.
+type hostPreview struct {
+	Date     time.Time           `json:"date"`
+	EventID  *primitive.ObjectID `json:"event_id,omitempty"`
+	HostID   primitive.ObjectID  `json:"host_id"`
+	HostName string              `json:"host_name"`
+	// Conflict is set when the host can't host on the day
+	Conflict bool `json:"conflict"`
+}
+
+// GetEventHosts previews who hosts the next occurrences of an event's series:
+// the ones already scheduled, then those the rotation would pick after them,
+// flagging hosts who can't make their date. Nothing is scheduled by it.
+func (s *Server) GetEventHosts(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid event id", http.StatusBadRequest)
+		return
+	}
+	count := defaultHostPreview
+	if c := r.URL.Query().Get("count"); c != "" {
+		count, err = strconv.Atoi(c)
+		if err != nil || count < 1 || count > maxHostPreview {
+			http.Error(w, fmt.Sprintf("count must be from 1 to %d", maxHostPreview), http.StatusBadRequest)
+			return
+		}
+	}
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	event, err := s.DB.GetEvent(ctx, id)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	allowed, err := s.Authz.CanViewEvent(ctx, actor, event)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+	if !inSeries(event) {
+		http.Error(w, "Event is not recurring", http.StatusBadRequest)
+		return
+	}
+
+	series, err := s.loadSeries(ctx, event)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	occurrences, err := s.DB.GetEventsByRecurrenceID(ctx, series.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	group, err := s.DB.GetGroup(ctx, series.GroupID)
+	if err != nil {
+		group = nil
+	}
+
+	var scheduled []models.Event
+	for i := range occurrences {
+		if isScheduled(&occurrences[i]) {
+			scheduled = append(scheduled, occurrences[i])
+		}
+	}
+	rotation := s.newHostRotation(ctx, series, group, scheduled)
+
+	previews := []hostPreview{}
+	for i := range scheduled {
+		if len(previews) == count {
+			break
+		}
+		o := &scheduled[i]
+		previews = append(previews, hostPreview{
+			Date:     o.Date,
+			EventID:  &o.ID,
+			HostID:   o.HostID,
+			Conflict: !rotation.available(o.HostID, occurrenceDay(o)),
+		})
+	}
+	last := lastOccurrence(occurrences)
+	for last != nil && len(previews) < count {
+		next, ok, err := followingOccurrence(last, series)
+		if err != nil || !ok {
+			break
+		}
+		if len(rotation.order) > 0 {
+			next.HostID = rotation.next(last.HostID, occurrenceDay(&next))
+		}
+		previews = append(previews, hostPreview{
+			Date:     next.Date,
+			HostID:   next.HostID,
+			Conflict: !rotation.available(next.HostID, occurrenceDay(&next)),
+		})
+		last = &next
+	}
+
+	names := map[primitive.ObjectID]string{}
+	for _, m := range rotation.order {
+		names[m.ID] = m.Name
+	}
+	for i := range previews {
+		previews[i].HostName = names[previews[i].HostID]
+	}
+	json.NewEncoder(w).Encode(previews)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckUnavailability(t *testing.T) {
+	tests := []struct {
+		name        string
+		unavailable []models.Unavailability
+		wantErr     bool
+	}{
+		{"none", nil, false},
+		{"one day", []models.Unavailability{{Start: "2025-06-01", End: "2025-06-01"}}, false},
+		{"a week", []models.Unavailability{{Start: "2025-06-01", End: "2025-06-07", Reason: "Beach"}}, false},
+		{"ends before it starts", []models.Unavailability{{Start: "2025-06-07", End: "2025-06-01"}}, true},
+		{"not a date", []models.Unavailability{{Start: "June 1st", End: "2025-06-01"}}, true},
+		{"missing end", []models.Unavailability{{Start: "2025-06-01"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkUnavailability(tt.unavailable); (err != nil) != tt.wantErr {
+				t.Errorf("checkUnavailability() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetEventHosts(t *testing.T) {
+	f := newSeriesFixture()
+	this := f.occurrences[1]
+	other := primitive.NewObjectID()
+	f.db.GetFamilyMembersByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.FamilyMember, error) {
+		return []models.FamilyMember{
+			{ID: f.hostID, Name: "Ann", Unavailable: []models.Unavailability{{Start: "2025-01-19", End: "2025-01-19"}}},
+			{ID: other, Name: "Bob", Unavailable: []models.Unavailability{{Start: "2025-01-20", End: "2025-01-31"}}},
+		}, nil
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/events/"+this.ID.Hex()+"/hosts"+query, nil)
+		req.SetPathValue("id", this.ID.Hex())
+		req = withFamilyMember(req, &models.FamilyMember{ID: f.hostID, GroupIDs: []primitive.ObjectID{this.GroupID}})
+		rr := httptest.NewRecorder()
+		f.server.GetEventHosts(rr, req)
+		return rr
+	}
+
+	rr := get("?count=4")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var got []hostPreview
+	json.NewDecoder(rr.Body).Decode(&got)
+
+	start := f.series.Start
+	want := []hostPreview{
+		{Date: start.AddDate(0, 0, 7), EventID: &this.ID, HostID: f.hostID, HostName: "Ann"},
+		// Ann is down to host a day she's away
+		{Date: start.AddDate(0, 0, 14), EventID: &f.occurrences[2].ID, HostID: f.hostID, HostName: "Ann", Conflict: true},
+		// Bob is away, so Ann hosts again
+		{Date: start.AddDate(0, 0, 21), HostID: f.hostID, HostName: "Ann"},
+		{Date: start.AddDate(0, 0, 28), HostID: other, HostName: "Bob"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d previews, got %+v", len(want), got)
+	}
+	for i := range want {
+		if !got[i].Date.Equal(want[i].Date) || !sameEventID(got[i].EventID, want[i].EventID) || got[i].HostID != want[i].HostID ||
+			got[i].HostName != want[i].HostName || got[i].Conflict != want[i].Conflict {
+			t.Errorf("preview %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	if len(f.created) > 0 {
+		t.Errorf("expected nothing to be scheduled, got %+v", f.created)
+	}
+
+	if rr := get("?count=0"); rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func sameEventID(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
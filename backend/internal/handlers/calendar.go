@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/ical"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/recurrence"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Calendar feed secrets carry their own prefix so they are never mistaken for
+// API tokens, which they can't be used as.
+const calendarTokenPrefix = "fpcal_"
+
+// CreateCalendarToken gives the current member a new secret for the URLs of
+// their calendar feeds, which stops the old URLs working. The secret is only
+// ever returned in this response.
+func (s *Server) CreateCalendarToken(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := newRandomToken()
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	token := calendarTokenPrefix + secret
+	update := bson.M{"$set": bson.M{"calendar_token_hash": hashToken(token)}}
+	if err := s.DB.UpdateFamilyMember(context.Background(), actor.ID, update); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"token":      token,
+		"events_url": "/calendar/" + token + "/events.ics",
+	})
+}
+
+// DeleteCalendarToken turns the current member's calendar feeds off.
+func (s *Server) DeleteCalendarToken(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	update := bson.M{"$unset": bson.M{"calendar_token_hash": ""}}
+	if err := s.DB.UpdateFamilyMember(context.Background(), actor.ID, update); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// calendarMember returns the member whose calendar feed secret is in the URL,
+// writing a 404 if there is none. Calendar apps can't sign in, so the secret
+// is all that protects a feed.
+func (s *Server) calendarMember(w http.ResponseWriter, r *http.Request) (*models.FamilyMember, bool) {
+	token := r.PathValue("token")
+	if !strings.HasPrefix(token, calendarTokenPrefix) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	member, err := s.DB.GetFamilyMemberByCalendarToken(r.Context(), hashToken(token))
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	return member, true
+}
+
+// GetUserCalendar serves a member's calendar feed: the events of all their
+// groups and those they are a guest at.
+func (s *Server) GetUserCalendar(w http.ResponseWriter, r *http.Request) {
+	member, ok := s.calendarMember(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	var events []models.Event
+	for _, groupID := range member.GroupIDs {
+		groupEvents, err := s.DB.GetEventsByGroupID(ctx, groupID, true)
+		if err != nil {
+			http.Error(w, "Failed to load events", http.StatusInternalServerError)
+			return
+		}
+		events = append(events, groupEvents...)
+	}
+	guestEvents, err := s.DB.GetEventsByUserID(ctx, member.ID)
+	if err != nil {
+		http.Error(w, "Failed to load events", http.StatusInternalServerError)
+		return
+	}
+	events = append(events, guestEvents...)
+
+	s.writeCalendar(w, s.calendarFeed(ctx, member, "Family Potluck", events), "events.ics")
+}
+
+// GetGroupCalendar serves the calendar feed of one of a member's groups. The
+// file name in the URL is the group's ID with an .ics extension.
+func (s *Server) GetGroupCalendar(w http.ResponseWriter, r *http.Request) {
+	member, ok := s.calendarMember(w, r)
+	if !ok {
+		return
+	}
+	file := r.PathValue("file")
+	groupID, err := primitive.ObjectIDFromHex(strings.TrimSuffix(file, ".ics"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	allowed, err := s.Authz.CanViewGroup(ctx, member, groupID)
+	if err != nil || !allowed {
+		http.NotFound(w, r)
+		return
+	}
+	group, err := s.DB.GetGroup(ctx, groupID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	events, err := s.DB.GetEventsByGroupID(ctx, groupID, true)
+	if err != nil {
+		http.Error(w, "Failed to load events", http.StatusInternalServerError)
+		return
+	}
+
+	s.writeCalendar(w, s.calendarFeed(ctx, member, group.Name, events), file)
+}
+
+func (s *Server) writeCalendar(w http.ResponseWriter, calendar *ical.Calendar, file string) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", file))
+	if err := calendar.Encode(w); err != nil {
+		fmt.Printf("Failed to write calendar %s: %v\n", file, err)
+	}
+}
+
+// calendarFeed turns events into a feed for member. The series of the
+// member's groups become recurring events, with their occurrences and skipped
+// days overriding the slots they are in; other events stand alone.
+func (s *Server) calendarFeed(ctx context.Context, member *models.FamilyMember, name string, events []models.Event) *ical.Calendar {
+	f := &feedBuilder{
+		s:         s,
+		ctx:       ctx,
+		member:    member,
+		stamp:     time.Now(),
+		hosts:     map[primitive.ObjectID]string{},
+		addresses: map[primitive.ObjectID]string{},
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+	seen := map[primitive.ObjectID]bool{}
+	var seriesIDs []primitive.ObjectID
+	occurrences := map[primitive.ObjectID][]models.Event{}
+	calendar := &ical.Calendar{Name: name}
+	for _, e := range events {
+		if seen[e.ID] {
+			continue
+		}
+		seen[e.ID] = true
+		if inSeries(&e) && slices.Contains(member.GroupIDs, e.GroupID) {
+			if _, ok := occurrences[e.RecurrenceID]; !ok {
+				seriesIDs = append(seriesIDs, e.RecurrenceID)
+			}
+			occurrences[e.RecurrenceID] = append(occurrences[e.RecurrenceID], e)
+			continue
+		}
+		calendar.Events = append(calendar.Events, f.event(&e, "event-"+e.ID.Hex()))
+	}
+
+	for _, id := range seriesIDs {
+		calendar.Events = append(calendar.Events, f.series(occurrences[id])...)
+	}
+	return calendar
+}
+
+// feedBuilder makes the events of a calendar feed, remembering the hosts it
+// has looked up.
+type feedBuilder struct {
+	s         *Server
+	ctx       context.Context
+	member    *models.FamilyMember
+	stamp     time.Time
+	hosts     map[primitive.ObjectID]string // names by member ID
+	addresses map[primitive.ObjectID]string // household addresses by member ID
+}
+
+// series returns the recurring event for the series occurrences belong to,
+// followed by their overrides. If the series can't be loaded, the occurrences
+// stand alone.
+func (f *feedBuilder) series(occurrences []models.Event) []ical.Event {
+	template, err := f.s.loadSeries(f.ctx, &occurrences[0])
+	var rs recurrence.Series
+	if err == nil {
+		rs, err = templateSeries(template)
+	}
+	if err != nil {
+		fmt.Printf("Failed to load series %s for calendar: %v\n", occurrences[0].RecurrenceID.Hex(), err)
+		var events []ical.Event
+		for i := range occurrences {
+			events = append(events, f.event(&occurrences[i], "event-"+occurrences[i].ID.Hex()))
+		}
+		return events
+	}
+
+	uid := "series-" + template.ID.Hex()
+	first := models.Event{
+		Name:            template.Name,
+		Date:            rs.Start,
+		TimeZone:        template.TimeZone,
+		DurationMinutes: template.DurationMinutes,
+		Location:        template.Location,
+		Description:     template.Description,
+	}
+	master := ical.Event{
+		UID:         uid + "@family-potluck",
+		Summary:     template.Name,
+		Description: template.Description,
+		Location:    template.Location,
+		Start:       rs.Start,
+		End:         first.End(),
+		TimeZone:    template.TimeZone,
+		RRule:       rs.RRule(),
+		Status:      ical.StatusConfirmed,
+		Stamp:       f.stamp,
+	}
+	events := []ical.Event{master}
+
+	for _, ex := range template.ExDates {
+		slot := rs.SlotOn(ex)
+		skipped := first
+		skipped.Date = slot
+		event := f.event(&skipped, uid)
+		event.RecurrenceID = &slot
+		event.Status = ical.StatusCancelled
+		events = append(events, event)
+	}
+	for i := range occurrences {
+		o := &occurrences[i]
+		slot := occurrenceSlot(o)
+		event := f.event(o, uid)
+		event.RecurrenceID = &slot
+		events = append(events, event)
+	}
+	return events
+}
+
+// event returns e as a calendar event with the given UID. It takes place at
+// the host's household if it has no location of its own, and says what the
+// member is bringing to it, or why it was cancelled.
+func (f *feedBuilder) event(e *models.Event, uid string) ical.Event {
+	location := e.Location
+	if location == "" && !e.HostID.IsZero() {
+		location = f.address(e.HostID)
+	}
+
+	var description []string
+	if e.Status == "cancelled" && e.CancelReason != "" {
+		description = append(description, "Cancelled: "+e.CancelReason)
+	}
+	if e.Description != "" {
+		description = append(description, e.Description)
+	}
+	if host := f.host(e.HostID); host != "" {
+		description = append(description, "Hosted by "+host)
+	}
+	if dishes := f.pledged(e); len(dishes) > 0 {
+		description = append(description, "You're bringing: "+strings.Join(dishes, ", "))
+	}
+
+	status := ical.StatusConfirmed
+	if e.Status == "cancelled" {
+		status = ical.StatusCancelled
+	}
+	return ical.Event{
+		UID:         uid + "@family-potluck",
+		Summary:     e.Name,
+		Description: strings.Join(description, "\n\n"),
+		Location:    location,
+		Start:       e.Date,
+		End:         e.End(),
+		TimeZone:    e.TimeZone,
+		Status:      status,
+		Stamp:       f.stamp,
+	}
+}
+
+func (f *feedBuilder) host(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	name, ok := f.hosts[id]
+	if !ok {
+		if host, err := f.s.DB.GetFamilyMemberByID(f.ctx, id); err == nil {
+			name = host.Name
+		}
+		f.hosts[id] = name
+	}
+	return name
+}
+
+func (f *feedBuilder) address(id primitive.ObjectID) string {
+	address, ok := f.addresses[id]
+	if !ok {
+		address = f.s.householdAddress(f.ctx, id)
+		f.addresses[id] = address
+	}
+	return address
+}
+
+// pledged returns the dishes the member has said they'll bring to e, if it's
+// yet to happen.
+func (f *feedBuilder) pledged(e *models.Event) []string {
+	if e.ID.IsZero() || !isScheduled(e) {
+		return nil
+	}
+	dishes, err := f.s.DB.GetDishesByEventID(f.ctx, e.ID)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, d := range dishes {
+		if d.BringerID != nil && *d.BringerID == f.member.ID {
+			names = append(names, d.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreateCalendarToken(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+	member := &models.FamilyMember{ID: primitive.NewObjectID()}
+
+	var saved bson.M
+	mockDB.UpdateFamilyMemberFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+		saved = update
+		return nil
+	}
+
+	req, _ := http.NewRequest("POST", "/auth/calendar", nil)
+	req = withFamilyMember(req, member)
+	rr := httptest.NewRecorder()
+	server.CreateCalendarToken(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+	}
+	var resp map[string]string
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if !strings.HasPrefix(resp["token"], calendarTokenPrefix) {
+		t.Fatalf("expected a calendar token, got %q", resp["token"])
+	}
+	if resp["events_url"] != "/calendar/"+resp["token"]+"/events.ics" {
+		t.Errorf("unexpected events url %q", resp["events_url"])
+	}
+	if set, _ := saved["$set"].(bson.M); set["calendar_token_hash"] != hashToken(resp["token"]) {
+		t.Errorf("expected the token's hash to be saved, got %v", saved)
+	}
+}
+
+// calendarFixture is the series fixture with a member who subscribes to its
+// group's calendar, a skipped week, a picnic at the host's house and an event
+// elsewhere the member is a guest at.
+type calendarFixture struct {
+	*seriesFixture
+	member  *models.FamilyMember
+	token   string
+	picnic  models.Event
+	wedding models.Event
+}
+
+func newCalendarFixture() *calendarFixture {
+	f := &calendarFixture{seriesFixture: newSeriesFixture(), token: calendarTokenPrefix + "secret"}
+	groupID := f.series.GroupID
+	household := primitive.NewObjectID()
+	f.member = &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}}
+	f.series.ExDates = []time.Time{f.series.Start.AddDate(0, 0, 28)}
+	f.picnic = models.Event{
+		ID:      primitive.NewObjectID(),
+		GroupID: groupID,
+		HostID:  f.hostID,
+		Name:    "Picnic",
+		Date:    time.Date(2025, time.July, 4, 12, 0, 0, 0, time.UTC),
+		Status:  "scheduled",
+	}
+	f.wedding = models.Event{
+		ID:           primitive.NewObjectID(),
+		GroupID:      primitive.NewObjectID(),
+		Name:         "Cousin's Wedding",
+		Date:         time.Date(2025, time.August, 9, 14, 0, 0, 0, time.UTC),
+		Recurrence:   "FREQ=YEARLY",
+		RecurrenceID: primitive.NewObjectID(),
+		Location:     "The Chapel",
+		Status:       "scheduled",
+	}
+
+	f.db.GetFamilyMemberByCalendarTokenFunc = func(ctx context.Context, hash string) (*models.FamilyMember, error) {
+		if hash != hashToken(f.token) {
+			return nil, database.ErrNoDocuments
+		}
+		return f.member, nil
+	}
+	f.db.GetEventsByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID, includeCompleted bool) ([]models.Event, error) {
+		if id != groupID {
+			return nil, nil
+		}
+		return append(append([]models.Event(nil), f.occurrences...), f.picnic), nil
+	}
+	f.db.GetEventsByUserIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Event, error) {
+		return []models.Event{f.wedding}, nil
+	}
+	f.db.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: id, Name: "Grandma", HouseholdID: &household}, nil
+	}
+	f.db.GetHouseholdFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Household, error) {
+		return &models.Household{ID: id, Address: "1 Oak Lane"}, nil
+	}
+	f.db.GetDishesByEventIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Dish, error) {
+		if id != f.picnic.ID {
+			return nil, nil
+		}
+		other := primitive.NewObjectID()
+		return []models.Dish{
+			{Name: "Potato Salad", BringerID: &f.member.ID},
+			{Name: "Lemonade", BringerID: &other},
+		}, nil
+	}
+	return f
+}
+
+func (f *calendarFixture) get(path string, handler http.HandlerFunc, values map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	for k, v := range values {
+		req.SetPathValue(k, v)
+	}
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+// unfold joins the folded lines of an iCalendar stream.
+func unfold(s string) string {
+	return strings.ReplaceAll(s, "\r\n ", "")
+}
+
+func TestGetUserCalendar(t *testing.T) {
+	f := newCalendarFixture()
+	rr := f.get("/calendar/"+f.token+"/events.ics", f.server.GetUserCalendar, map[string]string{"token": f.token})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("expected a calendar, got %q", ct)
+	}
+	body := unfold(rr.Body.String())
+	seriesUID := "UID:series-" + f.series.ID.Hex() + "@family-potluck"
+	for _, want := range []string{
+		seriesUID + "\r\n",
+		"DTSTART:20250105T180000Z\r\nDTEND:20250106T000000Z\r\nRRULE:FREQ=WEEKLY\r\n",
+		// The skipped week
+		"RECURRENCE-ID:20250202T180000Z\r\n",
+		"STATUS:CANCELLED\r\n",
+		// An occurrence
+		"RECURRENCE-ID:20250112T180000Z\r\n",
+		"UID:event-" + f.picnic.ID.Hex() + "@family-potluck\r\n",
+		"LOCATION:1 Oak Lane\r\n",
+		`DESCRIPTION:Hosted by Grandma\n\nYou're bringing: Potato Salad` + "\r\n",
+		// The member isn't in the wedding's group, so it stands alone
+		"UID:event-" + f.wedding.ID.Hex() + "@family-potluck\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("calendar is missing %q:\n%s", want, body)
+		}
+	}
+	if n := strings.Count(body, seriesUID); n != 1+1+len(f.occurrences) {
+		t.Errorf("expected the series, its skipped week and %d occurrences, got %d events", len(f.occurrences), n)
+	}
+	if n := strings.Count(body, "RRULE:"); n != 1 {
+		t.Errorf("expected only the series to recur, got %d rules", n)
+	}
+
+	rr = f.get("/calendar/fpcal_wrong/events.ics", f.server.GetUserCalendar, map[string]string{"token": "fpcal_wrong"})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestGetGroupCalendar(t *testing.T) {
+	f := newCalendarFixture()
+	file := f.series.GroupID.Hex() + ".ics"
+	rr := f.get("/calendar/"+f.token+"/groups/"+file, f.server.GetGroupCalendar, map[string]string{"token": f.token, "file": file})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	body := unfold(rr.Body.String())
+	if !strings.Contains(body, "RRULE:FREQ=WEEKLY\r\n") || !strings.Contains(body, "SUMMARY:Picnic\r\n") {
+		t.Errorf("expected the group's events, got:\n%s", body)
+	}
+	if strings.Contains(body, "Wedding") {
+		t.Errorf("expected only the group's events, got:\n%s", body)
+	}
+
+	// Groups the member isn't in aren't found
+	other := primitive.NewObjectID().Hex() + ".ics"
+	rr = f.get("/calendar/"+f.token+"/groups/"+other, f.server.GetGroupCalendar, map[string]string{"token": f.token, "file": other})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
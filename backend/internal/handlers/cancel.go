@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// What cancelling an occurrence of a recurring event does to its series.
+const (
+	// cancelNext calls off just the occurrence, and the series schedules
+	// another after its last to keep its number ahead. It is the default.
+	cancelNext = "next"
+	// cancelEnd calls off the occurrence and those scheduled after it, and
+	// ends the series before it.
+	cancelEnd = "end"
+)
+
+// loadEditableEvent returns the event in the URL if the current member can
+// edit it, writing the error response if not.
+func (s *Server) loadEditableEvent(w http.ResponseWriter, r *http.Request) (*models.FamilyMember, *models.Event, bool) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid event id", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	event, err := s.DB.GetEvent(context.Background(), id)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	// Verify admin of the group, host, or host's household
+	isAuthorized, err := s.Authz.CanEditEvent(context.Background(), actor, event)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if !isAuthorized {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return nil, nil, false
+	}
+	return actor, event, true
+}
+
+// CancelEvent calls an event off for the reason given, keeping its dishes,
+// RSVPs, swaps and chat, unlike DeleteEvent. Cancelled events drop out of
+// the usual listings. For an occurrence of a recurring event, series says
+// whether the series goes on to its next occurrence or ends.
+func (s *Server) CancelEvent(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reason string `json:"reason"`
+		Series string `json:"series"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	switch req.Series {
+	case "":
+		req.Series = cancelNext
+	case cancelNext, cancelEnd:
+	default:
+		http.Error(w, "series must be next or end", http.StatusBadRequest)
+		return
+	}
+
+	actor, event, ok := s.loadEditableEvent(w, r)
+	if !ok {
+		return
+	}
+	switch event.Status {
+	case "cancelled":
+		http.Error(w, "Event is already cancelled", http.StatusConflict)
+		return
+	case "completed":
+		http.Error(w, "Event has already happened", http.StatusConflict)
+		return
+	}
+
+	ctx := context.Background()
+	if inSeries(event) && req.Series == cancelEnd {
+		if err := s.cancelRestOfSeries(ctx, actor, event, reason); err != nil {
+			fmt.Printf("Failed to end series of %s: %v\n", event.ID.Hex(), err)
+			http.Error(w, "Failed to cancel event", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(event)
+		return
+	}
+
+	if err := s.cancelEvent(ctx, actor, event, bson.M{"status": "cancelled", "cancel_reason": reason}); err != nil {
+		http.Error(w, "Failed to cancel event", http.StatusInternalServerError)
+		return
+	}
+	if inSeries(event) {
+		series, err := s.loadSeries(ctx, event)
+		if err == nil {
+			_, err = s.fillSeries(ctx, actor, series)
+		}
+		if err != nil {
+			fmt.Printf("Failed to schedule the occurrence after %s: %v\n", event.ID.Hex(), err)
+		}
+	}
+	json.NewEncoder(w).Encode(event)
+}
+
+// cancelEvent saves set, which cancels event, and announces it.
+func (s *Server) cancelEvent(ctx context.Context, actor *models.FamilyMember, event *models.Event, set bson.M) error {
+	if err := s.DB.UpdateEvent(ctx, event.ID, bson.M{"$set": set}); err != nil {
+		return err
+	}
+	applyEventUpdate(event, set)
+	realtime.Publish(s.Hub, actor, realtime.EventCancelled(*event), eventTopics(event)...)
+	return nil
+}
+
+// cancelRestOfSeries cancels event and the occurrences scheduled after it,
+// and ends its series before it. The occurrences before it take the
+// shortened rule.
+func (s *Server) cancelRestOfSeries(ctx context.Context, actor *models.FamilyMember, event *models.Event, reason string) error {
+	series, err := s.loadSeries(ctx, event)
+	if err != nil {
+		return err
+	}
+	occurrences, err := s.DB.GetEventsByRecurrenceID(ctx, series.ID)
+	if err != nil {
+		return err
+	}
+	rs, err := templateSeries(series)
+	if err != nil {
+		return err
+	}
+	slot := occurrenceSlot(event)
+	ended := rs.Rule.EndBefore(slot.In(rs.Start.Location())).String()
+
+	for i := range occurrences {
+		if occurrences[i].ID == event.ID {
+			// Use the copy the caller loaded, in case the listing is stale
+			occurrences[i] = *event
+		}
+	}
+	for i := range occurrences {
+		o := &occurrences[i]
+		if !isScheduled(o) {
+			continue
+		}
+		set := bson.M{"recurrence": ended}
+		if occurrenceSlot(o).Before(slot) {
+			if err := s.DB.UpdateEvent(ctx, o.ID, bson.M{"$set": set}); err != nil {
+				return err
+			}
+			applyEventUpdate(o, set)
+			realtime.Publish(s.Hub, actor, realtime.EventUpdated(*o), eventTopics(o)...)
+			continue
+		}
+		set["status"], set["cancel_reason"] = "cancelled", reason
+		if err := s.cancelEvent(ctx, actor, o, set); err != nil {
+			return err
+		}
+		if o.ID == event.ID {
+			*event = *o
+		}
+	}
+	return s.DB.UpdateSeries(ctx, series.ID, bson.M{"$set": bson.M{"recurrence": ended}})
+}
+
+// UncancelEvent puts a cancelled event back on. An occurrence cut off when
+// its series was ended can't be, since it no longer fits the series' rule.
+func (s *Server) UncancelEvent(w http.ResponseWriter, r *http.Request) {
+	actor, event, ok := s.loadEditableEvent(w, r)
+	if !ok {
+		return
+	}
+	if event.Status != "cancelled" {
+		http.Error(w, "Event is not cancelled", http.StatusConflict)
+		return
+	}
+	if inSeries(event) {
+		ended, err := s.seriesEndedBefore(context.Background(), event)
+		if err != nil {
+			fmt.Printf("Failed to load series of %s: %v\n", event.ID.Hex(), err)
+			http.Error(w, "Failed to update event", http.StatusInternalServerError)
+			return
+		}
+		if ended {
+			http.Error(w, "The series ended before this occurrence", http.StatusConflict)
+			return
+		}
+	}
+
+	update := bson.M{"$set": bson.M{"status": "scheduled"}, "$unset": bson.M{"cancel_reason": ""}}
+	if err := s.DB.UpdateEvent(context.Background(), event.ID, update); err != nil {
+		http.Error(w, "Failed to update event", http.StatusInternalServerError)
+		return
+	}
+	event.Status = "scheduled"
+	event.CancelReason = ""
+
+	realtime.Publish(s.Hub, actor, realtime.EventUpdated(*event), eventTopics(event)...)
+	json.NewEncoder(w).Encode(event)
+}
+
+// seriesEndedBefore reports whether event's series ends before its slot, as
+// it does for the occurrence a series was ended at.
+func (s *Server) seriesEndedBefore(ctx context.Context, event *models.Event) (bool, error) {
+	series, err := s.loadSeries(ctx, event)
+	if err != nil {
+		return false, err
+	}
+	rs, err := templateSeries(series)
+	if err != nil {
+		return false, err
+	}
+	// Skipped slots are excluded, so only ask whether the rule gets that far
+	rs.ExDates = nil
+	_, ok := rs.Next(occurrenceSlot(event).Add(-time.Nanosecond))
+	return !ok, nil
+}
+
+// withoutCancelled drops cancelled events from a listing unless the request
+// asks for them with include_cancelled=true.
+func withoutCancelled(r *http.Request, events []models.Event) []models.Event {
+	if r.URL.Query().Get("include_cancelled") == "true" {
+		return events
+	}
+	kept := []models.Event{}
+	for _, e := range events {
+		if e.Status != "cancelled" {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
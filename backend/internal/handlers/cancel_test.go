@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (f *seriesFixture) cancel(event models.Event, body map[string]string) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/events/"+event.ID.Hex()+"/cancel", bytes.NewBuffer(b))
+	req.SetPathValue("id", event.ID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: f.hostID})
+	rr := httptest.NewRecorder()
+	f.server.CancelEvent(rr, req)
+	return rr
+}
+
+func TestCancelEvent(t *testing.T) {
+	f := newSeriesFixture()
+	oneOff := models.Event{ID: primitive.NewObjectID(), GroupID: f.series.GroupID, HostID: f.hostID, Name: "Picnic", Status: "scheduled"}
+	f.occurrences = append(f.occurrences, oneOff)
+
+	rr := f.cancel(oneOff, map[string]string{"reason": " Rain forecast "})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if set := f.updates[oneOff.ID]; set["status"] != "cancelled" || set["cancel_reason"] != "Rain forecast" {
+		t.Errorf("expected the picnic to be cancelled for the rain, got %v", set)
+	}
+	if len(f.deleted) > 0 || len(f.created) > 0 {
+		t.Errorf("expected nothing to be deleted or scheduled, got %v and %v", f.deleted, f.created)
+	}
+	var resp models.Event
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Status != "cancelled" || resp.CancelReason != "Rain forecast" {
+		t.Errorf("expected the cancelled event back, got %+v", resp)
+	}
+}
+
+func TestCancelEvent_Rejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+		body  map[string]string
+		want  int
+	}{
+		{"no reason", 1, map[string]string{"reason": "  "}, http.StatusBadRequest},
+		{"unknown series action", 1, map[string]string{"reason": "Ill", "series": "skip"}, http.StatusBadRequest},
+		{"already happened", 0, map[string]string{"reason": "Ill"}, http.StatusConflict},
+		{"already cancelled", 2, map[string]string{"reason": "Ill"}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSeriesFixture()
+			f.occurrences[2].Status = "cancelled"
+			rr := f.cancel(f.occurrences[tt.index], tt.body)
+			if rr.Code != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.want)
+			}
+			if len(f.updates) > 0 {
+				t.Errorf("expected nothing to change, got %v", f.updates)
+			}
+		})
+	}
+}
+
+func TestCancelEvent_Series(t *testing.T) {
+	t.Run("next", func(t *testing.T) {
+		f := newSeriesFixture()
+		this, next := f.occurrences[1], f.occurrences[2]
+
+		rr := f.cancel(this, map[string]string{"reason": "Grandma is away"})
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		if f.updates[this.ID]["status"] != "cancelled" {
+			t.Errorf("expected the occurrence to be cancelled, got %v", f.updates[this.ID])
+		}
+		if _, ok := f.updates[next.ID]; ok || len(f.deleted) > 0 || len(f.seriesUpdates) > 0 {
+			t.Errorf("expected the rest of the series to be left alone, got %v, %v and %v", f.updates, f.deleted, f.seriesUpdates)
+		}
+		// The cancelled occurrence no longer counts towards those scheduled
+		// ahead, so the series tops back up after its last
+		if len(f.created) != defaultOccurrencesAhead-1 || !f.created[0].Date.Equal(next.Date.AddDate(0, 0, 7)) {
+			t.Fatalf("expected %d occurrences after %v, got %v", defaultOccurrencesAhead-1, next.Date, f.created)
+		}
+	})
+
+	t.Run("end", func(t *testing.T) {
+		f := newSeriesFixture()
+		past, this, next := f.occurrences[0], f.occurrences[1], f.occurrences[2]
+
+		rr := f.cancel(this, map[string]string{"reason": "Moving away", "series": "end"})
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		for _, o := range []models.Event{this, next} {
+			if set := f.updates[o.ID]; set["status"] != "cancelled" || set["cancel_reason"] != "Moving away" || set["recurrence"] != "FREQ=WEEKLY;UNTIL=20250111" {
+				t.Errorf("expected %v to be cancelled and end the series, got %v", o.Date, set)
+			}
+		}
+		if _, ok := f.updates[past.ID]; ok {
+			t.Errorf("expected the completed occurrence to keep its history, got %v", f.updates[past.ID])
+		}
+		if len(f.seriesUpdates) != 1 || f.seriesUpdates[0]["recurrence"] != "FREQ=WEEKLY;UNTIL=20250111" {
+			t.Errorf("expected the series to end before the cancelled occurrence, got %v", f.seriesUpdates)
+		}
+		if len(f.deleted) > 0 || len(f.created) > 0 {
+			t.Errorf("expected nothing to be deleted or scheduled, got %v and %v", f.deleted, f.created)
+		}
+	})
+}
+
+func TestUncancelEvent(t *testing.T) {
+	f := newSeriesFixture()
+	f.occurrences[1].Status = "cancelled"
+	f.occurrences[1].CancelReason = "Grandma is away"
+	this := f.occurrences[1]
+
+	uncancel := func(event models.Event) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/events/"+event.ID.Hex()+"/uncancel", nil)
+		req.SetPathValue("id", event.ID.Hex())
+		req = withFamilyMember(req, &models.FamilyMember{ID: f.hostID})
+		rr := httptest.NewRecorder()
+		f.server.UncancelEvent(rr, req)
+		return rr
+	}
+
+	rr := uncancel(this)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if f.updates[this.ID]["status"] != "scheduled" {
+		t.Errorf("expected the occurrence to be back on, got %v", f.updates[this.ID])
+	}
+	var resp models.Event
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Status != "scheduled" || resp.CancelReason != "" {
+		t.Errorf("expected the event back without its reason, got %+v", resp)
+	}
+
+	if rr := uncancel(f.occurrences[2]); rr.Code != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+	}
+}
+
+func TestUncancelEvent_SeriesEnded(t *testing.T) {
+	f := newSeriesFixture()
+	this := f.occurrences[1]
+	if rr := f.cancel(this, map[string]string{"reason": "Moving away", "series": cancelEnd}); rr.Code != http.StatusOK {
+		t.Fatalf("cancel returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	req, _ := http.NewRequest("POST", "/events/"+this.ID.Hex()+"/uncancel", nil)
+	req.SetPathValue("id", this.ID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: f.hostID})
+	rr := httptest.NewRecorder()
+	f.server.UncancelEvent(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+	}
+	if f.occurrences[1].Status != "cancelled" {
+		t.Errorf("expected the occurrence past the series end to stay cancelled, got %q", f.occurrences[1].Status)
+	}
+}
+
+func TestFinishEvent_Cancelled(t *testing.T) {
+	f := newSeriesFixture()
+	f.occurrences[1].Status = "cancelled"
+	f.occurrences[1].CancelReason = "Grandma is away"
+	this := f.occurrences[1]
+
+	req, _ := http.NewRequest("POST", "/events/"+this.ID.Hex()+"/finish", nil)
+	req.SetPathValue("id", this.ID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: f.hostID})
+	rr := httptest.NewRecorder()
+	f.server.FinishEvent(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+	}
+	if f.occurrences[1].Status != "cancelled" || len(f.created) > 0 {
+		t.Errorf("expected the cancelled occurrence to be left alone, got %q and %d created", f.occurrences[1].Status, len(f.created))
+	}
+
+	// Cancelled after the scheduler read it
+	if _, err := f.server.finishEvent(context.Background(), nil, &this); !errors.Is(err, errAlreadyCompleted) {
+		t.Errorf("expected finishing a cancelled event to fail, got %v", err)
+	}
+	if f.occurrences[1].Status != "cancelled" || len(f.created) > 0 {
+		t.Errorf("expected the cancelled occurrence to be left alone, got %q and %d created", f.occurrences[1].Status, len(f.created))
+	}
+}
+
+func TestGetEvents_Cancelled(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+	groupID := primitive.NewObjectID()
+	mockDB.GetEventsByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID, includeCompleted bool) ([]models.Event, error) {
+		return []models.Event{
+			{ID: primitive.NewObjectID(), GroupID: groupID, Status: "scheduled"},
+			{ID: primitive.NewObjectID(), GroupID: groupID, Status: "cancelled"},
+		}, nil
+	}
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return &models.FamilyMember{Name: "Test Host"}, nil
+	}
+
+	for query, want := range map[string]int{"": 1, "&include_cancelled=true": 2} {
+		req, _ := http.NewRequest("GET", "/events?group_id="+groupID.Hex()+query, nil)
+		req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
+		rr := httptest.NewRecorder()
+		server.GetEvents(rr, req)
+
+		var resp []models.Event
+		json.NewDecoder(rr.Body).Decode(&resp)
+		if len(resp) != want {
+			t.Errorf("GET /events?...%s: expected %d events, got %d", query, want, len(resp))
+		}
+	}
+}
+
+func TestGetUserCalendar_Cancelled(t *testing.T) {
+	f := newCalendarFixture()
+	f.picnic.Status = "cancelled"
+	f.picnic.CancelReason = "Rain"
+	rr := f.get("/calendar/"+f.token+"/events.ics", f.server.GetUserCalendar, map[string]string{"token": f.token})
+
+	body := unfold(rr.Body.String())
+	start := strings.Index(body, "UID:event-"+f.picnic.ID.Hex())
+	if start < 0 {
+		t.Fatalf("expected the cancelled picnic to stay in the feed:\n%s", body)
+	}
+	picnic := body[start : start+strings.Index(body[start:], "END:VEVENT")]
+	if !strings.Contains(picnic, "STATUS:CANCELLED\r\n") || !strings.Contains(picnic, `DESCRIPTION:Cancelled: Rain\n\n`) {
+		t.Errorf("expected the picnic to be marked cancelled, got:\n%s", picnic)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
 	"net/http"
 	"time"
 
@@ -11,11 +12,19 @@ import (
 )
 
 func (s *Server) SendChatMessage(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var msg models.ChatMessage
 	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, msg.FamilyMemberID, "family_id") {
+		return
+	}
 
 	// Validation: Check if user is a group member (not just a guest)
 	// 1. Get Event to find GroupID
@@ -25,21 +34,13 @@ func (s *Server) SendChatMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 2. Get Family to check Group Membership
-	familyMember, err := s.DB.GetFamilyMemberByID(context.Background(), msg.FamilyMemberID)
+	// 2. Check the sender's Group Membership
+	isMember, err := s.Authz.CanPostChatMessage(context.Background(), actor, event)
 	if err != nil {
-		http.Error(w, "Family not found", http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	isMember := false
-	for _, gid := range familyMember.GroupIDs {
-		if gid == event.GroupID {
-			isMember = true
-			break
-		}
-	}
-
 	if !isMember {
 		http.Error(w, "Chat is restricted to group members only", http.StatusForbidden)
 		return
@@ -47,8 +48,9 @@ func (s *Server) SendChatMessage(w http.ResponseWriter, r *http.Request) {
 
 	// Set metadata
 	msg.ID = primitive.NewObjectID()
+	msg.FamilyMemberID = actor.ID
 	msg.CreatedAt = time.Now()
-	msg.FamilyName = familyMember.Name // Ensure name is correct from DB
+	msg.FamilyName = actor.Name // Ensure name is correct from DB
 
 	// Save to DB
 	err = s.DB.CreateChatMessage(context.Background(), &msg)
@@ -58,12 +60,7 @@ func (s *Server) SendChatMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast
-	broadcastMsg := map[string]interface{}{
-		"type": "new_chat_message",
-		"data": msg,
-	}
-	msgBytes, _ := json.Marshal(broadcastMsg)
-	s.Hub.Broadcast(msgBytes)
+	realtime.Publish(s.Hub, actor, realtime.ChatMessageSent(msg), eventTopics(event)...)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(msg)
@@ -82,6 +79,10 @@ func (s *Server) GetChatMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := s.loadViewableEvent(w, r, eventID); !ok {
+		return
+	}
+
 	messages, err := s.DB.GetChatMessagesByEventID(context.Background(), eventID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
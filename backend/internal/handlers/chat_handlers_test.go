@@ -44,6 +44,7 @@ func TestSendChatMessage(t *testing.T) {
 	body, _ := json.Marshal(msgReq)
 
 	req, _ := http.NewRequest("POST", "/chat", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID, Name: "Test Family", GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.SendChatMessage(rr, req)
@@ -64,6 +65,7 @@ func TestGetChatMessages(t *testing.T) {
 	server := NewServer(mockDB, nil)
 
 	eventID := primitive.NewObjectID()
+	groupID := primitive.NewObjectID()
 	messages := []models.ChatMessage{
 		{ID: primitive.NewObjectID(), EventID: eventID, Content: "Msg 1"},
 		{ID: primitive.NewObjectID(), EventID: eventID, Content: "Msg 2"},
@@ -73,7 +75,12 @@ func TestGetChatMessages(t *testing.T) {
 		return messages, nil
 	}
 
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: id, GroupID: groupID}, nil
+	}
+
 	req, _ := http.NewRequest("GET", "/chat?event_id="+eventID.Hex(), nil)
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.GetChatMessages(rr, req)
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CompleteEndedEvents finishes the events that had ended by now, as their
+// hosts would with POST /events/{id}/finish: one-off events are marked
+// completed and recurring ones roll forward to their next occurrences. The
+// scheduler runs it in the background.
+func (s *Server) CompleteEndedEvents(ctx context.Context, now time.Time) error {
+	events, err := s.DB.GetUnfinishedEventsBefore(ctx, now)
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		event := &events[i]
+		if !event.HasEnded(now) {
+			continue
+		}
+		// An event its host finished meanwhile is already rolled forward
+		if _, err := s.finishEvent(ctx, nil, event); err != nil && !errors.Is(err, errAlreadyCompleted) {
+			fmt.Printf("Failed to finish event %s: %v\n", event.ID.Hex(), err)
+		}
+	}
+	return nil
+}
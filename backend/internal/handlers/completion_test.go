@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"family-potluck/backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCompleteEndedEvents(t *testing.T) {
+	f := newSeriesFixture()
+	this := f.occurrences[1]
+	now := this.Date.AddDate(0, 0, 1)
+
+	oneOff := models.Event{ID: primitive.NewObjectID(), GroupID: this.GroupID, Date: now.Add(-3 * time.Hour), DurationMinutes: 120, Status: "scheduled"}
+	// Started an hour ago and has until midnight
+	ongoing := models.Event{ID: primitive.NewObjectID(), GroupID: this.GroupID, Date: now.Add(-time.Hour), Status: "scheduled"}
+	f.occurrences = append(f.occurrences, oneOff, ongoing)
+	f.db.GetUnfinishedEventsBeforeFunc = func(ctx context.Context, before time.Time) ([]models.Event, error) {
+		var events []models.Event
+		for _, e := range f.occurrences {
+			if e.Status != "completed" && e.Date.Before(before) {
+				events = append(events, e)
+			}
+		}
+		return events, nil
+	}
+
+	if err := f.server.CompleteEndedEvents(context.Background(), now); err != nil {
+		t.Fatalf("CompleteEndedEvents returned an error: %v", err)
+	}
+
+	for _, e := range []models.Event{this, oneOff} {
+		if f.updates[e.ID]["status"] != "completed" {
+			t.Errorf("expected the event on %v to be completed, got %v", e.Date, f.updates[e.ID])
+		}
+	}
+	if _, ok := f.updates[ongoing.ID]; ok {
+		t.Error("expected the ongoing event to be left alone")
+	}
+	// The series rolls forward to keep three occurrences scheduled
+	if len(f.created) != 2 || f.created[0].RecurrenceID != f.series.ID {
+		t.Errorf("expected the series to be topped up, got %+v", f.created)
+	}
+}
+
+func TestCompleteEndedEvents_StaleSeries(t *testing.T) {
+	f := newSeriesFixture()
+	// Nobody finished the January occurrences, and it is now June
+	now := time.Date(2025, time.June, 4, 12, 0, 0, 0, time.UTC)
+	f.server.Now = func() time.Time { return now }
+	f.db.GetUnfinishedEventsBeforeFunc = func(ctx context.Context, before time.Time) ([]models.Event, error) {
+		var events []models.Event
+		for _, e := range f.occurrences {
+			if e.Status != "completed" && e.Date.Before(before) {
+				events = append(events, e)
+			}
+		}
+		return events, nil
+	}
+
+	if err := f.server.CompleteEndedEvents(context.Background(), now); err != nil {
+		t.Fatalf("CompleteEndedEvents returned an error: %v", err)
+	}
+	want := []time.Time{
+		time.Date(2025, time.June, 8, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, time.June, 15, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, time.June, 22, 18, 0, 0, 0, time.UTC),
+	}
+	if len(f.created) != len(want) {
+		t.Fatalf("expected %d occurrences from June on, got %+v", len(want), f.created)
+	}
+	for i, e := range f.created {
+		if !e.Date.Equal(want[i]) {
+			t.Errorf("expected occurrence %d on %v, got %v", i, want[i], e.Date)
+		}
+	}
+
+	// The next tick has nothing left to catch up on
+	if err := f.server.CompleteEndedEvents(context.Background(), now.Add(5*time.Minute)); err != nil {
+		t.Fatalf("CompleteEndedEvents returned an error: %v", err)
+	}
+	if len(f.created) != len(want) {
+		t.Errorf("expected no more occurrences, got %d", len(f.created)-len(want))
+	}
+}
+
+func TestFinishEvent_OnlyOnce(t *testing.T) {
+	f := newSeriesFixture()
+	this := f.occurrences[1]
+	finish := func() int {
+		req, _ := http.NewRequest("POST", "/events/"+this.ID.Hex()+"/finish", nil)
+		req.SetPathValue("id", this.ID.Hex())
+		req = withFamilyMember(req, &models.FamilyMember{ID: f.hostID})
+		rr := httptest.NewRecorder()
+		f.server.FinishEvent(rr, req)
+		return rr.Code
+	}
+
+	if code := finish(); code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", code, http.StatusOK)
+	}
+	rolled := len(f.created)
+	// Both read the event before either completed it
+	f.occurrences[1].Status = "scheduled"
+	f.db.CompleteEventFunc = func(ctx context.Context, id primitive.ObjectID) (bool, error) {
+		return false, nil
+	}
+	if code := finish(); code != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v", code, http.StatusConflict)
+	}
+	f.db.GetUnfinishedEventsBeforeFunc = func(ctx context.Context, before time.Time) ([]models.Event, error) {
+		return []models.Event{f.occurrences[1]}, nil
+	}
+	if err := f.server.CompleteEndedEvents(context.Background(), this.Date.AddDate(0, 0, 1)); err != nil {
+		t.Errorf("CompleteEndedEvents returned an error: %v", err)
+	}
+
+	if rolled == 0 || len(f.created) != rolled {
+		t.Errorf("expected the series to roll forward once, got %d then %d occurrences", rolled, len(f.created))
+	}
+}
+
+func TestFillSeries_SkipsScheduledSlot(t *testing.T) {
+	f := newSeriesFixture()
+	f.occurrences[1].Status = "completed"
+	// Another request schedules the next occurrence between this one loading
+	// the series and creating it
+	createOccurrence := f.db.CreateOccurrenceFunc
+	f.db.CreateOccurrenceFunc = func(ctx context.Context, event *models.Event) (bool, error) {
+		createOccurrence(ctx, event)
+		return false, nil
+	}
+	f.created = nil
+
+	series := f.series
+	if _, err := f.server.fillSeries(context.Background(), nil, &series); err != nil {
+		t.Fatalf("fillSeries returned an error: %v", err)
+	}
+	if len(f.created) != 1 {
+		t.Errorf("expected to stop at the occurrence scheduled meanwhile, got %+v", f.created)
+	}
+}
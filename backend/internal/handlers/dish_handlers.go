@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
 	"net/http"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -11,11 +12,23 @@ import (
 )
 
 func (s *Server) AddDish(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var dish models.Dish
 	if err := json.NewDecoder(r.Body).Decode(&dish); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// A dish can only be pledged on behalf of the authenticated user
+	if dish.BringerID != nil && !matchesActor(w, actor, *dish.BringerID, "bringer_id") {
+		return
+	}
+	if _, ok := s.loadViewableEvent(w, r, dish.EventID); !ok {
+		return
+	}
 
 	dish.ID = primitive.NewObjectID()
 	err := s.DB.CreateDish(context.Background(), &dish)
@@ -25,12 +38,7 @@ func (s *Server) AddDish(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast update
-	msg := map[string]interface{}{
-		"type": "dish_added",
-		"data": dish,
-	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
+	realtime.Publish(s.Hub, actor, realtime.DishAdded(dish), s.eventTopicsByID(context.Background(), dish.EventID)...)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(dish)
@@ -49,6 +57,10 @@ func (s *Server) GetDishes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := s.loadViewableEvent(w, r, eventID); !ok {
+		return
+	}
+
 	dishes, err := s.DB.GetDishesByEventID(context.Background(), eventID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -94,6 +106,11 @@ func (s *Server) PledgeDish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		FamilyMemberID primitive.ObjectID `json:"family_id"`
 	}
@@ -101,6 +118,9 @@ func (s *Server) PledgeDish(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.FamilyMemberID, "family_id") {
+		return
+	}
 
 	// Fetch dish to get event_id
 	dish, err := s.DB.GetDishByID(context.Background(), id)
@@ -108,37 +128,29 @@ func (s *Server) PledgeDish(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Dish not found", http.StatusNotFound)
 		return
 	}
+	if _, ok := s.loadViewableEvent(w, r, dish.EventID); !ok {
+		return
+	}
 
 	err = s.DB.UpdateDish(
 		context.Background(),
 		id,
-		bson.M{"$set": bson.M{"bringer_id": req.FamilyMemberID}},
+		bson.M{"$set": bson.M{"bringer_id": actor.ID}},
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Fetch Family Name for broadcast
-	familyMember, err := s.DB.GetFamilyMemberByID(context.Background(), req.FamilyMemberID)
-	familyName := "Someone"
-	if err == nil {
-		familyName = familyMember.Name
-	}
-
 	// Broadcast update
-	msg := map[string]interface{}{
-		"type": "dish_pledged",
-		"data": map[string]interface{}{
-			"dish_id":      id,
-			"event_id":     dish.EventID,
-			"bringer_id":   req.FamilyMemberID,
-			"bringer_name": familyName,
-			"dish_name":    dish.Name,
-		},
-	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
+	pledged := realtime.DishPledged{
+		DishID:      id,
+		EventID:     dish.EventID,
+		BringerID:   actor.ID,
+		BringerName: actor.Name,
+		DishName:    dish.Name,
+	}
+	realtime.Publish(s.Hub, actor, pledged, s.eventTopicsByID(context.Background(), dish.EventID)...)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -157,6 +169,9 @@ func (s *Server) UnpledgeDish(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Dish not found", http.StatusNotFound)
 		return
 	}
+	if _, ok := s.loadViewableEvent(w, r, dish.EventID); !ok {
+		return
+	}
 
 	err = s.DB.UpdateDish(
 		context.Background(),
@@ -169,16 +184,9 @@ func (s *Server) UnpledgeDish(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast update
-	msg := map[string]interface{}{
-		"type": "dish_unpledged",
-		"data": map[string]interface{}{
-			"dish_id":   id,
-			"event_id":  dish.EventID,
-			"dish_name": dish.Name,
-		},
-	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
+	actor, _ := FamilyMemberFromContext(r.Context())
+	unpledged := realtime.DishUnpledged{DishID: id, EventID: dish.EventID, DishName: dish.Name}
+	realtime.Publish(s.Hub, actor, unpledged, s.eventTopicsByID(context.Background(), dish.EventID)...)
 
 	// If it was a suggested dish, we might want to delete it if unpledged?
 	// No, let's keep it as a suggestion again.
@@ -203,14 +211,11 @@ func (s *Server) DeleteDish(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Permission check
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		http.Error(w, "Missing user_id", http.StatusBadRequest)
+	actor, ok := currentMember(w, r)
+	if !ok {
 		return
 	}
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
-	if err != nil {
-		http.Error(w, "Invalid user_id", http.StatusBadRequest)
+	if !queryActor(w, r, actor, "user_id") {
 		return
 	}
 
@@ -220,24 +225,12 @@ func (s *Server) DeleteDish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	group, err := s.DB.GetGroup(context.Background(), event.GroupID)
+	isAuthorized, err := s.Authz.CanDeleteDish(context.Background(), actor, dish, event)
 	if err != nil {
 		http.Error(w, "Group not found", http.StatusInternalServerError)
 		return
 	}
 
-	isAuthorized := false
-	if isAdmin(group.AdminIDs, userID) || event.HostID == userID || (dish.BringerID != nil && *dish.BringerID == userID) {
-		isAuthorized = true
-	} else {
-		// Check if userID is in host's household
-		userMember, errUser := s.DB.GetFamilyMemberByID(context.Background(), userID)
-		hostMember, errHost := s.DB.GetFamilyMemberByID(context.Background(), event.HostID)
-		if errUser == nil && errHost == nil && userMember.HouseholdID != nil && hostMember.HouseholdID != nil && *userMember.HouseholdID == *hostMember.HouseholdID {
-			isAuthorized = true
-		}
-	}
-
 	if !isAuthorized {
 		http.Error(w, "Unauthorized", http.StatusForbidden)
 		return
@@ -250,15 +243,7 @@ func (s *Server) DeleteDish(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast update
-	msg := map[string]interface{}{
-		"type": "dish_deleted",
-		"data": map[string]interface{}{
-			"dish_id":  id,
-			"event_id": dish.EventID,
-		},
-	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
+	realtime.Publish(s.Hub, actor, realtime.DishDeleted{DishID: id, EventID: dish.EventID}, eventTopics(event)...)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -21,11 +21,15 @@ func TestAddDish(t *testing.T) {
 	server := NewServer(mockDB, hub)
 
 	eventID := primitive.NewObjectID()
+	groupID := primitive.NewObjectID()
 	dishName := "Test Dish"
 
 	mockDB.CreateDishFunc = func(ctx context.Context, dish *models.Dish) error {
 		return nil
 	}
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: id, GroupID: groupID}, nil
+	}
 
 	dishReq := models.Dish{
 		EventID: eventID,
@@ -34,6 +38,7 @@ func TestAddDish(t *testing.T) {
 	body, _ := json.Marshal(dishReq)
 
 	req, _ := http.NewRequest("POST", "/dishes", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.AddDish(rr, req)
@@ -54,6 +59,7 @@ func TestGetDishes(t *testing.T) {
 	server := NewServer(mockDB, nil)
 
 	eventID := primitive.NewObjectID()
+	groupID := primitive.NewObjectID()
 	dishes := []models.Dish{
 		{ID: primitive.NewObjectID(), Name: "Dish 1", EventID: eventID},
 		{ID: primitive.NewObjectID(), Name: "Dish 2", EventID: eventID},
@@ -63,7 +69,12 @@ func TestGetDishes(t *testing.T) {
 		return dishes, nil
 	}
 
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: id, GroupID: groupID}, nil
+	}
+
 	req, _ := http.NewRequest("GET", "/dishes?event_id="+eventID.Hex(), nil)
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.GetDishes(rr, req)
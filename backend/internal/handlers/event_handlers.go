@@ -3,8 +3,10 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"family-potluck/backend/internal/gemini"
 	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
 	"fmt"
 	"net/http"
 	"sort"
@@ -15,33 +17,90 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// CreateEvent schedules an event in a group the current member belongs to.
+// Only the fields a member chooses are read from the body; the rest, such as
+// its status and guests, start out fresh.
 func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		models.Event
+		GroupID         primitive.ObjectID `json:"group_id"`
+		HostID          primitive.ObjectID `json:"host_id"`
+		Name            string             `json:"name"`
+		Date            time.Time          `json:"date"`
+		TimeZone        string             `json:"time_zone"`
+		DurationMinutes int                `json:"duration_minutes"`
+		Type            string             `json:"type"`
+		Location        string             `json:"location"`
+		Description     string             `json:"description"`
+		Recurrence      string             `json:"recurrence"`
+		HostRotation    string             `json:"host_rotation"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	event := req.Event
-	event.ID = primitive.NewObjectID()
-	event.GuestJoinCode = generateJoinCode()
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+	isMember, err := s.Authz.CanViewGroup(context.Background(), actor, req.GroupID)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusInternalServerError)
+		return
+	}
+	if !isMember {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	event := models.Event{
+		ID:              primitive.NewObjectID(),
+		GroupID:         req.GroupID,
+		HostID:          req.HostID,
+		Name:            req.Name,
+		Date:            req.Date,
+		TimeZone:        req.TimeZone,
+		DurationMinutes: req.DurationMinutes,
+		Type:            req.Type,
+		Location:        req.Location,
+		Description:     req.Description,
+		Recurrence:      req.Recurrence,
+		HostRotation:    req.HostRotation,
+		GuestJoinCode:   generateJoinCode(),
+		Status:          "scheduled",
+	}
 	if event.Recurrence != "" {
+		rule, err := normalizeRecurrence(event.Recurrence)
+		if err != nil {
+			http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		start := event.Date
+		event.Recurrence = rule
 		event.RecurrenceID = primitive.NewObjectID()
+		event.RecurrenceStart = &start
 	}
 
-	// Check if host is in a household and set address if needed
-	hostFamilyMember, err := s.DB.GetFamilyMemberByID(context.Background(), event.HostID)
-	if err == nil {
-		event.HostHouseholdID = hostFamilyMember.HouseholdID
-		if hostFamilyMember.HouseholdID != nil {
-			household, err := s.DB.GetHousehold(context.Background(), *hostFamilyMember.HouseholdID)
-			if err == nil && household.Address != "" && event.Location == "" {
-				event.Location = household.Address
-			}
+	if event.TimeZone == "" {
+		group, err := s.DB.GetGroup(context.Background(), event.GroupID)
+		if err == nil {
+			event.TimeZone = group.TimeZone
 		}
 	}
+	if err := checkTimeZone(event.TimeZone); err != nil {
+		http.Error(w, "Invalid time zone: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if event.DurationMinutes < 0 {
+		http.Error(w, "duration_minutes can't be negative", http.StatusBadRequest)
+		return
+	}
+	if err := checkHostRotation(event.HostRotation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.hostAtHousehold(context.Background(), &event)
 
 	err = s.DB.CreateEvent(context.Background(), &event)
 	if err != nil {
@@ -50,65 +109,45 @@ func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast update
-	msg := map[string]interface{}{
-		"type": "event_created",
-		"data": event,
-	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
+	realtime.Publish(s.Hub, actor, realtime.EventCreated(event), eventTopics(&event)...)
 
 	// Suggest dishes using Gemini only if there is a proper description
-	if len(strings.TrimSpace(event.Description)) >= 10 {
-		go func(event models.Event) {
-			// Broadcast that suggestions are starting
-			startMsg := map[string]interface{}{
-				"type": "suggestions_started",
-				"data": map[string]interface{}{"event_id": event.ID},
-			}
-			startBytes, _ := json.Marshal(startMsg)
-			s.Hub.Broadcast(startBytes)
-
-			// Ensure we always send finished message
-			defer func() {
-				finishMsg := map[string]interface{}{
-					"type": "suggestions_finished",
-					"data": map[string]interface{}{"event_id": event.ID},
-				}
-				finishBytes, _ := json.Marshal(finishMsg)
-				s.Hub.Broadcast(finishBytes)
-			}()
+	s.suggestDishes(event)
 
-			suggestions, err := gemini.SuggestDishes(context.Background(), event.Name, event.Description, event.Type)
-			if err != nil {
-				fmt.Printf("Failed to suggest dishes: %v\n", err)
-				return
-			}
+	s.startSeries(context.Background(), actor, &event)
 
-			for _, sDish := range suggestions {
-				dish := models.Dish{
-					ID:          primitive.NewObjectID(),
-					EventID:     event.ID,
-					Name:        sDish.Name,
-					Description: sDish.Description,
-					DietaryTags: sDish.DietaryTags,
-					IsSuggested: true,
-				}
-				err = s.DB.CreateDish(context.Background(), &dish)
-				if err == nil {
-					// Broadcast update for each dish
-					msg := map[string]interface{}{
-						"type": "dish_added",
-						"data": dish,
-					}
-					msgBytes, _ := json.Marshal(msg)
-					s.Hub.Broadcast(msgBytes)
-				}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(event)
+}
+
+// hostAtHousehold notes the household of a new event's host and, if the event
+// has no location, has it at the household's address.
+func (s *Server) hostAtHousehold(ctx context.Context, event *models.Event) {
+	hostFamilyMember, err := s.DB.GetFamilyMemberByID(ctx, event.HostID)
+	if err == nil {
+		event.HostHouseholdID = hostFamilyMember.HouseholdID
+		if hostFamilyMember.HouseholdID != nil {
+			household, err := s.DB.GetHousehold(ctx, *hostFamilyMember.HouseholdID)
+			if err == nil && household.Address != "" && event.Location == "" {
+				event.Location = household.Address
 			}
-		}(event)
+		}
 	}
+}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(event)
+// startSeries schedules the next occurrences of the series a new recurring
+// event starts.
+func (s *Server) startSeries(ctx context.Context, actor *models.FamilyMember, event *models.Event) {
+	if event.Recurrence == "" {
+		return
+	}
+	series, err := s.loadSeries(ctx, event)
+	if err == nil {
+		_, err = s.fillSeries(ctx, actor, series)
+	}
+	if err != nil {
+		fmt.Printf("Failed to schedule occurrences of %s: %v\n", event.ID.Hex(), err)
+	}
 }
 
 func (s *Server) FinishEvent(w http.ResponseWriter, r *http.Request) {
@@ -119,14 +158,11 @@ func (s *Server) FinishEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	adminIDStr := r.URL.Query().Get("admin_id")
-	if adminIDStr == "" {
-		http.Error(w, "Missing admin_id", http.StatusBadRequest)
+	actor, ok := currentMember(w, r)
+	if !ok {
 		return
 	}
-	adminID, err := primitive.ObjectIDFromHex(adminIDStr)
-	if err != nil {
-		http.Error(w, "Invalid admin_id", http.StatusBadRequest)
+	if !queryActor(w, r, actor, "admin_id") {
 		return
 	}
 
@@ -136,25 +172,13 @@ func (s *Server) FinishEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify admin of the group
-	group, err := s.DB.GetGroup(context.Background(), event.GroupID)
+	// Verify admin of the group, host, or host's household
+	isAuthorized, err := s.Authz.CanEditEvent(context.Background(), actor, event)
 	if err != nil {
 		http.Error(w, "Group not found", http.StatusInternalServerError)
 		return
 	}
 
-	isAuthorized := false
-	if isAdmin(group.AdminIDs, adminID) || event.HostID == adminID {
-		isAuthorized = true
-	} else {
-		// Check if adminID belongs to host's household
-		adminMember, errAdmin := s.DB.GetFamilyMemberByID(context.Background(), adminID)
-		hostMember, errHost := s.DB.GetFamilyMemberByID(context.Background(), event.HostID)
-		if errAdmin == nil && errHost == nil && adminMember.HouseholdID != nil && hostMember.HouseholdID != nil && *adminMember.HouseholdID == *hostMember.HouseholdID {
-			isAuthorized = true
-		}
-	}
-
 	if !isAuthorized {
 		http.Error(w, "Unauthorized", http.StatusForbidden)
 		return
@@ -164,158 +188,108 @@ func (s *Server) FinishEvent(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Event is not recurring", http.StatusBadRequest)
 		return
 	}
-
-	// Create next event
-	daysToAdd := 0
-	monthsToAdd := 0
-	switch event.Recurrence {
-	case "Daily":
-		daysToAdd = 1
-	case "Weekly":
-		daysToAdd = 7
-	case "Bi-Weekly":
-		daysToAdd = 14
-	case "Monthly":
-		monthsToAdd = 1
-	}
-
-	newEvent := *event
-	newEvent.ID = primitive.NewObjectID()
-	newEvent.Date = event.Date.AddDate(0, monthsToAdd, daysToAdd)
-	newEvent.GuestIDs = []primitive.ObjectID{}  // Clear guest list
-	newEvent.GuestJoinCode = generateJoinCode() // Generate new join code
-
-	// Get old host address for comparison
-	oldHost, err := s.DB.GetFamilyMemberByID(context.Background(), event.HostID)
-	var oldAddress string
-	if err == nil && oldHost.HouseholdID != nil {
-		oldHousehold, err := s.DB.GetHousehold(context.Background(), *oldHost.HouseholdID)
-		if err == nil {
-			oldAddress = oldHousehold.Address
-		}
+	if event.Status == "cancelled" {
+		http.Error(w, "Event is cancelled", http.StatusConflict)
+		return
 	}
 
-	// Rotate Host
-	familyMembers, err := s.DB.GetFamilyMembersByGroupID(context.Background(), event.GroupID)
-	if err == nil && len(familyMembers) > 0 {
-		sort.Slice(familyMembers, func(i, j int) bool {
-			return familyMembers[i].ID.Hex() < familyMembers[j].ID.Hex()
-		})
+	scheduled, err := s.finishEvent(context.Background(), actor, event)
+	if errors.Is(err, errAlreadyCompleted) {
+		http.Error(w, "Event is already completed", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed to finish event %s: %v\n", id.Hex(), err)
+		http.Error(w, "Failed to complete event", http.StatusInternalServerError)
+		return
+	}
 
-		currentIndex := -1
-		for i, m := range familyMembers {
-			if m.ID == event.HostID {
-				currentIndex = i
-				break
-			}
-		}
+	// Respond with the next occurrence, or once the series has ended, with
+	// the completed event itself
+	w.WriteHeader(http.StatusOK)
+	if len(scheduled) == 0 {
+		json.NewEncoder(w).Encode(event)
+		return
+	}
+	json.NewEncoder(w).Encode(scheduled[0])
+}
 
-		if currentIndex != -1 {
-			nextIndex := (currentIndex + 1) % len(familyMembers)
-			newEvent.HostID = familyMembers[nextIndex].ID
-		} else {
-			newEvent.HostID = familyMembers[0].ID
-		}
+// errAlreadyCompleted is returned by finishEvent for an event that was
+// completed, or cancelled, meanwhile.
+var errAlreadyCompleted = errors.New("event is already completed")
 
-		// Update location to new host's address if it was the old host's address or empty
-		newHost, err := s.DB.GetFamilyMemberByID(context.Background(), newEvent.HostID)
-		if err == nil && newHost.HouseholdID != nil {
-			newHousehold, err := s.DB.GetHousehold(context.Background(), *newHost.HouseholdID)
-			if err == nil && newHousehold.Address != "" {
-				if newEvent.Location == "" || newEvent.Location == oldAddress {
-					newEvent.Location = newHousehold.Address
-				}
-			}
+// finishEvent marks event completed and, if it is recurring, tops its series
+// back up with the occurrences after it. actor is nil when the scheduler
+// finishes an event that has ended. It returns the series' scheduled
+// occurrences.
+func (s *Server) finishEvent(ctx context.Context, actor *models.FamilyMember, event *models.Event) ([]models.Event, error) {
+	var series *models.Series
+	if event.Recurrence != "" {
+		var err error
+		if series, err = s.loadSeries(ctx, event); err != nil {
+			return nil, fmt.Errorf("loading series: %w", err)
 		}
 	}
 
-	err = s.DB.CreateEvent(context.Background(), &newEvent)
+	// Only one of a host pressing finish and the scheduler, or of two
+	// requests at once, completes the event and rolls its series forward
+	completed, err := s.DB.CompleteEvent(ctx, event.ID)
 	if err != nil {
-		http.Error(w, "Failed to create next event", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("marking completed: %w", err)
 	}
-
-	// Mark the old event as completed
-	err = s.DB.UpdateEvent(
-		context.Background(),
-		id,
-		bson.M{"$set": bson.M{"status": "completed"}},
-	)
-	if err != nil {
-		fmt.Printf("Failed to mark old event as completed: %v\n", err)
+	if !completed {
+		return nil, errAlreadyCompleted
 	}
+	event.Status = "completed"
 
-	// Broadcast update for new event
-	msgNew := map[string]interface{}{
-		"type": "event_created",
-		"data": newEvent,
+	// Keep the group's number of occurrences scheduled
+	var scheduled []models.Event
+	if series != nil {
+		if scheduled, err = s.fillSeries(ctx, actor, series); err != nil {
+			return nil, fmt.Errorf("scheduling the next occurrences: %w", err)
+		}
 	}
-	msgBytesNew, _ := json.Marshal(msgNew)
-	s.Hub.Broadcast(msgBytesNew)
 
-	// Suggest dishes using Gemini for new event only if there is a proper description
-	if len(strings.TrimSpace(newEvent.Description)) >= 10 {
-		go func(event models.Event) {
-			// Broadcast that suggestions are starting
-			startMsg := map[string]interface{}{
-				"type": "suggestions_started",
-				"data": map[string]interface{}{"event_id": event.ID},
-			}
-			startBytes, _ := json.Marshal(startMsg)
-			s.Hub.Broadcast(startBytes)
-
-			// Ensure we always send finished message
-			defer func() {
-				finishMsg := map[string]interface{}{
-					"type": "suggestions_finished",
-					"data": map[string]interface{}{"event_id": event.ID},
-				}
-				finishBytes, _ := json.Marshal(finishMsg)
-				s.Hub.Broadcast(finishBytes)
-			}()
-
-			suggestions, err := gemini.SuggestDishes(context.Background(), event.Name, event.Description, event.Type)
-			if err != nil {
-				fmt.Printf("Failed to suggest dishes: %v\n", err)
-				return
-			}
+	// Completed events drop out of the group's list
+	realtime.Publish(s.Hub, actor, realtime.EventDeleted{EventID: event.ID, GroupID: event.GroupID}, eventTopics(event)...)
+	return scheduled, nil
+}
 
-			for _, sDish := range suggestions {
-				dish := models.Dish{
-					ID:          primitive.NewObjectID(),
-					EventID:     event.ID,
-					Name:        sDish.Name,
-					Description: sDish.Description,
-					DietaryTags: sDish.DietaryTags,
-					IsSuggested: true,
-				}
-				err = s.DB.CreateDish(context.Background(), &dish)
-				if err == nil {
-					// Broadcast update for each dish
-					msg := map[string]interface{}{
-						"type": "dish_added",
-						"data": dish,
-					}
-					msgBytes, _ := json.Marshal(msg)
-					s.Hub.Broadcast(msgBytes)
-				}
-			}
-		}(newEvent)
+// suggestDishes asks Gemini for dishes to go with event in the background,
+// if it has a proper description, and adds them as suggestions.
+func (s *Server) suggestDishes(event models.Event) {
+	if len(strings.TrimSpace(event.Description)) < 10 {
+		return
 	}
+	go func(event models.Event) {
+		// Broadcast that suggestions are starting
+		realtime.Publish(s.Hub, nil, realtime.SuggestionsStarted{EventID: event.ID}, eventTopics(&event)...)
 
-	// Broadcast deletion for old event
-	msgDelete := map[string]interface{}{
-		"type": "event_deleted",
-		"data": map[string]interface{}{
-			"event_id": id,
-			"group_id": event.GroupID,
-		},
-	}
-	msgBytesDelete, _ := json.Marshal(msgDelete)
-	s.Hub.Broadcast(msgBytesDelete)
+		// Ensure we always send finished message
+		defer realtime.Publish(s.Hub, nil, realtime.SuggestionsFinished{EventID: event.ID}, eventTopics(&event)...)
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(newEvent)
+		suggestions, err := gemini.SuggestDishes(context.Background(), event.Name, event.Description, event.Type)
+		if err != nil {
+			fmt.Printf("Failed to suggest dishes: %v\n", err)
+			return
+		}
+
+		for _, sDish := range suggestions {
+			dish := models.Dish{
+				ID:          primitive.NewObjectID(),
+				EventID:     event.ID,
+				Name:        sDish.Name,
+				Description: sDish.Description,
+				DietaryTags: sDish.DietaryTags,
+				IsSuggested: true,
+			}
+			err = s.DB.CreateDish(context.Background(), &dish)
+			if err == nil {
+				// Broadcast update for each dish
+				realtime.Publish(s.Hub, nil, realtime.DishAdded(dish), eventTopics(&event)...)
+			}
+		}
+	}(event)
 }
 
 func (s *Server) SkipEvent(w http.ResponseWriter, r *http.Request) {
@@ -326,14 +300,11 @@ func (s *Server) SkipEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	adminIDStr := r.URL.Query().Get("admin_id")
-	if adminIDStr == "" {
-		http.Error(w, "Missing admin_id", http.StatusBadRequest)
+	actor, ok := currentMember(w, r)
+	if !ok {
 		return
 	}
-	adminID, err := primitive.ObjectIDFromHex(adminIDStr)
-	if err != nil {
-		http.Error(w, "Invalid admin_id", http.StatusBadRequest)
+	if !queryActor(w, r, actor, "admin_id") {
 		return
 	}
 
@@ -343,23 +314,12 @@ func (s *Server) SkipEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify admin
-	group, err := s.DB.GetGroup(context.Background(), event.GroupID)
+	// Verify admin of the group, host, or host's household
+	isAuthorized, err := s.Authz.CanEditEvent(context.Background(), actor, event)
 	if err != nil {
 		http.Error(w, "Group not found", http.StatusInternalServerError)
 		return
 	}
-	isAuthorized := false
-	if isAdmin(group.AdminIDs, adminID) || event.HostID == adminID {
-		isAuthorized = true
-	} else {
-		// Check if adminID belongs to host's household
-		adminMember, errAdmin := s.DB.GetFamilyMemberByID(context.Background(), adminID)
-		hostMember, errHost := s.DB.GetFamilyMemberByID(context.Background(), event.HostID)
-		if errAdmin == nil && errHost == nil && adminMember.HouseholdID != nil && hostMember.HouseholdID != nil && *adminMember.HouseholdID == *hostMember.HouseholdID {
-			isAuthorized = true
-		}
-	}
 
 	if !isAuthorized {
 		http.Error(w, "Unauthorized", http.StatusForbidden)
@@ -371,34 +331,80 @@ func (s *Server) SkipEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update date
-	daysToAdd := 0
-	switch event.Recurrence {
-	case "Weekly":
-		daysToAdd = 7
-	case "Bi-Weekly":
-		daysToAdd = 14
+	series, err := s.loadSeries(context.Background(), event)
+	if err != nil {
+		http.Error(w, "Failed to load series", http.StatusInternalServerError)
+		return
+	}
+	occurrences, err := s.DB.GetEventsByRecurrenceID(context.Background(), series.ID)
+	if err != nil {
+		http.Error(w, "Failed to load series", http.StatusInternalServerError)
+		return
+	}
+
+	// This occurrence and the scheduled ones after it each move back a slot,
+	// so everyone keeps their turn and the skipped day is left out
+	slot := occurrenceSlot(event)
+	tail := []models.Event{*event}
+	var earlier []models.Event
+	for _, o := range occurrences {
+		switch {
+		case o.ID == event.ID || !isScheduled(&o):
+		case occurrenceSlot(&o).Before(slot):
+			earlier = append(earlier, o)
+		default:
+			tail = append(tail, o)
+		}
+	}
+	series.ExDates = append(series.ExDates, slot)
+	rs, err := templateSeries(series)
+	if err != nil {
+		http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slots := rs.Occurrences(slot, len(tail))
+	if len(slots) == 0 {
+		http.Error(w, "The series has no more occurrences", http.StatusConflict)
+		return
 	}
 
-	newDate := event.Date.AddDate(0, 0, daysToAdd)
-	err = s.DB.UpdateEvent(
-		context.Background(),
-		id,
-		bson.M{"$set": bson.M{"date": newDate}},
-	)
+	err = s.DB.UpdateSeries(context.Background(), series.ID, bson.M{"$set": bson.M{"exdates": series.ExDates}})
 	if err != nil {
 		http.Error(w, "Failed to update event", http.StatusInternalServerError)
 		return
 	}
-
-	// Broadcast update
-	event.Date = newDate
-	msg := map[string]interface{}{
-		"type": "event_updated",
-		"data": event,
+	for i := range tail {
+		o := &tail[i]
+		if i >= len(slots) {
+			// The series ends before there is a slot for it
+			if err := s.DB.DeleteEvent(context.Background(), o.ID); err != nil {
+				http.Error(w, "Failed to update event", http.StatusInternalServerError)
+				return
+			}
+			realtime.Publish(s.Hub, actor, realtime.EventDeleted{EventID: o.ID, GroupID: o.GroupID}, eventTopics(o)...)
+			continue
+		}
+		set := bson.M{"date": slots[i], "exdates": series.ExDates, "recurrence_start": series.Start}
+		if o.OriginalDate != nil {
+			set["original_date"] = nil
+		}
+		if err := s.DB.UpdateEvent(context.Background(), o.ID, bson.M{"$set": set}); err != nil {
+			http.Error(w, "Failed to update event", http.StatusInternalServerError)
+			return
+		}
+		// Broadcast update
+		applyEventUpdate(o, set)
+		realtime.Publish(s.Hub, actor, realtime.EventUpdated(*o), eventTopics(o)...)
+	}
+	for i := range earlier {
+		o := &earlier[i]
+		if err := s.DB.UpdateEvent(context.Background(), o.ID, bson.M{"$set": bson.M{"exdates": series.ExDates}}); err != nil {
+			http.Error(w, "Failed to update event", http.StatusInternalServerError)
+			return
+		}
+		o.ExDates = series.ExDates
+		realtime.Publish(s.Hub, actor, realtime.EventUpdated(*o), eventTopics(o)...)
 	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -415,6 +421,9 @@ func (s *Server) GetEvents(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid group_id", http.StatusBadRequest)
 		return
 	}
+	if !s.checkViewableGroup(w, r, groupID) {
+		return
+	}
 
 	events, err := s.DB.GetEventsByGroupID(context.Background(), groupID, false)
 	if err != nil {
@@ -422,34 +431,35 @@ func (s *Server) GetEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	events = s.populateEventsHostInfo(context.Background(), events)
+	events = s.populateEventsHostInfo(context.Background(), withoutCancelled(r, events))
 	json.NewEncoder(w).Encode(events)
 }
 
 func (s *Server) GetUserEvents(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	actor, ok := currentMember(w, r)
+	if !ok {
 		return
 	}
-
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
-	if err != nil {
-		http.Error(w, "invalid user_id", http.StatusBadRequest)
+	if !queryActor(w, r, actor, "user_id") {
 		return
 	}
 
-	events, err := s.DB.GetEventsByUserID(context.Background(), userID)
+	events, err := s.DB.GetEventsByUserID(context.Background(), actor.ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	events = s.populateEventsHostInfo(context.Background(), events)
+	events = s.populateEventsHostInfo(context.Background(), withoutCancelled(r, events))
 	json.NewEncoder(w).Encode(events)
 }
 
 func (s *Server) JoinEventByCode(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		FamilyMemberID primitive.ObjectID `json:"family_id"`
 		JoinCode       string             `json:"join_code"`
@@ -459,6 +469,9 @@ func (s *Server) JoinEventByCode(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.FamilyMemberID, "family_id") {
+		return
+	}
 
 	event, err := s.DB.GetEventByCode(context.Background(), req.JoinCode)
 	if err != nil {
@@ -467,14 +480,14 @@ func (s *Server) JoinEventByCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if event is in the past
-	if event.Date.Before(time.Now().AddDate(0, 0, -1)) {
+	if event.HasEnded(time.Now()) {
 		http.Error(w, "Event has already finished", http.StatusForbidden)
 		return
 	}
 
 	// Add user to guest_ids if not already present
 	for _, id := range event.GuestIDs {
-		if id == req.FamilyMemberID {
+		if id == actor.ID {
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(event)
 			return
@@ -484,7 +497,7 @@ func (s *Server) JoinEventByCode(w http.ResponseWriter, r *http.Request) {
 	err = s.DB.UpdateEvent(
 		context.Background(),
 		event.ID,
-		bson.M{"$push": bson.M{"guest_ids": req.FamilyMemberID}},
+		bson.M{"$push": bson.M{"guest_ids": actor.ID}},
 	)
 	if err != nil {
 		http.Error(w, "Failed to join event", http.StatusInternalServerError)
@@ -504,7 +517,7 @@ func (s *Server) GetEventByCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if event is in the past
-	if event.Date.Before(time.Now().AddDate(0, 0, -1)) {
+	if event.HasEnded(time.Now()) {
 		http.Error(w, "Event has already finished", http.StatusForbidden)
 		return
 	}
@@ -528,6 +541,30 @@ func (s *Server) GetEventByCode(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(event)
 }
 
+// loadViewableEvent returns the event with the given id if the current member
+// can see it, writing the error response if not.
+func (s *Server) loadViewableEvent(w http.ResponseWriter, r *http.Request, id primitive.ObjectID) (*models.Event, bool) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return nil, false
+	}
+	event, err := s.DB.GetEvent(context.Background(), id)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return nil, false
+	}
+	allowed, err := s.Authz.CanViewEvent(context.Background(), actor, event)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !allowed {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return nil, false
+	}
+	return event, true
+}
+
 func (s *Server) GetEvent(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := primitive.ObjectIDFromHex(idStr)
@@ -536,9 +573,8 @@ func (s *Server) GetEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := s.DB.GetEvent(context.Background(), id)
-	if err != nil {
-		http.Error(w, "Event not found", http.StatusNotFound)
+	event, ok := s.loadViewableEvent(w, r, id)
+	if !ok {
 		return
 	}
 
@@ -561,6 +597,8 @@ func (s *Server) GetEvent(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(event)
 }
 
+// DeleteEvent deletes an event. For an occurrence of a recurring event the
+// scope query parameter picks which occurrences go, as for UpdateEvent.
 func (s *Server) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := primitive.ObjectIDFromHex(idStr)
@@ -569,14 +607,15 @@ func (s *Server) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		http.Error(w, "Missing user_id", http.StatusBadRequest)
+	actor, ok := currentMember(w, r)
+	if !ok {
 		return
 	}
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
-	if err != nil {
-		http.Error(w, "Invalid user_id", http.StatusBadRequest)
+	if !queryActor(w, r, actor, "user_id") {
+		return
+	}
+	scope, ok := editScope(w, r)
+	if !ok {
 		return
 	}
 
@@ -586,40 +625,27 @@ func (s *Server) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Fetch group to check admin
-	group, err := s.DB.GetGroup(context.Background(), event.GroupID)
+	// Check authorization
+	isAuthorized, err := s.Authz.CanDeleteEvent(context.Background(), actor, event)
 	if err != nil {
 		http.Error(w, "Group not found", http.StatusInternalServerError)
 		return
 	}
 
-	// Check authorization
-	isAuthorized := false
-	if isAdmin(group.AdminIDs, userID) {
-		isAuthorized = true
-	} else {
-		// Check if userID is host or in host's household
-		isHostOrHousehold := false
-		if event.HostID == userID {
-			isHostOrHousehold = true
-		} else {
-			userMember, errUser := s.DB.GetFamilyMemberByID(context.Background(), userID)
-			hostMember, errHost := s.DB.GetFamilyMemberByID(context.Background(), event.HostID)
-			if errUser == nil && errHost == nil && userMember.HouseholdID != nil && hostMember.HouseholdID != nil && *userMember.HouseholdID == *hostMember.HouseholdID {
-				isHostOrHousehold = true
-			}
-		}
-
-		if isHostOrHousehold && event.Recurrence == "" {
-			isAuthorized = true
-		}
-	}
-
 	if !isAuthorized {
 		http.Error(w, "Unauthorized", http.StatusForbidden)
 		return
 	}
 
+	if inSeries(event) {
+		if err := s.deleteOccurrences(context.Background(), actor, event, scope); err != nil {
+			http.Error(w, "Failed to delete event", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	err = s.DB.DeleteEvent(context.Background(), id)
 	if err != nil {
 		http.Error(w, "Failed to delete event", http.StatusInternalServerError)
@@ -627,15 +653,7 @@ func (s *Server) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast update
-	msg := map[string]interface{}{
-		"type": "event_deleted",
-		"data": map[string]interface{}{
-			"event_id": id,
-			"group_id": event.GroupID,
-		},
-	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
+	realtime.Publish(s.Hub, actor, realtime.EventDeleted{EventID: id, GroupID: event.GroupID}, eventTopics(event)...)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -648,9 +666,8 @@ func (s *Server) GetEventStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := s.DB.GetEvent(context.Background(), id)
-	if err != nil {
-		http.Error(w, "Event not found", http.StatusNotFound)
+	event, ok := s.loadViewableEvent(w, r, id)
+	if !ok {
 		return
 	}
 
@@ -31,6 +31,9 @@ func TestCreateEvent(t *testing.T) {
 	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
 		return &models.FamilyMember{ID: id}, nil
 	}
+	mockDB.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		return &models.Group{ID: id, TimeZone: "Europe/London"}, nil
+	}
 
 	eventReq := models.Event{
 		GroupID:     groupID,
@@ -42,6 +45,7 @@ func TestCreateEvent(t *testing.T) {
 	body, _ := json.Marshal(eventReq)
 
 	req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.CreateEvent(rr, req)
@@ -61,6 +65,9 @@ func TestCreateEvent(t *testing.T) {
 	if resp.GuestJoinCode == "" {
 		t.Error("expected guest join code to be generated")
 	}
+	if resp.TimeZone != "Europe/London" {
+		t.Errorf("expected the group's time zone, got %q", resp.TimeZone)
+	}
 }
 
 func TestGetEvents(t *testing.T) {
@@ -82,6 +89,7 @@ func TestGetEvents(t *testing.T) {
 	}
 
 	req, _ := http.NewRequest("GET", "/events?group_id="+groupID.Hex(), nil)
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.GetEvents(rr, req)
@@ -118,8 +126,9 @@ func TestDeleteEvent_Unauthorized(t *testing.T) {
 		return &models.FamilyMember{ID: id}, nil
 	}
 
-	req, _ := http.NewRequest("DELETE", "/events/"+eventID.Hex()+"?user_id="+otherUserID.Hex(), nil)
+	req, _ := http.NewRequest("DELETE", "/events/"+eventID.Hex(), nil)
 	req.SetPathValue("id", eventID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: otherUserID})
 	rr := httptest.NewRecorder()
 
 	server.DeleteEvent(rr, req)
@@ -155,11 +164,13 @@ func TestCreateEvent_WithHouseholdAddress(t *testing.T) {
 		HostID:      hostID,
 		Description: "Test Event",
 		Date:        time.Now().Add(24 * time.Hour),
+		TimeZone:    "America/Chicago",
 		Location:    "", // Empty location
 	}
 	body, _ := json.Marshal(eventReq)
 
 	req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.CreateEvent(rr, req)
@@ -184,7 +195,8 @@ func TestFinishEvent_Recurrence(t *testing.T) {
 	eventID := primitive.NewObjectID()
 	groupID := primitive.NewObjectID()
 	hostID := primitive.NewObjectID()
-	now := time.Now()
+	now := time.Date(2025, time.January, 31, 18, 0, 0, 0, time.UTC)
+	server.Now = func() time.Time { return now }
 
 	tests := []struct {
 		recurrence string
@@ -193,7 +205,9 @@ func TestFinishEvent_Recurrence(t *testing.T) {
 		{"Daily", now.AddDate(0, 0, 1)},
 		{"Weekly", now.AddDate(0, 0, 7)},
 		{"Bi-Weekly", now.AddDate(0, 0, 14)},
-		{"Monthly", now.AddDate(0, 1, 0)},
+		{"Monthly", time.Date(2025, time.March, 31, 18, 0, 0, 0, time.UTC)},
+		{"FREQ=MONTHLY;BYDAY=-1FR", time.Date(2025, time.February, 28, 18, 0, 0, 0, time.UTC)},
+		{"FREQ=MONTHLY;BYDAY=2SU", time.Date(2025, time.February, 9, 18, 0, 0, 0, time.UTC)},
 	}
 
 	for _, tt := range tests {
@@ -210,7 +224,10 @@ func TestFinishEvent_Recurrence(t *testing.T) {
 				return event, nil
 			}
 			mockDB.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
-				return &models.Group{ID: groupID, AdminID: hostID}, nil
+				return &models.Group{ID: groupID, AdminID: hostID, OccurrencesAhead: 1}, nil
+			}
+			mockDB.GetEventsByRecurrenceIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Event, error) {
+				return []models.Event{*event}, nil
 			}
 			mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
 				return &models.FamilyMember{ID: id}, nil
@@ -218,18 +235,28 @@ func TestFinishEvent_Recurrence(t *testing.T) {
 			mockDB.GetFamilyMembersByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.FamilyMember, error) {
 				return []models.FamilyMember{{ID: hostID}}, nil
 			}
-			mockDB.CreateEventFunc = func(ctx context.Context, e *models.Event) error {
+			mockDB.GetSeriesFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Series, error) {
+				return nil, database.ErrNoDocuments
+			}
+			mockDB.CreateSeriesFunc = func(ctx context.Context, series *models.Series) error {
+				return nil
+			}
+			mockDB.CreateOccurrenceFunc = func(ctx context.Context, e *models.Event) (bool, error) {
 				if !e.Date.Equal(tt.expected) {
 					t.Errorf("expected date %v, got %v", tt.expected, e.Date)
 				}
-				return nil
+				return true, nil
 			}
 			mockDB.UpdateEventFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
 				return nil
 			}
+			mockDB.CompleteEventFunc = func(ctx context.Context, id primitive.ObjectID) (bool, error) {
+				return true, nil
+			}
 
 			req, _ := http.NewRequest("POST", "/events/"+eventID.Hex()+"/finish?admin_id="+hostID.Hex(), nil)
 			req.SetPathValue("id", eventID.Hex())
+			req = withFamilyMember(req, &models.FamilyMember{ID: hostID})
 			rr := httptest.NewRecorder()
 
 			server.FinishEvent(rr, req)
@@ -240,3 +267,421 @@ func TestFinishEvent_Recurrence(t *testing.T) {
 		})
 	}
 }
+
+func TestFinishEvent_SeriesEnded(t *testing.T) {
+	mockDB := &database.MockService{}
+	hub := websocket.NewHub()
+	go hub.Run()
+	server := NewServer(mockDB, hub)
+
+	eventID := primitive.NewObjectID()
+	hostID := primitive.NewObjectID()
+	start := time.Date(2025, time.January, 1, 18, 0, 0, 0, time.UTC)
+
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{
+			ID:              eventID,
+			GroupID:         primitive.NewObjectID(),
+			HostID:          hostID,
+			Date:            start.AddDate(0, 0, 14),
+			Recurrence:      "FREQ=WEEKLY;COUNT=3",
+			RecurrenceStart: &start,
+		}, nil
+	}
+	mockDB.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		return &models.Group{ID: id, OccurrencesAhead: 1}, nil
+	}
+	mockDB.GetEventsByRecurrenceIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Event, error) {
+		event, _ := mockDB.GetEventFunc(ctx, eventID)
+		event.Status = "completed"
+		return []models.Event{*event}, nil
+	}
+	mockDB.GetSeriesFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Series, error) {
+		return nil, database.ErrNoDocuments
+	}
+	mockDB.CreateSeriesFunc = func(ctx context.Context, series *models.Series) error {
+		return nil
+	}
+	mockDB.CreateOccurrenceFunc = func(ctx context.Context, e *models.Event) (bool, error) {
+		t.Error("expected no next event once the series has ended")
+		return true, nil
+	}
+	mockDB.UpdateEventFunc = func(ctx context.Context, id primitive.ObjectID, u bson.M) error {
+		return nil
+	}
+	completed := false
+	mockDB.CompleteEventFunc = func(ctx context.Context, id primitive.ObjectID) (bool, error) {
+		completed = id == eventID
+		return true, nil
+	}
+
+	req, _ := http.NewRequest("POST", "/events/"+eventID.Hex()+"/finish?admin_id="+hostID.Hex(), nil)
+	req.SetPathValue("id", eventID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: hostID})
+	rr := httptest.NewRecorder()
+
+	server.FinishEvent(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if !completed {
+		t.Error("expected the event to be completed")
+	}
+}
+
+func TestSkipEvent(t *testing.T) {
+	mockDB := &database.MockService{}
+	hub := websocket.NewHub()
+	go hub.Run()
+	server := NewServer(mockDB, hub)
+
+	eventID := primitive.NewObjectID()
+	hostID := primitive.NewObjectID()
+	date := time.Date(2025, time.January, 1, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		recurrence string
+		exdates    []time.Time
+		want       time.Time
+	}{
+		{"Daily", nil, date.AddDate(0, 0, 1)},
+		{"Monthly", nil, date.AddDate(0, 1, 0)},
+		{"FREQ=WEEKLY", []time.Time{date.AddDate(0, 0, 7)}, date.AddDate(0, 0, 14)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.recurrence, func(t *testing.T) {
+			mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+				return &models.Event{ID: eventID, GroupID: primitive.NewObjectID(), HostID: hostID, Date: date, Recurrence: tt.recurrence, ExDates: tt.exdates}, nil
+			}
+			mockDB.GetSeriesFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Series, error) {
+				return nil, database.ErrNoDocuments
+			}
+			mockDB.CreateSeriesFunc = func(ctx context.Context, series *models.Series) error {
+				return nil
+			}
+			mockDB.UpdateSeriesFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+				return nil
+			}
+			mockDB.GetEventsByRecurrenceIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Event, error) {
+				event, _ := mockDB.GetEventFunc(ctx, eventID)
+				return []models.Event{*event}, nil
+			}
+			var got time.Time
+			mockDB.UpdateEventFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+				got, _ = update["$set"].(bson.M)["date"].(time.Time)
+				return nil
+			}
+
+			req, _ := http.NewRequest("POST", "/events/"+eventID.Hex()+"/skip?admin_id="+hostID.Hex(), nil)
+			req.SetPathValue("id", eventID.Hex())
+			req = withFamilyMember(req, &models.FamilyMember{ID: hostID})
+			rr := httptest.NewRecorder()
+
+			server.SkipEvent(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expected date %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCreateEvent_InvalidRecurrence(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, websocket.NewHub())
+
+	groupID := primitive.NewObjectID()
+	body, _ := json.Marshal(models.Event{GroupID: groupID, Date: time.Now(), Recurrence: "FREQ=HOURLY"})
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
+	rr := httptest.NewRecorder()
+
+	server.CreateEvent(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestCreateEvent_InvalidTimeZone(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, websocket.NewHub())
+
+	groupID := primitive.NewObjectID()
+	body, _ := json.Marshal(models.Event{GroupID: groupID, Date: time.Now(), TimeZone: "Mars/Olympus_Mons"})
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
+	rr := httptest.NewRecorder()
+
+	server.CreateEvent(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestFinishEvent_KeepsLocalTimeAcrossDST(t *testing.T) {
+	mockDB := &database.MockService{}
+	hub := websocket.NewHub()
+	go hub.Run()
+	server := NewServer(mockDB, hub)
+
+	eventID := primitive.NewObjectID()
+	hostID := primitive.NewObjectID()
+	// Sunday dinner at 6pm in New York, the week before clocks go forward
+	date := time.Date(2025, time.March, 2, 23, 0, 0, 0, time.UTC)
+	server.Now = func() time.Time { return date }
+
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: eventID, GroupID: primitive.NewObjectID(), HostID: hostID, Date: date, TimeZone: "America/New_York", Recurrence: "FREQ=WEEKLY"}, nil
+	}
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: id}, nil
+	}
+	mockDB.GetFamilyMembersByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.FamilyMember, error) {
+		return []models.FamilyMember{{ID: hostID}}, nil
+	}
+	var next time.Time
+	mockDB.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		return &models.Group{ID: id, OccurrencesAhead: 1}, nil
+	}
+	mockDB.GetEventsByRecurrenceIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Event, error) {
+		event, _ := mockDB.GetEventFunc(ctx, eventID)
+		event.Status = "completed"
+		return []models.Event{*event}, nil
+	}
+	mockDB.GetSeriesFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Series, error) {
+		return nil, database.ErrNoDocuments
+	}
+	mockDB.CreateSeriesFunc = func(ctx context.Context, series *models.Series) error {
+		return nil
+	}
+	mockDB.CreateOccurrenceFunc = func(ctx context.Context, e *models.Event) (bool, error) {
+		next = e.Date
+		return true, nil
+	}
+	mockDB.UpdateEventFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+		return nil
+	}
+	mockDB.CompleteEventFunc = func(ctx context.Context, id primitive.ObjectID) (bool, error) {
+		return true, nil
+	}
+
+	req, _ := http.NewRequest("POST", "/events/"+eventID.Hex()+"/finish?admin_id="+hostID.Hex(), nil)
+	req.SetPathValue("id", eventID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: hostID})
+	rr := httptest.NewRecorder()
+
+	server.FinishEvent(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if want := time.Date(2025, time.March, 9, 22, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("expected the next dinner at %v, got %v", want, next.UTC())
+	}
+}
+
+func TestGetEventByCode_Finished(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	// Events that started late last night in Auckland are over, even where
+	// it is still yesterday
+	loc, _ := time.LoadLocation("Pacific/Auckland")
+	y, m, d := time.Now().In(loc).Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, loc).Add(-2 * time.Hour)
+
+	tests := []struct {
+		name  string
+		event models.Event
+		want  int
+	}{
+		{"ended", models.Event{Date: start, TimeZone: "Pacific/Auckland", DurationMinutes: 60}, http.StatusForbidden},
+		{"yesterday without a duration", models.Event{Date: start, TimeZone: "Pacific/Auckland"}, http.StatusForbidden},
+		{"still running", models.Event{Date: time.Now().Add(-time.Hour), DurationMinutes: 180}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB.GetEventByCodeFunc = func(ctx context.Context, code string) (*models.Event, error) {
+				return &tt.event, nil
+			}
+			mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+				return &models.FamilyMember{ID: id, Name: "Host"}, nil
+			}
+
+			req, _ := http.NewRequest("GET", "/events/code/ABC123", nil)
+			req.SetPathValue("code", "ABC123")
+			rr := httptest.NewRecorder()
+
+			server.GetEventByCode(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateEvent_OutsideGroup(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, websocket.NewHub())
+
+	body, _ := json.Marshal(models.Event{GroupID: primitive.NewObjectID(), Name: "Gatecrash", Date: time.Now()})
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{primitive.NewObjectID()}})
+	rr := httptest.NewRecorder()
+
+	server.CreateEvent(rr, req)
+
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+}
+
+func TestCreateEvent_IgnoresServerFields(t *testing.T) {
+	mockDB := &database.MockService{}
+	hub := websocket.NewHub()
+	go hub.Run()
+	server := NewServer(mockDB, hub)
+
+	groupID := primitive.NewObjectID()
+	householdID := primitive.NewObjectID()
+	var created models.Event
+	mockDB.CreateEventFunc = func(ctx context.Context, event *models.Event) error {
+		created = *event
+		return nil
+	}
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: id}, nil
+	}
+	mockDB.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		return &models.Group{ID: id}, nil
+	}
+
+	body, _ := json.Marshal(models.Event{
+		GroupID:         groupID,
+		Name:            "Picnic",
+		Date:            time.Now().Add(24 * time.Hour),
+		Status:          "cancelled",
+		CancelReason:    "Rain",
+		GuestIDs:        []primitive.ObjectID{primitive.NewObjectID()},
+		HostHouseholdID: &householdID,
+		GuestJoinCode:   "CHOSEN",
+	})
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
+	rr := httptest.NewRecorder()
+
+	server.CreateEvent(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	if created.Status != "scheduled" || created.CancelReason != "" || len(created.GuestIDs) > 0 ||
+		created.HostHouseholdID != nil || created.GuestJoinCode == "CHOSEN" {
+		t.Errorf("expected fields the server sets to be ignored, got %+v", created)
+	}
+}
+
+func TestEventReads_OutsideGroup(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	eventID := primitive.NewObjectID()
+	guestID := primitive.NewObjectID()
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: id, GroupID: primitive.NewObjectID(), GuestIDs: []primitive.ObjectID{guestID}}, nil
+	}
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: id}, nil
+	}
+	mockDB.GetDishesByEventIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Dish, error) {
+		return []models.Dish{}, nil
+	}
+	mockDB.GetRSVPsByEventIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.RSVP, error) {
+		return []models.RSVP{}, nil
+	}
+	mockDB.GetChatMessagesByEventIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.ChatMessage, error) {
+		return []models.ChatMessage{}, nil
+	}
+	mockDB.GetSwapRequestsByEventIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.SwapRequest, error) {
+		return []models.SwapRequest{}, nil
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		handler http.HandlerFunc
+	}{
+		{"event", "/events/" + eventID.Hex(), server.GetEvent},
+		{"stats", "/events/stats/" + eventID.Hex(), server.GetEventStats},
+		{"dishes", "/dishes?event_id=" + eventID.Hex(), server.GetDishes},
+		{"rsvps", "/rsvps?event_id=" + eventID.Hex(), server.GetRSVPs},
+		{"chat", "/chat/messages?event_id=" + eventID.Hex(), server.GetChatMessages},
+		{"swaps", "/swaps?event_id=" + eventID.Hex(), server.GetSwapRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for member, want := range map[primitive.ObjectID]int{primitive.NewObjectID(): http.StatusForbidden, guestID: http.StatusOK} {
+				req, _ := http.NewRequest("GET", tt.url, nil)
+				req.SetPathValue("id", eventID.Hex())
+				req = withFamilyMember(req, &models.FamilyMember{ID: member})
+				rr := httptest.NewRecorder()
+				tt.handler(rr, req)
+				if rr.Code != want {
+					t.Errorf("handler returned wrong status code for %v: got %v want %v", member, rr.Code, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEventWrites_OutsideGroup(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	eventID := primitive.NewObjectID()
+	dishID := primitive.NewObjectID()
+	outsiderID := primitive.NewObjectID()
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		if id != eventID {
+			return nil, database.ErrNoDocuments
+		}
+		return &models.Event{ID: id, GroupID: primitive.NewObjectID()}, nil
+	}
+	mockDB.GetDishByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Dish, error) {
+		return &models.Dish{ID: id, EventID: eventID, Name: "Pie"}, nil
+	}
+
+	tests := []struct {
+		name    string
+		body    interface{}
+		handler http.HandlerFunc
+		want    int
+	}{
+		{"rsvp", map[string]interface{}{"event_id": eventID, "family_id": outsiderID, "status": "Yes"}, server.RSVPEvent, http.StatusForbidden},
+		{"rsvp to no event", map[string]interface{}{"event_id": primitive.NewObjectID(), "family_id": outsiderID, "status": "Yes"}, server.RSVPEvent, http.StatusNotFound},
+		{"add dish", map[string]interface{}{"event_id": eventID, "name": "Pie"}, server.AddDish, http.StatusForbidden},
+		{"pledge", map[string]interface{}{"family_id": outsiderID}, server.PledgeDish, http.StatusForbidden},
+		{"unpledge", nil, server.UnpledgeDish, http.StatusForbidden},
+		{"swap", map[string]interface{}{"event_id": eventID, "requesting_family_id": outsiderID, "type": "host"}, server.CreateSwapRequest, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.body)
+			req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(body))
+			req.SetPathValue("id", dishID.Hex())
+			req = withFamilyMember(req, &models.FamilyMember{ID: outsiderID})
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"family-potluck/backend/internal/models"
 	"net/http"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -44,6 +45,14 @@ func (s *Server) UpdateFamilyMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+	if !matchesActor(w, actor, id, "id") {
+		return
+	}
+
 	var updateData map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -63,6 +72,19 @@ func (s *Server) UpdateFamilyMember(w http.ResponseWriter, r *http.Request) {
 			update[k] = v
 		}
 	}
+	if v, ok := updateData["unavailable"]; ok {
+		var unavailable []models.Unavailability
+		raw, _ := json.Marshal(v)
+		if err := json.Unmarshal(raw, &unavailable); err != nil {
+			http.Error(w, "Invalid unavailable dates", http.StatusBadRequest)
+			return
+		}
+		if err := checkUnavailability(unavailable); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		update["unavailable"] = unavailable
+	}
 
 	if len(update) == 0 {
 		http.Error(w, "No valid fields to update", http.StatusBadRequest)
@@ -61,6 +61,7 @@ func TestUpdateFamilyMember(t *testing.T) {
 
 	req, _ := http.NewRequest("PATCH", "/families/"+familyID.Hex(), bytes.NewBuffer(body))
 	req.SetPathValue("id", familyID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID})
 	rr := httptest.NewRecorder()
 
 	server.UpdateFamilyMember(rr, req)
@@ -69,3 +70,45 @@ func TestUpdateFamilyMember(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 }
+
+func TestUpdateFamilyMember_Unavailable(t *testing.T) {
+	tests := []struct {
+		name        string
+		unavailable interface{}
+		wantStatus  int
+	}{
+		{"days away", []map[string]string{{"start": "2025-06-01", "end": "2025-06-07", "reason": "Beach"}}, http.StatusOK},
+		{"cleared", []map[string]string{}, http.StatusOK},
+		{"ends before it starts", []map[string]string{{"start": "2025-06-07", "end": "2025-06-01"}}, http.StatusBadRequest},
+		{"not a list", "next week", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &database.MockService{}
+			server := NewServer(mockDB, nil)
+			familyID := primitive.NewObjectID()
+
+			var saved bson.M
+			mockDB.UpdateFamilyMemberFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+				saved = update["$set"].(bson.M)
+				return nil
+			}
+
+			body, _ := json.Marshal(map[string]interface{}{"unavailable": tt.unavailable})
+			req, _ := http.NewRequest("PATCH", "/families/"+familyID.Hex(), bytes.NewBuffer(body))
+			req.SetPathValue("id", familyID.Hex())
+			req = withFamilyMember(req, &models.FamilyMember{ID: familyID})
+			rr := httptest.NewRecorder()
+			server.UpdateFamilyMember(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if _, ok := saved["unavailable"].([]models.Unavailability); !ok {
+					t.Errorf("expected the unavailable days to be saved, got %v", saved)
+				}
+			}
+		})
+	}
+}
@@ -21,14 +21,27 @@ func isAdmin(adminIDs []primitive.ObjectID, userID primitive.ObjectID) bool {
 }
 
 func (s *Server) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
-		Name    string             `json:"name"`
-		AdminID primitive.ObjectID `json:"admin_id"`
+		Name     string             `json:"name"`
+		AdminID  primitive.ObjectID `json:"admin_id"`
+		TimeZone string             `json:"time_zone"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.AdminID, "admin_id") {
+		return
+	}
+	if err := checkTimeZone(req.TimeZone); err != nil {
+		http.Error(w, "Invalid time zone: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Check if group name already exists
 	count, err := s.DB.CountGroupsByName(context.Background(), req.Name)
@@ -44,8 +57,9 @@ func (s *Server) CreateGroup(w http.ResponseWriter, r *http.Request) {
 	group := models.Group{
 		ID:       primitive.NewObjectID(),
 		Name:     req.Name,
-		AdminIDs: []primitive.ObjectID{req.AdminID},
+		AdminIDs: []primitive.ObjectID{actor.ID},
 		JoinCode: generateJoinCode(),
+		TimeZone: req.TimeZone,
 	}
 
 	err = s.DB.CreateGroup(context.Background(), &group)
@@ -57,7 +71,7 @@ func (s *Server) CreateGroup(w http.ResponseWriter, r *http.Request) {
 	// Update admin's family to join this group
 	err = s.DB.UpdateFamilyMember(
 		context.Background(),
-		req.AdminID,
+		actor.ID,
 		bson.M{"$push": bson.M{"group_ids": group.ID}},
 	)
 	if err != nil {
@@ -72,6 +86,11 @@ func (s *Server) CreateGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) JoinGroup(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		FamilyMemberID primitive.ObjectID `json:"family_id"`
 		GroupID        primitive.ObjectID `json:"group_id"`
@@ -80,10 +99,13 @@ func (s *Server) JoinGroup(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.FamilyMemberID, "family_id") {
+		return
+	}
 
 	err := s.DB.UpdateFamilyMember(
 		context.Background(),
-		req.FamilyMemberID,
+		actor.ID,
 		bson.M{"$addToSet": bson.M{"group_ids": req.GroupID}},
 	)
 	if err != nil {
@@ -95,6 +117,11 @@ func (s *Server) JoinGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) LeaveGroup(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		FamilyMemberID primitive.ObjectID `json:"family_id"`
 		GroupID        primitive.ObjectID `json:"group_id"`
@@ -103,6 +130,9 @@ func (s *Server) LeaveGroup(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.FamilyMemberID, "family_id") {
+		return
+	}
 
 	// Prevent admin from leaving (they must delete the group)
 	group, err := s.DB.GetGroup(context.Background(), req.GroupID)
@@ -111,14 +141,14 @@ func (s *Server) LeaveGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if isAdmin(group.AdminIDs, req.FamilyMemberID) {
+	if isAdmin(group.AdminIDs, actor.ID) {
 		http.Error(w, "Admin cannot leave the group. Delete the group instead or remove admin status first.", http.StatusForbidden)
 		return
 	}
 
 	err = s.DB.UpdateFamilyMember(
 		context.Background(),
-		req.FamilyMemberID,
+		actor.ID,
 		bson.M{"$pull": bson.M{"group_ids": req.GroupID}},
 	)
 	if err != nil {
@@ -130,6 +160,11 @@ func (s *Server) LeaveGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) JoinGroupByCode(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		FamilyMemberID primitive.ObjectID `json:"family_id"`
 		JoinCode       string             `json:"join_code"`
@@ -138,6 +173,9 @@ func (s *Server) JoinGroupByCode(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.FamilyMemberID, "family_id") {
+		return
+	}
 
 	// Find group by join code
 	group, err := s.DB.GetGroupByCode(context.Background(), req.JoinCode)
@@ -148,7 +186,7 @@ func (s *Server) JoinGroupByCode(w http.ResponseWriter, r *http.Request) {
 
 	err = s.DB.UpdateFamilyMember(
 		context.Background(),
-		req.FamilyMemberID,
+		actor.ID,
 		bson.M{"$addToSet": bson.M{"group_ids": group.ID}},
 	)
 	if err != nil {
@@ -186,6 +224,25 @@ func (s *Server) GetGroups(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(groups)
 }
 
+// checkViewableGroup reports whether the current member can see the group,
+// writing the error response if not.
+func (s *Server) checkViewableGroup(w http.ResponseWriter, r *http.Request, groupID primitive.ObjectID) bool {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return false
+	}
+	allowed, err := s.Authz.CanViewGroup(context.Background(), actor, groupID)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusInternalServerError)
+		return false
+	}
+	if !allowed {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
 func (s *Server) GetGroup(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := primitive.ObjectIDFromHex(idStr)
@@ -193,6 +250,9 @@ func (s *Server) GetGroup(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid group id", http.StatusBadRequest)
 		return
 	}
+	if !s.checkViewableGroup(w, r, id) {
+		return
+	}
 
 	group, err := s.DB.GetGroup(context.Background(), id)
 	if err != nil {
@@ -211,24 +271,48 @@ func (s *Server) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		Name     string               `json:"name"`
 		AdminIDs []primitive.ObjectID `json:"admin_ids"`
-		UserID   primitive.ObjectID   `json:"user_id"`
+		TimeZone string               `json:"time_zone"`
+		// OccurrencesAhead is how many occurrences of each recurring series
+		// to keep scheduled
+		OccurrencesAhead int `json:"occurrences_ahead"`
+		// HostOrder and HostOptOutIDs replace the group's lists when given;
+		// an empty list clears one
+		HostOrder     []primitive.ObjectID `json:"host_order"`
+		HostOptOutIDs []primitive.ObjectID `json:"host_opt_out_ids"`
+		UserID        primitive.ObjectID   `json:"user_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.UserID, "user_id") {
+		return
+	}
+	if err := checkTimeZone(req.TimeZone); err != nil {
+		http.Error(w, "Invalid time zone: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.OccurrencesAhead < 0 || req.OccurrencesAhead > maxOccurrencesAhead {
+		http.Error(w, fmt.Sprintf("occurrences_ahead must be from 1 to %d", maxOccurrencesAhead), http.StatusBadRequest)
+		return
+	}
 
 	// Verify group exists and user is admin
-	group, err := s.DB.GetGroup(context.Background(), id)
+	isGroupAdmin, err := s.Authz.CanManageGroup(context.Background(), actor, id)
 	if err != nil {
 		http.Error(w, "Group not found", http.StatusNotFound)
 		return
 	}
 
-	if !isAdmin(group.AdminIDs, req.UserID) {
+	if !isGroupAdmin {
 		http.Error(w, "Unauthorized: Only admin can update group", http.StatusForbidden)
 		return
 	}
@@ -240,6 +324,18 @@ func (s *Server) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 	if len(req.AdminIDs) > 0 {
 		update["admin_ids"] = req.AdminIDs
 	}
+	if req.TimeZone != "" {
+		update["time_zone"] = req.TimeZone
+	}
+	if req.OccurrencesAhead > 0 {
+		update["occurrences_ahead"] = req.OccurrencesAhead
+	}
+	if req.HostOrder != nil {
+		update["host_order"] = req.HostOrder
+	}
+	if req.HostOptOutIDs != nil {
+		update["host_opt_out_ids"] = req.HostOptOutIDs
+	}
 
 	if len(update) == 0 {
 		w.WriteHeader(http.StatusOK)
@@ -252,6 +348,21 @@ func (s *Server) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Schedule more of each series if the group now wants more ahead; extra
+	// occurrences are kept if it wants fewer, as they may have RSVPs
+	if req.OccurrencesAhead > 0 {
+		series, err := s.DB.GetSeriesByGroupID(context.Background(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i := range series {
+			if _, err := s.fillSeries(context.Background(), actor, &series[i]); err != nil {
+				fmt.Printf("Failed to schedule occurrences of series %s: %v\n", series[i].ID.Hex(), err)
+			}
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -263,25 +374,22 @@ func (s *Server) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	adminIDStr := r.URL.Query().Get("admin_id")
-	if adminIDStr == "" {
-		http.Error(w, "Missing admin_id", http.StatusBadRequest)
+	actor, ok := currentMember(w, r)
+	if !ok {
 		return
 	}
-	adminID, err := primitive.ObjectIDFromHex(adminIDStr)
-	if err != nil {
-		http.Error(w, "Invalid admin_id", http.StatusBadRequest)
+	if !queryActor(w, r, actor, "admin_id") {
 		return
 	}
 
 	// Verify group exists and user is admin
-	group, err := s.DB.GetGroup(context.Background(), id)
+	isGroupAdmin, err := s.Authz.CanManageGroup(context.Background(), actor, id)
 	if err != nil {
 		http.Error(w, "Group not found", http.StatusNotFound)
 		return
 	}
 
-	if !isAdmin(group.AdminIDs, adminID) {
+	if !isGroupAdmin {
 		http.Error(w, "Unauthorized: Only admin can delete group", http.StatusForbidden)
 		return
 	}
@@ -315,6 +423,9 @@ func (s *Server) GetGroupMembers(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid group_id", http.StatusBadRequest)
 		return
 	}
+	if !s.checkViewableGroup(w, r, groupID) {
+		return
+	}
 
 	familyMembers, err := s.DB.GetFamilyMembersByGroupID(context.Background(), groupID)
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"family-potluck/backend/internal/models"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -34,12 +35,14 @@ func TestCreateGroup(t *testing.T) {
 	}
 
 	groupReq := models.Group{
-		Name:    groupName,
-		AdminID: adminID,
+		Name:     groupName,
+		AdminID:  adminID,
+		TimeZone: "America/Denver",
 	}
 	body, _ := json.Marshal(groupReq)
 
 	req, _ := http.NewRequest("POST", "/groups", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: adminID})
 	rr := httptest.NewRecorder()
 
 	server.CreateGroup(rr, req)
@@ -56,6 +59,9 @@ func TestCreateGroup(t *testing.T) {
 	if resp.JoinCode == "" {
 		t.Error("expected join code to be generated")
 	}
+	if resp.TimeZone != "America/Denver" {
+		t.Errorf("expected time zone America/Denver, got %v", resp.TimeZone)
+	}
 }
 
 func TestGetGroups(t *testing.T) {
@@ -99,8 +105,9 @@ func TestDeleteGroup_Unauthorized(t *testing.T) {
 		return &models.Group{ID: groupID, AdminID: adminID}, nil
 	}
 
-	req, _ := http.NewRequest("DELETE", "/groups/"+groupID.Hex()+"?admin_id="+otherUserID.Hex(), nil)
+	req, _ := http.NewRequest("DELETE", "/groups/"+groupID.Hex(), nil)
 	req.SetPathValue("id", groupID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: otherUserID})
 	rr := httptest.NewRecorder()
 
 	server.DeleteGroup(rr, req)
@@ -109,3 +116,67 @@ func TestDeleteGroup_Unauthorized(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusForbidden)
 	}
 }
+
+func TestUpdateGroup_OccurrencesAheadOutOfRange(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	groupID := primitive.NewObjectID()
+	req, _ := http.NewRequest("PUT", "/groups/"+groupID.Hex(), bytes.NewBufferString(`{"occurrences_ahead": 13}`))
+	req.SetPathValue("id", groupID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID()})
+	rr := httptest.NewRecorder()
+
+	server.UpdateGroup(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestGroupReads_OutsideGroup(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	groupID := primitive.NewObjectID()
+	mockDB.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		return &models.Group{ID: id, Name: "Smiths", JoinCode: "SECRET"}, nil
+	}
+	mockDB.GetFamilyMembersByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.FamilyMember, error) {
+		return []models.FamilyMember{}, nil
+	}
+	mockDB.GetEventsByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID, includeCompleted bool) ([]models.Event, error) {
+		return []models.Event{}, nil
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		handler http.HandlerFunc
+	}{
+		{"group", "/groups/" + groupID.Hex(), server.GetGroup},
+		{"members", "/groups/members?group_id=" + groupID.Hex(), server.GetGroupMembers},
+		{"events", "/events?group_id=" + groupID.Hex(), server.GetEvents},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := map[int]*models.FamilyMember{
+				http.StatusForbidden: {ID: primitive.NewObjectID()},
+				http.StatusOK:        {ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}},
+			}
+			for want, member := range members {
+				req, _ := http.NewRequest("GET", tt.url, nil)
+				req.SetPathValue("id", groupID.Hex())
+				req = withFamilyMember(req, member)
+				rr := httptest.NewRecorder()
+				tt.handler(rr, req)
+				if rr.Code != want {
+					t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, want)
+				}
+				if want == http.StatusForbidden && strings.Contains(rr.Body.String(), "SECRET") {
+					t.Errorf("expected the join code to stay hidden, got %s", rr.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -1,39 +1,60 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"family-potluck/backend/internal/authz"
 	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/identity"
 	"family-potluck/backend/internal/websocket"
+	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
-	"google.golang.org/api/idtoken"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type TokenValidator interface {
-	Validate(ctx context.Context, idToken string, audience string) (*idtoken.Payload, error)
-}
-
-type RealTokenValidator struct{}
-
-func (v *RealTokenValidator) Validate(ctx context.Context, idToken string, audience string) (*idtoken.Payload, error) {
-	return idtoken.Validate(ctx, idToken, audience)
-}
-
 type Server struct {
-	DB             database.Service
-	Hub            *websocket.Hub
-	TokenValidator TokenValidator
+	DB       database.Service
+	Hub      *websocket.Hub
+	Identity *identity.Registry
+	Authz    *authz.Authorizer
+	// Operators are the members who may see instance-wide details such as
+	// the realtime hub's metrics.
+	Operators map[primitive.ObjectID]bool
+	// Now tells the time series are scheduled from.
+	Now func() time.Time
 }
 
 func NewServer(db database.Service, hub *websocket.Hub) *Server {
 	return &Server{
-		DB:             db,
-		Hub:            hub,
-		TokenValidator: &RealTokenValidator{},
+		DB:        db,
+		Hub:       hub,
+		Identity:  identity.NewRegistryFromEnv(),
+		Authz:     authz.New(db),
+		Operators: operatorsFromEnv(),
+		Now:       time.Now,
+	}
+}
+
+// operatorsFromEnv reads OPERATOR_MEMBER_IDS, a comma-separated list of
+// FamilyMember IDs.
+func operatorsFromEnv() map[primitive.ObjectID]bool {
+	operators := make(map[primitive.ObjectID]bool)
+	for _, v := range strings.Split(os.Getenv("OPERATOR_MEMBER_IDS"), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			log.Printf("Ignoring invalid OPERATOR_MEMBER_IDS entry %q", v)
+			continue
+		}
+		operators[id] = true
 	}
+	return operators
 }
 
 func (s *Server) HealthHandler(w http.ResponseWriter, r *http.Request) {
@@ -12,6 +12,11 @@ import (
 )
 
 func (s *Server) CreateHousehold(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		Name           string             `json:"name"`
 		Address        string             `json:"address"`
@@ -21,12 +26,15 @@ func (s *Server) CreateHousehold(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.FamilyMemberID, "family_id") {
+		return
+	}
 
 	household := models.Household{
 		ID:        primitive.NewObjectID(),
 		Name:      req.Name,
 		Address:   req.Address,
-		MemberIDs: []primitive.ObjectID{req.FamilyMemberID},
+		MemberIDs: []primitive.ObjectID{actor.ID},
 	}
 
 	err := s.DB.CreateHousehold(context.Background(), &household)
@@ -38,7 +46,7 @@ func (s *Server) CreateHousehold(w http.ResponseWriter, r *http.Request) {
 	// Update family with household ID
 	err = s.DB.UpdateFamilyMember(
 		context.Background(),
-		req.FamilyMemberID,
+		actor.ID,
 		bson.M{"$set": bson.M{"household_id": household.ID}},
 	)
 	if err != nil {
@@ -52,6 +60,11 @@ func (s *Server) CreateHousehold(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) JoinHousehold(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		HouseholdID    primitive.ObjectID `json:"household_id"`
 		FamilyMemberID primitive.ObjectID `json:"family_id"`
@@ -60,12 +73,15 @@ func (s *Server) JoinHousehold(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.FamilyMemberID, "family_id") {
+		return
+	}
 
 	// Update Household
 	err := s.DB.UpdateHousehold(
 		context.Background(),
 		req.HouseholdID,
-		bson.M{"$addToSet": bson.M{"member_ids": req.FamilyMemberID}},
+		bson.M{"$addToSet": bson.M{"member_ids": actor.ID}},
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -75,7 +91,7 @@ func (s *Server) JoinHousehold(w http.ResponseWriter, r *http.Request) {
 	// Update Family
 	err = s.DB.UpdateFamilyMember(
 		context.Background(),
-		req.FamilyMemberID,
+		actor.ID,
 		bson.M{"$set": bson.M{"household_id": req.HouseholdID}},
 	)
 	if err != nil {
@@ -119,15 +135,31 @@ func (s *Server) GetHousehold(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) AddMemberToHousehold(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		HouseholdID primitive.ObjectID `json:"household_id"`
 		Email       string             `json:"email"`
+		GroupID     primitive.ObjectID `json:"group_id,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	canManage, err := s.Authz.CanManageHousehold(context.Background(), actor, req.HouseholdID, req.GroupID)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+	if !canManage {
+		http.Error(w, "Unauthorized: Only household members or admins can add members", http.StatusForbidden)
+		return
+	}
+
 	// Find family by email
 	familyMember, err := s.DB.GetFamilyMemberByEmail(context.Background(), req.Email)
 	if err != nil {
@@ -168,39 +200,32 @@ func (s *Server) DeleteHousehold(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Optional: Check for admin permissions if admin_id and group_id are provided
-	// For now, we assume the caller has verified permissions or it's the user's own household
-	// But the requirement is "Allow Admin to manage".
-	// So we should check if the requester is authorized.
-	// Since we don't have full auth middleware context here easily without parsing token again or passing it,
-	// we rely on the request body/query for admin_id/group_id for now, similar to DeleteGroup.
-
-	adminIDStr := r.URL.Query().Get("admin_id")
-	groupIDStr := r.URL.Query().Get("group_id")
+	// Household members may delete their own household; group admins pass group_id
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+	if !queryActor(w, r, actor, "admin_id") {
+		return
+	}
 
-	if adminIDStr != "" && groupIDStr != "" {
-		adminID, err := primitive.ObjectIDFromHex(adminIDStr)
-		if err != nil {
-			http.Error(w, "Invalid admin_id", http.StatusBadRequest)
-			return
-		}
-		groupID, err := primitive.ObjectIDFromHex(groupIDStr)
+	var groupID primitive.ObjectID
+	if groupIDStr := r.URL.Query().Get("group_id"); groupIDStr != "" {
+		groupID, err = primitive.ObjectIDFromHex(groupIDStr)
 		if err != nil {
 			http.Error(w, "Invalid group_id", http.StatusBadRequest)
 			return
 		}
+	}
 
-		// Verify admin
-		group, err := s.DB.GetGroup(context.Background(), groupID)
-		if err != nil {
-			http.Error(w, "Group not found", http.StatusNotFound)
-			return
-		}
-		if !isAdmin(group.AdminIDs, adminID) {
-			http.Error(w, "Unauthorized: Only admin can delete household", http.StatusForbidden)
-			return
-		}
-		// We could also verify the household belongs to this group (via members), but let's trust the admin for now.
+	canManage, err := s.Authz.CanManageHousehold(context.Background(), actor, id, groupID)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+	if !canManage {
+		http.Error(w, "Unauthorized: Only admin can delete household", http.StatusForbidden)
+		return
 	}
 
 	err = s.DB.DeleteHousehold(context.Background(), id)
@@ -213,6 +238,11 @@ func (s *Server) DeleteHousehold(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) RemoveMemberFromHousehold(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		HouseholdID    primitive.ObjectID  `json:"household_id"`
 		FamilyMemberID primitive.ObjectID  `json:"family_id"`
@@ -223,21 +253,25 @@ func (s *Server) RemoveMemberFromHousehold(w http.ResponseWriter, r *http.Reques
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.AdminID != nil && !matchesActor(w, actor, *req.AdminID, "admin_id") {
+		return
+	}
 
-	if req.AdminID != nil && req.GroupID != nil {
-		// Verify admin
-		group, err := s.DB.GetGroup(context.Background(), *req.GroupID)
-		if err != nil {
-			http.Error(w, "Group not found", http.StatusNotFound)
-			return
-		}
-		if !isAdmin(group.AdminIDs, *req.AdminID) {
-			http.Error(w, "Unauthorized: Only admin can remove members", http.StatusForbidden)
-			return
-		}
+	var groupID primitive.ObjectID
+	if req.GroupID != nil {
+		groupID = *req.GroupID
+	}
+	canManage, err := s.Authz.CanManageHousehold(context.Background(), actor, req.HouseholdID, groupID)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+	if !canManage {
+		http.Error(w, "Unauthorized: Only admin can remove members", http.StatusForbidden)
+		return
 	}
 
-	err := s.DB.RemoveMemberFromHousehold(context.Background(), req.HouseholdID, req.FamilyMemberID)
+	err = s.DB.RemoveMemberFromHousehold(context.Background(), req.HouseholdID, req.FamilyMemberID)
 	if err != nil {
 		http.Error(w, "Failed to remove member", http.StatusInternalServerError)
 		return
@@ -254,15 +288,40 @@ func (s *Server) UpdateHousehold(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
-		Name    string `json:"name"`
-		Address string `json:"address"`
+		Name    string             `json:"name"`
+		Address string             `json:"address"`
+		GroupID primitive.ObjectID `json:"group_id,omitempty"`
+		// Unavailable replaces the household's days away when given
+		Unavailable *[]models.Unavailability `json:"unavailable"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	if req.Unavailable != nil {
+		if err := checkUnavailability(*req.Unavailable); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	canManage, err := s.Authz.CanManageHousehold(context.Background(), actor, id, req.GroupID)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+	if !canManage {
+		http.Error(w, "Unauthorized: Only household members or admins can update household", http.StatusForbidden)
+		return
+	}
+
 	update := bson.M{}
 	if req.Name != "" {
 		update["name"] = req.Name
@@ -270,6 +329,9 @@ func (s *Server) UpdateHousehold(w http.ResponseWriter, r *http.Request) {
 	if req.Address != "" {
 		update["address"] = req.Address
 	}
+	if req.Unavailable != nil {
+		update["unavailable"] = *req.Unavailable
+	}
 
 	if len(update) == 0 {
 		http.Error(w, "No fields to update", http.StatusBadRequest)
@@ -17,6 +17,7 @@ import (
 func TestCreateHousehold(t *testing.T) {
 	mockDB := &database.MockService{}
 	server := NewServer(mockDB, nil)
+	familyID := primitive.NewObjectID()
 
 	mockDB.CreateHouseholdFunc = func(ctx context.Context, household *models.Household) error {
 		return nil
@@ -27,10 +28,11 @@ func TestCreateHousehold(t *testing.T) {
 
 	payload := map[string]interface{}{
 		"name":      "The Smiths",
-		"family_id": primitive.NewObjectID(),
+		"family_id": familyID,
 	}
 	body, _ := json.Marshal(payload)
 	req := httptest.NewRequest("POST", "/households", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID})
 	w := httptest.NewRecorder()
 
 	server.CreateHousehold(w, req)
@@ -43,6 +45,7 @@ func TestCreateHousehold(t *testing.T) {
 func TestJoinHousehold(t *testing.T) {
 	mockDB := &database.MockService{}
 	server := NewServer(mockDB, nil)
+	familyID := primitive.NewObjectID()
 
 	mockDB.UpdateHouseholdFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
 		return nil
@@ -53,10 +56,11 @@ func TestJoinHousehold(t *testing.T) {
 
 	payload := map[string]interface{}{
 		"household_id": primitive.NewObjectID(),
-		"family_id":    primitive.NewObjectID(),
+		"family_id":    familyID,
 	}
 	body, _ := json.Marshal(payload)
 	req := httptest.NewRequest("POST", "/households/join", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID})
 	w := httptest.NewRecorder()
 
 	server.JoinHousehold(w, req)
@@ -97,6 +101,7 @@ func TestDeleteHousehold(t *testing.T) {
 
 	req := httptest.NewRequest("DELETE", "/households/"+householdID.Hex(), nil)
 	req.SetPathValue("id", householdID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), HouseholdID: &householdID})
 	w := httptest.NewRecorder()
 
 	server.DeleteHousehold(w, req)
@@ -110,16 +115,18 @@ func TestRemoveMemberFromHousehold(t *testing.T) {
 	mockDB := &database.MockService{}
 	server := NewServer(mockDB, nil)
 
+	householdID := primitive.NewObjectID()
 	mockDB.RemoveMemberFromHouseholdFunc = func(ctx context.Context, householdID, familyID primitive.ObjectID) error {
 		return nil
 	}
 
 	payload := map[string]interface{}{
-		"household_id": primitive.NewObjectID(),
+		"household_id": householdID,
 		"family_id":    primitive.NewObjectID(),
 	}
 	body, _ := json.Marshal(payload)
 	req := httptest.NewRequest("POST", "/households/remove-member", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), HouseholdID: &householdID})
 	w := httptest.NewRecorder()
 
 	server.RemoveMemberFromHousehold(w, req)
@@ -128,3 +135,70 @@ func TestRemoveMemberFromHousehold(t *testing.T) {
 		t.Errorf("Expected status OK, got %v", w.Code)
 	}
 }
+
+func TestDeleteHousehold_Unauthorized(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	householdID := primitive.NewObjectID()
+	mockDB.GetHouseholdFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Household, error) {
+		return &models.Household{ID: householdID, MemberIDs: []primitive.ObjectID{primitive.NewObjectID()}}, nil
+	}
+	mockDB.DeleteHouseholdFunc = func(ctx context.Context, id primitive.ObjectID) error {
+		t.Error("household should not be deleted")
+		return nil
+	}
+
+	req := httptest.NewRequest("DELETE", "/households/"+householdID.Hex(), nil)
+	req.SetPathValue("id", householdID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID()})
+	w := httptest.NewRecorder()
+
+	server.DeleteHousehold(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status Forbidden, got %v", w.Code)
+	}
+}
+
+func TestUpdateHousehold_Unavailable(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+	householdID := primitive.NewObjectID()
+	resident := &models.FamilyMember{ID: primitive.NewObjectID(), HouseholdID: &householdID}
+
+	mockDB.GetHouseholdFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Household, error) {
+		return &models.Household{ID: householdID, MemberIDs: []primitive.ObjectID{resident.ID}}, nil
+	}
+	var saved bson.M
+	mockDB.UpdateHouseholdFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+		saved = update["$set"].(bson.M)
+		return nil
+	}
+
+	update := func(unavailable []models.Unavailability) int {
+		body, _ := json.Marshal(map[string]interface{}{"unavailable": unavailable})
+		req := httptest.NewRequest("PUT", "/households/"+householdID.Hex(), bytes.NewBuffer(body))
+		req.SetPathValue("id", householdID.Hex())
+		req = withFamilyMember(req, resident)
+		w := httptest.NewRecorder()
+		server.UpdateHousehold(w, req)
+		return w.Code
+	}
+
+	away := []models.Unavailability{{Start: "2025-07-01", End: "2025-07-14", Reason: "Summer trip"}}
+	if code := update(away); code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", code)
+	}
+	if got, _ := saved["unavailable"].([]models.Unavailability); len(got) != 1 || got[0] != away[0] {
+		t.Errorf("expected the days away to be saved, got %v", saved)
+	}
+
+	saved = nil
+	if code := update([]models.Unavailability{{Start: "2025-07-14", End: "2025-07-01"}}); code != http.StatusBadRequest {
+		t.Errorf("Expected status Bad Request, got %v", code)
+	}
+	if saved != nil {
+		t.Errorf("expected nothing to be saved, got %v", saved)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/identity"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// verifyCredentials runs the posted credentials through the provider named in
+// the path, writing the error response on failure.
+func (s *Server) verifyCredentials(w http.ResponseWriter, r *http.Request) (*identity.Identity, bool) {
+	provider, ok := s.Identity.Get(r.PathValue("provider"))
+	if !ok {
+		http.Error(w, "Unknown identity provider", http.StatusNotFound)
+		return nil, false
+	}
+
+	var creds identity.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	ident, err := provider.Authenticate(context.Background(), creds)
+	if err != nil {
+		http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+	return ident, true
+}
+
+// LinkIdentity attaches another sign-in method to the current FamilyMember.
+func (s *Server) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	ident, ok := s.verifyCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	owner, err := s.DB.GetFamilyMemberByIdentity(context.Background(), ident.Provider, ident.Subject)
+	if err == nil && owner.ID != actor.ID {
+		http.Error(w, "This sign-in belongs to another account; merge that account instead", http.StatusConflict)
+		return
+	} else if err != nil && err != mongo.ErrNoDocuments {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.linkIdentity(context.Background(), actor, ident); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(actor.ToSafe())
+}
+
+// MergeAccount folds a duplicate FamilyMember into the current one. The caller
+// proves ownership of the duplicate by signing in to it through the provider
+// in the path; its groups, household, RSVPs, dishes and chat messages move to
+// the current member and the duplicate is deleted.
+func (s *Server) MergeAccount(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	ident, ok := s.verifyCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	duplicate, err := s.DB.GetFamilyMemberByIdentity(context.Background(), ident.Provider, ident.Subject)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "No account is linked to this sign-in", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if duplicate.ID == actor.ID {
+		http.Error(w, "Cannot merge an account into itself", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.DB.MergeFamilyMembers(context.Background(), duplicate.ID, actor.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	merged, err := s.DB.GetFamilyMemberByID(context.Background(), actor.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(merged.ToSafe())
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/identity"
+	"family-potluck/backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newDevServer(mockDB *database.MockService) *Server {
+	server := NewServer(mockDB, nil)
+	server.Identity = identity.NewRegistry(identity.NewDevProvider([]identity.DevUser{{Email: "alt@example.com", Name: "Alt"}}))
+	return server
+}
+
+func TestLinkIdentity_BelongsToAnotherAccount(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := newDevServer(mockDB)
+
+	mockDB.GetFamilyMemberByIdentityFunc = func(ctx context.Context, provider, subject string) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: primitive.NewObjectID()}, nil
+	}
+
+	req, _ := http.NewRequest("POST", "/auth/identities/dev", bytes.NewBufferString(`{"email":"alt@example.com"}`))
+	req.SetPathValue("provider", "dev")
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID()})
+	rr := httptest.NewRecorder()
+
+	server.LinkIdentity(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+	}
+}
+
+func TestMergeAccount(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := newDevServer(mockDB)
+
+	actor := &models.FamilyMember{ID: primitive.NewObjectID()}
+	duplicate := &models.FamilyMember{ID: primitive.NewObjectID()}
+	mockDB.GetFamilyMemberByIdentityFunc = func(ctx context.Context, provider, subject string) (*models.FamilyMember, error) {
+		return duplicate, nil
+	}
+	var mergedFrom, mergedInto primitive.ObjectID
+	mockDB.MergeFamilyMembersFunc = func(ctx context.Context, sourceID, targetID primitive.ObjectID) error {
+		mergedFrom, mergedInto = sourceID, targetID
+		return nil
+	}
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return actor, nil
+	}
+
+	req, _ := http.NewRequest("POST", "/auth/merge/dev", bytes.NewBufferString(`{"email":"alt@example.com"}`))
+	req.SetPathValue("provider", "dev")
+	req = withFamilyMember(req, actor)
+	rr := httptest.NewRecorder()
+
+	server.MergeAccount(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if mergedFrom != duplicate.ID || mergedInto != actor.ID {
+		t.Errorf("expected %v to be merged into %v, got %v into %v", duplicate.ID, actor.ID, mergedFrom, mergedInto)
+	}
+}
+
+func TestMergeAccount_Self(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := newDevServer(mockDB)
+
+	actor := &models.FamilyMember{ID: primitive.NewObjectID()}
+	mockDB.GetFamilyMemberByIdentityFunc = func(ctx context.Context, provider, subject string) (*models.FamilyMember, error) {
+		return actor, nil
+	}
+
+	req, _ := http.NewRequest("POST", "/auth/merge/dev", bytes.NewBufferString(`{"email":"alt@example.com"}`))
+	req.SetPathValue("provider", "dev")
+	req = withFamilyMember(req, actor)
+	rr := httptest.NewRecorder()
+
+	server.MergeAccount(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
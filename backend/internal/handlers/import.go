@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/ical"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
+	"family-potluck/backend/internal/recurrence"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxImportBytes caps the size of an import request, calendar included.
+const maxImportBytes = 4 << 20
+
+// defaultImportType is the type of imported events when none is chosen.
+const defaultImportType = "Dinner"
+
+// What importing each event of a calendar does, or in a dry run would do.
+const (
+	importCreate    = "create"
+	importCreated   = "created"
+	importDuplicate = "duplicate"
+	importSkipped   = "skipped"
+)
+
+// importedEvent reports what became of one event of an imported calendar.
+// Recurring events are reported once, at their first occurrence yet to end.
+type importedEvent struct {
+	UID        string             `json:"uid,omitempty"`
+	Name       string             `json:"name"`
+	Date       time.Time          `json:"date"`
+	TimeZone   string             `json:"time_zone,omitempty"`
+	Recurrence string             `json:"recurrence,omitempty"`
+	HostID     primitive.ObjectID `json:"host_id"`
+	Result     string             `json:"result"`
+	Reason     string             `json:"reason,omitempty"`
+	// EventID is the event created, and DuplicateOf the existing one it
+	// matched
+	EventID     *primitive.ObjectID `json:"event_id,omitempty"`
+	DuplicateOf *primitive.ObjectID `json:"duplicate_of,omitempty"`
+
+	event models.Event
+}
+
+// ImportEvents creates events in a group from an iCalendar file, such as one
+// exported from Google or Apple Calendar. Each event is hosted by the member
+// whose email organized it, or else by the chosen host. Events with the same
+// name on the same day as one already in the group, or earlier in the file,
+// are reported as duplicates and left out. With dry_run set nothing is
+// created, so the result can be previewed.
+func (s *Server) ImportEvents(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GroupID primitive.ObjectID `json:"group_id"`
+		HostID  primitive.ObjectID `json:"host_id"`
+		Type    string             `json:"type"`
+		DryRun  bool               `json:"dry_run"`
+		ICS     string             `json:"ics"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.GroupID.IsZero() || req.HostID.IsZero() {
+		http.Error(w, "group_id and host_id are required", http.StatusBadRequest)
+		return
+	}
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	isGroupAdmin, err := s.Authz.CanManageGroup(ctx, actor, req.GroupID)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+	if !isGroupAdmin {
+		http.Error(w, "Unauthorized: Only admin can import events", http.StatusForbidden)
+		return
+	}
+	group, err := s.DB.GetGroup(ctx, req.GroupID)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+	members, err := s.DB.GetFamilyMembersByGroupID(ctx, req.GroupID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	hosts := map[string]primitive.ObjectID{}
+	defaultHost := false
+	for _, m := range members {
+		if m.Email != "" {
+			hosts[strings.ToLower(m.Email)] = m.ID
+		}
+		defaultHost = defaultHost || m.ID == req.HostID
+	}
+	if !defaultHost {
+		http.Error(w, "Host is not a member of the group", http.StatusBadRequest)
+		return
+	}
+
+	loc := time.UTC
+	if group.TimeZone != "" {
+		if l, err := time.LoadLocation(group.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	calendar, err := ical.Parse(strings.NewReader(req.ICS), loc)
+	if err != nil {
+		http.Error(w, "Invalid calendar: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	existing, err := s.DB.GetEventsByGroupID(ctx, req.GroupID, true)
+	if err != nil {
+		http.Error(w, "Failed to load events", http.StatusInternalServerError)
+		return
+	}
+
+	eventType := strings.TrimSpace(req.Type)
+	if eventType == "" {
+		eventType = defaultImportType
+	}
+	imported := importCalendar(calendar, group, eventType, func(organizer string) primitive.ObjectID {
+		if id, ok := hosts[strings.ToLower(organizer)]; ok {
+			return id
+		}
+		return req.HostID
+	}, time.Now())
+	markDuplicates(imported, existing)
+
+	created, duplicates, skipped := 0, 0, 0
+	for i := range imported {
+		im := &imported[i]
+		switch {
+		case im.Result == importDuplicate:
+			duplicates++
+		case im.Result == importSkipped:
+			skipped++
+		case req.DryRun:
+			created++
+		default:
+			if err := s.createImportedEvent(ctx, actor, &im.event); err != nil {
+				im.Result, im.Reason = importSkipped, "failed to save: "+err.Error()
+				skipped++
+				continue
+			}
+			im.Result = importCreated
+			im.EventID = &im.event.ID
+			created++
+		}
+	}
+
+	status := http.StatusOK
+	if created > 0 && !req.DryRun {
+		status = http.StatusCreated
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"dry_run":    req.DryRun,
+		"events":     imported,
+		"created":    created,
+		"duplicates": duplicates,
+		"skipped":    skipped,
+	})
+}
+
+// importCalendar maps the events of calendar to events of group, ready to be
+// created. Cancelled occurrences of a recurring event are skipped by its
+// series, and moved ones leave their slot for an event of their own.
+// Recurring events start at their first occurrence to end after now, and
+// other events that ended before it are imported as completed.
+func importCalendar(calendar *ical.Calendar, group *models.Group, eventType string, hostFor func(organizer string) primitive.ObjectID, now time.Time) []importedEvent {
+	masters := map[string]*ical.Event{}
+	for i := range calendar.Events {
+		e := &calendar.Events[i]
+		if e.RRule != "" && e.RecurrenceID == nil && e.UID != "" {
+			masters[e.UID] = e
+		}
+	}
+	for _, e := range calendar.Events {
+		if master, ok := masters[e.UID]; ok && e.RecurrenceID != nil {
+			master.ExDates = append(master.ExDates, *e.RecurrenceID)
+		}
+	}
+
+	var imported []importedEvent
+	for i := range calendar.Events {
+		e := &calendar.Events[i]
+		if e.RecurrenceID != nil && e.Status == ical.StatusCancelled {
+			continue
+		}
+
+		event := models.Event{
+			GroupID:     group.ID,
+			Name:        strings.TrimSpace(e.Summary),
+			Date:        e.Start,
+			TimeZone:    e.TimeZone,
+			Type:        eventType,
+			HostID:      hostFor(e.Organizer),
+			Location:    e.Location,
+			Description: e.Description,
+			Status:      "scheduled",
+		}
+		if event.Name == "" {
+			event.Name = "Untitled event"
+		}
+		if event.TimeZone == "" {
+			event.TimeZone = group.TimeZone
+		}
+		// Events with no duration last until the end of their day, as
+		// all-day events that don't run on do
+		oneDay := e.AllDay && !e.End.After(e.Start.AddDate(0, 0, 1))
+		if e.End.After(e.Start) && !oneDay {
+			event.DurationMinutes = int(e.End.Sub(e.Start).Minutes())
+		}
+
+		im := importedEvent{UID: e.UID, Result: importCreate}
+		switch {
+		case e.Status == ical.StatusCancelled:
+			im.Result, im.Reason = importSkipped, "the event is cancelled"
+		case e.RRule != "" && e.RecurrenceID == nil:
+			if reason := importSeries(&event, e, now); reason != "" {
+				im.Result, im.Reason = importSkipped, reason
+			}
+		case event.HasEnded(now):
+			event.Status = "completed"
+		}
+		im.Name = event.Name
+		im.Date = event.Date
+		im.TimeZone = event.TimeZone
+		im.Recurrence = event.Recurrence
+		im.HostID = event.HostID
+		im.event = event
+		imported = append(imported, im)
+	}
+	return imported
+}
+
+// importSeries makes event the start of a series recurring as e does, at its
+// first occurrence to end after now. It returns why the series can't be
+// imported, if it can't.
+func importSeries(event *models.Event, e *ical.Event, now time.Time) string {
+	rule, err := recurrence.Parse(e.RRule)
+	if err != nil {
+		return "unsupported recurrence: " + err.Error()
+	}
+	start := e.Start.In(event.TimeLocation())
+	event.Recurrence = rule.String()
+	event.RecurrenceStart = &start
+	event.ExDates = e.ExDates
+
+	// Occurrences that started more than their length ago have ended, so look
+	// from a little before then
+	length := event.End().Sub(event.Date)
+	rs := recurrence.Series{Rule: rule, Start: start, ExDates: e.ExDates}
+	date, ok := rs.Next(now.Add(-length - 24*time.Hour))
+	for ok {
+		event.Date = date
+		if !event.HasEnded(now) {
+			return ""
+		}
+		date, ok = rs.Next(date)
+	}
+	event.Date = e.Start
+	return "the series has ended"
+}
+
+// markDuplicates reports as duplicates the events in imported that have the
+// same name, ignoring case, on the same day as an existing event or one
+// earlier in imported.
+func markDuplicates(imported []importedEvent, existing []models.Event) {
+	key := func(e *models.Event) string {
+		return strings.ToLower(strings.TrimSpace(e.Name)) + "\x00" + occurrenceDay(e)
+	}
+	seen := map[string]*primitive.ObjectID{}
+	for i := range existing {
+		if _, ok := seen[key(&existing[i])]; !ok {
+			seen[key(&existing[i])] = &existing[i].ID
+		}
+	}
+	for i := range imported {
+		im := &imported[i]
+		if im.Result != importCreate {
+			continue
+		}
+		k := key(&im.event)
+		id, ok := seen[k]
+		switch {
+		case !ok:
+			seen[k] = nil
+			continue
+		case id == nil:
+			im.Reason = "the same event is earlier in the file"
+		default:
+			im.Reason = "an event with the same name is on that day"
+			im.DuplicateOf = id
+		}
+		im.Result = importDuplicate
+	}
+}
+
+// createImportedEvent saves an event mapped from a calendar, the way
+// CreateEvent saves a new one, starting its series if it recurs.
+func (s *Server) createImportedEvent(ctx context.Context, actor *models.FamilyMember, event *models.Event) error {
+	event.ID = primitive.NewObjectID()
+	event.GuestJoinCode = generateJoinCode()
+	if event.Recurrence != "" {
+		event.RecurrenceID = primitive.NewObjectID()
+	}
+	s.hostAtHousehold(ctx, event)
+	if err := s.DB.CreateEvent(ctx, event); err != nil {
+		return err
+	}
+	realtime.Publish(s.Hub, actor, realtime.EventCreated(*event), eventTopics(event)...)
+	s.startSeries(ctx, actor, event)
+	return nil
+}
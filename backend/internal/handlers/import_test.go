@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/ical"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestImportCalendar(t *testing.T) {
+	group := &models.Group{ID: primitive.NewObjectID(), TimeZone: "UTC"}
+	ann, host := primitive.NewObjectID(), primitive.NewObjectID()
+	hostFor := func(organizer string) primitive.ObjectID {
+		if organizer == "ann@example.com" {
+			return ann
+		}
+		return host
+	}
+	start := time.Date(2025, time.January, 5, 18, 0, 0, 0, time.UTC)
+	moved := start.AddDate(0, 0, 21)
+	calendar := &ical.Calendar{Events: []ical.Event{
+		{UID: "dinner", Summary: "Sunday Dinner", Start: start, End: start.Add(2 * time.Hour), RRule: "FREQ=WEEKLY", ExDates: []time.Time{start.AddDate(0, 0, 7)}, Organizer: "ann@example.com"},
+		{UID: "dinner", Summary: "Sunday Dinner", Start: start.AddDate(0, 0, 14), RecurrenceID: ptrTime(start.AddDate(0, 0, 14)), Status: ical.StatusCancelled},
+		{UID: "dinner", Summary: "Monday Dinner", Start: moved.AddDate(0, 0, 1), End: moved.AddDate(0, 0, 1).Add(time.Hour), RecurrenceID: &moved},
+		{UID: "picnic", Summary: " Picnic ", Start: time.Date(2025, time.July, 4, 0, 0, 0, 0, time.UTC), End: time.Date(2025, time.July, 5, 0, 0, 0, 0, time.UTC), AllDay: true},
+		{UID: "camp", Summary: "", Start: time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2025, time.August, 4, 0, 0, 0, 0, time.UTC), AllDay: true},
+		{UID: "party", Summary: "Party", Start: start.AddDate(0, 0, 2), Status: ical.StatusCancelled},
+		{UID: "odd", Summary: "Odd", Start: start, RRule: "FREQ=HOURLY"},
+	}}
+	now := time.Date(2025, time.January, 6, 12, 0, 0, 0, time.UTC)
+
+	imported := importCalendar(calendar, group, "Dinner", hostFor, now)
+	if len(imported) != 6 {
+		t.Fatalf("got %d events, want 6: %+v", len(imported), imported)
+	}
+
+	dinner := imported[0].event
+	if imported[0].Result != importCreate || dinner.HostID != ann || dinner.Type != "Dinner" {
+		t.Errorf("dinner is %+v, want it created and hosted by its organizer", imported[0])
+	}
+	// The first week is over, the second skipped, the third cancelled and the
+	// fourth moved to a Monday
+	if want := start.AddDate(0, 0, 28); !dinner.Date.Equal(want) || !dinner.RecurrenceStart.Equal(start) {
+		t.Errorf("dinner starts %v from %v, want %v from %v", dinner.Date, dinner.RecurrenceStart, want, start)
+	}
+	if dinner.Recurrence != "FREQ=WEEKLY" || dinner.DurationMinutes != 120 || len(dinner.ExDates) != 3 {
+		t.Errorf("dinner recurs %q for %d minutes without %v", dinner.Recurrence, dinner.DurationMinutes, dinner.ExDates)
+	}
+
+	monday := imported[1].event
+	if monday.Name != "Monday Dinner" || monday.Recurrence != "" || monday.HostID != host || monday.Status != "scheduled" {
+		t.Errorf("moved occurrence is %+v, want a one-off", monday)
+	}
+	picnic := imported[2].event
+	if picnic.Name != "Picnic" || picnic.DurationMinutes != 0 || picnic.TimeZone != "UTC" {
+		t.Errorf("picnic is %+v, want it to last the day", picnic)
+	}
+	camp := imported[3].event
+	if camp.Name != "Untitled event" || camp.DurationMinutes != 3*24*60 {
+		t.Errorf("camp is %+v, want it to last three days", camp)
+	}
+	if imported[4].Result != importSkipped || imported[5].Result != importSkipped || !strings.HasPrefix(imported[5].Reason, "unsupported recurrence") {
+		t.Errorf("expected the cancelled party and the hourly event to be skipped, got %+v and %+v", imported[4], imported[5])
+	}
+
+	later := importCalendar(&ical.Calendar{Events: []ical.Event{calendar.Events[3]}}, group, "Dinner", hostFor, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if later[0].event.Status != "completed" {
+		t.Errorf("expected a past event to be imported as completed, got %q", later[0].event.Status)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+func TestMarkDuplicates(t *testing.T) {
+	day := time.Date(2025, time.July, 4, 12, 0, 0, 0, time.UTC)
+	existing := []models.Event{{ID: primitive.NewObjectID(), Name: "Picnic", Date: day}}
+	imported := []importedEvent{
+		{Result: importCreate, event: models.Event{Name: "picnic ", Date: day.Add(3 * time.Hour)}},
+		{Result: importCreate, event: models.Event{Name: "Picnic", Date: day.AddDate(0, 0, 1)}},
+		{Result: importCreate, event: models.Event{Name: "Picnic", Date: day.AddDate(0, 0, 1)}},
+		{Result: importSkipped, event: models.Event{Name: "Picnic", Date: day}},
+	}
+
+	markDuplicates(imported, existing)
+
+	if imported[0].Result != importDuplicate || imported[0].DuplicateOf == nil || *imported[0].DuplicateOf != existing[0].ID {
+		t.Errorf("expected the first picnic to duplicate the existing one, got %+v", imported[0])
+	}
+	if imported[1].Result != importCreate {
+		t.Errorf("expected the next day's picnic to be created, got %+v", imported[1])
+	}
+	if imported[2].Result != importDuplicate || imported[2].DuplicateOf != nil {
+		t.Errorf("expected the repeated picnic to be a duplicate within the file, got %+v", imported[2])
+	}
+	if imported[3].Result != importSkipped {
+		t.Errorf("expected a skipped event to stay skipped, got %+v", imported[3])
+	}
+}
+
+const importFile = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\nUID:a\r\nDTSTART:20300105T180000Z\r\nDTEND:20300105T200000Z\r\nSUMMARY:Reunion\r\nORGANIZER:mailto:Ann@Example.com\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:b\r\nDTSTART:20300106T180000Z\r\nRRULE:FREQ=WEEKLY;COUNT=4\r\nSUMMARY:Game Night\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:c\r\nDTSTART;VALUE=DATE:20300704\r\nSUMMARY:Picnic\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+// importFixture is a group run by admin, with Ann in it and a picnic already
+// on the calendar. Events created are kept in created.
+type importFixture struct {
+	db      *database.MockService
+	server  *Server
+	group   models.Group
+	admin   models.FamilyMember
+	ann     models.FamilyMember
+	picnic  models.Event
+	created []models.Event
+}
+
+func newImportFixture() *importFixture {
+	f := &importFixture{db: &database.MockService{}}
+	hub := websocket.NewHub()
+	go hub.Run()
+	f.server = NewServer(f.db, hub)
+
+	f.admin = models.FamilyMember{ID: primitive.NewObjectID(), Name: "Admin"}
+	f.ann = models.FamilyMember{ID: primitive.NewObjectID(), Name: "Ann", Email: "ann@example.com"}
+	f.group = models.Group{ID: primitive.NewObjectID(), AdminIDs: []primitive.ObjectID{f.admin.ID}, TimeZone: "UTC"}
+	f.admin.GroupIDs = []primitive.ObjectID{f.group.ID}
+	f.ann.GroupIDs = []primitive.ObjectID{f.group.ID}
+	f.picnic = models.Event{ID: primitive.NewObjectID(), GroupID: f.group.ID, Name: "Picnic", Date: time.Date(2030, time.July, 4, 12, 0, 0, 0, time.UTC)}
+
+	f.db.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		if id != f.group.ID {
+			return nil, database.ErrNoDocuments
+		}
+		return &f.group, nil
+	}
+	f.db.GetFamilyMembersByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.FamilyMember, error) {
+		return []models.FamilyMember{f.admin, f.ann}, nil
+	}
+	f.db.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: id}, nil
+	}
+	f.db.GetEventsByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID, includeCompleted bool) ([]models.Event, error) {
+		return []models.Event{f.picnic}, nil
+	}
+	f.db.CreateEventFunc = func(ctx context.Context, event *models.Event) error {
+		f.created = append(f.created, *event)
+		return nil
+	}
+	f.db.CreateOccurrenceFunc = func(ctx context.Context, event *models.Event) (bool, error) {
+		return true, f.db.CreateEventFunc(ctx, event)
+	}
+	f.db.GetSeriesFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Series, error) {
+		return nil, database.ErrNoDocuments
+	}
+	f.db.CreateSeriesFunc = func(ctx context.Context, series *models.Series) error {
+		return nil
+	}
+	f.db.GetEventsByRecurrenceIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Event, error) {
+		var events []models.Event
+		for _, e := range f.created {
+			if e.RecurrenceID == id {
+				events = append(events, e)
+			}
+		}
+		return events, nil
+	}
+	return f
+}
+
+func (f *importFixture) post(actor *models.FamilyMember, body map[string]any) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/events/import", bytes.NewBuffer(b))
+	req = withFamilyMember(req, actor)
+	rr := httptest.NewRecorder()
+	f.server.ImportEvents(rr, req)
+	return rr
+}
+
+type importResponse struct {
+	DryRun     bool            `json:"dry_run"`
+	Events     []importedEvent `json:"events"`
+	Created    int             `json:"created"`
+	Duplicates int             `json:"duplicates"`
+	Skipped    int             `json:"skipped"`
+}
+
+func TestImportEvents_DryRun(t *testing.T) {
+	f := newImportFixture()
+	f.db.CreateEventFunc = func(ctx context.Context, event *models.Event) error {
+		t.Fatal("expected a dry run not to create events")
+		return nil
+	}
+
+	rr := f.post(&f.admin, map[string]any{"group_id": f.group.ID, "host_id": f.admin.ID, "dry_run": true, "ics": importFile})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp importResponse
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if !resp.DryRun || resp.Created != 2 || resp.Duplicates != 1 || len(resp.Events) != 3 {
+		t.Fatalf("unexpected preview %+v", resp)
+	}
+	if resp.Events[0].Result != importCreate || resp.Events[0].HostID != f.ann.ID {
+		t.Errorf("expected the reunion to be hosted by its organizer, got %+v", resp.Events[0])
+	}
+	if resp.Events[1].Recurrence != "FREQ=WEEKLY;COUNT=4" {
+		t.Errorf("expected game night to recur, got %+v", resp.Events[1])
+	}
+	if dup := resp.Events[2]; dup.Result != importDuplicate || dup.DuplicateOf == nil || *dup.DuplicateOf != f.picnic.ID {
+		t.Errorf("expected the picnic to be reported as a duplicate, got %+v", dup)
+	}
+}
+
+func TestImportEvents(t *testing.T) {
+	f := newImportFixture()
+
+	rr := f.post(&f.admin, map[string]any{"group_id": f.group.ID, "host_id": f.admin.ID, "type": "Potluck", "ics": importFile})
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	var resp importResponse
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Created != 2 || resp.Events[0].Result != importCreated || resp.Events[0].EventID == nil {
+		t.Fatalf("unexpected result %+v", resp)
+	}
+	// The reunion, game night, and the rest of game night's occurrences
+	// scheduled ahead
+	if len(f.created) != 2+defaultOccurrencesAhead-1 {
+		t.Fatalf("expected %d events to be created, got %d", 2+defaultOccurrencesAhead-1, len(f.created))
+	}
+	if f.created[0].ID != *resp.Events[0].EventID || f.created[0].Type != "Potluck" || f.created[0].GuestJoinCode == "" {
+		t.Errorf("unexpected reunion %+v", f.created[0])
+	}
+	if gameNight := f.created[1]; gameNight.RecurrenceID.IsZero() || f.created[2].RecurrenceID != gameNight.RecurrenceID {
+		t.Errorf("expected game night to start a series, got %+v and %+v", gameNight, f.created[2])
+	}
+}
+
+func TestImportEvents_Rejected(t *testing.T) {
+	f := newImportFixture()
+	outsider := primitive.NewObjectID()
+	tests := []struct {
+		name  string
+		actor *models.FamilyMember
+		body  map[string]any
+		want  int
+	}{
+		{"not an admin", &f.ann, map[string]any{"group_id": f.group.ID, "host_id": f.ann.ID, "ics": importFile}, http.StatusForbidden},
+		{"host outside the group", &f.admin, map[string]any{"group_id": f.group.ID, "host_id": outsider, "ics": importFile}, http.StatusBadRequest},
+		{"no host", &f.admin, map[string]any{"group_id": f.group.ID, "ics": importFile}, http.StatusBadRequest},
+		{"not a calendar", &f.admin, map[string]any{"group_id": f.group.ID, "host_id": f.admin.ID, "ics": "Name,Date\n"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := f.post(tt.actor, tt.body)
+			if rr.Code != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
+	"family-potluck/backend/internal/websocket"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// eventTopics are the topics a message about event reaches: members of its
+// group and the guests invited to the event itself.
+func eventTopics(event *models.Event) []string {
+	return []string{
+		websocket.GroupTopic(event.GroupID.Hex()),
+		websocket.EventTopic(event.ID.Hex()),
+	}
+}
+
+// eventTopicsByID looks the event up to find its group. If the lookup fails the
+// message still reaches the event's own topic.
+func (s *Server) eventTopicsByID(ctx context.Context, eventID primitive.ObjectID) []string {
+	event, err := s.DB.GetEvent(ctx, eventID)
+	if err != nil {
+		return []string{websocket.EventTopic(eventID.Hex())}
+	}
+	return eventTopics(event)
+}
+
+// AuthorizeTopic reports whether familyMember may subscribe to topic: its own
+// user topic, the groups it belongs to, and events it can see as a group
+// member or invited guest.
+func (s *Server) AuthorizeTopic(ctx context.Context, familyMember *models.FamilyMember, topic string) (bool, error) {
+	kind, id, ok := websocket.SplitTopic(topic)
+	if !ok {
+		return false, nil
+	}
+	if kind == "user" {
+		return id == familyMember.ID.Hex(), nil
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, nil
+	}
+	switch kind {
+	case "group":
+		return s.Authz.CanViewGroup(ctx, familyMember, objID)
+	case "event":
+		event, err := s.DB.GetEvent(ctx, objID)
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		return s.Authz.CanViewEvent(ctx, familyMember, event)
+	}
+	return false, nil
+}
+
+// ServeWs authenticates the WebSocket upgrade from the token cookie. The
+// connection starts on the caller's user topic; group and event topics are
+// subscribed to over the socket and checked with AuthorizeTopic and, for API
+// tokens, the token's scopes. Requests sent over the socket run through
+// HandleRequest.
+func (s *Server) ServeWs(w http.ResponseWriter, r *http.Request) {
+	principal, err := s.Authenticate(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	authorize := func(ctx context.Context, topic string) (bool, error) {
+		return s.authorizeSubscription(ctx, principal, topic)
+	}
+	handle := func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		current, err := s.refreshPrincipal(ctx, principal)
+		if err != nil {
+			return nil, err
+		}
+		return s.HandleRequest(ctx, current, method, params)
+	}
+
+	s.Hub.ServeWs(w, r, websocket.ConnOptions{
+		Member:    principal.FamilyMember,
+		Topics:    []string{websocket.UserTopic(principal.FamilyMember.ID.Hex())},
+		Authorize: authorize,
+		Handle:    handle,
+	})
+}
+
+// refreshPrincipal reloads the principal a socket was opened with. The
+// socket outlives the token it was opened with, so each request and
+// subscription checks that the session or API token is still valid, as the
+// REST routes do, and sees groups joined after connecting.
+func (s *Server) refreshPrincipal(ctx context.Context, principal *Principal) (*Principal, error) {
+	current := *principal
+	if principal.Session != nil {
+		session, err := s.DB.GetSessionByID(ctx, principal.Session.ID)
+		if err != nil || !session.IsActive(time.Now()) {
+			return nil, &websocket.RequestError{Status: http.StatusUnauthorized, Message: "Unauthorized"}
+		}
+		current.Session = session
+	} else if principal.APIToken != nil {
+		apiToken, err := s.DB.GetAPITokenByHash(ctx, principal.APIToken.TokenHash)
+		if err != nil || apiToken.IsExpired(time.Now()) {
+			return nil, &websocket.RequestError{Status: http.StatusUnauthorized, Message: "Unauthorized"}
+		}
+		current.APIToken = apiToken
+	}
+	familyMember, err := s.DB.GetFamilyMemberByID(ctx, principal.FamilyMember.ID)
+	if err != nil {
+		return nil, err
+	}
+	current.FamilyMember = familyMember
+	return &current, nil
+}
+
+// topicRoutes are the REST routes whose scope an API token needs to
+// subscribe to each kind of topic, so a socket shows a token no more than
+// the routes would.
+var topicRoutes = map[string]string{
+	"group": "GET /groups/{id}",
+	"event": "GET /events/{id}",
+}
+
+// authorizeSubscription reports whether the principal a socket was opened
+// with may still subscribe to topic.
+func (s *Server) authorizeSubscription(ctx context.Context, principal *Principal, topic string) (bool, error) {
+	current, err := s.refreshPrincipal(ctx, principal)
+	var reqErr *websocket.RequestError
+	if errors.As(err, &reqErr) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if kind, _, ok := websocket.SplitTopic(topic); ok && kind != "user" && !current.Allows(topicRoutes[kind]) {
+		return false, nil
+	}
+	return s.AuthorizeTopic(ctx, current.FamilyMember, topic)
+}
+
+// GetEventPresence returns who currently has the event open, for pages to show
+// before the first "presence_changed" message arrives.
+func (s *Server) GetEventPresence(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	topic := websocket.EventTopic(id.Hex())
+	allowed, err := s.AuthorizeTopic(r.Context(), actor, topic)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	json.NewEncoder(w).Encode(realtime.PresenceChanged{EventID: id, Members: s.Hub.Presence(topic)})
+}
+
+// GetRealtimeMetrics reports connection counts and queue depths for the hub.
+// They cover the whole instance, so only operators may see them.
+func (s *Server) GetRealtimeMetrics(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+	if !s.Operators[actor.ID] {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	json.NewEncoder(w).Encode(s.Hub.Metrics())
+}
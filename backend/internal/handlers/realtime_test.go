@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAuthorizeTopic(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	groupID := primitive.NewObjectID()
+	otherGroupID := primitive.NewObjectID()
+	familyMember := &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}}
+
+	groupEvent := &models.Event{ID: primitive.NewObjectID(), GroupID: groupID}
+	guestEvent := &models.Event{ID: primitive.NewObjectID(), GroupID: otherGroupID, GuestIDs: []primitive.ObjectID{familyMember.ID}}
+	otherEvent := &models.Event{ID: primitive.NewObjectID(), GroupID: otherGroupID}
+	events := map[primitive.ObjectID]*models.Event{groupEvent.ID: groupEvent, guestEvent.ID: guestEvent, otherEvent.ID: otherEvent}
+
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		if event, ok := events[id]; ok {
+			return event, nil
+		}
+		return nil, database.ErrNoDocuments
+	}
+
+	tests := []struct {
+		name  string
+		topic string
+		want  bool
+	}{
+		{"own user", websocket.UserTopic(familyMember.ID.Hex()), true},
+		{"other user", websocket.UserTopic(primitive.NewObjectID().Hex()), false},
+		{"own group", websocket.GroupTopic(groupID.Hex()), true},
+		{"other group", websocket.GroupTopic(otherGroupID.Hex()), false},
+		{"group event", websocket.EventTopic(groupEvent.ID.Hex()), true},
+		{"guest event", websocket.EventTopic(guestEvent.ID.Hex()), true},
+		{"other event", websocket.EventTopic(otherEvent.ID.Hex()), false},
+		{"missing event", websocket.EventTopic(primitive.NewObjectID().Hex()), false},
+		{"malformed id", websocket.EventTopic("nope"), false},
+		{"unknown kind", "household:" + groupID.Hex(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.AuthorizeTopic(context.Background(), familyMember, tt.topic)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AuthorizeTopic(%s) = %v, want %v", tt.topic, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeSubscription(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	groupID := primitive.NewObjectID()
+	familyMember := &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}}
+	event := &models.Event{ID: primitive.NewObjectID(), GroupID: groupID}
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return familyMember, nil
+	}
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return event, nil
+	}
+	revoked := time.Now().Add(-time.Minute)
+	sessions := map[primitive.ObjectID]*models.Session{}
+	mockDB.GetSessionByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+		if session, ok := sessions[id]; ok {
+			return session, nil
+		}
+		return nil, database.ErrNoDocuments
+	}
+	tokens := map[string]*models.APIToken{}
+	mockDB.GetAPITokenByHashFunc = func(ctx context.Context, hash string) (*models.APIToken, error) {
+		if token, ok := tokens[hash]; ok {
+			return token, nil
+		}
+		return nil, database.ErrNoDocuments
+	}
+	session := func(active bool) *Principal {
+		s := &models.Session{ID: primitive.NewObjectID(), ExpiresAt: time.Now().Add(time.Hour)}
+		if !active {
+			s.RevokedAt = &revoked
+		}
+		sessions[s.ID] = s
+		return &Principal{FamilyMember: familyMember, Session: s}
+	}
+	token := func(expired bool, scopes ...string) *Principal {
+		t := &models.APIToken{TokenHash: primitive.NewObjectID().Hex(), Scopes: scopes}
+		if expired {
+			t.ExpiresAt = &revoked
+		}
+		tokens[t.TokenHash] = t
+		return &Principal{FamilyMember: familyMember, APIToken: t}
+	}
+
+	groupTopic := websocket.GroupTopic(groupID.Hex())
+	eventTopic := websocket.EventTopic(event.ID.Hex())
+	tests := []struct {
+		name      string
+		principal *Principal
+		topic     string
+		want      bool
+	}{
+		{"session", session(true), groupTopic, true},
+		{"revoked session", session(false), groupTopic, false},
+		{"token with groups:read", token(false, "groups:read"), groupTopic, true},
+		{"token with groups:write", token(false, "groups:write"), groupTopic, true},
+		{"token without groups scope", token(false, "chat:write"), groupTopic, false},
+		{"token with events:read", token(false, "events:read"), eventTopic, true},
+		{"token without events scope", token(false, "groups:read"), eventTopic, false},
+		{"expired token", token(true, "events:read"), eventTopic, false},
+		{"token on own user topic", token(false, "chat:write"), websocket.UserTopic(familyMember.ID.Hex()), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.authorizeSubscription(context.Background(), tt.principal, tt.topic)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("authorizeSubscription(%s) = %v, want %v", tt.topic, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeWs_Unauthenticated(t *testing.T) {
+	server := NewServer(&database.MockService{}, websocket.NewHub())
+
+	req, _ := http.NewRequest("GET", "/ws", nil)
+	rr := httptest.NewRecorder()
+
+	server.ServeWs(rr, req)
+
+	if status := rr.Code; status != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+	}
+}
+
+func TestGetRealtimeMetrics(t *testing.T) {
+	t.Setenv("OPERATOR_MEMBER_IDS", "")
+	server := NewServer(&database.MockService{}, websocket.NewHub())
+	operator := &models.FamilyMember{ID: primitive.NewObjectID()}
+	server.Operators[operator.ID] = true
+
+	req, _ := http.NewRequest("GET", "/ws/metrics", nil)
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID()})
+	rr := httptest.NewRecorder()
+	server.GetRealtimeMetrics(rr, req)
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code for a member: got %v want %v", status, http.StatusForbidden)
+	}
+
+	req, _ = http.NewRequest("GET", "/ws/metrics", nil)
+	req = withFamilyMember(req, operator)
+	rr = httptest.NewRecorder()
+
+	server.GetRealtimeMetrics(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var metrics websocket.Metrics
+	if err := json.NewDecoder(rr.Body).Decode(&metrics); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if metrics.ConnectedClients != 0 || metrics.QueueCapacity == 0 {
+		t.Errorf("Unexpected metrics for an idle hub: %+v", metrics)
+	}
+}
+
+func TestOperatorsFromEnv(t *testing.T) {
+	id := primitive.NewObjectID()
+	t.Setenv("OPERATOR_MEMBER_IDS", " "+id.Hex()+", not-an-id,")
+
+	operators := operatorsFromEnv()
+	if len(operators) != 1 || !operators[id] {
+		t.Errorf("expected only %s to be an operator, got %v", id.Hex(), operators)
+	}
+}
+
+func TestGetEventPresence(t *testing.T) {
+	mockDB := &database.MockService{}
+	hub := websocket.NewHub()
+	go hub.Run()
+	server := NewServer(mockDB, hub)
+
+	groupID := primitive.NewObjectID()
+	event := &models.Event{ID: primitive.NewObjectID(), GroupID: groupID}
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return event, nil
+	}
+
+	tests := []struct {
+		name   string
+		member *models.FamilyMember
+		want   int
+	}{
+		{"group member", &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}}, http.StatusOK},
+		{"outsider", &models.FamilyMember{ID: primitive.NewObjectID()}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/events/"+event.ID.Hex()+"/presence", nil)
+			req.SetPathValue("id", event.ID.Hex())
+			req = withFamilyMember(req, tt.member)
+			rr := httptest.NewRecorder()
+
+			server.GetEventPresence(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+			if tt.want == http.StatusOK && !strings.Contains(rr.Body.String(), `"members":[]`) {
+				t.Errorf("Expected nobody present, got %s", rr.Body.String())
+			}
+		})
+	}
+}
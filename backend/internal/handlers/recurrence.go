@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/recurrence"
+	"fmt"
+	"time"
+)
+
+// normalizeRecurrence checks an event's recurrence rule and stores it in its
+// canonical RRULE form, so legacy names like "Weekly" aren't saved again.
+func normalizeRecurrence(rule string) (string, error) {
+	parsed, err := recurrence.Parse(rule)
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// checkTimeZone accepts an empty zone or an IANA name such as
+// "Europe/London".
+func checkTimeZone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if name == "Local" {
+		return fmt.Errorf("unknown time zone %s", name)
+	}
+	_, err := time.LoadLocation(name)
+	return err
+}
+
+// eventSeries returns the recurrence series a recurring event belongs to,
+// in the event's time zone so occurrences keep their local time across
+// daylight saving changes. Events from before series had a start are taken
+// to start on their own date.
+func eventSeries(event *models.Event) (recurrence.Series, error) {
+	rule, err := recurrence.Parse(event.Recurrence)
+	if err != nil {
+		return recurrence.Series{}, err
+	}
+	start := event.Date
+	if event.RecurrenceStart != nil {
+		start = *event.RecurrenceStart
+	}
+	return recurrence.Series{Rule: rule, Start: start.In(event.TimeLocation()), ExDates: event.ExDates}, nil
+}
+
+// templateSeries returns the recurrence series a series template describes,
+// in its time zone.
+func templateSeries(series *models.Series) (recurrence.Series, error) {
+	rule, err := recurrence.Parse(series.Recurrence)
+	if err != nil {
+		return recurrence.Series{}, err
+	}
+	loc := time.UTC
+	if series.TimeZone != "" {
+		if l, err := time.LoadLocation(series.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	return recurrence.Series{Rule: rule, Start: series.Start.In(loc), ExDates: series.ExDates}, nil
+}
+
+// nextOccurrence returns the date of the occurrence after event, for finishing
+// or skipping it. An occurrence moved on its own is followed by whichever
+// comes later of its date and its slot. ok is false once the series has
+// ended.
+func nextOccurrence(event *models.Event) (next time.Time, ok bool, err error) {
+	series, err := eventSeries(event)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	after := event.Date
+	if event.OriginalDate != nil && event.OriginalDate.After(after) {
+		after = *event.OriginalDate
+	}
+	next, ok = series.Next(after)
+	return next, ok, nil
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"family-potluck/backend/internal/models"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Host rotations a series can pick the host of each new occurrence by.
+// Members the group has opted out of hosting are passed over by all of them,
+// as are members who can't host on the occurrence's date while someone else
+// can.
+const (
+	// rotateMembers hands each occurrence to the next member, in the order
+	// their accounts were created. Series without a rotation use it.
+	rotateMembers = "members"
+	// rotateOrdered follows the host order the group's admins keep. Members
+	// not in it take their turns after those who are.
+	rotateOrdered = "ordered"
+	// rotateHouseholds hands each occurrence to the next household, taking
+	// households in the host order, and within it to whoever has hosted the
+	// series least.
+	rotateHouseholds = "household"
+	// rotateFewestHosted hands each occurrence to whoever has hosted the
+	// series least, taking turns in the host order when that is a tie.
+	rotateFewestHosted = "fewest_hosted"
+)
+
+// checkHostRotation accepts an empty rotation or one of the above.
+func checkHostRotation(name string) error {
+	switch name {
+	case "", rotateMembers, rotateOrdered, rotateHouseholds, rotateFewestHosted:
+		return nil
+	}
+	return fmt.Errorf("unknown host rotation %q", name)
+}
+
+// hostRotation picks the hosts of a series' new occurrences.
+type hostRotation struct {
+	strategy string
+	order    []models.FamilyMember       // every member of the group, in turn order
+	skip     map[primitive.ObjectID]bool // members who don't host
+	hosted   map[primitive.ObjectID]int  // occurrences each member has hosted or is down to host
+
+	// unavailable are the days each member, or their household, can't host
+	unavailable map[primitive.ObjectID][]models.Unavailability
+}
+
+// newHostRotation sets up the rotation for series in group, which may be nil
+// if it couldn't be loaded, with its occurrences scheduled so far. The
+// rotation has no one in it if the group's members couldn't be loaded.
+func (s *Server) newHostRotation(ctx context.Context, series *models.Series, group *models.Group, scheduled []models.Event) *hostRotation {
+	members, err := s.DB.GetFamilyMembersByGroupID(ctx, series.GroupID)
+	if err != nil || len(members) == 0 {
+		return &hostRotation{}
+	}
+	if group == nil {
+		group = &models.Group{}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID.Hex() < members[j].ID.Hex()
+	})
+
+	r := &hostRotation{
+		strategy:    series.HostRotation,
+		order:       members,
+		skip:        map[primitive.ObjectID]bool{},
+		hosted:      map[primitive.ObjectID]int{},
+		unavailable: map[primitive.ObjectID][]models.Unavailability{},
+	}
+	if r.strategy != "" && r.strategy != rotateMembers {
+		r.order = inHostOrder(members, group.HostOrder)
+	}
+	for _, id := range group.HostOptOutIDs {
+		r.skip[id] = true
+	}
+	if len(r.after(primitive.NilObjectID)) == 0 {
+		// Someone has to host
+		r.skip = map[primitive.ObjectID]bool{}
+	}
+
+	households := map[primitive.ObjectID][]models.Unavailability{}
+	for _, m := range members {
+		unavailable := append([]models.Unavailability(nil), m.Unavailable...)
+		if m.HouseholdID != nil {
+			away, ok := households[*m.HouseholdID]
+			if !ok {
+				if household, err := s.DB.GetHousehold(ctx, *m.HouseholdID); err == nil {
+					away = household.Unavailable
+				}
+				households[*m.HouseholdID] = away
+			}
+			unavailable = append(unavailable, away...)
+		}
+		r.unavailable[m.ID] = unavailable
+	}
+
+	if r.strategy == rotateHouseholds || r.strategy == rotateFewestHosted {
+		completed, err := s.DB.GetCompletedEventsByRecurrenceID(ctx, series.ID)
+		if err != nil {
+			fmt.Printf("Failed to get hosting history of series %s: %v\n", series.ID.Hex(), err)
+		}
+		for _, e := range completed {
+			r.hosted[e.HostID]++
+		}
+		for _, e := range scheduled {
+			r.hosted[e.HostID]++
+		}
+	}
+	return r
+}
+
+// inHostOrder returns members in the order of order, followed by those not
+// in it in the order they were given.
+func inHostOrder(members []models.FamilyMember, order []primitive.ObjectID) []models.FamilyMember {
+	position := map[primitive.ObjectID]int{}
+	for i, id := range order {
+		if _, ok := position[id]; !ok {
+			position[id] = i
+		}
+	}
+	ordered := append([]models.FamilyMember(nil), members...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		pi, iOrdered := position[ordered[i].ID]
+		pj, jOrdered := position[ordered[j].ID]
+		if iOrdered && jOrdered {
+			return pi < pj
+		}
+		return iOrdered && !jOrdered
+	})
+	return ordered
+}
+
+// next picks the host of the occurrence on day, a YYYY-MM-DD date, after one
+// hosted by prev.
+func (r *hostRotation) next(prev primitive.ObjectID, day string) primitive.ObjectID {
+	free := func(m models.FamilyMember) bool {
+		return r.available(m.ID, day)
+	}
+	if len(filterMembers(r.after(prev), free)) == 0 {
+		// Someone has to host, even if it's a conflict
+		free = func(models.FamilyMember) bool { return true }
+	}
+
+	var host primitive.ObjectID
+	switch r.strategy {
+	case rotateHouseholds:
+		host = r.nextHousehold(prev, free)
+	case rotateFewestHosted:
+		host = r.leastHosted(filterMembers(r.after(prev), free))
+	default:
+		host = filterMembers(r.after(prev), free)[0].ID
+	}
+	r.hosted[host]++
+	return host
+}
+
+// available reports whether member can host on day.
+func (r *hostRotation) available(member primitive.ObjectID, day string) bool {
+	for _, u := range r.unavailable[member] {
+		if u.Covers(day) {
+			return false
+		}
+	}
+	return true
+}
+
+func filterMembers(members []models.FamilyMember, keep func(models.FamilyMember) bool) []models.FamilyMember {
+	var kept []models.FamilyMember
+	for _, m := range members {
+		if keep(m) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// after returns the members who host, in turn order from the one after prev.
+func (r *hostRotation) after(prev primitive.ObjectID) []models.FamilyMember {
+	start := 0
+	for i, m := range r.order {
+		if m.ID == prev {
+			start = i + 1
+			break
+		}
+	}
+	var hosts []models.FamilyMember
+	for i := range r.order {
+		m := r.order[(start+i)%len(r.order)]
+		if !r.skip[m.ID] {
+			hosts = append(hosts, m)
+		}
+	}
+	return hosts
+}
+
+// nextHousehold hands the turn to the household after prev's with a member
+// free to host. Members without a household count as one on their own.
+func (r *hostRotation) nextHousehold(prev primitive.ObjectID, free func(models.FamilyMember) bool) primitive.ObjectID {
+	householdOf := func(m models.FamilyMember) primitive.ObjectID {
+		if m.HouseholdID != nil {
+			return *m.HouseholdID
+		}
+		return m.ID
+	}
+
+	var households []primitive.ObjectID
+	hosts := map[primitive.ObjectID][]models.FamilyMember{}
+	prevHousehold := prev
+	for _, m := range r.order {
+		h := householdOf(m)
+		if m.ID == prev {
+			prevHousehold = h
+		}
+		if r.skip[m.ID] || !free(m) {
+			continue
+		}
+		if _, ok := hosts[h]; !ok {
+			households = append(households, h)
+		}
+		hosts[h] = append(hosts[h], m)
+	}
+
+	next := households[0]
+	for i, h := range households {
+		if h == prevHousehold {
+			next = households[(i+1)%len(households)]
+			break
+		}
+	}
+	return r.leastHosted(hosts[next])
+}
+
+// leastHosted returns the first of candidates to have hosted least.
+func (r *hostRotation) leastHosted(candidates []models.FamilyMember) primitive.ObjectID {
+	best := candidates[0].ID
+	for _, m := range candidates[1:] {
+		if r.hosted[m.ID] < r.hosted[best] {
+			best = m.ID
+		}
+	}
+	return best
+}
+
+// rotateHost hands next, the occurrence after prev, to the next host in
+// rotation, and moves it to their address if it was at the old host's. It
+// leaves the host alone if there is no one in the rotation.
+func (s *Server) rotateHost(ctx context.Context, rotation *hostRotation, prev, next *models.Event) {
+	if len(rotation.order) == 0 {
+		return
+	}
+	oldAddress := s.householdAddress(ctx, prev.HostID)
+	next.HostID = rotation.next(prev.HostID, occurrenceDay(next))
+
+	// Update location to new host's address if it was the old host's address or empty
+	if address := s.householdAddress(ctx, next.HostID); address != "" {
+		if next.Location == "" || next.Location == oldAddress {
+			next.Location = address
+		}
+	}
+}
+
+// householdAddress returns the address of member's household, if they have
+// one.
+func (s *Server) householdAddress(ctx context.Context, memberID primitive.ObjectID) string {
+	member, err := s.DB.GetFamilyMemberByID(ctx, memberID)
+	if err != nil || member.HouseholdID == nil {
+		return ""
+	}
+	household, err := s.DB.GetHousehold(ctx, *member.HouseholdID)
+	if err != nil {
+		return ""
+	}
+	return household.Address
+}
+
+// occurrenceDay is the YYYY-MM-DD date of event where it happens.
+func occurrenceDay(event *models.Event) string {
+	return event.Date.In(event.TimeLocation()).Format(time.DateOnly)
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rotationGroup has two members in one household, created in the order ann,
+// bob, cat, dan.
+type rotationGroup struct {
+	db                 *database.MockService
+	server             *Server
+	group              models.Group
+	members            []models.FamilyMember
+	ann, bob, cat, dan primitive.ObjectID
+	household          models.Household
+	completed          []models.Event
+}
+
+func newRotationGroup() *rotationGroup {
+	g := &rotationGroup{db: &database.MockService{}}
+	g.server = NewServer(g.db, nil)
+	household := primitive.NewObjectID()
+	g.household = models.Household{ID: household}
+	g.ann, g.bob, g.cat, g.dan = primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	g.members = []models.FamilyMember{
+		{ID: g.dan},
+		{ID: g.cat},
+		{ID: g.bob, HouseholdID: &household},
+		{ID: g.ann, HouseholdID: &household},
+	}
+	g.db.GetFamilyMembersByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.FamilyMember, error) {
+		return append([]models.FamilyMember(nil), g.members...), nil
+	}
+	g.db.GetHouseholdFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Household, error) {
+		return &g.household, nil
+	}
+	g.db.GetCompletedEventsByRecurrenceIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Event, error) {
+		return g.completed, nil
+	}
+	return g
+}
+
+// hosts returns who hosts the n weekly occurrences from June 1st 2025 after
+// one hosted by prev.
+func (g *rotationGroup) hosts(strategy string, prev primitive.ObjectID, n int) []primitive.ObjectID {
+	series := &models.Series{ID: primitive.NewObjectID(), HostRotation: strategy}
+	rotation := g.server.newHostRotation(context.Background(), series, &g.group, nil)
+	day := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	var hosts []primitive.ObjectID
+	for range n {
+		prev = rotation.next(prev, day.Format(time.DateOnly))
+		hosts = append(hosts, prev)
+		day = day.AddDate(0, 0, 7)
+	}
+	return hosts
+}
+
+func sameHosts(got, want []primitive.ObjectID) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHostRotation(t *testing.T) {
+	t.Run("members in turn", func(t *testing.T) {
+		g := newRotationGroup()
+		got := g.hosts("", g.bob, 4)
+		if want := []primitive.ObjectID{g.cat, g.dan, g.ann, g.bob}; !sameHosts(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("ordered, with new members last", func(t *testing.T) {
+		g := newRotationGroup()
+		g.group.HostOrder = []primitive.ObjectID{g.dan, g.ann, g.cat}
+		got := g.hosts(rotateOrdered, g.dan, 4)
+		if want := []primitive.ObjectID{g.ann, g.cat, g.bob, g.dan}; !sameHosts(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("households take turns", func(t *testing.T) {
+		g := newRotationGroup()
+		got := g.hosts(rotateHouseholds, g.dan, 6)
+		// ann and bob share a household, which hosts every third time
+		if want := []primitive.ObjectID{g.ann, g.cat, g.dan, g.bob, g.cat, g.dan}; !sameHosts(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("fewest hosted", func(t *testing.T) {
+		g := newRotationGroup()
+		g.completed = []models.Event{{HostID: g.ann}, {HostID: g.ann}, {HostID: g.bob}, {HostID: g.dan}}
+		got := g.hosts(rotateFewestHosted, g.dan, 3)
+		// cat hasn't hosted; then everyone but ann has once, so it's dan's
+		// turn after cat's
+		if want := []primitive.ObjectID{g.cat, g.dan, g.bob}; !sameHosts(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("opted out members are passed over", func(t *testing.T) {
+		g := newRotationGroup()
+		g.group.HostOptOutIDs = []primitive.ObjectID{g.cat}
+		got := g.hosts("", g.bob, 3)
+		if want := []primitive.ObjectID{g.dan, g.ann, g.bob}; !sameHosts(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("unavailable members are passed over for the day", func(t *testing.T) {
+		g := newRotationGroup()
+		g.members[1].Unavailable = []models.Unavailability{{Start: "2025-05-30", End: "2025-06-01"}}
+		got := g.hosts("", g.bob, 3)
+		// cat is away on June 1st, so the turn passes on to dan
+		if want := []primitive.ObjectID{g.dan, g.ann, g.bob}; !sameHosts(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("households away are passed over", func(t *testing.T) {
+		g := newRotationGroup()
+		g.household.Unavailable = []models.Unavailability{{Start: "2025-06-08", End: "2025-06-08", Reason: "Camping"}}
+		got := g.hosts(rotateHouseholds, g.cat, 3)
+		// ann and bob are away for their turn on June 8th
+		if want := []primitive.ObjectID{g.dan, g.cat, g.dan}; !sameHosts(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("someone hosts when no one is available", func(t *testing.T) {
+		g := newRotationGroup()
+		away := []models.Unavailability{{Start: "2025-06-01", End: "2025-06-01"}}
+		for i := range g.members {
+			g.members[i].Unavailable = away
+		}
+		got := g.hosts("", g.ann, 1)
+		if want := []primitive.ObjectID{g.bob}; !sameHosts(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("someone hosts when everyone opted out", func(t *testing.T) {
+		g := newRotationGroup()
+		g.group.HostOptOutIDs = []primitive.ObjectID{g.ann, g.bob, g.cat, g.dan}
+		got := g.hosts("", g.ann, 1)
+		if want := []primitive.ObjectID{g.bob}; !sameHosts(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+}
+
+func TestUpdateEvent_HostRotation(t *testing.T) {
+	t.Run("following", func(t *testing.T) {
+		f := newSeriesFixture()
+		this := f.occurrences[1]
+
+		rr := f.update(t, this, "following", map[string]interface{}{"host_rotation": rotateFewestHosted})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if f.series.HostRotation != rotateFewestHosted {
+			t.Errorf("expected the series to rotate by fewest hosted, got %q", f.series.HostRotation)
+		}
+	})
+
+	t.Run("this can't change it", func(t *testing.T) {
+		f := newSeriesFixture()
+		rr := f.update(t, f.occurrences[1], "this", map[string]interface{}{"host_rotation": rotateOrdered})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("unknown rotation", func(t *testing.T) {
+		f := newSeriesFixture()
+		rr := f.update(t, f.occurrences[1], "all", map[string]interface{}{"host_rotation": "alphabetical"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/websocket"
+	"net/http"
+	"strings"
+)
+
+// WebSocket requests run the same handlers as the REST routes they stand in
+// for, so validation, authorization, broadcasts and API token scopes are
+// shared rather than duplicated.
+type rpcMethod struct {
+	// route is the REST route's pattern, used for API token scopes.
+	route   string
+	handler func(*Server, http.ResponseWriter, *http.Request)
+	// idParam, if set, names the param that fills the route's {id}.
+	idParam string
+}
+
+var rpcMethods = map[string]rpcMethod{
+	"chat.send":   {route: "POST /chat/messages", handler: (*Server).SendChatMessage},
+	"dish.pledge": {route: "POST /dishes/{id}/pledge", handler: (*Server).PledgeDish, idParam: "dish_id"},
+	"rsvp.set":    {route: "POST /rsvps", handler: (*Server).RSVPEvent},
+}
+
+// HandleRequest runs a WebSocket request for principal. params are the body
+// of the equivalent REST request, plus the idParam if the route has one.
+func (s *Server) HandleRequest(ctx context.Context, principal *Principal, method string, params json.RawMessage) (json.RawMessage, error) {
+	m, ok := rpcMethods[method]
+	if !ok {
+		return nil, &websocket.RequestError{Status: http.StatusNotFound, Message: "unknown method " + method}
+	}
+	if !principal.Allows(m.route) {
+		return nil, &websocket.RequestError{Status: http.StatusForbidden, Message: "API token does not have the required scope"}
+	}
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+
+	r, err := http.NewRequestWithContext(WithPrincipal(ctx, principal), http.MethodPost, "/", bytes.NewReader(params))
+	if err != nil {
+		return nil, err
+	}
+	r.Pattern = m.route
+	if m.idParam != "" {
+		var ids map[string]json.RawMessage
+		var id string
+		if json.Unmarshal(params, &ids) == nil {
+			json.Unmarshal(ids[m.idParam], &id)
+		}
+		r.SetPathValue("id", id)
+	}
+
+	w := &rpcResponse{header: make(http.Header), status: http.StatusOK}
+	m.handler(s, w, r)
+
+	if w.status >= http.StatusBadRequest {
+		return nil, &websocket.RequestError{Status: w.status, Message: strings.TrimSpace(w.body.String())}
+	}
+	if w.body.Len() == 0 || !json.Valid(w.body.Bytes()) {
+		return nil, nil
+	}
+	return json.RawMessage(bytes.TrimSpace(w.body.Bytes())), nil
+}
+
+// rpcResponse collects what a handler writes for HandleRequest.
+type rpcResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *rpcResponse) Header() http.Header { return w.header }
+
+func (w *rpcResponse) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+}
+
+func (w *rpcResponse) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
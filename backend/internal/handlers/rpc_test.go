@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/websocket"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestHandleRequest(t *testing.T) {
+	mockDB := &database.MockService{}
+	hub := websocket.NewHub()
+	go hub.Run()
+	server := NewServer(mockDB, hub)
+
+	groupID := primitive.NewObjectID()
+	member := &models.FamilyMember{ID: primitive.NewObjectID(), Name: "Aunt May", GroupIDs: []primitive.ObjectID{groupID}}
+	eventID := primitive.NewObjectID()
+	dishID := primitive.NewObjectID()
+	rsvpID := primitive.NewObjectID()
+
+	mockDB.UpsertRSVPFunc = func(ctx context.Context, rsvp *models.RSVP) (primitive.ObjectID, error) {
+		if rsvp.FamilyMemberID != member.ID {
+			t.Errorf("Expected the RSVP to be for the principal, got %v", rsvp.FamilyMemberID)
+		}
+		return rsvpID, nil
+	}
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: id, GroupID: groupID}, nil
+	}
+	var pledged primitive.ObjectID
+	mockDB.GetDishByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Dish, error) {
+		return &models.Dish{ID: id, EventID: eventID, Name: "Pie"}, nil
+	}
+	mockDB.UpdateDishFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+		pledged = id
+		return nil
+	}
+
+	session := &Principal{FamilyMember: member, Session: &models.Session{}}
+	outsider := &Principal{FamilyMember: &models.FamilyMember{ID: primitive.NewObjectID()}, Session: &models.Session{}}
+
+	t.Run("rsvp.set", func(t *testing.T) {
+		params, _ := json.Marshal(map[string]interface{}{"event_id": eventID, "status": "Yes", "count": 2})
+		result, err := server.HandleRequest(context.Background(), session, "rsvp.set", params)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var rsvp models.RSVP
+		if err := json.Unmarshal(result, &rsvp); err != nil || rsvp.ID != rsvpID || rsvp.FamilyName != "Aunt May" {
+			t.Errorf("Unexpected result %s", result)
+		}
+	})
+
+	t.Run("dish.pledge", func(t *testing.T) {
+		params, _ := json.Marshal(map[string]interface{}{"dish_id": dishID, "family_id": member.ID})
+		result, err := server.HandleRequest(context.Background(), session, "dish.pledge", params)
+		if err != nil || result != nil {
+			t.Fatalf("Expected an empty success, got %s, %v", result, err)
+		}
+		if pledged != dishID {
+			t.Errorf("Expected dish %v to be pledged, got %v", dishID, pledged)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		method    string
+		params    interface{}
+		want      int
+	}{
+		{"unknown method", session, "dish.delete", nil, http.StatusNotFound},
+		{"pledge for someone else", session, "dish.pledge", map[string]interface{}{"dish_id": dishID, "family_id": primitive.NewObjectID()}, http.StatusForbidden},
+		{"rsvp outside the group", outsider, "rsvp.set", map[string]interface{}{"event_id": eventID}, http.StatusForbidden},
+		{"pledge outside the group", outsider, "dish.pledge", map[string]interface{}{"dish_id": dishID, "family_id": outsider.FamilyMember.ID}, http.StatusForbidden},
+		{"bad dish id", session, "dish.pledge", map[string]interface{}{"dish_id": "nope"}, http.StatusBadRequest},
+		{"token without scope", &Principal{FamilyMember: member, APIToken: &models.APIToken{Scopes: []string{"chat:write"}}}, "rsvp.set", map[string]interface{}{"event_id": eventID}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := json.Marshal(tt.params)
+			_, err := server.HandleRequest(context.Background(), tt.principal, tt.method, params)
+			var reqErr *websocket.RequestError
+			if !errors.As(err, &reqErr) || reqErr.Status != tt.want {
+				t.Errorf("Expected a %d error, got %v", tt.want, err)
+			}
+		})
+	}
+}
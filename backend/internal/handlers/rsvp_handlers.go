@@ -4,17 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
 	"net/http"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func (s *Server) RSVPEvent(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var rsvp models.RSVP
 	if err := json.NewDecoder(r.Body).Decode(&rsvp); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, rsvp.FamilyMemberID, "family_id") {
+		return
+	}
+	rsvp.FamilyMemberID = actor.ID
+	if _, ok := s.loadViewableEvent(w, r, rsvp.EventID); !ok {
+		return
+	}
 
 	id, err := s.DB.UpsertRSVP(context.Background(), &rsvp)
 	if err != nil {
@@ -22,20 +35,10 @@ func (s *Server) RSVPEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	rsvp.ID = id
-
-	// Fetch Family Name for broadcast
-	familyMember, err := s.DB.GetFamilyMemberByID(context.Background(), rsvp.FamilyMemberID)
-	if err == nil {
-		rsvp.FamilyName = familyMember.Name
-	}
+	rsvp.FamilyName = actor.Name
 
 	// Broadcast update
-	msg := map[string]interface{}{
-		"type": "rsvp_updated",
-		"data": rsvp,
-	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
+	realtime.Publish(s.Hub, actor, realtime.RSVPUpdated(rsvp), s.eventTopicsByID(context.Background(), rsvp.EventID)...)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(rsvp)
@@ -54,6 +57,10 @@ func (s *Server) GetRSVPs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := s.loadViewableEvent(w, r, eventID); !ok {
+		return
+	}
+
 	rsvps, err := s.DB.GetRSVPsByEventID(context.Background(), eventID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
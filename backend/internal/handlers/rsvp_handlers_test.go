@@ -23,10 +23,14 @@ func TestRSVPEvent(t *testing.T) {
 	eventID := primitive.NewObjectID()
 	familyID := primitive.NewObjectID()
 	rsvpID := primitive.NewObjectID()
+	groupID := primitive.NewObjectID()
 
 	mockDB.UpsertRSVPFunc = func(ctx context.Context, rsvp *models.RSVP) (primitive.ObjectID, error) {
 		return rsvpID, nil
 	}
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: id, GroupID: groupID}, nil
+	}
 
 	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
 		return &models.FamilyMember{ID: familyID, Name: "Test Family"}, nil
@@ -41,6 +45,7 @@ func TestRSVPEvent(t *testing.T) {
 	body, _ := json.Marshal(rsvpReq)
 
 	req, _ := http.NewRequest("POST", "/rsvps", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID, Name: "Test Family", GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.RSVPEvent(rr, req)
@@ -64,6 +69,7 @@ func TestGetRSVPs(t *testing.T) {
 	server := NewServer(mockDB, nil)
 
 	eventID := primitive.NewObjectID()
+	groupID := primitive.NewObjectID()
 	rsvps := []models.RSVP{
 		{ID: primitive.NewObjectID(), EventID: eventID, FamilyMemberID: primitive.NewObjectID()},
 		{ID: primitive.NewObjectID(), EventID: eventID, FamilyMemberID: primitive.NewObjectID()},
@@ -77,7 +83,12 @@ func TestGetRSVPs(t *testing.T) {
 		return &models.FamilyMember{ID: id, Name: "Test Family"}, nil
 	}
 
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: id, GroupID: groupID}, nil
+	}
+
 	req, _ := http.NewRequest("GET", "/rsvps?event_id="+eventID.Hex(), nil)
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.GetRSVPs(rr, req)
@@ -92,3 +103,24 @@ func TestGetRSVPs(t *testing.T) {
 		t.Errorf("expected 2 rsvps, got %v", len(resp))
 	}
 }
+
+func TestRSVPEvent_MismatchedFamilyID(t *testing.T) {
+	server := NewServer(&database.MockService{}, nil)
+
+	rsvpReq := models.RSVP{
+		EventID:        primitive.NewObjectID(),
+		FamilyMemberID: primitive.NewObjectID(),
+		Status:         "Yes",
+	}
+	body, _ := json.Marshal(rsvpReq)
+
+	req, _ := http.NewRequest("POST", "/rsvps", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID()})
+	rr := httptest.NewRecorder()
+
+	server.RSVPEvent(rr, req)
+
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,697 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultOccurrencesAhead is how many occurrences of each recurring series
+// are kept scheduled for groups that haven't chosen, and maxOccurrencesAhead
+// the most a group can choose.
+const (
+	defaultOccurrencesAhead = 3
+	maxOccurrencesAhead     = 12
+)
+
+// Scopes of an update or delete of an occurrence of a recurring event.
+const (
+	scopeThis      = "this"
+	scopeFollowing = "following"
+	scopeAll       = "all"
+)
+
+// editScope reads which occurrences of a recurring event a change applies to
+// from the scope query parameter: this one (the default), it and the ones
+// after it, or the whole series.
+func editScope(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch scope := r.URL.Query().Get("scope"); scope {
+	case "", scopeThis:
+		return scopeThis, true
+	case scopeFollowing, scopeAll:
+		return scope, true
+	}
+	http.Error(w, "scope must be this, following or all", http.StatusBadRequest)
+	return "", false
+}
+
+// inSeries reports whether event is an occurrence of a recurring series.
+func inSeries(event *models.Event) bool {
+	return event.Recurrence != "" && !event.RecurrenceID.IsZero()
+}
+
+// occurrenceSlot is where event falls in its series, which is its date unless
+// it was moved on its own.
+func occurrenceSlot(event *models.Event) time.Time {
+	if event.OriginalDate != nil {
+		return *event.OriginalDate
+	}
+	return event.Date
+}
+
+// isScheduled reports whether an occurrence is yet to happen, rather than
+// history or called off.
+func isScheduled(event *models.Event) bool {
+	return event.Status != "completed" && event.Status != "cancelled"
+}
+
+// loadSeries returns the template of the series event belongs to. Series
+// started before templates were stored get one made from event.
+func (s *Server) loadSeries(ctx context.Context, event *models.Event) (*models.Series, error) {
+	series, err := s.DB.GetSeries(ctx, event.RecurrenceID)
+	if err == nil {
+		return series, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	start := event.Date
+	if event.RecurrenceStart != nil {
+		start = *event.RecurrenceStart
+	}
+	series = &models.Series{
+		ID:              event.RecurrenceID,
+		GroupID:         event.GroupID,
+		Name:            event.Name,
+		Type:            event.Type,
+		Location:        event.Location,
+		Description:     event.Description,
+		TimeZone:        event.TimeZone,
+		DurationMinutes: event.DurationMinutes,
+		Recurrence:      event.Recurrence,
+		Start:           start,
+		ExDates:         event.ExDates,
+		HostRotation:    event.HostRotation,
+	}
+	if err := s.DB.CreateSeries(ctx, series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// applySeries sets the fields occurrences inherit from series on event.
+func applySeries(event *models.Event, series *models.Series) {
+	start := series.Start
+	event.Name = series.Name
+	event.Type = series.Type
+	event.Location = series.Location
+	event.Description = series.Description
+	event.TimeZone = series.TimeZone
+	event.DurationMinutes = series.DurationMinutes
+	event.Recurrence = series.Recurrence
+	event.RecurrenceID = series.ID
+	event.RecurrenceStart = &start
+	event.ExDates = series.ExDates
+	event.HostRotation = series.HostRotation
+}
+
+// followingOccurrence returns a new occurrence of series for the slot after
+// event, hosted by the same person. ok is false once the series has ended.
+func followingOccurrence(event *models.Event, series *models.Series) (next models.Event, ok bool, err error) {
+	next = *event
+	applySeries(&next, series)
+	date, ok, err := nextOccurrence(&next)
+	if err != nil || !ok {
+		return models.Event{}, ok, err
+	}
+	next.ID = primitive.NewObjectID()
+	next.Date = date
+	next.OriginalDate = nil
+	next.Status = "scheduled"
+	next.GuestIDs = []primitive.ObjectID{}  // Clear guest list
+	next.GuestJoinCode = generateJoinCode() // Generate new join code
+	return next, true, nil
+}
+
+// occurrencesAhead returns how many occurrences of each series group keeps
+// scheduled.
+func occurrencesAhead(group *models.Group) int {
+	if group.OccurrencesAhead > 0 {
+		return group.OccurrencesAhead
+	}
+	return defaultOccurrencesAhead
+}
+
+// lastOccurrence returns the occurrence in the latest slot of its series, or
+// nil if there are none.
+func lastOccurrence(occurrences []models.Event) *models.Event {
+	var last *models.Event
+	for i := range occurrences {
+		o := &occurrences[i]
+		if last == nil || !occurrenceSlot(o).Before(occurrenceSlot(last)) {
+			last = o
+		}
+	}
+	return last
+}
+
+// endedBy returns the time an occurrence like event has to start after to
+// not have ended by now.
+func endedBy(event *models.Event, now time.Time) time.Time {
+	if event.DurationMinutes > 0 {
+		return now.Add(-time.Duration(event.DurationMinutes) * time.Minute)
+	}
+	y, m, d := now.In(event.TimeLocation()).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, event.TimeLocation()).Add(-time.Nanosecond)
+}
+
+// fillSeries schedules occurrences of series after its last one until its
+// group's number of occurrences ahead are scheduled, each hosted by the next
+// host in the rotation. Slots that have already ended, as they have when a
+// series went unfinished for a while, are passed over. It returns the
+// scheduled occurrences in date order.
+func (s *Server) fillSeries(ctx context.Context, actor *models.FamilyMember, series *models.Series) ([]models.Event, error) {
+	occurrences, err := s.DB.GetEventsByRecurrenceID(ctx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+	ahead := defaultOccurrencesAhead
+	group, err := s.DB.GetGroup(ctx, series.GroupID)
+	if err == nil {
+		ahead = occurrencesAhead(group)
+	} else {
+		group = nil
+	}
+
+	var scheduled []models.Event
+	for i := range occurrences {
+		if isScheduled(&occurrences[i]) {
+			scheduled = append(scheduled, occurrences[i])
+		}
+	}
+	last := lastOccurrence(occurrences)
+	now := s.Now()
+	var rotation *hostRotation
+	for last != nil && len(scheduled) < ahead {
+		next, ok, err := followingOccurrence(last, series)
+		if err == nil && ok && next.HasEnded(now) {
+			// Resume from the first slot that is still to come
+			stale := next
+			stale.Date = endedBy(&next, now)
+			next, ok, err = followingOccurrence(&stale, series)
+		}
+		if err != nil {
+			return scheduled, err
+		}
+		if !ok {
+			break
+		}
+		if rotation == nil {
+			rotation = s.newHostRotation(ctx, series, group, scheduled)
+		}
+		s.rotateHost(ctx, rotation, last, &next)
+		created, err := s.DB.CreateOccurrence(ctx, &next)
+		if err != nil {
+			return scheduled, err
+		}
+		if !created {
+			// The slot was filled since the occurrences were read
+			break
+		}
+		realtime.Publish(s.Hub, actor, realtime.EventCreated(next), eventTopics(&next)...)
+		s.suggestDishes(next)
+		scheduled = append(scheduled, next)
+		last = &next
+	}
+	return scheduled, nil
+}
+
+// shiftWallClock moves t, an occurrence in from's time zone, to the same
+// number of days away from to as it was from from, at to's time of day.
+func shiftWallClock(t, from, to time.Time) time.Time {
+	fy, fm, fd := from.Date()
+	ty, tm, td := to.Date()
+	days := int(time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC).Sub(time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	y, m, d := t.In(from.Location()).Date()
+	hour, min, sec := to.Clock()
+	return time.Date(y, m, d+days, hour, min, sec, 0, to.Location())
+}
+
+// sameRule reports whether two recurrence rules are the same once
+// normalized, so a legacy "Weekly" matches "FREQ=WEEKLY".
+func sameRule(a, b string) bool {
+	if a == b {
+		return true
+	}
+	na, errA := normalizeRecurrence(a)
+	nb, errB := normalizeRecurrence(b)
+	return errA == nil && errB == nil && na == nb
+}
+
+// updateSeries applies updates to the template of event's series and to its
+// scheduled occurrences: all of them for scopeAll, or event and the ones after
+// it for scopeFollowing. Completed occurrences are history and keep their
+// details. A new rule or time for the following occurrences restarts the
+// series at event, splitting it from any scheduled occurrences before it.
+func (s *Server) updateSeries(w http.ResponseWriter, actor *models.FamilyMember, event *models.Event, scope string, updates eventUpdates) (*models.Event, bool) {
+	ctx := context.Background()
+	series, err := s.loadSeries(ctx, event)
+	if err != nil {
+		http.Error(w, "Failed to load series", http.StatusInternalServerError)
+		return nil, false
+	}
+	occurrences, err := s.DB.GetEventsByRecurrenceID(ctx, series.ID)
+	if err != nil {
+		http.Error(w, "Failed to load series", http.StatusInternalServerError)
+		return nil, false
+	}
+	slot := occurrenceSlot(event)
+
+	// Details every affected occurrence and the template share
+	details := bson.M{}
+	if updates.Name != "" {
+		details["name"] = updates.Name
+	}
+	if updates.Type != "" {
+		details["type"] = updates.Type
+	}
+	if updates.Location != "" {
+		details["location"] = updates.Location
+	}
+	if updates.Description != "" {
+		details["description"] = updates.Description
+	}
+	if updates.TimeZone != "" {
+		details["time_zone"] = updates.TimeZone
+	}
+	if updates.Duration > 0 {
+		details["duration_minutes"] = updates.Duration
+	}
+
+	from := event.Date.In(event.TimeLocation())
+	to := from
+	if !updates.Date.IsZero() {
+		loc := event.TimeLocation()
+		if updates.TimeZone != "" {
+			loc, _ = time.LoadLocation(updates.TimeZone)
+		}
+		to = updates.Date.In(loc)
+	}
+	dateChanged := !to.Equal(from) || to.Location().String() != from.Location().String()
+
+	rule := series.Recurrence
+	if updates.Recurrence != nil {
+		rule = *updates.Recurrence
+	}
+	if rule == "" {
+		// The series stops repeating: event is its last occurrence, as a
+		// one-off
+		if err := s.endSeriesBefore(ctx, actor, series, occurrences, slot, event.ID); err != nil {
+			http.Error(w, "Failed to end series", http.StatusInternalServerError)
+			return nil, false
+		}
+		set := details
+		set["recurrence"] = ""
+		if dateChanged {
+			set["date"] = to
+		}
+		if err := s.DB.UpdateEvent(ctx, event.ID, bson.M{"$set": set}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil, false
+		}
+		applyEventUpdate(event, set)
+		realtime.Publish(s.Hub, actor, realtime.EventUpdated(*event), eventTopics(event)...)
+		return event, true
+	}
+
+	ruleChanged := !sameRule(rule, series.Recurrence)
+	start := series.Start
+	restart := scope == scopeFollowing && (dateChanged || ruleChanged)
+	switch {
+	case restart:
+		start = to
+		if !ruleChanged {
+			// The restarted series has only what is left of the old one's
+			// COUNT
+			old, err := eventSeries(event)
+			if err == nil && old.Rule.Count > 0 {
+				r := *old.Rule
+				r.Count = max(r.Count-old.CountBefore(slot), 1)
+				rule = r.String()
+			}
+		}
+	case dateChanged:
+		start = shiftWallClock(series.Start, from, to)
+	}
+
+	// Scheduled occurrences before event stay with the old series, which
+	// now ends before event
+	split := false
+	if restart {
+		for i := range occurrences {
+			if o := &occurrences[i]; o.ID != event.ID && isScheduled(o) && occurrenceSlot(o).Before(slot) {
+				split = true
+			}
+		}
+	}
+	ended := ""
+	if split {
+		old, err := eventSeries(event)
+		if err != nil {
+			http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusInternalServerError)
+			return nil, false
+		}
+		ended = old.Rule.EndBefore(slot.In(old.Start.Location())).String()
+	}
+
+	// Fields of the series itself, shared by every occurrence of it
+	seriesFields := bson.M{}
+	if ruleChanged || restart || rule != series.Recurrence {
+		seriesFields["recurrence"] = rule
+	}
+	if !start.Equal(series.Start) {
+		seriesFields["recurrence_start"] = start
+	}
+	if updates.ExDates != nil {
+		seriesFields["exdates"] = updates.ExDates
+	}
+	// Occurrences already scheduled keep their hosts
+	if updates.HostRotation != "" && updates.HostRotation != series.HostRotation {
+		seriesFields["host_rotation"] = updates.HostRotation
+	}
+
+	template := *series
+	if split {
+		if err := s.DB.UpdateSeries(ctx, series.ID, bson.M{"$set": bson.M{"recurrence": ended}}); err != nil {
+			http.Error(w, "Failed to update series", http.StatusInternalServerError)
+			return nil, false
+		}
+		template.ID = primitive.NewObjectID()
+		seriesFields["recurrence_id"] = template.ID
+		seriesFields["recurrence"] = rule
+		seriesFields["recurrence_start"] = start
+	}
+	templateSet := bson.M{}
+	for k, v := range details {
+		templateSet[k] = v
+	}
+	for k, v := range seriesFields {
+		switch k {
+		case "recurrence_start":
+			templateSet["start"] = v
+		case "recurrence_id":
+		default:
+			templateSet[k] = v
+		}
+	}
+	applySeriesUpdate(&template, templateSet)
+	if split {
+		err = s.DB.CreateSeries(ctx, &template)
+	} else if len(templateSet) > 0 {
+		err = s.DB.UpdateSeries(ctx, series.ID, bson.M{"$set": templateSet})
+	}
+	if err != nil {
+		http.Error(w, "Failed to update series", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	isAffected := func(o *models.Event) bool {
+		return isScheduled(o) && (scope == scopeAll || !occurrenceSlot(o).Before(slot))
+	}
+	newDate := func(o *models.Event) time.Time {
+		if dateChanged {
+			return shiftWallClock(o.Date, from, to)
+		}
+		return o.Date
+	}
+	for i := range occurrences {
+		if occurrences[i].ID == event.ID {
+			// Use the copy the caller loaded, in case the listing is stale
+			occurrences[i] = *event
+		}
+	}
+
+	// A new rule or skipped days move the affected occurrences onto the
+	// series' days, from the first of them on; any the series no longer has
+	// room for are deleted
+	var slots map[primitive.ObjectID]time.Time
+	if ruleChanged || updates.ExDates != nil {
+		var affected []*models.Event
+		for i := range occurrences {
+			if isAffected(&occurrences[i]) {
+				affected = append(affected, &occurrences[i])
+			}
+		}
+		rs, err := templateSeries(&template)
+		if err != nil {
+			http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		slots = map[primitive.ObjectID]time.Time{}
+		if len(affected) > 0 {
+			// Just before the first, so that it keeps its day if the rule
+			// still has it
+			after := newDate(affected[0]).Add(-time.Nanosecond)
+			for i, t := range rs.Occurrences(after, len(affected)) {
+				slots[affected[i].ID] = t
+			}
+		}
+	}
+
+	var updated *models.Event
+	for i := range occurrences {
+		o := &occurrences[i]
+		if !isScheduled(o) {
+			continue
+		}
+		affected := isAffected(o)
+		set := bson.M{}
+		if affected {
+			for k, v := range details {
+				set[k] = v
+			}
+			if dateChanged {
+				set["date"] = newDate(o)
+				if o.OriginalDate != nil {
+					set["original_date"] = shiftWallClock(*o.OriginalDate, from, to)
+				}
+			}
+			if slots != nil {
+				date, ok := slots[o.ID]
+				if !ok {
+					if err := s.DB.DeleteEvent(ctx, o.ID); err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return nil, false
+					}
+					realtime.Publish(s.Hub, actor, realtime.EventDeleted{EventID: o.ID, GroupID: o.GroupID}, eventTopics(o)...)
+					continue
+				}
+				set["date"] = date
+				if o.OriginalDate != nil {
+					set["original_date"] = nil
+				}
+			}
+		}
+		if affected || !split {
+			for k, v := range seriesFields {
+				set[k] = v
+			}
+		} else {
+			set["recurrence"] = ended
+		}
+		if len(set) == 0 {
+			continue
+		}
+		if err := s.DB.UpdateEvent(ctx, o.ID, bson.M{"$set": set}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil, false
+		}
+		applyEventUpdate(o, set)
+		realtime.Publish(s.Hub, actor, realtime.EventUpdated(*o), eventTopics(o)...)
+		if o.ID == event.ID {
+			updated = o
+		}
+	}
+	if updated == nil {
+		updated = event
+	}
+
+	if _, err := s.fillSeries(ctx, actor, &template); err != nil {
+		fmt.Printf("Failed to schedule occurrences of series %s: %v\n", template.ID.Hex(), err)
+	}
+	return updated, true
+}
+
+// applySeriesUpdate reflects a $set of fields in series.
+func applySeriesUpdate(series *models.Series, fields bson.M) {
+	for key, val := range fields {
+		switch key {
+		case "name":
+			series.Name = val.(string)
+		case "type":
+			series.Type = val.(string)
+		case "location":
+			series.Location = val.(string)
+		case "description":
+			series.Description = val.(string)
+		case "time_zone":
+			series.TimeZone = val.(string)
+		case "duration_minutes":
+			series.DurationMinutes = val.(int)
+		case "recurrence":
+			series.Recurrence = val.(string)
+		case "start":
+			series.Start = val.(time.Time)
+		case "exdates":
+			series.ExDates = val.([]time.Time)
+		case "host_rotation":
+			series.HostRotation = val.(string)
+		}
+	}
+}
+
+// endSeriesBefore cuts series short so that its last occurrence is the one
+// before slot. Its occurrences from slot on that haven't been completed are
+// deleted, except keep, and the ones before it take the shortened rule.
+func (s *Server) endSeriesBefore(ctx context.Context, actor *models.FamilyMember, series *models.Series, occurrences []models.Event, slot time.Time, keep primitive.ObjectID) error {
+	rs, err := templateSeries(series)
+	if err != nil {
+		return err
+	}
+	ended := rs.Rule.EndBefore(slot.In(rs.Start.Location())).String()
+
+	for i := range occurrences {
+		o := &occurrences[i]
+		if o.Status == "completed" || o.ID == keep {
+			continue
+		}
+		if !occurrenceSlot(o).Before(slot) {
+			if err := s.DB.DeleteEvent(ctx, o.ID); err != nil {
+				return err
+			}
+			realtime.Publish(s.Hub, actor, realtime.EventDeleted{EventID: o.ID, GroupID: o.GroupID}, eventTopics(o)...)
+			continue
+		}
+		if err := s.DB.UpdateEvent(ctx, o.ID, bson.M{"$set": bson.M{"recurrence": ended}}); err != nil {
+			return err
+		}
+		o.Recurrence = ended
+		realtime.Publish(s.Hub, actor, realtime.EventUpdated(*o), eventTopics(o)...)
+	}
+
+	if !slot.After(series.Start) {
+		// Nothing of the series is left to come
+		return s.DB.DeleteSeries(ctx, series.ID)
+	}
+	return s.DB.UpdateSeries(ctx, series.ID, bson.M{"$set": bson.M{"recurrence": ended}})
+}
+
+// deleteOccurrences deletes event for scopeThis, event and the scheduled
+// occurrences after it for scopeFollowing, or the whole series, history
+// included, for scopeAll. A series that loses an occurrence on its own
+// schedules another to keep its number ahead.
+func (s *Server) deleteOccurrences(ctx context.Context, actor *models.FamilyMember, event *models.Event, scope string) error {
+	series, err := s.loadSeries(ctx, event)
+	if err != nil {
+		return err
+	}
+	occurrences, err := s.DB.GetEventsByRecurrenceID(ctx, series.ID)
+	if err != nil {
+		return err
+	}
+	slot := occurrenceSlot(event)
+
+	switch scope {
+	case scopeAll:
+		for i := range occurrences {
+			o := &occurrences[i]
+			if err := s.DB.DeleteEvent(ctx, o.ID); err != nil {
+				return err
+			}
+			realtime.Publish(s.Hub, actor, realtime.EventDeleted{EventID: o.ID, GroupID: o.GroupID}, eventTopics(o)...)
+		}
+		return s.DB.DeleteSeries(ctx, series.ID)
+
+	case scopeFollowing:
+		if err := s.endSeriesBefore(ctx, actor, series, occurrences, slot, primitive.NilObjectID); err != nil {
+			return err
+		}
+		if event.Status != "completed" {
+			return nil
+		}
+		// A completed occurrence isn't among the ones ended above
+		if err := s.DB.DeleteEvent(ctx, event.ID); err != nil {
+			return err
+		}
+		realtime.Publish(s.Hub, actor, realtime.EventDeleted{EventID: event.ID, GroupID: event.GroupID}, eventTopics(event)...)
+		return nil
+	}
+
+	// Only this occurrence: the series skips its day from now on
+	series.ExDates = append(series.ExDates, slot)
+	if err := s.DB.UpdateSeries(ctx, series.ID, bson.M{"$set": bson.M{"exdates": series.ExDates}}); err != nil {
+		return err
+	}
+	if err := s.DB.DeleteEvent(ctx, event.ID); err != nil {
+		return err
+	}
+	realtime.Publish(s.Hub, actor, realtime.EventDeleted{EventID: event.ID, GroupID: event.GroupID}, eventTopics(event)...)
+
+	for i := range occurrences {
+		o := &occurrences[i]
+		if o.ID == event.ID || !isScheduled(o) {
+			continue
+		}
+		if err := s.DB.UpdateEvent(ctx, o.ID, bson.M{"$set": bson.M{"exdates": series.ExDates}}); err != nil {
+			return err
+		}
+		o.ExDates = series.ExDates
+		realtime.Publish(s.Hub, actor, realtime.EventUpdated(*o), eventTopics(o)...)
+	}
+	_, err = s.fillSeries(ctx, actor, series)
+	return err
+}
+
+// GetEventOccurrences lists the scheduled occurrences of the series an event
+// belongs to in date order, so members can RSVP and bring dishes to any of
+// them. A one-off event is its own only occurrence.
+func (s *Server) GetEventOccurrences(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid event id", http.StatusBadRequest)
+		return
+	}
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := s.DB.GetEvent(context.Background(), id)
+	if err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	allowed, err := s.Authz.CanViewEvent(context.Background(), actor, event)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	occurrences := []models.Event{*event}
+	if !event.RecurrenceID.IsZero() {
+		all, err := s.DB.GetEventsByRecurrenceID(context.Background(), event.RecurrenceID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		occurrences = []models.Event{}
+		for _, o := range all {
+			if isScheduled(&o) {
+				occurrences = append(occurrences, o)
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(occurrences)
+}
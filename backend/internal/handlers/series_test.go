@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// seriesFixture is a weekly Sunday dinner with one completed occurrence and
+// two scheduled ones, in a mock database that keeps and records changes.
+type seriesFixture struct {
+	db          *database.MockService
+	server      *Server
+	hostID      primitive.ObjectID
+	series      models.Series
+	occurrences []models.Event
+
+	updates       map[primitive.ObjectID]bson.M
+	deleted       []primitive.ObjectID
+	created       []models.Event
+	seriesCreated []models.Series
+	seriesUpdates []bson.M
+	seriesDeleted bool
+}
+
+func newSeriesFixture() *seriesFixture {
+	f := &seriesFixture{
+		db:      &database.MockService{},
+		hostID:  primitive.NewObjectID(),
+		updates: map[primitive.ObjectID]bson.M{},
+	}
+	hub := websocket.NewHub()
+	go hub.Run()
+	f.server = NewServer(f.db, hub)
+
+	groupID := primitive.NewObjectID()
+	start := time.Date(2025, time.January, 5, 18, 0, 0, 0, time.UTC)
+	f.server.Now = func() time.Time { return start }
+	f.series = models.Series{
+		ID:         primitive.NewObjectID(),
+		GroupID:    groupID,
+		Name:       "Sunday Dinner",
+		Location:   "Grandma's",
+		Recurrence: "FREQ=WEEKLY",
+		Start:      start,
+	}
+	for i, status := range []string{"completed", "scheduled", "scheduled"} {
+		event := models.Event{
+			ID:              primitive.NewObjectID(),
+			GroupID:         groupID,
+			HostID:          f.hostID,
+			Name:            f.series.Name,
+			Location:        f.series.Location,
+			Date:            start.AddDate(0, 0, 7*i),
+			Recurrence:      f.series.Recurrence,
+			RecurrenceID:    f.series.ID,
+			RecurrenceStart: &start,
+			Status:          status,
+		}
+		f.occurrences = append(f.occurrences, event)
+	}
+
+	f.db.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		for _, e := range f.occurrences {
+			if e.ID == id {
+				return &e, nil
+			}
+		}
+		return nil, database.ErrNoDocuments
+	}
+	f.db.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		return &models.Group{ID: groupID, AdminIDs: []primitive.ObjectID{f.hostID}}, nil
+	}
+	f.db.GetEventsByRecurrenceIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Event, error) {
+		var events []models.Event
+		for _, e := range f.occurrences {
+			if e.RecurrenceID == id {
+				events = append(events, e)
+			}
+		}
+		return events, nil
+	}
+	f.db.GetCompletedEventsByRecurrenceIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Event, error) {
+		var events []models.Event
+		for _, e := range f.occurrences {
+			if e.RecurrenceID == id && e.Status == "completed" {
+				events = append(events, e)
+			}
+		}
+		return events, nil
+	}
+	f.db.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: id}, nil
+	}
+	f.db.GetFamilyMembersByGroupIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.FamilyMember, error) {
+		return []models.FamilyMember{{ID: f.hostID}}, nil
+	}
+	f.db.GetSeriesFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Series, error) {
+		series := f.series
+		return &series, nil
+	}
+	f.db.CreateSeriesFunc = func(ctx context.Context, series *models.Series) error {
+		f.seriesCreated = append(f.seriesCreated, *series)
+		return nil
+	}
+	f.db.UpdateSeriesFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+		f.seriesUpdates = append(f.seriesUpdates, update["$set"].(bson.M))
+		applySeriesUpdate(&f.series, update["$set"].(bson.M))
+		return nil
+	}
+	f.db.DeleteSeriesFunc = func(ctx context.Context, id primitive.ObjectID) error {
+		f.seriesDeleted = true
+		return nil
+	}
+	f.db.UpdateEventFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+		set := update["$set"].(bson.M)
+		f.updates[id] = set
+		for i := range f.occurrences {
+			if f.occurrences[i].ID == id {
+				applyEventUpdate(&f.occurrences[i], set)
+				if status, ok := set["status"].(string); ok {
+					f.occurrences[i].Status = status
+				}
+			}
+		}
+		return nil
+	}
+	f.db.DeleteEventFunc = func(ctx context.Context, id primitive.ObjectID) error {
+		f.deleted = append(f.deleted, id)
+		for i := range f.occurrences {
+			if f.occurrences[i].ID == id {
+				f.occurrences = append(f.occurrences[:i], f.occurrences[i+1:]...)
+				break
+			}
+		}
+		return nil
+	}
+	f.db.CreateEventFunc = func(ctx context.Context, event *models.Event) error {
+		f.created = append(f.created, *event)
+		f.occurrences = append(f.occurrences, *event)
+		return nil
+	}
+	f.db.CreateOccurrenceFunc = func(ctx context.Context, event *models.Event) (bool, error) {
+		for _, e := range f.occurrences {
+			if e.RecurrenceID == event.RecurrenceID && e.Date.Equal(event.Date) {
+				return false, nil
+			}
+		}
+		return true, f.db.CreateEventFunc(ctx, event)
+	}
+	f.db.CompleteEventFunc = func(ctx context.Context, id primitive.ObjectID) (bool, error) {
+		for _, e := range f.occurrences {
+			if e.ID == id && e.Status != "completed" && e.Status != "cancelled" {
+				return true, f.db.UpdateEventFunc(ctx, id, bson.M{"$set": bson.M{"status": "completed"}})
+			}
+		}
+		return false, nil
+	}
+	return f
+}
+
+func (f *seriesFixture) update(t *testing.T, event models.Event, scope string, updates map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(updates)
+	req, _ := http.NewRequest("PUT", "/events/"+event.ID.Hex()+"?scope="+scope, bytes.NewBuffer(body))
+	req.SetPathValue("id", event.ID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: f.hostID})
+	rr := httptest.NewRecorder()
+	f.server.UpdateEvent(rr, req)
+	return rr
+}
+
+func (f *seriesFixture) delete(t *testing.T, event models.Event, scope string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, _ := http.NewRequest("DELETE", "/events/"+event.ID.Hex()+"?scope="+scope, nil)
+	req.SetPathValue("id", event.ID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: f.hostID})
+	rr := httptest.NewRecorder()
+	f.server.DeleteEvent(rr, req)
+	return rr
+}
+
+func TestUpdateEvent_Scope(t *testing.T) {
+	t.Run("this", func(t *testing.T) {
+		f := newSeriesFixture()
+		this, next := f.occurrences[1], f.occurrences[2]
+
+		rr := f.update(t, this, "this", map[string]interface{}{"location": "The park"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if f.updates[this.ID]["location"] != "The park" {
+			t.Errorf("expected the occurrence to move to the park, got %v", f.updates[this.ID])
+		}
+		if _, ok := f.updates[next.ID]; ok || len(f.seriesUpdates) > 0 {
+			t.Errorf("expected only this occurrence to change, got %v and %v", f.updates, f.seriesUpdates)
+		}
+	})
+
+	t.Run("following", func(t *testing.T) {
+		f := newSeriesFixture()
+		past, this, next := f.occurrences[0], f.occurrences[1], f.occurrences[2]
+
+		rr := f.update(t, this, "following", map[string]interface{}{"location": "The park"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		for _, e := range []models.Event{this, next} {
+			if f.updates[e.ID]["location"] != "The park" {
+				t.Errorf("expected %v to move to the park, got %v", e.Date, f.updates[e.ID])
+			}
+		}
+		if _, ok := f.updates[past.ID]; ok {
+			t.Error("expected the completed occurrence to be left alone")
+		}
+		if len(f.seriesUpdates) != 1 || f.seriesUpdates[0]["location"] != "The park" {
+			t.Errorf("expected the series to move to the park, got %v", f.seriesUpdates)
+		}
+		var got models.Event
+		json.NewDecoder(rr.Body).Decode(&got)
+		if got.ID != this.ID || got.Location != "The park" {
+			t.Errorf("expected the updated occurrence in the response, got %+v", got)
+		}
+	})
+
+	t.Run("all moves the time of day", func(t *testing.T) {
+		f := newSeriesFixture()
+		this, next := f.occurrences[1], f.occurrences[2]
+		start := f.series.Start
+
+		rr := f.update(t, next, "all", map[string]interface{}{"date": next.Date.Add(time.Hour)})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		for _, e := range []models.Event{this, next} {
+			if date, _ := f.updates[e.ID]["date"].(time.Time); !date.Equal(e.Date.Add(time.Hour)) {
+				t.Errorf("expected %v to move to 7pm, got %v", e.Date, f.updates[e.ID])
+			}
+		}
+		if got, _ := f.seriesUpdates[0]["start"].(time.Time); !got.Equal(start.Add(time.Hour)) {
+			t.Errorf("expected the series to start at 7pm, got %v", f.seriesUpdates)
+		}
+	})
+
+	t.Run("following splits the series on a new rule", func(t *testing.T) {
+		f := newSeriesFixture()
+		this, next := f.occurrences[1], f.occurrences[2]
+
+		rr := f.update(t, next, "following", map[string]interface{}{"recurrence": "FREQ=WEEKLY;INTERVAL=2"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if got := f.updates[this.ID]["recurrence"]; got != "FREQ=WEEKLY;UNTIL=20250118" {
+			t.Errorf("expected the earlier occurrence to end the old series, got %v", got)
+		}
+		if len(f.seriesCreated) != 1 || f.seriesCreated[0].Recurrence != "FREQ=WEEKLY;INTERVAL=2" || !f.seriesCreated[0].Start.Equal(next.Date) {
+			t.Fatalf("expected a new series from the edited occurrence, got %+v", f.seriesCreated)
+		}
+		if got := f.updates[next.ID]["recurrence_id"]; got != f.seriesCreated[0].ID {
+			t.Errorf("expected the edited occurrence to join the new series, got %v", got)
+		}
+	})
+
+	// API clients send only what they change; leaving the rule out must not
+	// end the series and delete what comes after
+	for _, scope := range []string{"following", "all"} {
+		t.Run(scope+" without a recurrence keeps the rule", func(t *testing.T) {
+			f := newSeriesFixture()
+			this, next := f.occurrences[1], f.occurrences[2]
+
+			rr := f.update(t, this, scope, map[string]interface{}{"name": "Brunch"})
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			if len(f.deleted) > 0 || len(f.seriesCreated) > 0 {
+				t.Errorf("expected the series to carry on, got %v deleted and %v created", f.deleted, f.seriesCreated)
+			}
+			if f.updates[next.ID]["name"] != "Brunch" {
+				t.Errorf("expected the next occurrence to be renamed, got %v", f.updates[next.ID])
+			}
+			for _, set := range append(f.seriesUpdates, f.updates[this.ID], f.updates[next.ID]) {
+				if rule, ok := set["recurrence"]; ok {
+					t.Errorf("expected the rule to be left alone, got %v", rule)
+				}
+			}
+		})
+	}
+
+	t.Run("following with an empty recurrence ends the series", func(t *testing.T) {
+		f := newSeriesFixture()
+		this, next := f.occurrences[1], f.occurrences[2]
+
+		rr := f.update(t, this, "following", map[string]interface{}{"recurrence": ""})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if f.updates[this.ID]["recurrence"] != "" {
+			t.Errorf("expected the occurrence to become a one-off, got %v", f.updates[this.ID])
+		}
+		if len(f.deleted) != 1 || f.deleted[0] != next.ID {
+			t.Errorf("expected the later occurrence to go, got %v", f.deleted)
+		}
+	})
+
+	t.Run("this can't change the rule", func(t *testing.T) {
+		f := newSeriesFixture()
+		rr := f.update(t, f.occurrences[1], "this", map[string]interface{}{"recurrence": "FREQ=DAILY"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("unknown scope", func(t *testing.T) {
+		f := newSeriesFixture()
+		rr := f.update(t, f.occurrences[1], "everything", map[string]interface{}{"name": "Brunch"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestDeleteEvent_Scope(t *testing.T) {
+	t.Run("this skips the occurrence", func(t *testing.T) {
+		f := newSeriesFixture()
+		f.occurrences = f.occurrences[:2]
+		this := f.occurrences[1]
+
+		rr := f.delete(t, this, "this")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if len(f.deleted) != 1 || f.deleted[0] != this.ID {
+			t.Errorf("expected only this occurrence to be deleted, got %v", f.deleted)
+		}
+		if exdates, _ := f.seriesUpdates[0]["exdates"].([]time.Time); len(exdates) != 1 || !exdates[0].Equal(this.Date) {
+			t.Errorf("expected the series to skip %v, got %v", this.Date, f.seriesUpdates)
+		}
+		// It was the only scheduled occurrence, so the series is topped back up
+		if len(f.created) != defaultOccurrencesAhead || !f.created[0].Date.Equal(this.Date.AddDate(0, 0, 7)) {
+			t.Errorf("expected the next %d occurrences to be created, got %+v", defaultOccurrencesAhead, f.created)
+		}
+	})
+
+	t.Run("following ends the series", func(t *testing.T) {
+		f := newSeriesFixture()
+		past, this, next := f.occurrences[0], f.occurrences[1], f.occurrences[2]
+
+		rr := f.delete(t, this, "following")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if len(f.deleted) != 2 || f.deleted[0] != this.ID || f.deleted[1] != next.ID {
+			t.Errorf("expected this and the next occurrence to be deleted, got %v", f.deleted)
+		}
+		for _, id := range f.deleted {
+			if id == past.ID {
+				t.Error("expected the completed occurrence to be kept")
+			}
+		}
+		if len(f.seriesUpdates) != 1 || !strings.Contains(f.seriesUpdates[0]["recurrence"].(string), "UNTIL=20250111") {
+			t.Errorf("expected the series to end the day before, got %v", f.seriesUpdates)
+		}
+	})
+
+	t.Run("all", func(t *testing.T) {
+		f := newSeriesFixture()
+		count := len(f.occurrences)
+
+		rr := f.delete(t, f.occurrences[1], "all")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if len(f.deleted) != count || !f.seriesDeleted {
+			t.Errorf("expected the whole series to be deleted, got %v", f.deleted)
+		}
+	})
+}
+
+func TestGetEventOccurrences(t *testing.T) {
+	f := newSeriesFixture()
+	this, next := f.occurrences[1], f.occurrences[2]
+
+	req, _ := http.NewRequest("GET", "/events/"+this.ID.Hex()+"/occurrences", nil)
+	req.SetPathValue("id", this.ID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{this.GroupID}})
+	rr := httptest.NewRecorder()
+	f.server.GetEventOccurrences(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var got []models.Event
+	json.NewDecoder(rr.Body).Decode(&got)
+	if len(got) != 2 || got[0].ID != this.ID || got[1].ID != next.ID {
+		t.Errorf("expected the scheduled occurrences, got %+v", got)
+	}
+
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID()})
+	rr = httptest.NewRecorder()
+	f.server.GetEventOccurrences(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+}
+
+func TestFillSeries(t *testing.T) {
+	f := newSeriesFixture()
+	last := f.occurrences[2]
+
+	scheduled, err := f.server.fillSeries(context.Background(), &models.FamilyMember{ID: f.hostID}, &f.series)
+	if err != nil {
+		t.Fatalf("fillSeries returned an error: %v", err)
+	}
+	if len(scheduled) != defaultOccurrencesAhead {
+		t.Errorf("expected %d scheduled occurrences, got %d", defaultOccurrencesAhead, len(scheduled))
+	}
+	if len(f.created) != 1 || !f.created[0].Date.Equal(last.Date.AddDate(0, 0, 7)) {
+		t.Fatalf("expected one occurrence after %v, got %+v", last.Date, f.created)
+	}
+	if f.created[0].RecurrenceID != f.series.ID || f.created[0].Location != f.series.Location {
+		t.Errorf("expected the new occurrence to follow the series, got %+v", f.created[0])
+	}
+
+	// A full series is left alone
+	f.created = nil
+	if _, err := f.server.fillSeries(context.Background(), &models.FamilyMember{ID: f.hostID}, &f.series); err != nil {
+		t.Fatalf("fillSeries returned an error: %v", err)
+	}
+	if len(f.created) != 0 {
+		t.Errorf("expected nothing to be created, got %+v", f.created)
+	}
+}
+
+func TestFillSeries_GroupSetting(t *testing.T) {
+	f := newSeriesFixture()
+	f.db.GetGroupFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Group, error) {
+		return &models.Group{ID: id, OccurrencesAhead: 6}, nil
+	}
+
+	if _, err := f.server.fillSeries(context.Background(), &models.FamilyMember{ID: f.hostID}, &f.series); err != nil {
+		t.Fatalf("fillSeries returned an error: %v", err)
+	}
+	if len(f.created) != 4 {
+		t.Errorf("expected 4 more occurrences to make 6, got %d", len(f.created))
+	}
+}
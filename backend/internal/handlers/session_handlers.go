@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"net"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Refresh and API tokens are stored hashed so a database leak does not hand
+// out usable credentials.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return forwarded
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// startSession records a new session for familyMember and sets the access and
+// refresh cookies on the response.
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, familyMember *models.FamilyMember) error {
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:               primitive.NewObjectID(),
+		FamilyMemberID:   familyMember.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		UserAgent:        r.UserAgent(),
+		IPAddress:        clientIP(r),
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(refreshTokenTTL),
+	}
+	if err := s.DB.CreateSession(context.Background(), &session); err != nil {
+		return err
+	}
+
+	token, err := GenerateToken(familyMember.ID.Hex(), session.ID.Hex())
+	if err != nil {
+		return err
+	}
+	setTokenCookie(w, token)
+	setRefreshCookie(w, refreshToken, session.ExpiresAt)
+	return nil
+}
+
+// RefreshSession exchanges the refresh cookie for a new access token and a new
+// refresh token. Presenting an already-rotated refresh token revokes the
+// session, since it means the token was copied.
+func (s *Server) RefreshSession(w http.ResponseWriter, r *http.Request) {
+	c, err := r.Cookie("refresh_token")
+	if err != nil || c.Value == "" {
+		http.Error(w, "Missing refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	hash := hashToken(c.Value)
+	session, err := s.DB.GetSessionByRefreshTokenHash(context.Background(), hash)
+	if err != nil {
+		clearAuthCookies(w)
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	if session.RefreshTokenHash != hash {
+		s.DB.RevokeSession(context.Background(), session.ID)
+		clearAuthCookies(w)
+		http.Error(w, "Refresh token has already been used", http.StatusUnauthorized)
+		return
+	}
+
+	if !session.IsActive(time.Now()) {
+		clearAuthCookies(w)
+		http.Error(w, "Session expired", http.StatusUnauthorized)
+		return
+	}
+
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	expiresAt := time.Now().Add(refreshTokenTTL)
+	rotated, err := s.DB.RotateSessionRefreshToken(context.Background(), session.ID, hash, hashToken(refreshToken), expiresAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !rotated {
+		http.Error(w, "Refresh token has already been used", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := GenerateToken(session.FamilyMemberID.Hex(), session.ID.Hex())
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	setTokenCookie(w, token)
+	setRefreshCookie(w, refreshToken, expiresAt)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Refreshed"))
+}
+
+func (s *Server) GetSessions(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := s.DB.GetActiveSessionsByFamilyMemberID(context.Background(), actor.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if sessions == nil {
+		sessions = []models.Session{}
+	}
+
+	if current, ok := SessionFromContext(r.Context()); ok {
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == current.ID
+		}
+	}
+
+	json.NewEncoder(w).Encode(sessions)
+}
+
+func (s *Server) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid session id", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := s.DB.GetSessionByID(context.Background(), id)
+	if err != nil || session.FamilyMemberID != actor.ID {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.DB.RevokeSession(context.Background(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if current, ok := SessionFromContext(r.Context()); ok && current.ID == id {
+		clearAuthCookies(w)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Session revoked"))
+}
+
+// RevokeAllSessions signs the current FamilyMember out everywhere, including
+// this device.
+func (s *Server) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.DB.RevokeSessionsByFamilyMemberID(context.Background(), actor.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	clearAuthCookies(w)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("All sessions revoked"))
+}
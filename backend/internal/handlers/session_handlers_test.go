@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRefreshSession_Rotates(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	oldToken := "old-refresh-token"
+	session := &models.Session{
+		ID:               primitive.NewObjectID(),
+		FamilyMemberID:   primitive.NewObjectID(),
+		RefreshTokenHash: hashToken(oldToken),
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+	mockDB.GetSessionByRefreshTokenHashFunc = func(ctx context.Context, hash string) (*models.Session, error) {
+		return session, nil
+	}
+	var newHash string
+	mockDB.RotateSessionRefreshTokenFunc = func(ctx context.Context, id primitive.ObjectID, old, new string, expiresAt time.Time) (bool, error) {
+		if old != session.RefreshTokenHash {
+			t.Errorf("expected rotation from current hash")
+		}
+		newHash = new
+		return true, nil
+	}
+
+	req, _ := http.NewRequest("POST", "/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: oldToken})
+	rr := httptest.NewRecorder()
+
+	server.RefreshSession(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	cookies := map[string]string{}
+	for _, c := range rr.Result().Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	if cookies["refresh_token"] == "" || cookies["refresh_token"] == oldToken {
+		t.Errorf("expected a new refresh token, got %q", cookies["refresh_token"])
+	}
+	if hashToken(cookies["refresh_token"]) != newHash {
+		t.Error("stored refresh token hash does not match the issued cookie")
+	}
+	if cookies["token"] == "" {
+		t.Error("expected a new access token cookie")
+	}
+}
+
+func TestRefreshSession_ReuseRevokes(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	reused := "rotated-refresh-token"
+	session := &models.Session{
+		ID:                       primitive.NewObjectID(),
+		RefreshTokenHash:         hashToken("current-refresh-token"),
+		PreviousRefreshTokenHash: hashToken(reused),
+		ExpiresAt:                time.Now().Add(time.Hour),
+	}
+	mockDB.GetSessionByRefreshTokenHashFunc = func(ctx context.Context, hash string) (*models.Session, error) {
+		return session, nil
+	}
+	revoked := false
+	mockDB.RevokeSessionFunc = func(ctx context.Context, id primitive.ObjectID) error {
+		revoked = id == session.ID
+		return nil
+	}
+
+	req, _ := http.NewRequest("POST", "/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: reused})
+	rr := httptest.NewRecorder()
+
+	server.RefreshSession(rr, req)
+
+	if status := rr.Code; status != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnauthorized)
+	}
+	if !revoked {
+		t.Error("expected the session to be revoked after refresh token reuse")
+	}
+}
+
+func TestGetSessions(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	familyID := primitive.NewObjectID()
+	current := models.Session{ID: primitive.NewObjectID(), FamilyMemberID: familyID}
+	other := models.Session{ID: primitive.NewObjectID(), FamilyMemberID: familyID}
+	mockDB.GetActiveSessionsByFamilyMemberIDFunc = func(ctx context.Context, id primitive.ObjectID) ([]models.Session, error) {
+		return []models.Session{current, other}, nil
+	}
+
+	req, _ := http.NewRequest("GET", "/auth/sessions", nil)
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID})
+	req = req.WithContext(WithSession(req.Context(), &current))
+	rr := httptest.NewRecorder()
+
+	server.GetSessions(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var resp []models.Session
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(resp))
+	}
+	if !resp[0].Current || resp[1].Current {
+		t.Errorf("expected only the first session to be current, got %v and %v", resp[0].Current, resp[1].Current)
+	}
+}
+
+func TestRevokeSession_OtherMember(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	sessionID := primitive.NewObjectID()
+	mockDB.GetSessionByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+		return &models.Session{ID: sessionID, FamilyMemberID: primitive.NewObjectID()}, nil
+	}
+
+	req, _ := http.NewRequest("DELETE", "/auth/sessions/"+sessionID.Hex(), nil)
+	req.SetPathValue("id", sessionID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID()})
+	rr := httptest.NewRecorder()
+
+	server.RevokeSession(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, nil)
+
+	familyID := primitive.NewObjectID()
+	var revokedFor primitive.ObjectID
+	mockDB.RevokeSessionsByFamilyMemberIDFunc = func(ctx context.Context, id primitive.ObjectID) error {
+		revokedFor = id
+		return nil
+	}
+
+	req, _ := http.NewRequest("DELETE", "/auth/sessions", nil)
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID})
+	rr := httptest.NewRecorder()
+
+	server.RevokeAllSessions(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if revokedFor != familyID {
+		t.Errorf("expected sessions of %v to be revoked, got %v", familyID, revokedFor)
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"family-potluck/backend/internal/websocket"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Server-Sent Events carry the same envelopes as the WebSocket for browsers
+// and networks where WebSockets don't work. Each stream follows one topic and
+// uses the topic's sequence numbers as event IDs, so an EventSource that
+// reconnects with Last-Event-ID resumes where it left off.
+
+// sseRetry is how long, in milliseconds, EventSource waits before reconnecting.
+const sseRetry = 3000
+
+// StreamEvent streams the messages of one event.
+func (s *Server) StreamEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+	s.serveStream(w, r, websocket.EventTopic(id.Hex()))
+}
+
+// StreamGroup streams the messages of every event in a group.
+func (s *Server) StreamGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	s.serveStream(w, r, websocket.GroupTopic(id.Hex()))
+}
+
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request, topic string) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
+	allowed, err := s.AuthorizeTopic(r.Context(), actor, topic)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	since, err := lastEventID(r)
+	if err != nil {
+		http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+
+	pingInterval := s.Hub.Config.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = websocket.DefaultConfig().PingInterval
+	}
+	writeTimeout := s.Hub.Config.WriteTimeout
+	if writeTimeout <= 0 {
+		writeTimeout = websocket.DefaultConfig().WriteTimeout
+	}
+
+	// The server's WriteTimeout would cut the stream off, so each write gets
+	// its own deadline instead.
+	rc := http.NewResponseController(w)
+	write := func(format string, args ...interface{}) error {
+		rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := write("retry: %d\n\n", sseRetry); err != nil {
+		return
+	}
+
+	stream := s.Hub.Stream(r.Context(), topic, since)
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-stream.C:
+			if !ok {
+				if reason := stream.CloseReason(); reason != "" {
+					frame, _ := json.Marshal(map[string]string{"type": websocket.FrameDisconnect, "reason": reason})
+					write("data: %s\n\n", frame)
+				}
+				return
+			}
+			if id, ok := sseEventID(topic, msg); ok {
+				err = write("id: %d\ndata: %s\n\n", id, msg)
+			} else {
+				err = write("data: %s\n\n", msg)
+			}
+			if err != nil {
+				return
+			}
+		case <-ticker.C:
+			// Comments keep proxies from timing out an idle stream
+			if err := write(": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// lastEventID reads the sequence number to resume after from the Last-Event-ID
+// header EventSource sends on reconnect, or the last_event_id query parameter
+// for the first connection.
+func lastEventID(r *http.Request) (*uint64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return nil, nil
+	}
+	seq, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &seq, nil
+}
+
+// sseEventID finds msg's sequence number on topic. Published messages map
+// each topic to its number; the subscribed acknowledgement carries the
+// topic's current number directly.
+func sseEventID(topic string, msg []byte) (uint64, bool) {
+	var envelope struct {
+		Seq json.RawMessage `json:"seq"`
+	}
+	if err := json.Unmarshal(msg, &envelope); err != nil || len(envelope.Seq) == 0 {
+		return 0, false
+	}
+
+	var seqs map[string]uint64
+	if err := json.Unmarshal(envelope.Seq, &seqs); err == nil {
+		seq, ok := seqs[topic]
+		return seq, ok
+	}
+	var seq uint64
+	if err := json.Unmarshal(envelope.Seq, &seq); err == nil {
+		return seq, true
+	}
+	return 0, false
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"family-potluck/backend/internal/database"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStreamEvent_Forbidden(t *testing.T) {
+	mockDB := &database.MockService{}
+	server := NewServer(mockDB, websocket.NewHub())
+
+	event := &models.Event{ID: primitive.NewObjectID(), GroupID: primitive.NewObjectID()}
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return event, nil
+	}
+	outsider := &models.FamilyMember{ID: primitive.NewObjectID()}
+
+	req, _ := http.NewRequest("GET", "/events/"+event.ID.Hex()+"/stream", nil)
+	req.SetPathValue("id", event.ID.Hex())
+	req = withFamilyMember(req, outsider)
+	rr := httptest.NewRecorder()
+
+	server.StreamEvent(rr, req)
+
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+}
+
+func TestStreamEvent_ResumesFromLastEventID(t *testing.T) {
+	mockDB := &database.MockService{}
+	hub := websocket.NewHub()
+	go hub.Run()
+	server := NewServer(mockDB, hub)
+
+	groupID := primitive.NewObjectID()
+	event := &models.Event{ID: primitive.NewObjectID(), GroupID: groupID}
+	member := &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}}
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return event, nil
+	}
+
+	topic := websocket.EventTopic(event.ID.Hex())
+	hub.Publish([]byte(`{"type":"dish_added","data":{"n":1}}`), topic)
+	hub.Publish([]byte(`{"type":"dish_added","data":{"n":2}}`), topic)
+	time.Sleep(50 * time.Millisecond)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/{id}/stream", func(w http.ResponseWriter, r *http.Request) {
+		server.StreamEvent(w, withFamilyMember(r, member))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/events/"+event.ID.Hex()+"/stream", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	// Read the retry hint, the replayed message and the acknowledgement
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(lines) < 5 && scanner.Scan() {
+		if scanner.Text() != "" {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	want := []string{
+		"retry: 3000",
+		"id: 2",
+		`data: {"type":"dish_added","data":{"n":2},"seq":{"` + topic + `":2}}`,
+		"id: 2",
+		`data: {"type":"subscribed","topic":"` + topic + `","seq":2}`,
+	}
+	if got := strings.Join(lines, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("stream =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestSSEEventID(t *testing.T) {
+	topic := websocket.EventTopic("e1")
+	tests := []struct {
+		msg  string
+		id   uint64
+		want bool
+	}{
+		{`{"type":"dish_added","seq":{"event:e1":7,"group:g1":3}}`, 7, true},
+		{`{"type":"subscribed","topic":"event:e1","seq":4}`, 4, true},
+		{`{"type":"dish_added","seq":{"group:g1":3}}`, 0, false},
+		{`{"type":"resync_required","topic":"event:e1"}`, 0, false},
+	}
+	for _, tt := range tests {
+		id, ok := sseEventID(topic, []byte(tt.msg))
+		if id != tt.id || ok != tt.want {
+			t.Errorf("sseEventID(%s) = %d, %v; want %d, %v", tt.msg, id, ok, tt.id, tt.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
 	"fmt"
 	"net/http"
 	"time"
@@ -13,11 +14,23 @@ import (
 )
 
 func (s *Server) CreateSwapRequest(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var req models.SwapRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, req.RequestingFamilyMemberID, "requesting_family_id") {
+		return
+	}
+	req.RequestingFamilyMemberID = actor.ID
+	if _, ok := s.loadViewableEvent(w, r, req.EventID); !ok {
+		return
+	}
 
 	req.ID = primitive.NewObjectID()
 	req.Status = "pending"
@@ -30,12 +43,7 @@ func (s *Server) CreateSwapRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast update
-	msg := map[string]interface{}{
-		"type": "swap_created",
-		"data": req,
-	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
+	realtime.Publish(s.Hub, actor, realtime.SwapCreated(req), s.eventTopicsByID(context.Background(), req.EventID)...)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(req)
@@ -49,6 +57,11 @@ func (s *Server) UpdateSwapRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
+	}
+
 	var update struct {
 		Status               string              `json:"status"`
 		TargetFamilyMemberID *primitive.ObjectID `json:"target_family_id"`
@@ -61,6 +74,10 @@ func (s *Server) UpdateSwapRequest(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// Accepting a swap makes the acting user the target
+	if update.TargetFamilyMemberID != nil && !matchesActor(w, actor, *update.TargetFamilyMemberID, "target_family_id") {
+		return
+	}
 
 	// Fetch request to get details for broadcast
 	req, err := s.DB.GetSwapRequestByID(context.Background(), id)
@@ -146,12 +163,7 @@ func (s *Server) UpdateSwapRequest(w http.ResponseWriter, r *http.Request) {
 				// Broadcast event update
 				updatedEvent, err := s.DB.GetEvent(context.Background(), req.EventID)
 				if err == nil {
-					eventMsg := map[string]interface{}{
-						"type": "event_updated",
-						"data": updatedEvent,
-					}
-					eventMsgBytes, _ := json.Marshal(eventMsg)
-					s.Hub.Broadcast(eventMsgBytes)
+					realtime.Publish(s.Hub, actor, realtime.EventUpdated(*updatedEvent), eventTopics(updatedEvent)...)
 				}
 			}
 
@@ -182,27 +194,24 @@ func (s *Server) UpdateSwapRequest(w http.ResponseWriter, r *http.Request) {
 				fmt.Printf("Failed to update dish bringer: %v\n", err)
 			} else {
 				// Broadcast dish update
-				dishMsg := map[string]interface{}{
-					"type": "dish_pledged",
-					"data": map[string]interface{}{
-						"dish_id":    req.DishID,
-						"event_id":   req.EventID,
-						"bringer_id": req.RequestingFamilyMemberID,
-					},
+				pledged := realtime.DishPledged{
+					DishID:    *req.DishID,
+					EventID:   req.EventID,
+					BringerID: newBringerID,
+				}
+				if currentDish != nil {
+					pledged.DishName = currentDish.Name
 				}
-				dishMsgBytes, _ := json.Marshal(dishMsg)
-				s.Hub.Broadcast(dishMsgBytes)
+				if bringer, err := s.DB.GetFamilyMemberByID(context.Background(), newBringerID); err == nil && bringer != nil {
+					pledged.BringerName = bringer.Name
+				}
+				realtime.Publish(s.Hub, actor, pledged, s.eventTopicsByID(context.Background(), req.EventID)...)
 			}
 		}
 	}
 
 	// Broadcast update
-	msg := map[string]interface{}{
-		"type": "swap_updated",
-		"data": req,
-	}
-	msgBytes, _ := json.Marshal(msg)
-	s.Hub.Broadcast(msgBytes)
+	realtime.Publish(s.Hub, actor, realtime.SwapUpdated(*req), s.eventTopicsByID(context.Background(), req.EventID)...)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -219,6 +228,9 @@ func (s *Server) GetSwapRequests(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid event_id", http.StatusBadRequest)
 		return
 	}
+	if _, ok := s.loadViewableEvent(w, r, eventID); !ok {
+		return
+	}
 
 	requests, err := s.DB.GetSwapRequestsByEventID(context.Background(), eventID)
 	if err != nil {
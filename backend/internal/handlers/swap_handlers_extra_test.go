@@ -115,6 +115,7 @@ func TestUpdateSwapRequest_HostSwap_LocationUpdate(t *testing.T) {
 
 	req, _ := http.NewRequest("PATCH", "/swaps/"+swapID.Hex(), bytes.NewBuffer(body))
 	req.SetPathValue("id", swapID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: newHostID})
 	rr := httptest.NewRecorder()
 
 	server.UpdateSwapRequest(rr, req)
@@ -127,3 +128,81 @@ func TestUpdateSwapRequest_HostSwap_LocationUpdate(t *testing.T) {
 		t.Error("expected event location to be updated to New Address")
 	}
 }
+
+func TestUpdateSwapRequest_DishSwapBroadcastsPledge(t *testing.T) {
+	mockDB := &database.MockService{}
+	hub := websocket.NewHub()
+	go hub.Run()
+	server := NewServer(mockDB, hub)
+
+	swapID := primitive.NewObjectID()
+	eventID := primitive.NewObjectID()
+	dishID := primitive.NewObjectID()
+	requesterID := primitive.NewObjectID()
+	offeredToID := primitive.NewObjectID()
+
+	// The requester currently brings the dish and offers it to someone else
+	mockDB.GetSwapRequestByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.SwapRequest, error) {
+		return &models.SwapRequest{
+			ID:                       swapID,
+			EventID:                  eventID,
+			DishID:                   &dishID,
+			Type:                     "dish",
+			RequestingFamilyMemberID: requesterID,
+			Status:                   "pending",
+		}, nil
+	}
+	mockDB.UpdateSwapRequestFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+		return nil
+	}
+	mockDB.GetDishByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Dish, error) {
+		return &models.Dish{ID: dishID, EventID: eventID, Name: "Lasagna", BringerID: &requesterID}, nil
+	}
+	mockDB.UpdateDishFunc = func(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+		return nil
+	}
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: eventID}, nil
+	}
+	mockDB.GetFamilyMemberByIDFunc = func(ctx context.Context, id primitive.ObjectID) (*models.FamilyMember, error) {
+		return &models.FamilyMember{ID: id, Name: "The Joneses"}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := hub.Stream(ctx, websocket.EventTopic(eventID.Hex()), nil)
+	<-stream.C // subscribed
+
+	body, _ := json.Marshal(map[string]interface{}{"status": "approved", "target_family_id": offeredToID})
+	req, _ := http.NewRequest("PATCH", "/swaps/"+swapID.Hex(), bytes.NewBuffer(body))
+	req.SetPathValue("id", swapID.Hex())
+	req = withFamilyMember(req, &models.FamilyMember{ID: offeredToID, Name: "The Joneses"})
+	rr := httptest.NewRecorder()
+
+	server.UpdateSwapRequest(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	select {
+	case msg := <-stream.C:
+		var got struct {
+			Type string `json:"type"`
+			Data struct {
+				BringerID   primitive.ObjectID `json:"bringer_id"`
+				BringerName string             `json:"bringer_name"`
+				DishName    string             `json:"dish_name"`
+			} `json:"data"`
+		}
+		json.Unmarshal(msg, &got)
+		if got.Type != "dish_pledged" {
+			t.Fatalf("Expected dish_pledged, got %s", msg)
+		}
+		if got.Data.BringerID != offeredToID || got.Data.BringerName != "The Joneses" || got.Data.DishName != "Lasagna" {
+			t.Errorf("dish_pledged data = %+v", got.Data)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Expected a dish_pledged message")
+	}
+}
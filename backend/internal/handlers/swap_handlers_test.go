@@ -21,11 +21,15 @@ func TestCreateSwapRequest(t *testing.T) {
 	server := NewServer(mockDB, hub)
 
 	eventID := primitive.NewObjectID()
+	groupID := primitive.NewObjectID()
 	familyID := primitive.NewObjectID()
 
 	mockDB.CreateSwapRequestFunc = func(ctx context.Context, swap *models.SwapRequest) error {
 		return nil
 	}
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: id, GroupID: groupID}, nil
+	}
 
 	swapReq := models.SwapRequest{
 		EventID:                  eventID,
@@ -35,6 +39,7 @@ func TestCreateSwapRequest(t *testing.T) {
 	body, _ := json.Marshal(swapReq)
 
 	req, _ := http.NewRequest("POST", "/swaps", bytes.NewBuffer(body))
+	req = withFamilyMember(req, &models.FamilyMember{ID: familyID, GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.CreateSwapRequest(rr, req)
@@ -55,6 +60,7 @@ func TestGetSwapRequests(t *testing.T) {
 	server := NewServer(mockDB, nil)
 
 	eventID := primitive.NewObjectID()
+	groupID := primitive.NewObjectID()
 	requests := []models.SwapRequest{
 		{ID: primitive.NewObjectID(), EventID: eventID, RequestingFamilyMemberID: primitive.NewObjectID()},
 		{ID: primitive.NewObjectID(), EventID: eventID, RequestingFamilyMemberID: primitive.NewObjectID()},
@@ -68,7 +74,12 @@ func TestGetSwapRequests(t *testing.T) {
 		return &models.FamilyMember{ID: id, Name: "Test Family"}, nil
 	}
 
+	mockDB.GetEventFunc = func(ctx context.Context, id primitive.ObjectID) (*models.Event, error) {
+		return &models.Event{ID: id, GroupID: groupID}, nil
+	}
+
 	req, _ := http.NewRequest("GET", "/swaps?event_id="+eventID.Hex(), nil)
+	req = withFamilyMember(req, &models.FamilyMember{ID: primitive.NewObjectID(), GroupIDs: []primitive.ObjectID{groupID}})
 	rr := httptest.NewRecorder()
 
 	server.GetSwapRequests(rr, req)
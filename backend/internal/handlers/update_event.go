@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -11,6 +13,27 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// eventUpdates are the changes UpdateEvent accepts. Empty fields are left as
+// they were. So is the recurrence when it is omitted; an empty one stops the
+// event repeating.
+type eventUpdates struct {
+	Name        string      `json:"name"`
+	Date        time.Time   `json:"date"`
+	TimeZone    string      `json:"time_zone"`
+	Duration    int         `json:"duration_minutes"`
+	Location    string      `json:"location"`
+	Description string      `json:"description"`
+	Recurrence  *string     `json:"recurrence"`
+	ExDates     []time.Time `json:"exdates"`
+	// HostRotation picks the hosts of the series' occurrences from now on
+	HostRotation string             `json:"host_rotation"`
+	Type         string             `json:"type"`
+	UserID       primitive.ObjectID `json:"user_id"`
+}
+
+// UpdateEvent changes an event. For an occurrence of a recurring event the
+// scope query parameter picks whether the change is to this occurrence alone
+// (the default), to it and the ones after it, or to the whole series.
 func (s *Server) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := primitive.ObjectIDFromHex(idStr)
@@ -19,53 +42,48 @@ func (s *Server) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var updates struct {
-		Name        string    `json:"name"`
-		Date        time.Time `json:"date"`
-		Location    string    `json:"location"`
-		Description string    `json:"description"`
-		Recurrence  string    `json:"recurrence"`
-		Type        string    `json:"type"`
-		UserID      string    `json:"user_id"`
+	actor, ok := currentMember(w, r)
+	if !ok {
+		return
 	}
+
+	scope, ok := editScope(w, r)
+	if !ok {
+		return
+	}
+
+	var updates eventUpdates
 	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !matchesActor(w, actor, updates.UserID, "user_id") {
+		return
+	}
+	if err := checkTimeZone(updates.TimeZone); err != nil {
+		http.Error(w, "Invalid time zone: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if updates.Duration < 0 {
+		http.Error(w, "duration_minutes can't be negative", http.StatusBadRequest)
+		return
+	}
+	if err := checkHostRotation(updates.HostRotation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	collection := s.DB.GetCollection("events")
-	var event models.Event
-	err = collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&event)
+	event, err := s.DB.GetEvent(context.Background(), id)
 	if err != nil {
 		http.Error(w, "Event not found", http.StatusNotFound)
 		return
 	}
 
 	// Verify permissions: Host, Host Household Member, or Group Admin
-	isAuthorized := false
-	if updates.UserID == event.HostID.Hex() {
-		isAuthorized = true
-	} else {
-		// Check if user is in the same household as the host
-		userOID, _ := primitive.ObjectIDFromHex(updates.UserID)
-		var userMember models.FamilyMember
-		var hostMember models.FamilyMember
-
-		familyColl := s.DB.GetCollection("families")
-		errUser := familyColl.FindOne(context.Background(), bson.M{"_id": userOID}).Decode(&userMember)
-		errHost := familyColl.FindOne(context.Background(), bson.M{"_id": event.HostID}).Decode(&hostMember)
-
-		if errUser == nil && errHost == nil && userMember.HouseholdID != nil && hostMember.HouseholdID != nil && *userMember.HouseholdID == *hostMember.HouseholdID {
-			isAuthorized = true
-		} else {
-			// Check if user is group admin
-			groupColl := s.DB.GetCollection("groups")
-			var group models.Group
-			errGroup := groupColl.FindOne(context.Background(), bson.M{"_id": event.GroupID}).Decode(&group)
-			if errGroup == nil && isAdmin(group.AdminIDs, userOID) {
-				isAuthorized = true
-			}
-		}
+	isAuthorized, err := s.Authz.CanEditEvent(context.Background(), actor, event)
+	if err != nil {
+		http.Error(w, "Group not found", http.StatusInternalServerError)
+		return
 	}
 
 	if !isAuthorized {
@@ -73,12 +91,56 @@ func (s *Server) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Recurrence can be cleared (set to empty)
+	if updates.Recurrence != nil && *updates.Recurrence != "" {
+		rule, err := normalizeRecurrence(*updates.Recurrence)
+		if err != nil {
+			http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		updates.Recurrence = &rule
+	}
+
+	if inSeries(event) && scope != scopeThis {
+		updated, ok := s.updateSeries(w, actor, event, scope, updates)
+		if !ok {
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(updated)
+		return
+	}
+	if inSeries(event) {
+		if (updates.Recurrence != nil && !sameRule(*updates.Recurrence, event.Recurrence)) || updates.ExDates != nil {
+			http.Error(w, "The recurrence can only be changed for the following or all occurrences", http.StatusBadRequest)
+			return
+		}
+		if updates.HostRotation != "" && updates.HostRotation != event.HostRotation {
+			http.Error(w, "The host rotation can only be changed for the following or all occurrences", http.StatusBadRequest)
+			return
+		}
+		// Keep the series as it was before this occurrence departs from it
+		if _, err := s.loadSeries(context.Background(), event); err != nil {
+			http.Error(w, "Failed to load series", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	updateFields := bson.M{}
 	if updates.Name != "" {
 		updateFields["name"] = updates.Name
 	}
 	if !updates.Date.IsZero() {
 		updateFields["date"] = updates.Date
+		if inSeries(event) && event.OriginalDate == nil && !updates.Date.Equal(event.Date) {
+			updateFields["original_date"] = event.Date
+		}
+	}
+	if updates.TimeZone != "" {
+		updateFields["time_zone"] = updates.TimeZone
+	}
+	if updates.Duration > 0 {
+		updateFields["duration_minutes"] = updates.Duration
 	}
 	if updates.Location != "" {
 		updateFields["location"] = updates.Location
@@ -90,54 +152,100 @@ func (s *Server) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	if updates.Description != "" {
 		updateFields["description"] = updates.Description
 	}
-	// Recurrence can be cleared (set to empty)
-	updateFields["recurrence"] = updates.Recurrence
-	if updates.Recurrence != "" && event.RecurrenceID.IsZero() {
-		updateFields["recurrence_id"] = primitive.NewObjectID()
+	if updates.Recurrence != nil {
+		rule := *updates.Recurrence
+		updateFields["recurrence"] = rule
+		if rule != "" && !inSeries(event) {
+			// A new series, even if the event was part of one before
+			start := event.Date
+			if !updates.Date.IsZero() {
+				start = updates.Date
+			}
+			updateFields["recurrence_id"] = primitive.NewObjectID()
+			updateFields["recurrence_start"] = start
+		} else if rule != "" && event.RecurrenceStart == nil {
+			updateFields["recurrence_start"] = event.Date
+		}
+	}
+	if updates.ExDates != nil {
+		updateFields["exdates"] = updates.ExDates
 	}
 	if updates.Type != "" {
 		updateFields["type"] = updates.Type
 	}
+	if updates.HostRotation != "" && !inSeries(event) {
+		updateFields["host_rotation"] = updates.HostRotation
+	}
 
 	if len(updateFields) > 0 {
-		_, err = collection.UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": updateFields})
+		err = s.DB.UpdateEvent(context.Background(), id, bson.M{"$set": updateFields})
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		// Update local event object for response/broadcast
-		if val, ok := updateFields["name"]; ok {
-			event.Name = val.(string)
+		applyEventUpdate(event, updateFields)
+
+		// Broadcast update
+		realtime.Publish(s.Hub, actor, realtime.EventUpdated(*event), eventTopics(event)...)
+
+		// An event that starts repeating schedules its next occurrences
+		if _, ok := updateFields["recurrence_id"]; ok {
+			series, err := s.loadSeries(context.Background(), event)
+			if err == nil {
+				_, err = s.fillSeries(context.Background(), actor, series)
+			}
+			if err != nil {
+				fmt.Printf("Failed to schedule occurrences of %s: %v\n", event.ID.Hex(), err)
+			}
 		}
-		if val, ok := updateFields["date"]; ok {
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(event)
+}
+
+// applyEventUpdate reflects a $set of fields in event, for responses and
+// broadcasts.
+func applyEventUpdate(event *models.Event, fields bson.M) {
+	for key, val := range fields {
+		switch key {
+		case "name":
+			event.Name = val.(string)
+		case "date":
 			event.Date = val.(time.Time)
-		}
-		if val, ok := updateFields["location"]; ok {
+		case "original_date":
+			if date, ok := val.(time.Time); ok {
+				event.OriginalDate = &date
+			} else {
+				event.OriginalDate = nil
+			}
+		case "time_zone":
+			event.TimeZone = val.(string)
+		case "duration_minutes":
+			event.DurationMinutes = val.(int)
+		case "location":
 			event.Location = val.(string)
-		}
-		if val, ok := updateFields["description"]; ok {
+		case "description":
 			event.Description = val.(string)
-		}
-		if val, ok := updateFields["recurrence"]; ok {
+		case "recurrence":
 			event.Recurrence = val.(string)
-		}
-		if val, ok := updateFields["recurrence_id"]; ok {
+		case "recurrence_id":
 			event.RecurrenceID = val.(primitive.ObjectID)
-		}
-		if val, ok := updateFields["type"]; ok {
+		case "recurrence_start":
+			start := val.(time.Time)
+			event.RecurrenceStart = &start
+		case "exdates":
+			event.ExDates = val.([]time.Time)
+		case "type":
 			event.Type = val.(string)
+		case "host_rotation":
+			event.HostRotation = val.(string)
+		case "status":
+			event.Status = val.(string)
+		case "cancel_reason":
+			event.CancelReason = val.(string)
 		}
-
-		// Broadcast update
-		msg := map[string]interface{}{
-			"type": "event_updated",
-			"data": event,
-		}
-		msgBytes, _ := json.Marshal(msg)
-		s.Hub.Broadcast(msgBytes)
 	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(event)
 }
@@ -0,0 +1,175 @@
+// Package ical reads and writes iCalendar (RFC 5545) streams: the feeds
+// calendar apps subscribe to and the files they export.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Statuses of an Event.
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// maxLineOctets is how long a content line may be before it is folded.
+const maxLineOctets = 75
+
+const (
+	dateLayout        = "20060102"
+	dateTimeLayout    = "20060102T150405"
+	utcDateTimeLayout = "20060102T150405Z"
+)
+
+// Calendar is a feed of events.
+type Calendar struct {
+	// Name is what calendar apps show the feed as
+	Name   string
+	Events []Event
+}
+
+// Event is a VEVENT. A recurring event is one with an RRule, and an event
+// with a RecurrenceID overrides the occurrence of the event with the same UID
+// in that slot.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Start, End   time.Time
+	TimeZone     string // IANA name the times are given in; UTC when empty
+	AllDay       bool   // Start and End are dates, at midnight in TimeZone
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time
+	Status       string
+	Organizer    string    // email address
+	Stamp        time.Time // when the feed was made
+}
+
+// Encode writes c to w as an iCalendar stream.
+func (c *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	lw := &lineWriter{w: bw}
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:-//Family Potluck//Calendar//EN")
+	lw.line("CALSCALE:GREGORIAN")
+	lw.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+	for i := range c.Events {
+		c.Events[i].encode(lw)
+	}
+	lw.line("END:VCALENDAR")
+	if lw.err != nil {
+		return lw.err
+	}
+	return bw.Flush()
+}
+
+func (e *Event) encode(lw *lineWriter) {
+	loc := time.UTC
+	if e.TimeZone != "" {
+		if l, err := time.LoadLocation(e.TimeZone); err == nil && l != time.Local {
+			loc = l
+		}
+	}
+
+	lw.line("BEGIN:VEVENT")
+	lw.line("UID:" + e.UID)
+	lw.line("DTSTAMP:" + e.Stamp.UTC().Format(utcDateTimeLayout))
+	if e.RecurrenceID != nil {
+		lw.line(e.timeProperty("RECURRENCE-ID", *e.RecurrenceID, loc))
+	}
+	lw.line(e.timeProperty("DTSTART", e.Start, loc))
+	if !e.End.IsZero() {
+		lw.line(e.timeProperty("DTEND", e.End, loc))
+	}
+	if e.RRule != "" {
+		lw.line("RRULE:" + e.RRule)
+	}
+	for _, ex := range e.ExDates {
+		lw.line(e.timeProperty("EXDATE", ex, loc))
+	}
+	lw.line("SUMMARY:" + escapeText(e.Summary))
+	if e.Location != "" {
+		lw.line("LOCATION:" + escapeText(e.Location))
+	}
+	if e.Description != "" {
+		lw.line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	if e.Status != "" {
+		lw.line("STATUS:" + e.Status)
+	}
+	if e.Organizer != "" {
+		lw.line("ORGANIZER:mailto:" + e.Organizer)
+	}
+	lw.line("END:VEVENT")
+}
+
+// timeProperty formats t as a date if e is all day, and otherwise as a local
+// time in loc with its TZID, or as a UTC time when loc is UTC.
+func (e *Event) timeProperty(name string, t time.Time, loc *time.Location) string {
+	if e.AllDay {
+		return name + ";VALUE=DATE:" + t.In(loc).Format(dateLayout)
+	}
+	if loc == time.UTC {
+		return name + ":" + t.UTC().Format(utcDateTimeLayout)
+	}
+	return name + ";TZID=" + loc.String() + ":" + t.In(loc).Format(dateTimeLayout)
+}
+
+// escapeText escapes a TEXT value.
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(s)
+}
+
+// lineWriter writes content lines ending in CRLF, folded so that none is
+// longer than maxLineOctets, and keeps the first error.
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = lw.w.WriteString(fold(s) + "\r\n")
+}
+
+// fold breaks s into lines of at most maxLineOctets, continuing each with a
+// space, without splitting a UTF-8 character.
+func fold(s string) string {
+	if len(s) <= maxLineOctets {
+		return s
+	}
+	var b strings.Builder
+	limit := maxLineOctets
+	n := 0
+	for _, r := range s {
+		size := utf8.RuneLen(r)
+		if n+size > limit {
+			b.WriteString("\r\n ")
+			// The leading space counts towards the continuation line
+			limit = maxLineOctets - 1
+			n = 0
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	start := time.Date(2025, 6, 1, 18, 0, 0, 0, london)
+	skipped := start.AddDate(0, 0, 7)
+	stamp := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := Calendar{
+		Name: "Smith Family",
+		Events: []Event{
+			{
+				UID:      "series-1@family-potluck",
+				Summary:  "Sunday Dinner",
+				Location: "12 High St, London",
+				Start:    start,
+				End:      start.Add(2 * time.Hour),
+				TimeZone: "Europe/London",
+				RRule:    "FREQ=WEEKLY",
+				Stamp:    stamp,
+			},
+			{
+				UID:          "series-1@family-potluck",
+				Summary:      "Sunday Dinner",
+				Start:        skipped,
+				TimeZone:     "Europe/London",
+				RecurrenceID: &skipped,
+				Status:       StatusCancelled,
+				Stamp:        stamp,
+			},
+			{
+				UID:         "event-2@family-potluck",
+				Summary:     "Picnic",
+				Description: "Bring a blanket; and chairs\nYou're bringing: Salad, Bread",
+				Start:       time.Date(2025, 7, 4, 12, 0, 0, 0, time.UTC),
+				Stamp:       stamp,
+			},
+		},
+	}
+
+	var b strings.Builder
+	if err := c.Encode(&b); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"X-WR-CALNAME:Smith Family\r\n",
+		"DTSTART;TZID=Europe/London:20250601T180000\r\nDTEND;TZID=Europe/London:20250601T200000\r\nRRULE:FREQ=WEEKLY\r\n",
+		"LOCATION:12 High St\\, London\r\n",
+		"RECURRENCE-ID;TZID=Europe/London:20250608T180000\r\n",
+		"STATUS:CANCELLED\r\n",
+		"DTSTART:20250704T120000Z\r\n",
+		"DESCRIPTION:Bring a blanket\\; and chairs\\nYou're bringing: Salad\\, Bread\r\n",
+		"DTSTAMP:20250501T120000Z\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Encode() output is missing %q:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "BEGIN:VEVENT"); n != 3 {
+		t.Errorf("Encode() wrote %d events, want 3", n)
+	}
+}
+
+func TestFold(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("é", 60)
+	folded := fold(line)
+	for i, l := range strings.Split(folded, "\r\n") {
+		if len(l) > maxLineOctets {
+			t.Errorf("line %d is %d octets long", i, len(l))
+		}
+		if i > 0 && !strings.HasPrefix(l, " ") {
+			t.Errorf("continuation line %d doesn't start with a space: %q", i, l)
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Errorf("unfolding gave %q, want %q", unfolded, line)
+	}
+	if short := "SUMMARY:Dinner"; fold(short) != short {
+		t.Errorf("fold() changed a short line to %q", fold(short))
+	}
+}
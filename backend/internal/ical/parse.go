@@ -0,0 +1,291 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLineBytes caps how long an unfolded content line may be.
+const maxLineBytes = 1 << 20
+
+// Parse reads the calendar in r: its name and its VEVENTs, leaving out
+// anything nested in them such as alarms. Floating times, and times in a zone
+// Go doesn't know such as Windows zone names, are taken to be in the
+// calendar's X-WR-TIMEZONE, or in loc if it has none.
+func Parse(r io.Reader, loc *time.Location) (*Calendar, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Calendar{}
+	var event *Event
+	var endSet bool
+	var duration time.Duration
+	depth := 0 // of components nested in the current VEVENT
+	inCalendar := false
+	for _, l := range lines {
+		name, params, value, ok := parseContentLine(l.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid content line", l.number)
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			inCalendar = true
+			continue
+		case !inCalendar:
+			return nil, fmt.Errorf("line %d: not an iCalendar file", l.number)
+		case name == "BEGIN" && event != nil:
+			depth++
+			continue
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event = &Event{}
+			endSet, duration = false, 0
+			continue
+		case name == "END" && event != nil && depth > 0:
+			depth--
+			continue
+		case name == "END" && event != nil:
+			if event.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", l.number, event.Summary)
+			}
+			if !endSet {
+				switch {
+				case duration > 0:
+					event.End = event.Start.Add(duration)
+				case event.AllDay:
+					event.End = event.Start.AddDate(0, 0, 1)
+				}
+			}
+			c.Events = append(c.Events, *event)
+			event = nil
+			continue
+		case event == nil || depth > 0:
+			switch name {
+			case "X-WR-CALNAME":
+				c.Name = unescapeText(value)
+			case "X-WR-TIMEZONE":
+				if l, ok := loadLocation(value); ok {
+					loc = l
+				}
+			}
+			continue
+		}
+
+		var err error
+		switch name {
+		case "UID":
+			event.UID = value
+		case "SUMMARY":
+			event.Summary = unescapeText(value)
+		case "DESCRIPTION":
+			event.Description = unescapeText(value)
+		case "LOCATION":
+			event.Location = unescapeText(value)
+		case "STATUS":
+			event.Status = strings.ToUpper(value)
+		case "RRULE":
+			event.RRule = value
+		case "ORGANIZER":
+			if email, ok := cutPrefixFold(value, "mailto:"); ok {
+				event.Organizer = email
+			}
+		case "DTSTART":
+			var zone string
+			event.Start, zone, event.AllDay, err = parseTime(value, params, loc)
+			event.TimeZone = zone
+		case "DTEND":
+			event.End, _, _, err = parseTime(value, params, loc)
+			endSet = true
+		case "DURATION":
+			duration, err = parseDuration(value)
+		case "RECURRENCE-ID":
+			var t time.Time
+			t, _, _, err = parseTime(value, params, loc)
+			event.RecurrenceID = &t
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				var t time.Time
+				if t, _, _, err = parseTime(v, params, loc); err != nil {
+					break
+				}
+				event.ExDates = append(event.ExDates, t)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid %s: %v", l.number, name, err)
+		}
+	}
+	if !inCalendar {
+		return nil, fmt.Errorf("not an iCalendar file")
+	}
+	return c, nil
+}
+
+type contentLine struct {
+	number int // of the line it starts on
+	text   string
+}
+
+// unfoldLines reads the content lines of r, joining folded ones.
+func unfoldLines(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	var lines []contentLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) {
+			last := &lines[len(lines)-1]
+			if len(last.text)+len(text) > maxLineBytes {
+				return nil, fmt.Errorf("line %d: too long", last.number)
+			}
+			last.text += text[1:]
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		lines = append(lines, contentLine{number: number, text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseContentLine splits a line like DTSTART;TZID=Europe/London:20250601T180000
+// into its upper-cased name, its parameters and its value.
+func parseContentLine(line string) (name string, params map[string]string, value string, ok bool) {
+	params = map[string]string{}
+	quoted := false
+	start := 0
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted || (r != ';' && r != ':'):
+		default:
+			part := line[start:i]
+			if start == 0 {
+				name = strings.ToUpper(part)
+			} else if k, v, found := strings.Cut(part, "="); found {
+				params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+			}
+			start = i + 1
+			if r == ':' {
+				return name, params, line[i+1:], name != ""
+			}
+		}
+	}
+	return "", nil, "", false
+}
+
+// parseTime reads a DATE or DATE-TIME value. It returns the time, the IANA
+// zone it was given in if any, and whether it was a date.
+func parseTime(value string, params map[string]string, loc *time.Location) (t time.Time, zone string, isDate bool, err error) {
+	value = strings.TrimSpace(value)
+	if l, ok := loadLocation(params["TZID"]); ok {
+		loc = l
+	}
+	if loc != time.UTC {
+		zone = loc.String()
+	}
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		t, err = time.ParseInLocation(dateLayout, value, loc)
+		return t, zone, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(utcDateTimeLayout, value)
+		return t, "", false, err
+	}
+	t, err = time.ParseInLocation(dateTimeLayout, value, loc)
+	return t, zone, false, err
+}
+
+// loadLocation loads an IANA zone, also finding it at the end of a TZID with
+// a prefix such as /mozilla.org/20070129_1/Europe/London.
+func loadLocation(tzid string) (*time.Location, bool) {
+	tzid = strings.Trim(strings.TrimSpace(tzid), `"`)
+	for tzid != "" && tzid != "Local" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			return loc, true
+		}
+		_, rest, found := strings.Cut(tzid, "/")
+		if !found {
+			break
+		}
+		tzid = rest
+	}
+	return nil, false
+}
+
+// parseDuration reads a DURATION value such as PT1H30M, P1D or P2W.
+func parseDuration(value string) (time.Duration, error) {
+	s, negative := strings.CutPrefix(strings.TrimPrefix(value, "+"), "-")
+	s, ok := strings.CutPrefix(s, "P")
+	if !ok || s == "" {
+		return 0, fmt.Errorf("%q is not a duration", value)
+	}
+	var d time.Duration
+	inTime := false
+	for s != "" {
+		if s[0] == 'T' {
+			inTime = true
+			s = s[1:]
+			continue
+		}
+		i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return 0, fmt.Errorf("%q is not a duration", value)
+		}
+		n, _ := strconv.Atoi(s[:i])
+		unit := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+		if inTime {
+			unit = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		}
+		u, ok := unit[s[i]]
+		if !ok {
+			return 0, fmt.Errorf("%q is not a duration", value)
+		}
+		d += time.Duration(n) * u
+		s = s[i+1:]
+	}
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
+
+// unescapeText undoes escapeText.
+func unescapeText(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped && (r == 'n' || r == 'N'):
+			b.WriteRune('\n')
+		case escaped:
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+	return b.String()
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
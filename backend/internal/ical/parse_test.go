@@ -0,0 +1,177 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const googleExport = "BEGIN:VCALENDAR\r\n" +
+	"PRODID:-//Google Inc//Google Calendar 70.9054//EN\r\n" +
+	"VERSION:2.0\r\n" +
+	"X-WR-CALNAME:Smith Family\r\n" +
+	"X-WR-TIMEZONE:America/New_York\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:America/New_York\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:19701101T020000\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;TZID=America/New_York:20250105T180000\r\n" +
+	"DTEND;TZID=America/New_York:20250105T200000\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=SU\r\n" +
+	"EXDATE;TZID=America/New_York:20250112T180000,20250119T180000\r\n" +
+	"UID:dinner@google.com\r\n" +
+	"ORGANIZER;CN=Ann:mailto:ann@example.com\r\n" +
+	"SUMMARY:Sunday Dinner\r\n" +
+	"DESCRIPTION:Bring a side\\, or dessert.\\nKids welcome!\r\n" +
+	"LOCATION:12 High St\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"DESCRIPTION:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;TZID=America/New_York:20250127T190000\r\n" +
+	"DURATION:PT1H30M\r\n" +
+	"RECURRENCE-ID;TZID=America/New_York:20250126T180000\r\n" +
+	"UID:dinner@google.com\r\n" +
+	"SUMMARY:Sunday Dinner (on Monday)\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20250704\r\n" +
+	"UID:picnic@google.com\r\n" +
+	"SUMMARY:Fourth of July Picnic with the whole extended family and the neighb\r\n" +
+	" ours\r\n" +
+	"STATUS:CONFIRMED\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	c, err := Parse(strings.NewReader(googleExport), time.UTC)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if c.Name != "Smith Family" {
+		t.Errorf("Name = %q", c.Name)
+	}
+	if len(c.Events) != 3 {
+		t.Fatalf("got %d events, want 3", len(c.Events))
+	}
+
+	dinner := c.Events[0]
+	if !dinner.Start.Equal(time.Date(2025, 1, 5, 18, 0, 0, 0, newYork)) || dinner.End.Sub(dinner.Start) != 2*time.Hour {
+		t.Errorf("dinner is from %v to %v", dinner.Start, dinner.End)
+	}
+	if dinner.TimeZone != "America/New_York" || dinner.RRule != "FREQ=WEEKLY;BYDAY=SU" {
+		t.Errorf("dinner is in %q and recurs %q", dinner.TimeZone, dinner.RRule)
+	}
+	if len(dinner.ExDates) != 2 || !dinner.ExDates[1].Equal(time.Date(2025, 1, 19, 18, 0, 0, 0, newYork)) {
+		t.Errorf("ExDates = %v", dinner.ExDates)
+	}
+	if dinner.Description != "Bring a side, or dessert.\nKids welcome!" {
+		t.Errorf("Description = %q, want the event's rather than its alarm's", dinner.Description)
+	}
+	if dinner.Organizer != "ann@example.com" || dinner.Location != "12 High St" {
+		t.Errorf("Organizer = %q, Location = %q", dinner.Organizer, dinner.Location)
+	}
+
+	moved := c.Events[1]
+	if moved.RecurrenceID == nil || !moved.RecurrenceID.Equal(time.Date(2025, 1, 26, 18, 0, 0, 0, newYork)) {
+		t.Errorf("RecurrenceID = %v", moved.RecurrenceID)
+	}
+	if moved.End.Sub(moved.Start) != 90*time.Minute {
+		t.Errorf("moved dinner lasts %v, want its DURATION", moved.End.Sub(moved.Start))
+	}
+
+	picnic := c.Events[2]
+	if !picnic.AllDay || !picnic.Start.Equal(time.Date(2025, 7, 4, 0, 0, 0, 0, newYork)) || !picnic.End.Equal(picnic.Start.AddDate(0, 0, 1)) {
+		t.Errorf("picnic is from %v to %v, all day %v", picnic.Start, picnic.End, picnic.AllDay)
+	}
+	if picnic.Summary != "Fourth of July Picnic with the whole extended family and the neighbours" {
+		t.Errorf("Summary = %q, want it unfolded", picnic.Summary)
+	}
+}
+
+func TestParse_TimeZones(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	calendar := func(dtstart string) string {
+		return "BEGIN:VCALENDAR\nBEGIN:VEVENT\n" + dtstart + "\nSUMMARY:Tea\nEND:VEVENT\nEND:VCALENDAR\n"
+	}
+	tests := []struct {
+		name     string
+		dtstart  string
+		want     time.Time
+		wantZone string
+	}{
+		{"utc", "DTSTART:20250601T150000Z", time.Date(2025, 6, 1, 15, 0, 0, 0, time.UTC), ""},
+		{"floating", "DTSTART:20250601T150000", time.Date(2025, 6, 1, 15, 0, 0, 0, london), "Europe/London"},
+		{"windows zone name", `DTSTART;TZID="GMT Standard Time":20250601T150000`, time.Date(2025, 6, 1, 15, 0, 0, 0, london), "Europe/London"},
+		{"prefixed zone", "DTSTART;TZID=/mozilla.org/20070129_1/Europe/Paris:20250601T160000", time.Date(2025, 6, 1, 15, 0, 0, 0, london), "Europe/Paris"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(strings.NewReader(calendar(tt.dtstart)), london)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			e := c.Events[0]
+			if !e.Start.Equal(tt.want) || e.TimeZone != tt.wantZone {
+				t.Errorf("Start = %v in %q, want %v in %q", e.Start, e.TimeZone, tt.want, tt.wantZone)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name, input string
+	}{
+		{"not a calendar", "Name,Date\nDinner,2025-06-01\n"},
+		{"bad start", "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:June 1st\nEND:VEVENT\nEND:VCALENDAR\n"},
+		{"no start", "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Tea\nEND:VEVENT\nEND:VCALENDAR\n"},
+		{"bad duration", "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20250601T150000Z\nDURATION:1 hour\nEND:VEVENT\nEND:VCALENDAR\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.input), time.UTC); err == nil {
+				t.Error("Parse() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	start := time.Date(2025, 6, 1, 18, 0, 0, 0, time.UTC)
+	want := Event{
+		UID:         "event-1@family-potluck",
+		Summary:     "Dinner; at Ann's, 6pm",
+		Description: `Bring a dish\and a chair` + "\nThanks!",
+		Start:       start,
+		End:         start.Add(2 * time.Hour),
+		RRule:       "FREQ=MONTHLY;BYDAY=1SU",
+		ExDates:     []time.Time{start.AddDate(0, 1, 0)},
+		Stamp:       start,
+	}
+	var b strings.Builder
+	if err := (&Calendar{Events: []Event{want}}).Encode(&b); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Parse(strings.NewReader(b.String()), time.UTC)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	got := c.Events[0]
+	if got.Summary != want.Summary || got.Description != want.Description || got.RRule != want.RRule ||
+		!got.Start.Equal(want.Start) || !got.End.Equal(want.End) || len(got.ExDates) != 1 || !got.ExDates[0].Equal(want.ExDates[0]) {
+		t.Errorf("Parse(Encode()) = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+package identity
+
+import (
+	"context"
+	"strings"
+)
+
+// DevUser is a seeded account the dev provider can sign in as.
+type DevUser struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+var defaultDevUsers = []DevUser{
+	{Email: "alice@example.com", Name: "Alice Dev"},
+	{Email: "bob@example.com", Name: "Bob Dev"},
+	{Email: "carol@example.com", Name: "Carol Dev"},
+}
+
+// ParseDevUsers reads a comma-separated list of "email" or "email:Name"
+// entries, falling back to the default seeded users when spec is empty.
+func ParseDevUsers(spec string) []DevUser {
+	var users []DevUser
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		email, name, _ := strings.Cut(entry, ":")
+		email = normalizeEmail(email)
+		if name == "" {
+			name, _, _ = strings.Cut(email, "@")
+		}
+		users = append(users, DevUser{Email: email, Name: strings.TrimSpace(name)})
+	}
+	if len(users) == 0 {
+		return defaultDevUsers
+	}
+	return users
+}
+
+// DevProvider signs in any of a fixed set of users without a password. It is
+// meant for local development and CI and must never be enabled in production.
+type DevProvider struct {
+	users []DevUser
+}
+
+func NewDevProvider(users []DevUser) *DevProvider {
+	return &DevProvider{users: users}
+}
+
+func (p *DevProvider) Name() string { return "dev" }
+
+func (p *DevProvider) Users() []DevUser {
+	return p.users
+}
+
+func (p *DevProvider) Authenticate(ctx context.Context, creds Credentials) (*Identity, error) {
+	email := normalizeEmail(creds.Email)
+	for _, u := range p.users {
+		if u.Email == email {
+			return &Identity{
+				Provider:      p.Name(),
+				Subject:       u.Email,
+				Email:         u.Email,
+				EmailVerified: true,
+				Name:          u.Name,
+			}, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
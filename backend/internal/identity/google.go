@@ -0,0 +1,53 @@
+package identity
+
+import (
+	"context"
+
+	"google.golang.org/api/idtoken"
+)
+
+type TokenValidator interface {
+	Validate(ctx context.Context, idToken string, audience string) (*idtoken.Payload, error)
+}
+
+type RealTokenValidator struct{}
+
+func (v *RealTokenValidator) Validate(ctx context.Context, idToken string, audience string) (*idtoken.Payload, error) {
+	return idtoken.Validate(ctx, idToken, audience)
+}
+
+// GoogleProvider verifies Google Sign-In ID tokens.
+type GoogleProvider struct {
+	Validator TokenValidator
+	ClientID  string
+}
+
+func NewGoogleProvider(validator TokenValidator, clientID string) *GoogleProvider {
+	return &GoogleProvider{Validator: validator, ClientID: clientID}
+}
+
+func (p *GoogleProvider) Name() string { return "google" }
+
+func (p *GoogleProvider) Authenticate(ctx context.Context, creds Credentials) (*Identity, error) {
+	payload, err := p.Validator.Validate(ctx, creds.IDToken, p.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	email, _ := payload.Claims["email"].(string)
+	if email == "" {
+		return nil, ErrInvalidCredentials
+	}
+	name, _ := payload.Claims["name"].(string)
+	picture, _ := payload.Claims["picture"].(string)
+	verified, _ := payload.Claims["email_verified"].(bool)
+
+	return &Identity{
+		Provider:      p.Name(),
+		Subject:       payload.Subject,
+		Email:         normalizeEmail(email),
+		EmailVerified: verified,
+		Name:          name,
+		Picture:       picture,
+	}, nil
+}
@@ -0,0 +1,97 @@
+// Package identity verifies sign-in credentials from external identity
+// providers and reduces them to a provider-neutral Identity.
+package identity
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Identity is a verified claim, made by a provider, about who is signing in.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+// Credentials is the body posted to /auth/{provider}. Token-based providers
+// read IDToken; the dev provider reads Email.
+type Credentials struct {
+	IDToken string `json:"id_token"`
+	Email   string `json:"email"`
+}
+
+type Provider interface {
+	Name() string
+	Authenticate(ctx context.Context, creds Credentials) (*Identity, error)
+}
+
+// Registry holds the providers enabled for this deployment, keyed by name.
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names returns the enabled provider names in a stable order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewRegistryFromEnv enables Google, plus a generic OIDC provider when
+// OIDC_ISSUER and OIDC_CLIENT_ID are set and the dev provider when AUTH_DEV_PROVIDER=true outside
+// production.
+func NewRegistryFromEnv() *Registry {
+	providers := []Provider{
+		NewGoogleProvider(&RealTokenValidator{}, os.Getenv("GOOGLE_CLIENT_ID")),
+	}
+
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		p, err := NewOIDCProvider(OIDCConfig{
+			Name:     os.Getenv("OIDC_PROVIDER_NAME"),
+			Issuer:   issuer,
+			JWKSURL:  os.Getenv("OIDC_JWKS_URL"),
+			ClientID: os.Getenv("OIDC_CLIENT_ID"),
+		})
+		if err != nil {
+			log.Printf("Not enabling OIDC sign-in: %v", err)
+		} else {
+			providers = append(providers, p)
+		}
+	}
+
+	if os.Getenv("AUTH_DEV_PROVIDER") == "true" && os.Getenv("APP_ENV") != "production" {
+		providers = append(providers, NewDevProvider(ParseDevUsers(os.Getenv("AUTH_DEV_USERS"))))
+	}
+
+	return NewRegistry(providers...)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
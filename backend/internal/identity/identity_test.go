@@ -0,0 +1,191 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestParseDevUsers(t *testing.T) {
+	users := ParseDevUsers(" Alice@Example.com:Alice A, bob@example.com ")
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(users))
+	}
+	if users[0].Email != "alice@example.com" || users[0].Name != "Alice A" {
+		t.Errorf("unexpected first user: %+v", users[0])
+	}
+	if users[1].Name != "bob" {
+		t.Errorf("expected name derived from email, got %q", users[1].Name)
+	}
+
+	if got := ParseDevUsers(""); len(got) != len(defaultDevUsers) {
+		t.Errorf("expected default users, got %v", got)
+	}
+}
+
+func TestDevProvider(t *testing.T) {
+	p := NewDevProvider([]DevUser{{Email: "alice@example.com", Name: "Alice"}})
+
+	ident, err := p.Authenticate(context.Background(), Credentials{Email: "ALICE@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ident.Email != "alice@example.com" || ident.Provider != "dev" || !ident.EmailVerified {
+		t.Errorf("unexpected identity: %+v", ident)
+	}
+
+	if _, err := p.Authenticate(context.Background(), Credentials{Email: "mallory@example.com"}); err != ErrInvalidCredentials {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+// newTestIssuer serves an openid-configuration and JWKS for a freshly
+// generated RSA key.
+func newTestIssuer(t *testing.T) (*httptest.Server, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": srv.URL, "jwks_uri": srv.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	return srv, key
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, claims oidcClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestOIDCProvider(t *testing.T) {
+	srv, key := newTestIssuer(t)
+	p, err := NewOIDCProvider(OIDCConfig{Issuer: srv.URL, ClientID: "potluck"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	valid := oidcClaims{
+		Email:         "Dana@Example.com",
+		EmailVerified: true,
+		Name:          "Dana",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    srv.URL,
+			Subject:   "dana-123",
+			Audience:  jwt.ClaimStrings{"potluck"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	wrongAudience := valid
+	wrongAudience.Audience = jwt.ClaimStrings{"someone-else"}
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	wrongIssuer := valid
+	wrongIssuer.Issuer = "https://evil.example.com"
+
+	tests := []struct {
+		name    string
+		claims  oidcClaims
+		wantErr bool
+	}{
+		{"valid", valid, false},
+		{"wrong audience", wrongAudience, true},
+		{"expired", expired, true},
+		{"wrong issuer", wrongIssuer, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ident, err := p.Authenticate(context.Background(), Credentials{IDToken: signTestToken(t, key, tt.claims)})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected verification to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ident.Subject != "dana-123" || ident.Email != "dana@example.com" || ident.Provider != "oidc" {
+				t.Errorf("unexpected identity: %+v", ident)
+			}
+		})
+	}
+}
+
+func TestOIDCProvider_UnknownKey(t *testing.T) {
+	srv, _ := newTestIssuer(t)
+	p, err := NewOIDCProvider(OIDCConfig{Issuer: srv.URL, JWKSURL: srv.URL + "/jwks", ClientID: "potluck"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fetches := 0
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		fetches++
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	claims := oidcClaims{
+		Email: "eve@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    srv.URL,
+			Subject:   "eve",
+			Audience:  jwt.ClaimStrings{"potluck"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+
+	for _, kid := range []string{"test-key", "made-up-1", "made-up-2"} {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = kid
+		signed, _ := token.SignedString(otherKey)
+		if _, err := p.Authenticate(context.Background(), Credentials{IDToken: signed}); err == nil {
+			t.Errorf("expected a token signed by an unknown key to be rejected (kid %q)", kid)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected unknown kids to refetch the key set at most once a minute, got %d fetches", fetches)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestNewOIDCProvider_RequiresClientID(t *testing.T) {
+	if _, err := NewOIDCProvider(OIDCConfig{Issuer: "https://issuer.example.com"}); err == nil {
+		t.Error("expected a provider without a client ID to be refused")
+	}
+}
@@ -17,6 +17,32 @@ type FamilyMember struct {
 	DietaryPreferences []string             `json:"dietary_preferences" bson:"dietary_preferences"` // e.g., ["Vegan", "Gluten-Free"]
 	GroupIDs           []primitive.ObjectID `json:"group_ids" bson:"group_ids,omitempty"`
 	HouseholdID        *primitive.ObjectID  `json:"household_id,omitempty" bson:"household_id,omitempty"`
+	Identities         []LinkedIdentity     `json:"identities,omitempty" bson:"identities,omitempty"`
+	Unavailable        []Unavailability     `json:"unavailable,omitempty" bson:"unavailable,omitempty"` // Days they can't host
+	CalendarTokenHash  string               `json:"-" bson:"calendar_token_hash,omitempty"`             // Secret in their calendar feed URLs
+}
+
+// Unavailability is a stretch of days a member or household can't host, from
+// Start to End inclusive as YYYY-MM-DD dates. Days are compared with the
+// date of an event where it happens.
+type Unavailability struct {
+	Start  string `json:"start" bson:"start"`
+	End    string `json:"end" bson:"end"`
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+// Covers reports whether day, a YYYY-MM-DD date, falls in u.
+func (u Unavailability) Covers(day string) bool {
+	return u.Start <= day && day <= u.End
+}
+
+// LinkedIdentity is a verified sign-in (provider + subject) attached to a
+// FamilyMember. A member may have several, e.g. Google and a work OIDC login.
+type LinkedIdentity struct {
+	Provider string    `json:"provider" bson:"provider"`
+	Subject  string    `json:"-" bson:"subject"`
+	Email    string    `json:"email" bson:"email"`
+	LinkedAt time.Time `json:"linked_at" bson:"linked_at"`
 }
 
 // SafeFamilyMember is a version of FamilyMember with sensitive fields omitted for API responses
@@ -28,6 +54,9 @@ type SafeFamilyMember struct {
 	DietaryPreferences []string             `json:"dietary_preferences"`
 	GroupIDs           []primitive.ObjectID `json:"group_ids"`
 	HouseholdID        *primitive.ObjectID  `json:"household_id,omitempty"`
+	Identities         []LinkedIdentity     `json:"identities,omitempty"`
+	Unavailable        []Unavailability     `json:"unavailable,omitempty"`
+	CalendarFeed       bool                 `json:"calendar_feed"` // Whether they have calendar feed URLs
 }
 
 func (f *FamilyMember) ToSafe() SafeFamilyMember {
@@ -39,6 +68,9 @@ func (f *FamilyMember) ToSafe() SafeFamilyMember {
 		DietaryPreferences: f.DietaryPreferences,
 		GroupIDs:           f.GroupIDs,
 		HouseholdID:        f.HouseholdID,
+		Identities:         f.Identities,
+		Unavailable:        f.Unavailable,
+		CalendarFeed:       f.CalendarTokenHash != "",
 	}
 }
 
@@ -48,6 +80,8 @@ type Household struct {
 	Address   string               `json:"address" bson:"address"`
 	MemberIDs []primitive.ObjectID `json:"member_ids" bson:"member_ids"` // IDs of Family members
 	Members   []SafeFamilyMember   `json:"members,omitempty" bson:"-"`   // Full member details for response
+	// Unavailable are days no one in the household can host
+	Unavailable []Unavailability `json:"unavailable,omitempty" bson:"unavailable,omitempty"`
 }
 
 type Group struct {
@@ -56,6 +90,14 @@ type Group struct {
 	AdminIDs []primitive.ObjectID `json:"admin_ids" bson:"admin_ids"`
 	AdminID  primitive.ObjectID   `json:"admin_id,omitempty" bson:"admin_id,omitempty"` // Legacy field
 	JoinCode string               `json:"join_code" bson:"join_code"`
+	TimeZone string               `json:"time_zone,omitempty" bson:"time_zone,omitempty"` // IANA zone new events default to
+	// OccurrencesAhead is how many occurrences of each recurring series are
+	// kept scheduled; unset means the default
+	OccurrencesAhead int `json:"occurrences_ahead,omitempty" bson:"occurrences_ahead,omitempty"`
+	// HostOrder is the order admins have set for members to take turns
+	// hosting, and HostOptOutIDs the members who don't host
+	HostOrder     []primitive.ObjectID `json:"host_order,omitempty" bson:"host_order,omitempty"`
+	HostOptOutIDs []primitive.ObjectID `json:"host_opt_out_ids,omitempty" bson:"host_opt_out_ids,omitempty"`
 }
 
 type Event struct {
@@ -63,17 +105,67 @@ type Event struct {
 	GroupID         primitive.ObjectID   `json:"group_id" bson:"group_id"`
 	Name            string               `json:"name" bson:"name"`
 	Date            time.Time            `json:"date" bson:"date"`
-	Type            string               `json:"type" bson:"type"` // Dinner, Lunch, Coffee
+	TimeZone        string               `json:"time_zone,omitempty" bson:"time_zone,omitempty"`               // IANA zone, e.g. Europe/London
+	DurationMinutes int                  `json:"duration_minutes,omitempty" bson:"duration_minutes,omitempty"` // Unset means until the end of the day
+	Type            string               `json:"type" bson:"type"`                                             // Dinner, Lunch, Coffee
 	HostID          primitive.ObjectID   `json:"host_id" bson:"host_id"`
 	HostName        string               `json:"host_name,omitempty" bson:"-"`
 	HostHouseholdID *primitive.ObjectID  `json:"host_household_id,omitempty" bson:"-"`
 	Location        string               `json:"location" bson:"location"`
 	Description     string               `json:"description" bson:"description"`
-	Recurrence      string               `json:"recurrence,omitempty" bson:"recurrence,omitempty"`       // Weekly, Bi-Weekly
-	RecurrenceID    primitive.ObjectID   `json:"recurrence_id,omitempty" bson:"recurrence_id,omitempty"` // ID linking the series
+	Recurrence      string               `json:"recurrence,omitempty" bson:"recurrence,omitempty"`             // RRULE, e.g. FREQ=MONTHLY;BYDAY=2SU
+	RecurrenceID    primitive.ObjectID   `json:"recurrence_id,omitempty" bson:"recurrence_id,omitempty"`       // ID linking the series
+	RecurrenceStart *time.Time           `json:"recurrence_start,omitempty" bson:"recurrence_start,omitempty"` // First occurrence (DTSTART)
+	ExDates         []time.Time          `json:"exdates,omitempty" bson:"exdates,omitempty"`                   // Days skipped by the series
+	HostRotation    string               `json:"host_rotation,omitempty" bson:"host_rotation,omitempty"`       // How the series picks hosts: members, ordered, household, fewest_hosted
+	OriginalDate    *time.Time           `json:"original_date,omitempty" bson:"original_date,omitempty"`       // Slot in the series when moved on its own (RECURRENCE-ID)
 	GuestIDs        []primitive.ObjectID `json:"guest_ids,omitempty" bson:"guest_ids,omitempty"`
 	GuestJoinCode   string               `json:"guest_join_code" bson:"guest_join_code"`
-	Status          string               `json:"status" bson:"status"` // scheduled, completed, cancelled
+	Status          string               `json:"status" bson:"status"`                                   // scheduled, completed, cancelled
+	CancelReason    string               `json:"cancel_reason,omitempty" bson:"cancel_reason,omitempty"` // Why a cancelled event isn't going ahead
+}
+
+// Series is the template a recurring event's occurrences are made from. Its
+// ID is the occurrences' RecurrenceID. Edits to a single occurrence leave it
+// alone, so later occurrences don't inherit them.
+type Series struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id"`
+	GroupID         primitive.ObjectID `json:"group_id" bson:"group_id"`
+	Name            string             `json:"name" bson:"name"`
+	Type            string             `json:"type" bson:"type"`
+	Location        string             `json:"location" bson:"location"`
+	Description     string             `json:"description" bson:"description"`
+	TimeZone        string             `json:"time_zone,omitempty" bson:"time_zone,omitempty"`
+	DurationMinutes int                `json:"duration_minutes,omitempty" bson:"duration_minutes,omitempty"`
+	Recurrence      string             `json:"recurrence" bson:"recurrence"`
+	Start           time.Time          `json:"start" bson:"start"` // DTSTART; also gives the time of day
+	ExDates         []time.Time        `json:"exdates,omitempty" bson:"exdates,omitempty"`
+	HostRotation    string             `json:"host_rotation,omitempty" bson:"host_rotation,omitempty"`
+}
+
+// TimeLocation returns the event's time zone, or UTC if it has none.
+func (e *Event) TimeLocation() *time.Location {
+	if e.TimeZone != "" {
+		if loc, err := time.LoadLocation(e.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// End returns when the event finishes: after its duration, or if it has none,
+// at midnight ending its day in its own time zone.
+func (e *Event) End() time.Time {
+	if e.DurationMinutes > 0 {
+		return e.Date.Add(time.Duration(e.DurationMinutes) * time.Minute)
+	}
+	y, m, d := e.Date.In(e.TimeLocation()).Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, e.TimeLocation())
+}
+
+// HasEnded reports whether the event was over by now.
+func (e *Event) HasEnded(now time.Time) bool {
+	return !now.Before(e.End())
 }
 
 type RSVP struct {
@@ -122,3 +214,59 @@ type ChatMessage struct {
 	Content        string             `json:"content" bson:"content"`
 	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
 }
+
+// Session is a signed-in device. Access tokens carry the session ID so the
+// session can be revoked server-side; the refresh token is stored hashed.
+type Session struct {
+	ID                       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	FamilyMemberID           primitive.ObjectID `json:"family_id" bson:"family_id"`
+	RefreshTokenHash         string             `json:"-" bson:"refresh_token_hash"`
+	PreviousRefreshTokenHash string             `json:"-" bson:"previous_refresh_token_hash,omitempty"`
+	UserAgent                string             `json:"user_agent" bson:"user_agent"`
+	IPAddress                string             `json:"ip_address" bson:"ip_address"`
+	CreatedAt                time.Time          `json:"created_at" bson:"created_at"`
+	LastUsedAt               time.Time          `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt                time.Time          `json:"expires_at" bson:"expires_at"`
+	RevokedAt                *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	Current                  bool               `json:"current" bson:"-"`
+}
+
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// APIToken is a named personal access token for scripts and integrations.
+// Only a hash of the token is stored; Prefix lets the owner recognise it.
+type APIToken struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	FamilyMemberID primitive.ObjectID `json:"family_id" bson:"family_id"`
+	Name           string             `json:"name" bson:"name"`
+	Prefix         string             `json:"prefix" bson:"prefix"`
+	TokenHash      string             `json:"-" bson:"token_hash"`
+	Scopes         []string           `json:"scopes" bson:"scopes"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	LastUsedAt     *time.Time         `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	ExpiresAt      *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+}
+
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// RealtimeMessage is a published WebSocket message kept so that clients which
+// reconnect can catch up on what they missed. Seq increases per topic.
+type RealtimeMessage struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Topic     string             `json:"topic" bson:"topic"`
+	Seq       uint64             `json:"seq" bson:"seq"`
+	Message   []byte             `json:"message" bson:"message"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// Lease gives one server instance the sole right to run a background job
+// until it expires, so replicas don't repeat each other's work.
+type Lease struct {
+	Name      string    `json:"name" bson:"_id"`
+	Holder    string    `json:"holder" bson:"holder"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
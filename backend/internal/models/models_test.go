@@ -70,3 +70,27 @@ func TestEventStruct(t *testing.T) {
 		t.Errorf("Expected Status %v, got %v", event.Status, decoded.Status)
 	}
 }
+
+func TestEventEnd(t *testing.T) {
+	date := time.Date(2025, time.March, 1, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		event Event
+		want  time.Time
+	}{
+		{"duration", Event{Date: date, DurationMinutes: 150}, date.Add(150 * time.Minute)},
+		{"end of the day in UTC", Event{Date: date}, time.Date(2025, time.March, 2, 0, 0, 0, 0, time.UTC)},
+		// 23:00 UTC is already the next morning in Tokyo, whose day ends at 15:00 UTC
+		{"end of the day in its zone", Event{Date: date, TimeZone: "Asia/Tokyo"}, time.Date(2025, time.March, 2, 15, 0, 0, 0, time.UTC)},
+		{"unknown zone", Event{Date: date, TimeZone: "Nowhere/Special"}, time.Date(2025, time.March, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := tt.event.End(); !got.Equal(tt.want) {
+			t.Errorf("%s: End() = %v, want %v", tt.name, got, tt.want)
+		}
+		if tt.event.HasEnded(tt.want.Add(-time.Second)) || !tt.event.HasEnded(tt.want) {
+			t.Errorf("%s: HasEnded disagrees with End", tt.name)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package realtime
+
+import (
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Version is the envelope format. It changes only when a message type loses
+// or renames a field; added fields and message types keep the version.
+const Version = 1
+
+// Envelope wraps every message sent to clients. The hub adds a "seq" field
+// on delivery.
+type Envelope struct {
+	Version   int       `json:"version"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	// Actor is the member whose action caused the message, or nil for
+	// messages the server sends on its own.
+	Actor *Actor  `json:"actor,omitempty"`
+	Data  Message `json:"data"`
+}
+
+type Actor struct {
+	ID   primitive.ObjectID `json:"id"`
+	Name string             `json:"name"`
+}
+
+// NewEnvelope wraps msg as sent now by actor, which may be nil.
+func NewEnvelope(actor *models.FamilyMember, msg Message) Envelope {
+	e := Envelope{
+		Version:   Version,
+		Type:      msg.MessageType(),
+		Timestamp: time.Now().UTC(),
+		Data:      msg,
+	}
+	if actor != nil {
+		e.Actor = &Actor{ID: actor.ID, Name: actor.Name}
+	}
+	return e
+}
+
+// Publisher is satisfied by *websocket.Hub.
+type Publisher interface {
+	Publish(message []byte, topics ...string)
+}
+
+// Publish sends msg, caused by actor, to the subscribers of topics.
+func Publish(p Publisher, actor *models.FamilyMember, msg Message, topics ...string) {
+	b, err := json.Marshal(NewEnvelope(actor, msg))
+	if err != nil {
+		log.Printf("realtime: encoding %s: %v", msg.MessageType(), err)
+		return
+	}
+	p.Publish(b, topics...)
+}
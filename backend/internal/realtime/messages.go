@@ -0,0 +1,132 @@
+// Package realtime defines every message the backend pushes to clients over
+// the WebSocket and Server-Sent Event streams, and the envelope they travel
+// in. Handlers publish through Publish rather than building payloads by hand,
+// so each message type has exactly one shape.
+package realtime
+
+import (
+	"family-potluck/backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Message is the payload of an envelope.
+type Message interface {
+	// MessageType is the envelope's "type", e.g. "dish_pledged".
+	MessageType() string
+}
+
+// EventCreated announces a new event to its group.
+type EventCreated models.Event
+
+// EventUpdated carries an event after any change to it.
+type EventUpdated models.Event
+
+// EventDeleted announces that an event was removed, or replaced by the next
+// one in its series.
+type EventDeleted struct {
+	EventID primitive.ObjectID `json:"event_id"`
+	GroupID primitive.ObjectID `json:"group_id"`
+}
+
+// EventCancelled carries an event that was called off, with the reason in
+// cancel_reason. Events that go ahead again after all are sent as updates.
+type EventCancelled models.Event
+
+// SuggestionsStarted and SuggestionsFinished bracket the dish suggestions
+// generated for a new event.
+type SuggestionsStarted struct {
+	EventID primitive.ObjectID `json:"event_id"`
+}
+
+type SuggestionsFinished struct {
+	EventID primitive.ObjectID `json:"event_id"`
+}
+
+// DishAdded carries a dish added by a member or suggested for the event.
+type DishAdded models.Dish
+
+// DishPledged announces who is now bringing a dish.
+type DishPledged struct {
+	DishID      primitive.ObjectID `json:"dish_id"`
+	EventID     primitive.ObjectID `json:"event_id"`
+	BringerID   primitive.ObjectID `json:"bringer_id"`
+	BringerName string             `json:"bringer_name"`
+	DishName    string             `json:"dish_name"`
+}
+
+// DishUnpledged announces that nobody is bringing a dish any more.
+type DishUnpledged struct {
+	DishID   primitive.ObjectID `json:"dish_id"`
+	EventID  primitive.ObjectID `json:"event_id"`
+	DishName string             `json:"dish_name"`
+}
+
+type DishDeleted struct {
+	DishID  primitive.ObjectID `json:"dish_id"`
+	EventID primitive.ObjectID `json:"event_id"`
+}
+
+// RSVPUpdated carries a member's RSVP, with their name filled in.
+type RSVPUpdated models.RSVP
+
+type SwapCreated models.SwapRequest
+
+// SwapUpdated carries a swap request after it was approved or rejected.
+type SwapUpdated models.SwapRequest
+
+type ChatMessageSent models.ChatMessage
+
+// PresenceChanged lists everyone who currently has an event open. It is sent
+// whenever someone arrives or leaves, and is not numbered or replayed.
+type PresenceChanged struct {
+	EventID primitive.ObjectID `json:"event_id"`
+	Members []Actor            `json:"members"`
+}
+
+// TypingChanged says that a member started or stopped typing in an event's
+// chat. Like PresenceChanged it is not numbered or replayed; clients should
+// stop showing the indicator if it isn't repeated within a few seconds.
+type TypingChanged struct {
+	EventID primitive.ObjectID `json:"event_id"`
+	Member  Actor              `json:"member"`
+	Typing  bool               `json:"typing"`
+}
+
+func (EventCreated) MessageType() string        { return "event_created" }
+func (EventUpdated) MessageType() string        { return "event_updated" }
+func (EventDeleted) MessageType() string        { return "event_deleted" }
+func (EventCancelled) MessageType() string      { return "event_cancelled" }
+func (SuggestionsStarted) MessageType() string  { return "suggestions_started" }
+func (SuggestionsFinished) MessageType() string { return "suggestions_finished" }
+func (DishAdded) MessageType() string           { return "dish_added" }
+func (DishPledged) MessageType() string         { return "dish_pledged" }
+func (DishUnpledged) MessageType() string       { return "dish_unpledged" }
+func (DishDeleted) MessageType() string         { return "dish_deleted" }
+func (RSVPUpdated) MessageType() string         { return "rsvp_updated" }
+func (SwapCreated) MessageType() string         { return "swap_created" }
+func (SwapUpdated) MessageType() string         { return "swap_updated" }
+func (ChatMessageSent) MessageType() string     { return "new_chat_message" }
+func (PresenceChanged) MessageType() string     { return "presence_changed" }
+func (TypingChanged) MessageType() string       { return "typing_changed" }
+
+// Catalogue lists one value of every message type, in the order they appear
+// in the schema.
+var Catalogue = []Message{
+	EventCreated{},
+	EventUpdated{},
+	EventDeleted{},
+	EventCancelled{},
+	SuggestionsStarted{},
+	SuggestionsFinished{},
+	DishAdded{},
+	DishPledged{},
+	DishUnpledged{},
+	DishDeleted{},
+	RSVPUpdated{},
+	SwapCreated{},
+	SwapUpdated{},
+	ChatMessageSent{},
+	PresenceChanged{},
+	TypingChanged{},
+}
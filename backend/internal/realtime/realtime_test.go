@@ -0,0 +1,142 @@
+package realtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type recordingPublisher struct {
+	message []byte
+	topics  []string
+}
+
+func (p *recordingPublisher) Publish(message []byte, topics ...string) {
+	p.message, p.topics = message, topics
+}
+
+func TestPublish(t *testing.T) {
+	actor := &models.FamilyMember{ID: primitive.NewObjectID(), Name: "The Smiths"}
+	dishID, eventID := primitive.NewObjectID(), primitive.NewObjectID()
+
+	p := &recordingPublisher{}
+	before := time.Now().UTC()
+	Publish(p, actor, DishPledged{DishID: dishID, EventID: eventID, BringerID: actor.ID, BringerName: actor.Name, DishName: "Pie"}, "event:e1", "group:g1")
+
+	if len(p.topics) != 2 || p.topics[0] != "event:e1" || p.topics[1] != "group:g1" {
+		t.Errorf("Published to %v", p.topics)
+	}
+
+	var got struct {
+		Version   int                    `json:"version"`
+		Type      string                 `json:"type"`
+		Timestamp time.Time              `json:"timestamp"`
+		Actor     *Actor                 `json:"actor"`
+		Data      map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(p.message, &got); err != nil {
+		t.Fatalf("Published invalid JSON %s: %v", p.message, err)
+	}
+	if got.Version != Version || got.Type != "dish_pledged" {
+		t.Errorf("Envelope version/type = %d/%q", got.Version, got.Type)
+	}
+	if got.Timestamp.Before(before.Truncate(time.Second)) {
+		t.Errorf("Timestamp %v is before publishing", got.Timestamp)
+	}
+	if got.Actor == nil || got.Actor.ID != actor.ID || got.Actor.Name != actor.Name {
+		t.Errorf("Actor = %+v", got.Actor)
+	}
+	for key, want := range map[string]interface{}{
+		"dish_id":      dishID.Hex(),
+		"event_id":     eventID.Hex(),
+		"bringer_id":   actor.ID.Hex(),
+		"bringer_name": "The Smiths",
+		"dish_name":    "Pie",
+	} {
+		if got.Data[key] != want {
+			t.Errorf("data.%s = %v, want %v", key, got.Data[key], want)
+		}
+	}
+}
+
+func TestNewEnvelopeWithoutActor(t *testing.T) {
+	b, err := json.Marshal(NewEnvelope(nil, SuggestionsStarted{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte(`"actor"`)) {
+		t.Errorf("Expected no actor for a server message, got %s", b)
+	}
+}
+
+func TestCatalogueTypesAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, msg := range Catalogue {
+		if seen[msg.MessageType()] {
+			t.Errorf("Duplicate message type %q", msg.MessageType())
+		}
+		seen[msg.MessageType()] = true
+	}
+}
+
+func TestSchemaDescribesEnvelopes(t *testing.T) {
+	b, err := Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Defs map[string]struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	actor := &models.FamilyMember{ID: primitive.NewObjectID(), Name: "The Smiths"}
+	for _, msg := range Catalogue {
+		def, ok := schema.Defs[msg.MessageType()]
+		if !ok {
+			t.Errorf("Schema has no definition for %q", msg.MessageType())
+			continue
+		}
+		encoded, _ := json.Marshal(NewEnvelope(actor, msg))
+		var fields map[string]json.RawMessage
+		json.Unmarshal(encoded, &fields)
+		for name := range fields {
+			if _, ok := def.Properties[name]; !ok {
+				t.Errorf("%s: envelope field %q missing from schema", msg.MessageType(), name)
+			}
+		}
+		for _, name := range def.Required {
+			if _, ok := fields[name]; !ok {
+				t.Errorf("%s: required field %q missing from envelope", msg.MessageType(), name)
+			}
+		}
+	}
+}
+
+// The frontend's copy is generated; run `go generate ./internal/realtime`
+// after changing a message.
+func TestGeneratedSchemaIsUpToDate(t *testing.T) {
+	want, err := Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../../../frontend/src/realtime/schema.json")
+	if os.IsNotExist(err) {
+		t.Skip("frontend not checked out")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("frontend/src/realtime/schema.json is stale; run go generate ./internal/realtime")
+	}
+}
@@ -0,0 +1,128 @@
+package realtime
+
+//go:generate go run ../../cmd/realtime-schema -o ../../../frontend/src/realtime/schema.json
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// Schema returns a JSON Schema (draft 2020-12) matching any envelope in the
+// Catalogue, built from the Go types so it can't drift from them.
+func Schema() ([]byte, error) {
+	defs := map[string]interface{}{
+		"actor": schemaFor(reflect.TypeOf(Actor{})),
+		"seq": map[string]interface{}{
+			"description":          "The message's sequence number on each topic it was published to.",
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "integer", "minimum": 0},
+		},
+	}
+	oneOf := make([]interface{}, 0, len(Catalogue))
+	for _, msg := range Catalogue {
+		name := msg.MessageType()
+		defs[name] = map[string]interface{}{
+			"type":     "object",
+			"required": []string{"version", "type", "timestamp", "data"},
+			"properties": map[string]interface{}{
+				"version":   map[string]interface{}{"const": Version},
+				"type":      map[string]interface{}{"const": name},
+				"timestamp": map[string]interface{}{"type": "string", "format": "date-time"},
+				"actor":     map[string]interface{}{"$ref": "#/$defs/actor"},
+				"data":      schemaFor(reflect.TypeOf(msg)),
+				"seq":       map[string]interface{}{"$ref": "#/$defs/seq"},
+			},
+		}
+		oneOf = append(oneOf, map[string]interface{}{"$ref": "#/$defs/" + name})
+	}
+
+	schema := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "Family Potluck realtime message",
+		"oneOf":   oneOf,
+		"$defs":   defs,
+	}
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// schemaFor describes how encoding/json renders a value of type t.
+func schemaFor(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case objectIDType:
+		return map[string]interface{}{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(schemaFor(t.Elem()))
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		// nil slices encode as null
+		return nullable(map[string]interface{}{"type": "array", "items": schemaFor(t.Elem())})
+	case reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return nullable(map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem())})
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := []string{}
+		addFields(t, properties, &required)
+		return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	}
+	return map[string]interface{}{}
+}
+
+// addFields adds t's JSON fields, promoting those of embedded structs the way
+// encoding/json does.
+func addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addFields(f.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = schemaFor(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func nullable(s map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"anyOf": []interface{}{s, map[string]interface{}{"type": "null"}}}
+}
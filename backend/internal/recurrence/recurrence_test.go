@@ -0,0 +1,197 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Weekly", "FREQ=WEEKLY"},
+		{"Bi-Weekly", "FREQ=WEEKLY;INTERVAL=2"},
+		{"RRULE:FREQ=MONTHLY;BYDAY=2SU", "FREQ=MONTHLY;BYDAY=2SU"},
+		{"freq=monthly;byday=-1fr", "FREQ=MONTHLY;BYDAY=-1FR"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;WKST=SU;UNTIL=20251231", "FREQ=WEEKLY;INTERVAL=2;UNTIL=20251231;BYDAY=TU,TH;WKST=SU"},
+		{"FREQ=MONTHLY;COUNT=6;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", "FREQ=MONTHLY;COUNT=6;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1"},
+		{"FREQ=YEARLY;UNTIL=20300101T000000Z;BYMONTH=11;BYDAY=4TH", "FREQ=YEARLY;UNTIL=20300101T000000Z;BYMONTH=11;BYDAY=4TH"},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsUnknownRules(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"Fortnightly",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=WEEKLY;BYHOUR=18",
+		"FREQ=WEEKLY;INTERVAL=0",
+		"FREQ=WEEKLY;FREQ=DAILY",
+		"FREQ=WEEKLY;COUNT=3;UNTIL=20251231",
+		"FREQ=WEEKLY;BYDAY=2SU",
+		"FREQ=WEEKLY;BYMONTHDAY=3",
+		"FREQ=MONTHLY;BYDAY=6SU",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+	} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 18, 0, 0, 0, time.UTC)
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		ex    []time.Time
+		want  []time.Time
+	}{
+		{"every 2nd Sunday", "FREQ=MONTHLY;BYDAY=2SU", date(2025, 1, 12), nil,
+			[]time.Time{date(2025, 2, 9), date(2025, 3, 9), date(2025, 4, 13)}},
+		{"last Friday", "FREQ=MONTHLY;BYDAY=-1FR", date(2025, 1, 31), nil,
+			[]time.Time{date(2025, 2, 28), date(2025, 3, 28), date(2025, 4, 25)}},
+		{"fortnightly on two days", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", date(2025, 1, 7), nil,
+			[]time.Time{date(2025, 1, 9), date(2025, 1, 21), date(2025, 1, 23)}},
+		{"31st skips short months", "FREQ=MONTHLY", date(2025, 1, 31), nil,
+			[]time.Time{date(2025, 3, 31), date(2025, 5, 31), date(2025, 7, 31)}},
+		{"last day of the month", "FREQ=MONTHLY;BYMONTHDAY=-1", date(2025, 1, 31), nil,
+			[]time.Time{date(2025, 2, 28), date(2025, 3, 31), date(2025, 4, 30)}},
+		{"last weekday of the month", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", date(2025, 1, 31), nil,
+			[]time.Time{date(2025, 2, 28), date(2025, 3, 31), date(2025, 4, 30)}},
+		{"thanksgiving", "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", date(2024, 11, 28), nil,
+			[]time.Time{date(2025, 11, 27), date(2026, 11, 26), date(2027, 11, 25)}},
+		{"leap day", "FREQ=YEARLY", date(2024, 2, 29), nil,
+			[]time.Time{date(2028, 2, 29), date(2032, 2, 29), date(2036, 2, 29)}},
+		{"count includes the start", "FREQ=WEEKLY;COUNT=3", date(2025, 1, 1), nil,
+			[]time.Time{date(2025, 1, 8), date(2025, 1, 15)}},
+		{"until date is inclusive", "FREQ=DAILY;UNTIL=20250103", date(2025, 1, 1), nil,
+			[]time.Time{date(2025, 1, 2), date(2025, 1, 3)}},
+		{"exdates", "FREQ=WEEKLY;COUNT=4", date(2025, 1, 1), []time.Time{time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC)},
+			[]time.Time{date(2025, 1, 15), date(2025, 1, 22)}},
+		{"never", "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", date(2025, 1, 1), nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+			s := Series{Rule: rule, Start: tt.start, ExDates: tt.ex}
+			got := s.Occurrences(tt.start, 3)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Occurrences() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("Occurrences()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	rule, _ := Parse("FREQ=WEEKLY;COUNT=2")
+	s := Series{Rule: rule, Start: date(2025, 1, 1)}
+
+	if next, ok := s.Next(date(2025, 1, 1)); !ok || !next.Equal(date(2025, 1, 8)) {
+		t.Errorf("Next() = %v, %v; want %v", next, ok, date(2025, 1, 8))
+	}
+	// Before the series begins, its next occurrence is the start
+	if next, ok := s.Next(date(2024, 12, 1)); !ok || !next.Equal(date(2025, 1, 1)) {
+		t.Errorf("Next() = %v, %v; want the start", next, ok)
+	}
+	if next, ok := s.Next(date(2025, 1, 8)); ok {
+		t.Errorf("Next() = %v after the last occurrence", next)
+	}
+}
+
+func TestOccurrencesKeepLocalTimeAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	rule, _ := Parse("FREQ=WEEKLY")
+	s := Series{Rule: rule, Start: time.Date(2025, 3, 2, 18, 0, 0, 0, loc)}
+
+	next, _ := s.Next(s.Start)
+	if next.Hour() != 18 || next.Sub(s.Start) != 7*24*time.Hour-time.Hour {
+		t.Errorf("Next() = %v, want 18:00 local a week later", next)
+	}
+}
+
+func TestEndBefore(t *testing.T) {
+	rule, _ := Parse("FREQ=WEEKLY;COUNT=10")
+	s := Series{Rule: rule.EndBefore(date(2025, 1, 15)), Start: date(2025, 1, 1)}
+
+	if got := s.Rule.String(); got != "FREQ=WEEKLY;UNTIL=20250114" {
+		t.Errorf("EndBefore().String() = %q", got)
+	}
+	if got := s.Occurrences(date(2025, 1, 1), 3); len(got) != 1 || !got[0].Equal(date(2025, 1, 8)) {
+		t.Errorf("Occurrences() = %v, want only %v", got, date(2025, 1, 8))
+	}
+}
+
+func TestCountBefore(t *testing.T) {
+	rule, _ := Parse("FREQ=WEEKLY;COUNT=5")
+	s := Series{Rule: rule, Start: date(2025, 1, 1), ExDates: []time.Time{date(2025, 1, 8)}}
+
+	// The skipped week still counts towards COUNT
+	if got := s.CountBefore(date(2025, 1, 15)); got != 2 {
+		t.Errorf("CountBefore() = %d, want 2", got)
+	}
+	if got := s.CountBefore(date(2026, 1, 1)); got != 5 {
+		t.Errorf("CountBefore() = %d, want 5", got)
+	}
+}
+
+func TestRRule(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	rule, _ := Parse("FREQ=WEEKLY;UNTIL=20250714")
+	s := Series{Rule: rule, Start: time.Date(2025, 6, 2, 18, 0, 0, 0, london)}
+
+	// The end of July 14th in London is 22:59:59 UTC
+	if got := s.RRule(); got != "FREQ=WEEKLY;UNTIL=20250714T225959Z" {
+		t.Errorf("RRule() = %q", got)
+	}
+	if got := s.Rule.String(); got != "FREQ=WEEKLY;UNTIL=20250714" {
+		t.Errorf("RRule() changed the rule to %q", got)
+	}
+
+	rule, _ = Parse("FREQ=MONTHLY;BYDAY=2SU")
+	if got := (Series{Rule: rule, Start: s.Start}).RRule(); got != "FREQ=MONTHLY;BYDAY=2SU" {
+		t.Errorf("RRule() = %q", got)
+	}
+}
+
+func TestSlotOn(t *testing.T) {
+	rule, _ := Parse("FREQ=WEEKLY")
+	s := Series{Rule: rule, Start: time.Date(2025, 1, 5, 18, 30, 0, 0, time.UTC)}
+
+	if got, want := s.SlotOn(date(2025, 1, 19)), time.Date(2025, 1, 19, 18, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("SlotOn() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,451 @@
+// Package recurrence computes when recurring events happen, from RFC 5545
+// recurrence rules (RRULE) and the dates excluded from them (EXDATE).
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is a rule's FREQ. Rules repeating more often than daily make no
+// sense for a potluck and are rejected.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// WeekdayNum is one BYDAY entry, such as "SU", "2SU" (the second Sunday) or
+// "-1FR" (the last Friday). N is zero when there is no ordinal.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is a parsed RRULE. The supported parts are FREQ, INTERVAL, COUNT,
+// UNTIL, BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS and WKST.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count and Until end the series; at most one of them is set.
+	Count int
+	Until time.Time
+	// untilDate is set when UNTIL was a date, which includes that whole day
+	// in the series' time zone.
+	untilDate bool
+
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+// legacyRules are the recurrence names events were created with before
+// rules were stored as RRULEs.
+var legacyRules = map[string]string{
+	"Daily":     "FREQ=DAILY",
+	"Weekly":    "FREQ=WEEKLY",
+	"Bi-Weekly": "FREQ=WEEKLY;INTERVAL=2",
+	"Monthly":   "FREQ=MONTHLY",
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+const (
+	untilLayout     = "20060102T150405Z"
+	untilDateLayout = "20060102"
+)
+
+// Parse parses an RRULE value such as "FREQ=MONTHLY;BYDAY=-1FR", with or
+// without the "RRULE:" prefix. The legacy names "Daily", "Weekly",
+// "Bi-Weekly" and "Monthly" are accepted too.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	if legacy, ok := legacyRules[s]; ok {
+		s = legacy
+	}
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	if s == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("malformed rule part %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is given more than once", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			default:
+				err = fmt.Errorf("unsupported frequency %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parseInt(value, 1, 1000)
+		case "COUNT":
+			r.Count, err = parseInt(value, 1, 10000)
+		case "UNTIL":
+			if t, perr := time.Parse(untilLayout, value); perr == nil {
+				r.Until = t
+			} else if t, perr := time.Parse(untilDateLayout, value); perr == nil {
+				r.Until, r.untilDate = t, true
+			} else {
+				err = fmt.Errorf("UNTIL must look like 20250131 or 20250131T180000Z")
+			}
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				var wd WeekdayNum
+				if wd, err = parseWeekdayNum(v); err != nil {
+					break
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				var d int
+				if d, err = parseInt(v, -31, 31); err != nil || d == 0 {
+					err = fmt.Errorf("invalid BYMONTHDAY %s", v)
+					break
+				}
+				r.ByMonthDay = append(r.ByMonthDay, d)
+			}
+		case "BYMONTH":
+			for _, v := range strings.Split(value, ",") {
+				var m int
+				if m, err = parseInt(v, 1, 12); err != nil {
+					break
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			for _, v := range strings.Split(value, ",") {
+				var p int
+				if p, err = parseInt(v, -366, 366); err != nil || p == 0 {
+					err = fmt.Errorf("invalid BYSETPOS %s", v)
+					break
+				}
+				r.BySetPos = append(r.BySetPos, p)
+			}
+		case "WKST":
+			wd, ok := weekdays[value]
+			if !ok {
+				err = fmt.Errorf("invalid WKST %s", value)
+			}
+			r.WeekStart = wd
+		default:
+			err = fmt.Errorf("unsupported rule part %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Rule) validate() error {
+	switch {
+	case r.Freq == "":
+		return fmt.Errorf("FREQ is required")
+	case r.Count > 0 && !r.Until.IsZero():
+		return fmt.Errorf("COUNT and UNTIL can't both be given")
+	case r.Freq == Weekly && len(r.ByMonthDay) > 0:
+		return fmt.Errorf("BYMONTHDAY can't be used with FREQ=WEEKLY")
+	case len(r.BySetPos) > 0 && len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByMonth) == 0:
+		return fmt.Errorf("BYSETPOS needs BYDAY, BYMONTHDAY or BYMONTH")
+	}
+	for _, wd := range r.ByDay {
+		if wd.N == 0 {
+			continue
+		}
+		if r.Freq != Monthly && r.Freq != Yearly {
+			return fmt.Errorf("BYDAY ordinals like %d%s need FREQ=MONTHLY or FREQ=YEARLY", wd.N, weekdayNames[wd.Day])
+		}
+		if r.Freq == Monthly || len(r.ByMonth) > 0 {
+			if wd.N < -5 || wd.N > 5 {
+				return fmt.Errorf("there is no %d%s in a month", wd.N, weekdayNames[wd.Day])
+			}
+		}
+	}
+	return nil
+}
+
+func parseInt(s string, min, max int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s is not a number from %d to %d", s, min, max)
+	}
+	return n, nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %s", s)
+	}
+	day, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %s", s)
+	}
+	wd := WeekdayNum{Day: day}
+	if ordinal := s[:len(s)-2]; ordinal != "" {
+		n, err := parseInt(strings.TrimPrefix(ordinal, "+"), -53, 53)
+		if err != nil || n == 0 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %s", s)
+		}
+		wd.N = n
+	}
+	return wd, nil
+}
+
+// EndBefore returns a copy of the rule that ends on the day before t, for
+// cutting a series short. Any COUNT is dropped.
+func (r Rule) EndBefore(t time.Time) *Rule {
+	r.Count = 0
+	r.Until, r.untilDate = time.Date(t.Year(), t.Month(), t.Day()-1, 0, 0, 0, 0, time.UTC), true
+	return &r
+}
+
+// String formats the rule as an RRULE value, without the "RRULE:" prefix.
+// Parsing the result gives back an equal rule.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.untilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format(untilDateLayout))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+		}
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]int, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = int(m)
+		}
+		parts = append(parts, "BYMONTH="+joinInts(months))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = weekdayNames[wd.Day]
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// candidates returns the dates, as midnight UTC, that the rule picks in the
+// period'th period of a series starting on start, in order.
+func (r *Rule) candidates(start time.Time, period int) []time.Time {
+	y, m, d := start.Date()
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := time.Date(y, m, d+period*r.Interval, 0, 0, 0, 0, time.UTC)
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		first := time.Date(y, m, d-offset+period*r.Interval*7, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 7; i++ {
+			day := first.AddDate(0, 0, i)
+			if !r.matchesMonth(day) {
+				continue
+			}
+			if len(r.ByDay) == 0 && day.Weekday() != start.Weekday() {
+				continue
+			}
+			if r.matchesWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		month := time.Date(y, m+time.Month(period*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		if r.matchesMonth(month) {
+			days = r.expandMonth(month, d)
+		}
+	case Yearly:
+		year := y + period*r.Interval
+		if len(r.ByDay) > 0 && len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 {
+			// Ordinals count through the whole year, e.g. 20MO.
+			first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+			days = r.expandWeekdays(first, first.AddDate(1, 0, 0))
+			break
+		}
+		months := r.ByMonth
+		if len(months) == 0 {
+			if len(r.ByMonthDay) > 0 {
+				months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+			} else {
+				months = []time.Month{m}
+			}
+		}
+		sorted := append([]time.Month(nil), months...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		for _, month := range sorted {
+			days = append(days, r.expandMonth(time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), d)...)
+		}
+	}
+	return r.applySetPos(days)
+}
+
+// expandMonth returns the days of the month starting on first that the rule
+// picks; without BYDAY or BYMONTHDAY that is startDay, if the month has it.
+func (r *Rule) expandMonth(first time.Time, startDay int) []time.Time {
+	next := first.AddDate(0, 1, 0)
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		day := first.AddDate(0, 0, startDay-1)
+		if day.Before(next) {
+			return []time.Time{day}
+		}
+		return nil
+	}
+	var days []time.Time
+	for _, day := range r.expandWeekdays(first, next) {
+		if r.matchesMonthDay(day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// expandWeekdays returns the days in [from, to) matching BYDAY, with ordinals
+// counted within that range. Without BYDAY every day matches.
+func (r *Rule) expandWeekdays(from, to time.Time) []time.Time {
+	total := int(to.Sub(from).Hours() / 24)
+	var days []time.Time
+	for i := 0; i < total; i++ {
+		day := from.AddDate(0, 0, i)
+		if len(r.ByDay) == 0 {
+			days = append(days, day)
+			continue
+		}
+		for _, wd := range r.ByDay {
+			if day.Weekday() != wd.Day {
+				continue
+			}
+			if wd.N == 0 || wd.N == i/7+1 || wd.N == -((total-1-i)/7+1) {
+				days = append(days, day)
+				break
+			}
+		}
+	}
+	return days
+}
+
+func (r *Rule) matchesMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if day.Month() == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.ByMonthDay {
+		if md == day.Day() || (md < 0 && last+md+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday checks BYDAY where it limits rather than expands, so
+// ordinals don't apply.
+func (r *Rule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if day.Weekday() == wd.Day {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) applySetPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(days) == 0 {
+		return days
+	}
+	picked := make(map[int]bool)
+	for _, pos := range r.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(days) + pos
+		}
+		if i >= 0 && i < len(days) {
+			picked[i] = true
+		}
+	}
+	var out []time.Time
+	for i, day := range days {
+		if picked[i] {
+			out = append(out, day)
+		}
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package recurrence
+
+import "time"
+
+// maxEmptyPeriods stops the search for a rule's next occurrence, such as one
+// asking for February 30th, that will never come.
+const maxEmptyPeriods = 1000
+
+// Series is a rule anchored at its first occurrence, the DTSTART of RFC 5545,
+// less the days in ExDates. Occurrences are on the days the rule picks, at
+// Start's time of day in Start's location, so they keep their local time
+// across daylight saving changes.
+type Series struct {
+	Rule  *Rule
+	Start time.Time
+	// ExDates exclude whichever occurrence falls on the same day, in Start's
+	// location.
+	ExDates []time.Time
+}
+
+// Next returns the first occurrence after t. ok is false if the series ends
+// before then.
+func (s Series) Next(after time.Time) (next time.Time, ok bool) {
+	s.each(func(t time.Time) bool {
+		if t.After(after) && !s.excluded(t) {
+			next, ok = t, true
+			return false
+		}
+		return true
+	})
+	return next, ok
+}
+
+// Occurrences returns up to n occurrences after t.
+func (s Series) Occurrences(after time.Time, n int) []time.Time {
+	var out []time.Time
+	if n <= 0 {
+		return out
+	}
+	s.each(func(t time.Time) bool {
+		if t.After(after) && !s.excluded(t) {
+			out = append(out, t)
+		}
+		return len(out) < n
+	})
+	return out
+}
+
+// CountBefore returns how many occurrences, excluded ones included, come
+// before t. It is what a series restarted at t uses up of the rule's COUNT.
+func (s Series) CountBefore(t time.Time) int {
+	n := 0
+	s.each(func(o time.Time) bool {
+		if !o.Before(t) {
+			return false
+		}
+		n++
+		return true
+	})
+	return n
+}
+
+// each calls fn with every occurrence of the rule, excluded or not, in order,
+// until fn returns false or the series ends. Start is always the first, as
+// RFC 5545 requires, and counts towards COUNT.
+func (s Series) each(fn func(time.Time) bool) {
+	loc := s.Start.Location()
+	hour, min, sec := s.Start.Clock()
+	count := 0
+	emit := func(t time.Time) bool {
+		if s.ended(t) {
+			return false
+		}
+		count++
+		if !fn(t) {
+			return false
+		}
+		return s.Rule.Count == 0 || count < s.Rule.Count
+	}
+
+	if !emit(s.Start) {
+		return
+	}
+	empty := 0
+	for period := 0; empty < maxEmptyPeriods; period++ {
+		days := s.Rule.candidates(s.Start, period)
+		found := false
+		for _, day := range days {
+			t := time.Date(day.Year(), day.Month(), day.Day(), hour, min, sec, s.Start.Nanosecond(), loc)
+			if !t.After(s.Start) {
+				continue
+			}
+			found = true
+			if !emit(t) {
+				return
+			}
+		}
+		if found {
+			empty = 0
+		} else {
+			empty++
+		}
+	}
+}
+
+// ended reports whether t is past the rule's UNTIL.
+func (s Series) ended(t time.Time) bool {
+	until := s.Rule.Until
+	if until.IsZero() {
+		return false
+	}
+	if s.Rule.untilDate {
+		y, m, d := until.Date()
+		return !t.Before(time.Date(y, m, d+1, 0, 0, 0, 0, s.Start.Location()))
+	}
+	return t.After(until)
+}
+
+func (s Series) excluded(t time.Time) bool {
+	y, m, d := t.Date()
+	for _, ex := range s.ExDates {
+		ey, em, ed := ex.In(s.Start.Location()).Date()
+		if y == ey && m == em && d == ed {
+			return true
+		}
+	}
+	return false
+}
+
+// RRule returns the rule as the RRULE to go with Start as DTSTART. An UNTIL
+// date becomes the last second of that day in Start's location, since RFC
+// 5545 wants UNTIL as a time when DTSTART is one.
+func (s Series) RRule() string {
+	if !s.Rule.untilDate {
+		return s.Rule.String()
+	}
+	r := *s.Rule
+	y, m, d := r.Until.Date()
+	r.Until = time.Date(y, m, d+1, 0, 0, 0, 0, s.Start.Location()).Add(-time.Second)
+	r.untilDate = false
+	return r.String()
+}
+
+// SlotOn returns when an occurrence on t's day, in Start's location, would
+// be, such as the one an ExDate excludes.
+func (s Series) SlotOn(t time.Time) time.Time {
+	y, m, d := t.In(s.Start.Location()).Date()
+	hour, min, sec := s.Start.Clock()
+	return time.Date(y, m, d, hour, min, sec, s.Start.Nanosecond(), s.Start.Location())
+}
@@ -0,0 +1,119 @@
+// Package scheduler runs background jobs inside the server process. Each job
+// is guarded by a lease in Mongo, so that when several instances run, only
+// the one holding a job's lease runs it.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// leaseIntervals is how many of a job's intervals its lease lasts. The holder
+// renews it on every run, so another instance takes over only once the holder
+// has missed a few.
+const leaseIntervals = 3
+
+// Leases is satisfied by database.Service.
+type Leases interface {
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+}
+
+// Job is work run every Interval by whichever instance holds the lease called
+// Name. Run gets the time of the run and must finish within Interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context, now time.Time) error
+}
+
+type Scheduler struct {
+	Leases Leases
+	// Holder identifies this instance in the leases it takes.
+	Holder string
+
+	jobs []Job
+	now  func() time.Time
+}
+
+// New returns a scheduler taking leases from leases under a name unique to
+// this process.
+func New(leases Leases) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		Leases: leases,
+		Holder: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), primitive.NewObjectID().Hex()),
+		now:    time.Now,
+	}
+}
+
+// Add registers job. It must be called before Run.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Run runs each job straight away and then every interval, until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		s.runOnce(ctx, job)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce runs job if this instance holds its lease or can take it, and
+// reports whether it did.
+func (s *Scheduler) runOnce(ctx context.Context, job Job) bool {
+	held, err := s.Leases.AcquireLease(ctx, job.Name, s.Holder, leaseIntervals*job.Interval)
+	if err != nil {
+		log.Printf("scheduler: taking lease for %s: %v", job.Name, err)
+		return false
+	}
+	if !held {
+		return false
+	}
+	// Stop before the next run, well inside the lease
+	ctx, cancel := context.WithTimeout(ctx, job.Interval)
+	defer cancel()
+	if err := job.Run(ctx, s.now()); err != nil {
+		log.Printf("scheduler: %s: %v", job.Name, err)
+	}
+	return true
+}
+
+// IntervalFromEnv reads the Go duration (e.g. "5m") in the environment
+// variable name, or returns fallback if it is unset or invalid.
+func IntervalFromEnv(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Ignoring invalid %s %q", name, v)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// memoryLeases keeps leases the way the database does, on a clock the test
+// moves.
+type memoryLeases struct {
+	now     time.Time
+	holders map[string]string
+	expires map[string]time.Time
+}
+
+func newMemoryLeases(now time.Time) *memoryLeases {
+	return &memoryLeases{now: now, holders: map[string]string{}, expires: map[string]time.Time{}}
+}
+
+func (m *memoryLeases) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	if current, ok := m.holders[name]; ok && current != holder && m.now.Before(m.expires[name]) {
+		return false, nil
+	}
+	m.holders[name] = holder
+	m.expires[name] = m.now.Add(ttl)
+	return true, nil
+}
+
+func TestRunOnce_OnlyTheLeaseHolderRuns(t *testing.T) {
+	start := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	leases := newMemoryLeases(start)
+	a, b := New(leases), New(leases)
+	if a.Holder == b.Holder {
+		t.Fatalf("expected each scheduler to have its own holder name, both are %q", a.Holder)
+	}
+
+	var runs []string
+	job := func(name string) Job {
+		return Job{Name: "complete-ended-events", Interval: time.Minute, Run: func(ctx context.Context, now time.Time) error {
+			runs = append(runs, name)
+			return nil
+		}}
+	}
+
+	a.runOnce(context.Background(), job("a"))
+	b.runOnce(context.Background(), job("b"))
+	leases.now = start.Add(time.Minute)
+	a.runOnce(context.Background(), job("a"))
+	b.runOnce(context.Background(), job("b"))
+	if len(runs) != 2 || runs[0] != "a" || runs[1] != "a" {
+		t.Fatalf("expected only the lease holder to run the job, got %v", runs)
+	}
+
+	// a stops renewing, so b takes over once the lease runs out
+	leases.now = start.Add(time.Minute + leaseIntervals*time.Minute)
+	if !b.runOnce(context.Background(), job("b")) {
+		t.Fatal("expected b to take over the expired lease")
+	}
+	if a.runOnce(context.Background(), job("a")) {
+		t.Error("expected a to have lost the lease")
+	}
+}
+
+func TestIntervalFromEnv(t *testing.T) {
+	t.Setenv("SCHEDULER_INTERVAL", "30s")
+	if got := IntervalFromEnv("SCHEDULER_INTERVAL", time.Minute); got != 30*time.Second {
+		t.Errorf("expected 30s, got %v", got)
+	}
+	t.Setenv("SCHEDULER_INTERVAL", "soon")
+	if got := IntervalFromEnv("SCHEDULER_INTERVAL", time.Minute); got != time.Minute {
+		t.Errorf("expected the fallback for an invalid interval, got %v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+)
+
+// BrokerMessage is a message on its way from the hub it was published on to
+// every hub, including that one.
+type BrokerMessage struct {
+	// Origin identifies the hub that published the message.
+	Origin string `json:"origin"`
+	// Broadcast messages go to every client; the rest to Topics.
+	Broadcast bool     `json:"broadcast,omitempty"`
+	Topics    []string `json:"topics,omitempty"`
+	Message   []byte   `json:"message,omitempty"`
+	// Ephemeral messages are delivered without a sequence number and aren't
+	// kept for replay.
+	Ephemeral bool `json:"ephemeral,omitempty"`
+	// Presence, when set, is a presence change rather than a message.
+	Presence *PresenceUpdate `json:"presence,omitempty"`
+}
+
+// Broker carries published messages between the hubs of every backend
+// instance. Hubs deliver only what they receive back from the broker, so all
+// instances see messages in the broker's order and number them alike.
+type Broker interface {
+	Publish(ctx context.Context, msg BrokerMessage) error
+	// Subscribe returns every message published through the broker from now
+	// on, until ctx is done.
+	Subscribe(ctx context.Context) (<-chan BrokerMessage, error)
+}
+
+// MemoryBroker connects hubs within one process. It is the default, for a
+// single instance, and lets tests run several hubs side by side.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[*memorySubscriber]bool
+}
+
+type memorySubscriber struct {
+	ch   chan BrokerMessage
+	done <-chan struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: make(map[*memorySubscriber]bool)}
+}
+
+// Publish hands msg to every subscriber, waiting for those that are behind.
+func (b *MemoryBroker) Publish(ctx context.Context, msg BrokerMessage) error {
+	b.mu.Lock()
+	subscribers := make([]*memorySubscriber, 0, len(b.subscribers))
+	for sub := range b.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subscribers {
+		select {
+		case sub.ch <- msg:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context) (<-chan BrokerMessage, error) {
+	sub := &memorySubscriber{ch: make(chan BrokerMessage, sendBufferSize), done: ctx.Done()}
+	b.mu.Lock()
+	b.subscribers[sub] = true
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers, sub)
+		b.mu.Unlock()
+	}()
+	return sub.ch, nil
+}
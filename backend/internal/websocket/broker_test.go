@@ -0,0 +1,221 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestHubsShareBroker(t *testing.T) {
+	broker := NewMemoryBroker()
+	hubA, hubB := NewHub(), NewHub()
+	hubA.Broker, hubB.Broker = broker, broker
+	go hubA.Run()
+	go hubB.Run()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hubB.ServeWs(w, r, ConnOptions{Topics: []string{EventTopic("e1")}})
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer ws.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	// Published on the other instance
+	hubA.Publish([]byte(`{"type":"dish_added"}`), EventTopic("e1"))
+
+	ws.SetReadDeadline(time.Now().Add(1 * time.Second))
+	_, p, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if want := `{"type":"dish_added","seq":{"event:e1":1}}`; string(p) != want {
+		t.Errorf("Expected %s, got %s", want, p)
+	}
+}
+
+type failingBroker struct{}
+
+func (failingBroker) Publish(ctx context.Context, msg BrokerMessage) error {
+	return context.DeadlineExceeded
+}
+
+func (failingBroker) Subscribe(ctx context.Context) (<-chan BrokerMessage, error) {
+	return make(chan BrokerMessage), nil
+}
+
+func TestPublishFallsBackToLocalDelivery(t *testing.T) {
+	hub := NewHub()
+	hub.Broker = failingBroker{}
+	go hub.Run()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{Topics: []string{GroupTopic("g1")}})
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer ws.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	hub.Publish([]byte("local only"), GroupTopic("g1"))
+
+	ws.SetReadDeadline(time.Now().Add(1 * time.Second))
+	if _, p, err := ws.ReadMessage(); err != nil || string(p) != "local only" {
+		t.Fatalf("Expected %q, got %q (%v)", "local only", p, err)
+	}
+	if got := hub.Metrics().BrokerErrors; got != 1 {
+		t.Errorf("BrokerErrors = %d, want 1", got)
+	}
+}
+
+// fakeRedis is a stand-in for a Redis server that understands just AUTH,
+// SUBSCRIBE and PUBLISH.
+type fakeRedis struct {
+	listener    net.Listener
+	password    string
+	mu          sync.Mutex
+	subscribers map[string][]net.Conn
+}
+
+func startFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	f := &fakeRedis{listener: l, password: password, subscribers: make(map[string][]net.Conn)}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		cmd, err := readRESP(r)
+		if err != nil {
+			return
+		}
+		args, _ := cmd.([]interface{})
+		if len(args) == 0 {
+			return
+		}
+		name, _ := args[0].(string)
+		switch strings.ToUpper(name) {
+		case "AUTH":
+			if args[1] != f.password {
+				conn.Write([]byte("-WRONGPASS invalid password\r\n"))
+				continue
+			}
+			authed = true
+			conn.Write([]byte("+OK\r\n"))
+		case "SUBSCRIBE":
+			if !authed {
+				conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+				continue
+			}
+			channel := args[1].(string)
+			f.mu.Lock()
+			f.subscribers[channel] = append(f.subscribers[channel], conn)
+			f.mu.Unlock()
+			conn.Write(encodeCommand("subscribe", channel))
+		case "PUBLISH":
+			if !authed {
+				conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+				continue
+			}
+			channel, payload := args[1].(string), args[2].(string)
+			f.mu.Lock()
+			subs := f.subscribers[channel]
+			for _, sub := range subs {
+				sub.Write(encodeCommand("message", channel, payload))
+			}
+			f.mu.Unlock()
+			conn.Write([]byte(":" + strconv.Itoa(len(subs)) + "\r\n"))
+		}
+	}
+}
+
+func (f *fakeRedis) subscriberCount(channel string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subscribers[channel])
+}
+
+func TestRedisBroker(t *testing.T) {
+	server := startFakeRedis(t, "secret")
+	addr := server.listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subscriber := NewRedisBroker(addr, "secret", "")
+	msgs, err := subscriber.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	deadline := time.Now().Add(1 * time.Second)
+	for server.subscriberCount(defaultRedisChannel) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscriber never subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	publisher := NewRedisBroker(addr, "secret", "")
+	sent := BrokerMessage{Origin: "a", Topics: []string{EventTopic("e1")}, Message: []byte(`{"type":"dish_added"}`)}
+	if err := publisher.Publish(ctx, sent); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	select {
+	case got := <-msgs:
+		if got.Origin != "a" || len(got.Topics) != 1 || got.Topics[0] != EventTopic("e1") || string(got.Message) != string(sent.Message) {
+			t.Errorf("Received %+v, want %+v", got, sent)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Expected message from broker")
+	}
+
+	wrongPassword := NewRedisBroker(addr, "nope", "")
+	if err := wrongPassword.Publish(ctx, sent); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Expected auth error, got %v", err)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-msgs:
+		if ok {
+			t.Error("Expected no further messages after cancel")
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Expected message channel to close after cancel")
+	}
+}
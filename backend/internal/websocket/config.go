@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sendBufferSize is how many outgoing messages may queue for one client before
+// it is dropped as a slow consumer.
+const sendBufferSize = 256
+
+// Config controls connection liveness and limits. Zero fields fall back to
+// DefaultConfig.
+type Config struct {
+	// PingInterval is how often the server pings each connection.
+	PingInterval time.Duration
+	// PongTimeout is how long a connection may go without answering a ping
+	// before it is considered dead. It must be longer than PingInterval.
+	PongTimeout time.Duration
+	// WriteTimeout bounds each write, so a client that stops reading can't
+	// block its writer forever.
+	WriteTimeout time.Duration
+	// MaxMessageSize is the largest frame, in bytes, a client may send.
+	MaxMessageSize int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PingInterval:   50 * time.Second,
+		PongTimeout:    60 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxMessageSize: 4096,
+	}
+}
+
+// ConfigFromEnv reads WS_PING_INTERVAL, WS_PONG_TIMEOUT and WS_WRITE_TIMEOUT
+// (Go durations such as "30s") and WS_MAX_MESSAGE_SIZE (bytes), keeping the
+// defaults for anything unset or invalid.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	cfg.PingInterval = durationFromEnv("WS_PING_INTERVAL", cfg.PingInterval)
+	cfg.PongTimeout = durationFromEnv("WS_PONG_TIMEOUT", cfg.PongTimeout)
+	cfg.WriteTimeout = durationFromEnv("WS_WRITE_TIMEOUT", cfg.WriteTimeout)
+	if v := os.Getenv("WS_MAX_MESSAGE_SIZE"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || size <= 0 {
+			log.Printf("Ignoring invalid WS_MAX_MESSAGE_SIZE %q", v)
+		} else {
+			cfg.MaxMessageSize = size
+		}
+	}
+	if cfg.PongTimeout <= cfg.PingInterval {
+		log.Printf("WS_PONG_TIMEOUT (%s) must exceed WS_PING_INTERVAL (%s); using defaults", cfg.PongTimeout, cfg.PingInterval)
+		defaults := DefaultConfig()
+		cfg.PingInterval, cfg.PongTimeout = defaults.PingInterval, defaults.PongTimeout
+	}
+	return cfg
+}
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Ignoring invalid %s %q", name, v)
+		return fallback
+	}
+	return d
+}
+
+// withDefaults fills in zero fields from DefaultConfig.
+func (c Config) withDefaults() Config {
+	d := DefaultConfig()
+	if c.PingInterval <= 0 {
+		c.PingInterval = d.PingInterval
+	}
+	if c.PongTimeout <= 0 {
+		c.PongTimeout = d.PongTimeout
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = d.WriteTimeout
+	}
+	if c.MaxMessageSize <= 0 {
+		c.MaxMessageSize = d.MaxMessageSize
+	}
+	return c
+}
@@ -0,0 +1,188 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"log"
+	"time"
+)
+
+// historySize is how many recent messages are kept per topic for clients that
+// resume after a disconnect. It stays below the client send buffer so that a
+// full replay never gets the client dropped as too slow.
+const historySize = 128
+
+// storeTimeout bounds each read or write against the Store.
+const storeTimeout = 2 * time.Second
+
+// Store persists the per-topic history so that sequence numbers, and the
+// ability to resume, survive a restart.
+type Store interface {
+	AppendRealtimeMessage(ctx context.Context, msg *models.RealtimeMessage, keep int) error
+	GetRecentRealtimeMessages(ctx context.Context, topic string, limit int) ([]models.RealtimeMessage, error)
+}
+
+type historyEntry struct {
+	seq     uint64
+	message []byte
+}
+
+// topicHistory is a ring buffer of a topic's most recent messages.
+type topicHistory struct {
+	head    uint64 // last sequence number handed out
+	entries []historyEntry
+	start   int
+}
+
+func (t *topicHistory) push(e historyEntry) {
+	t.head = e.seq
+	if len(t.entries) < historySize {
+		t.entries = append(t.entries, e)
+		return
+	}
+	t.entries[t.start] = e
+	t.start = (t.start + 1) % len(t.entries)
+}
+
+// since returns the entries after seq, oldest first. ok is false when some of
+// them have already been overwritten, or seq is from a log this hub doesn't
+// have (e.g. before a restart without a Store).
+func (t *topicHistory) since(seq uint64) (entries []historyEntry, ok bool) {
+	if seq > t.head {
+		return nil, false
+	}
+	if seq == t.head {
+		return nil, true
+	}
+	if len(t.entries) == 0 || t.entries[t.start].seq > seq+1 {
+		return nil, false
+	}
+	for i := range t.entries {
+		e := t.entries[(t.start+i)%len(t.entries)]
+		if e.seq > seq {
+			entries = append(entries, e)
+		}
+	}
+	return entries, true
+}
+
+// history returns the log for topic, starting an empty one the first time the
+// topic is seen. It does no I/O, as Run calls it with h.mu held; warmHistory
+// seeds logs from the Store beforehand. The caller must hold h.mu.
+func (h *Hub) history(topic string) *topicHistory {
+	if t, ok := h.histories[topic]; ok {
+		return t
+	}
+	t := &topicHistory{}
+	h.histories[topic] = t
+	return t
+}
+
+// warmHistory seeds the logs of topics the hub hasn't seen yet from the
+// Store. It is called before a message or subscription for the topics is
+// handed to Run, and reads the Store without holding h.mu so a slow read
+// holds up only its caller.
+func (h *Hub) warmHistory(topics ...string) {
+	if h.Store == nil {
+		return
+	}
+	for _, topic := range topics {
+		h.mu.Lock()
+		_, ok := h.histories[topic]
+		h.mu.Unlock()
+		if ok {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		stored, err := h.Store.GetRecentRealtimeMessages(ctx, topic, historySize)
+		cancel()
+		if err != nil {
+			log.Printf("websocket: loading history for %s: %v", topic, err)
+			continue
+		}
+		t := &topicHistory{}
+		for _, m := range stored {
+			t.push(historyEntry{seq: m.Seq, message: m.Message})
+		}
+
+		h.mu.Lock()
+		// Another caller may have seeded it, or Run numbered messages on
+		// it, while the Store was read; the log already in place wins.
+		if _, ok := h.histories[topic]; !ok {
+			h.histories[topic] = t
+		}
+		h.mu.Unlock()
+	}
+}
+
+// record numbers message on each of topics and adds it to their histories,
+// returning the message as it should be delivered. It is written to the Store
+// only when persist is set. The caller must hold h.mu.
+func (h *Hub) record(message []byte, topics []string, persist bool) []byte {
+	seqs := make(map[string]uint64, len(topics))
+	for _, topic := range topics {
+		seqs[topic] = h.history(topic).head + 1
+	}
+	stamped := stampSeq(message, seqs)
+
+	for _, topic := range topics {
+		h.history(topic).push(historyEntry{seq: seqs[topic], message: stamped})
+		if persist && h.Store != nil {
+			select {
+			case h.persist <- models.RealtimeMessage{Topic: topic, Seq: seqs[topic], Message: stamped, CreatedAt: time.Now()}:
+			default:
+				log.Printf("websocket: history store is behind, not persisting %s #%d", topic, seqs[topic])
+			}
+		}
+	}
+	return stamped
+}
+
+// replay sends client what it missed on topic since seq, or tells it to
+// reload if that is no longer available. The caller must hold h.mu.
+func (h *Hub) replay(client *Client, topic string, seq uint64) {
+	entries, ok := h.history(topic).since(seq)
+	if !ok {
+		h.deliver(client, encodeReply(controlReply{Type: FrameResyncRequired, Topic: topic}))
+		return
+	}
+	for _, e := range entries {
+		h.deliver(client, e.message)
+	}
+}
+
+func (h *Hub) persistHistory() {
+	for msg := range h.persist {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		if err := h.Store.AppendRealtimeMessage(ctx, &msg, historySize); err != nil {
+			log.Printf("websocket: persisting %s #%d: %v", msg.Topic, msg.Seq, err)
+		}
+		cancel()
+	}
+}
+
+// stampSeq adds a "seq" field mapping each topic to the message's sequence
+// number on it. Messages that aren't JSON objects are returned unchanged.
+func stampSeq(message []byte, seqs map[string]uint64) []byte {
+	trimmed := bytes.TrimSpace(message)
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' {
+		return message
+	}
+	seqJSON, err := json.Marshal(seqs)
+	if err != nil {
+		return message
+	}
+
+	body := trimmed[:len(trimmed)-1]
+	stamped := make([]byte, 0, len(trimmed)+len(seqJSON)+8)
+	stamped = append(stamped, body...)
+	if len(bytes.TrimSpace(body[1:])) > 0 {
+		stamped = append(stamped, ',')
+	}
+	stamped = append(stamped, `"seq":`...)
+	stamped = append(stamped, seqJSON...)
+	return append(stamped, '}')
+}
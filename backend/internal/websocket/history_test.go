@@ -0,0 +1,260 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestStampSeq(t *testing.T) {
+	seqs := map[string]uint64{"event:e1": 3}
+	tests := []struct {
+		in, want string
+	}{
+		{`{"type":"dish_added","data":{}}`, `{"type":"dish_added","data":{},"seq":{"event:e1":3}}`},
+		{`{}`, `{"seq":{"event:e1":3}}`},
+		{`not json`, `not json`},
+	}
+	for _, tt := range tests {
+		if got := string(stampSeq([]byte(tt.in), seqs)); got != tt.want {
+			t.Errorf("stampSeq(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTopicHistorySince(t *testing.T) {
+	var h topicHistory
+	for seq := uint64(1); seq <= historySize+10; seq++ {
+		h.push(historyEntry{seq: seq, message: []byte(fmt.Sprint(seq))})
+	}
+
+	entries, ok := h.since(historySize + 7)
+	if !ok || len(entries) != 3 || entries[0].seq != historySize+8 {
+		t.Errorf("since(recent) = %v, %v; want the last 3 entries", entries, ok)
+	}
+	if entries, ok := h.since(historySize + 10); !ok || len(entries) != 0 {
+		t.Errorf("since(head) = %v, %v; want nothing to replay", entries, ok)
+	}
+	if _, ok := h.since(5); ok {
+		t.Error("since(overwritten) should require a resync")
+	}
+	if _, ok := h.since(historySize + 50); ok {
+		t.Error("since(beyond head) should require a resync")
+	}
+}
+
+func TestSubscribeResumesFromSeq(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	allowAll := func(ctx context.Context, topic string) (bool, error) { return true, nil }
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{Authorize: allowAll})
+	}))
+	defer s.Close()
+
+	// Published while the client was away
+	for i := 1; i <= 3; i++ {
+		hub.Publish([]byte(fmt.Sprintf(`{"type":"dish_added","data":{"n":%d}}`, i)), EventTopic("e1"))
+	}
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer ws.Close()
+
+	since := uint64(1)
+	ws.WriteJSON(controlFrame{Type: FrameSubscribe, Topic: EventTopic("e1"), Since: &since})
+	for want := uint64(2); want <= 3; want++ {
+		ws.SetReadDeadline(time.Now().Add(1 * time.Second))
+		var msg struct {
+			Seq map[string]uint64 `json:"seq"`
+		}
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatalf("Failed to read replayed message: %v", err)
+		}
+		if msg.Seq[EventTopic("e1")] != want {
+			t.Errorf("Replayed seq = %d, want %d", msg.Seq[EventTopic("e1")], want)
+		}
+	}
+	if reply := readReply(t, ws); reply.Type != FrameSubscribed || reply.Seq != 3 {
+		t.Fatalf("Expected subscribed at seq 3, got %+v", reply)
+	}
+
+	// A sequence number this hub never issued can't be resumed from
+	stale := uint64(99)
+	ws.WriteJSON(controlFrame{Type: FrameSubscribe, Topic: EventTopic("e1"), Since: &stale})
+	if reply := readReply(t, ws); reply.Type != FrameResyncRequired || reply.Topic != EventTopic("e1") {
+		t.Fatalf("Expected resync_required, got %+v", reply)
+	}
+	if reply := readReply(t, ws); reply.Type != FrameSubscribed {
+		t.Fatalf("Expected subscribed after resync_required, got %+v", reply)
+	}
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	messages []models.RealtimeMessage
+}
+
+func (m *memoryStore) AppendRealtimeMessage(ctx context.Context, msg *models.RealtimeMessage, keep int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+func (m *memoryStore) GetRecentRealtimeMessages(ctx context.Context, topic string, limit int) ([]models.RealtimeMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.RealtimeMessage
+	for _, msg := range m.messages {
+		if msg.Topic == topic {
+			out = append(out, msg)
+		}
+	}
+	return out, nil
+}
+
+func TestHistorySurvivesRestartWithStore(t *testing.T) {
+	store := &memoryStore{}
+	first := NewHub()
+	first.Store = store
+	go first.Run()
+	first.Publish([]byte(`{"type":"rsvp_updated"}`), GroupTopic("g1"))
+	first.Publish([]byte(`{"type":"rsvp_updated"}`), GroupTopic("g1"))
+
+	deadline := time.Now().Add(1 * time.Second)
+	for {
+		store.mu.Lock()
+		n := len(store.messages)
+		store.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 persisted messages, got %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	second := NewHub()
+	second.Store = store
+	second.warmHistory(GroupTopic("g1"))
+	second.mu.Lock()
+	h := second.history(GroupTopic("g1"))
+	stamped := second.record([]byte(`{"type":"rsvp_updated"}`), []string{GroupTopic("g1")}, true)
+	second.mu.Unlock()
+
+	if h.head != 3 {
+		t.Errorf("head after restart = %d, want 3", h.head)
+	}
+	var msg struct {
+		Seq map[string]uint64 `json:"seq"`
+	}
+	if err := json.Unmarshal(stamped, &msg); err != nil || msg.Seq[GroupTopic("g1")] != 3 {
+		t.Errorf("stamped = %s, want seq 3", stamped)
+	}
+}
+
+// slowStore holds up reads of one topic until released.
+type slowStore struct {
+	memoryStore
+	topic   string
+	release chan struct{}
+}
+
+func (s *slowStore) GetRecentRealtimeMessages(ctx context.Context, topic string, limit int) ([]models.RealtimeMessage, error) {
+	if topic == s.topic {
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return s.memoryStore.GetRecentRealtimeMessages(ctx, topic, limit)
+}
+
+func TestSlowStoreDoesNotBlockHub(t *testing.T) {
+	store := &slowStore{topic: EventTopic("slow"), release: make(chan struct{})}
+	defer close(store.release)
+	hub := NewHub()
+	hub.Store = store
+	go hub.Run()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Stream(ctx, EventTopic("slow"), nil)
+	time.Sleep(50 * time.Millisecond)
+
+	fast := hub.Stream(ctx, EventTopic("fast"), nil)
+	hub.Publish([]byte(`{"type":"dish_added"}`), EventTopic("fast"))
+	for _, want := range []string{FrameSubscribed, "dish_added"} {
+		select {
+		case msg := <-fast.C:
+			if !strings.Contains(string(msg), want) {
+				t.Errorf("expected %s, got %s", want, msg)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("expected %s while another topic's history loads", want)
+		}
+	}
+}
+
+func TestStreamReplaysAndFollowsTopic(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	hub.Publish([]byte(`{"type":"rsvp_updated","data":{"n":1}}`), EventTopic("e1"))
+	hub.Publish([]byte(`{"type":"rsvp_updated","data":{"n":2}}`), EventTopic("e1"))
+	// Publishing is asynchronous; let the hub number both first
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	since := uint64(1)
+	stream := hub.Stream(ctx, EventTopic("e1"), &since)
+
+	next := func() string {
+		select {
+		case msg := <-stream.C:
+			return string(msg)
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timed out waiting for stream message")
+			return ""
+		}
+	}
+
+	if got, want := next(), `{"type":"rsvp_updated","data":{"n":2},"seq":{"event:e1":2}}`; got != want {
+		t.Errorf("replay = %s, want %s", got, want)
+	}
+	if got, want := next(), `{"type":"subscribed","topic":"event:e1","seq":2}`; got != want {
+		t.Errorf("ack = %s, want %s", got, want)
+	}
+	hub.Publish([]byte(`{"type":"rsvp_updated","data":{"n":3}}`), EventTopic("e1"))
+	if got, want := next(), `{"type":"rsvp_updated","data":{"n":3},"seq":{"event:e1":3}}`; got != want {
+		t.Errorf("live = %s, want %s", got, want)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-stream.C:
+		if ok {
+			t.Error("Expected stream to close after cancel")
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Stream was not closed after cancel")
+	}
+	if reason := stream.CloseReason(); reason != "" {
+		t.Errorf("CloseReason = %q, want none", reason)
+	}
+}
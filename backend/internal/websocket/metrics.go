@@ -0,0 +1,44 @@
+package websocket
+
+// Metrics is a snapshot of the hub's load.
+type Metrics struct {
+	ConnectedClients int `json:"connected_clients"`
+	Topics           int `json:"topics"`
+	// MessagesSent counts messages queued to clients, including replays.
+	MessagesSent uint64 `json:"messages_sent"`
+	// SlowConsumerDrops counts clients disconnected because their queue was
+	// full.
+	SlowConsumerDrops uint64 `json:"slow_consumer_drops"`
+	// QueueDepth is the number of messages waiting to be written across all
+	// clients, and MaxQueueDepth the longest single client's queue, out of
+	// QueueCapacity.
+	QueueDepth    int `json:"queue_depth"`
+	MaxQueueDepth int `json:"max_queue_depth"`
+	QueueCapacity int `json:"queue_capacity"`
+	// BrokerErrors counts messages that could not be published through the
+	// Broker and reached this instance's clients only.
+	BrokerErrors uint64 `json:"broker_errors"`
+}
+
+// Metrics returns the hub's current counters.
+func (h *Hub) Metrics() Metrics {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	m := Metrics{
+		ConnectedClients:  len(h.clients),
+		Topics:            len(h.topics),
+		MessagesSent:      h.sent,
+		SlowConsumerDrops: h.drops,
+		QueueCapacity:     sendBufferSize,
+		BrokerErrors:      h.brokerErrors.Load(),
+	}
+	for client := range h.clients {
+		depth := len(client.send)
+		m.QueueDepth += depth
+		if depth > m.MaxQueueDepth {
+			m.MaxQueueDepth = depth
+		}
+	}
+	return m
+}
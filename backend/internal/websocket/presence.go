@@ -0,0 +1,227 @@
+package websocket
+
+import (
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Presence frames let a client say which event it has open, on an event
+// topic it is subscribed to:
+//
+//	{"type": "join", "topic": "event:<id>"}
+//	{"type": "heartbeat", "topic": "event:<id>"}
+//	{"type": "leave", "topic": "event:<id>"}
+//	{"type": "typing", "topic": "event:<id>", "typing": true}
+//
+// They are only answered if rejected. A client that joined must send a
+// heartbeat more often than presenceTTL or it is taken to have left; leaving,
+// unsubscribing and disconnecting all end its presence straight away.
+//
+// Whenever the set of members on an event changes, its subscribers are sent a
+// "presence_changed" snapshot, and "typing" frames are passed on to them as
+// "typing_changed". Neither is numbered or replayed: a client that missed
+// them asks GET /events/{id}/presence.
+const (
+	FrameJoin      = "join"
+	FrameHeartbeat = "heartbeat"
+	FrameLeave     = "leave"
+	FrameTyping    = "typing"
+)
+
+// presenceTTL is how long a join or heartbeat keeps a connection present.
+const presenceTTL = 60 * time.Second
+
+// PresenceUpdate travels through the Broker so that every instance knows who
+// is present on connections held by the others.
+type PresenceUpdate struct {
+	Topic string `json:"topic"`
+	// Conn identifies the connection across instances.
+	Conn    string         `json:"conn"`
+	Member  realtime.Actor `json:"member"`
+	Present bool           `json:"present"`
+}
+
+type presenceEntry struct {
+	member  realtime.Actor
+	expires time.Time
+}
+
+// Presence returns the members present on topic, sorted by name.
+func (h *Hub) Presence(topic string) []realtime.Actor {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.presenceSnapshot(topic)
+}
+
+// presenceSnapshot lists each member present on topic once, however many
+// connections they have it open on. The caller must hold h.mu.
+func (h *Hub) presenceSnapshot(topic string) []realtime.Actor {
+	seen := make(map[primitive.ObjectID]bool)
+	members := []realtime.Actor{}
+	for _, e := range h.presence[topic] {
+		if seen[e.member.ID] {
+			continue
+		}
+		seen[e.member.ID] = true
+		members = append(members, e.member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Name != members[j].Name {
+			return members[i].Name < members[j].Name
+		}
+		return members[i].ID.Hex() < members[j].ID.Hex()
+	})
+	return members
+}
+
+// applyPresence records u and, if that changes who is present, tells the
+// topic's subscribers on this instance. The caller must hold h.mu.
+func (h *Hub) applyPresence(u PresenceUpdate, now time.Time) {
+	before := h.presenceSnapshot(u.Topic)
+	if u.Present {
+		if h.presence[u.Topic] == nil {
+			h.presence[u.Topic] = make(map[string]presenceEntry)
+		}
+		h.presence[u.Topic][u.Conn] = presenceEntry{member: u.Member, expires: now.Add(presenceTTL)}
+	} else {
+		delete(h.presence[u.Topic], u.Conn)
+		if len(h.presence[u.Topic]) == 0 {
+			delete(h.presence, u.Topic)
+		}
+	}
+	h.notifyPresence(u.Topic, before)
+}
+
+// expirePresence drops connections that stopped sending heartbeats, including
+// those of an instance that went away. The caller must hold h.mu.
+func (h *Hub) expirePresence(now time.Time) {
+	for topic, entries := range h.presence {
+		before := h.presenceSnapshot(topic)
+		for conn, e := range entries {
+			if now.After(e.expires) {
+				delete(entries, conn)
+			}
+		}
+		if len(entries) == 0 {
+			delete(h.presence, topic)
+		}
+		h.notifyPresence(topic, before)
+	}
+}
+
+// notifyPresence sends the topic's subscribers a snapshot if it differs from
+// before. The caller must hold h.mu.
+func (h *Hub) notifyPresence(topic string, before []realtime.Actor) {
+	after := h.presenceSnapshot(topic)
+	if sameMembers(before, after) {
+		return
+	}
+	_, id, _ := SplitTopic(topic)
+	eventID, _ := primitive.ObjectIDFromHex(id)
+	message, err := json.Marshal(realtime.NewEnvelope(nil, realtime.PresenceChanged{EventID: eventID, Members: after}))
+	if err != nil {
+		log.Printf("websocket: encoding presence for %s: %v", topic, err)
+		return
+	}
+	for client := range h.topics[topic] {
+		h.deliver(client, message)
+	}
+}
+
+func sameMembers(a, b []realtime.Actor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// handlePresenceFrame checks a presence or typing frame and passes it on
+// through the broker. It runs on the client's reader, like handleFrame.
+func (c *Client) handlePresenceFrame(frame controlFrame) {
+	kind, id, _ := SplitTopic(frame.Topic)
+	if _, err := primitive.ObjectIDFromHex(id); kind != "event" || err != nil {
+		c.hub.changes <- c.rejection(frame, "presence is only tracked on events")
+		return
+	}
+	if c.member == nil {
+		c.hub.changes <- c.rejection(frame, "anonymous connection")
+		return
+	}
+
+	c.hub.mu.Lock()
+	subscribed := c.topics[frame.Topic]
+	if subscribed && frame.Type != FrameTyping {
+		if frame.Type == FrameLeave {
+			delete(c.present, frame.Topic)
+		} else {
+			c.present[frame.Topic] = true
+		}
+	}
+	c.hub.mu.Unlock()
+	if !subscribed {
+		c.hub.changes <- c.rejection(frame, "not subscribed")
+		return
+	}
+
+	switch frame.Type {
+	case FrameJoin, FrameHeartbeat:
+		c.relayPresence(frame.Topic, true)
+	case FrameLeave:
+		c.relayPresence(frame.Topic, false)
+	case FrameTyping:
+		eventID, _ := primitive.ObjectIDFromHex(id)
+		typing := realtime.TypingChanged{EventID: eventID, Member: c.actor(), Typing: frame.Typing}
+		message, err := json.Marshal(realtime.NewEnvelope(c.member, typing))
+		if err != nil {
+			return
+		}
+		c.hub.relay(BrokerMessage{Origin: c.hub.id, Ephemeral: true, Topics: []string{frame.Topic}, Message: message})
+	}
+}
+
+// leave ends the client's presence on topic when it unsubscribes, or on every
+// topic, with topic empty, when it disconnects.
+func (c *Client) leave(topic string) {
+	c.hub.mu.Lock()
+	var topics []string
+	for t := range c.present {
+		if topic == "" || t == topic {
+			topics = append(topics, t)
+			delete(c.present, t)
+		}
+	}
+	c.hub.mu.Unlock()
+	for _, t := range topics {
+		c.relayPresence(t, false)
+	}
+}
+
+func (c *Client) relayPresence(topic string, present bool) {
+	c.hub.relay(BrokerMessage{
+		Origin:   c.hub.id,
+		Presence: &PresenceUpdate{Topic: topic, Conn: c.id, Member: c.actor(), Present: present},
+	})
+}
+
+func (c *Client) actor() realtime.Actor {
+	return realtime.Actor{ID: c.member.ID, Name: c.member.Name}
+}
+
+// memberOf keeps just what presence needs of the connection's member.
+func memberOf(member *models.FamilyMember) *models.FamilyMember {
+	if member == nil {
+		return nil
+	}
+	return &models.FamilyMember{ID: member.ID, Name: member.Name}
+}
@@ -0,0 +1,198 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
+	"family-potluck/backend/internal/realtime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// presenceServer serves WebSockets on hub as the member named in the "name"
+// query parameter, allowing every subscription.
+func presenceServer(t *testing.T, hub *Hub, members map[string]*models.FamilyMember) *httptest.Server {
+	t.Helper()
+	allowAll := func(ctx context.Context, topic string) (bool, error) { return true, nil }
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{Member: members[r.URL.Query().Get("name")], Authorize: allowAll})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func dialAs(t *testing.T, s *httptest.Server, name string) *websocket.Conn {
+	t.Helper()
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"?name="+name, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+type presenceEnvelope struct {
+	Type string `json:"type"`
+	Data struct {
+		Members []realtime.Actor `json:"members"`
+		Member  realtime.Actor   `json:"member"`
+		Typing  bool             `json:"typing"`
+	} `json:"data"`
+}
+
+func readEnvelope(t *testing.T, ws *websocket.Conn) presenceEnvelope {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(1 * time.Second))
+	_, p, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	var e presenceEnvelope
+	if err := json.Unmarshal(p, &e); err != nil {
+		t.Fatalf("Failed to decode %q: %v", p, err)
+	}
+	return e
+}
+
+func memberNames(actors []realtime.Actor) string {
+	names := make([]string, len(actors))
+	for i, a := range actors {
+		names[i] = a.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestPresenceJoinTypingAndDisconnect(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	may := &models.FamilyMember{ID: primitive.NewObjectID(), Name: "Aunt May"}
+	ben := &models.FamilyMember{ID: primitive.NewObjectID(), Name: "Uncle Ben"}
+	s := presenceServer(t, hub, map[string]*models.FamilyMember{"may": may, "ben": ben})
+	topic := EventTopic(primitive.NewObjectID().Hex())
+
+	wsMay := dialAs(t, s, "may")
+	wsMay.WriteJSON(controlFrame{Type: FrameSubscribe, Topic: topic})
+	readReply(t, wsMay)
+	wsMay.WriteJSON(controlFrame{Type: FrameJoin, Topic: topic})
+	if e := readEnvelope(t, wsMay); e.Type != "presence_changed" || memberNames(e.Data.Members) != "Aunt May" {
+		t.Fatalf("Unexpected presence after joining: %+v", e)
+	}
+
+	wsBen := dialAs(t, s, "ben")
+	wsBen.WriteJSON(controlFrame{Type: FrameSubscribe, Topic: topic})
+	readReply(t, wsBen)
+	wsBen.WriteJSON(controlFrame{Type: FrameJoin, Topic: topic})
+	for _, ws := range []*websocket.Conn{wsMay, wsBen} {
+		if e := readEnvelope(t, ws); memberNames(e.Data.Members) != "Aunt May,Uncle Ben" {
+			t.Fatalf("Unexpected presence after second join: %+v", e)
+		}
+	}
+	if got := memberNames(hub.Presence(topic)); got != "Aunt May,Uncle Ben" {
+		t.Errorf("Presence() = %s", got)
+	}
+
+	// A heartbeat from someone already present changes nothing
+	wsBen.WriteJSON(controlFrame{Type: FrameHeartbeat, Topic: topic})
+	wsBen.WriteJSON(controlFrame{Type: FrameTyping, Topic: topic, Typing: true})
+	if e := readEnvelope(t, wsMay); e.Type != "typing_changed" || e.Data.Member.ID != ben.ID || !e.Data.Typing {
+		t.Fatalf("Expected Ben typing, got %+v", e)
+	}
+
+	wsBen.Close()
+	if e := readEnvelope(t, wsMay); e.Type != "presence_changed" || memberNames(e.Data.Members) != "Aunt May" {
+		t.Fatalf("Unexpected presence after disconnect: %+v", e)
+	}
+}
+
+func TestPresenceFrameRejections(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	may := &models.FamilyMember{ID: primitive.NewObjectID(), Name: "Aunt May"}
+	s := presenceServer(t, hub, map[string]*models.FamilyMember{"may": may})
+	topic := EventTopic(primitive.NewObjectID().Hex())
+
+	tests := []struct {
+		name  string
+		as    string
+		frame controlFrame
+		want  string
+	}{
+		{"not subscribed", "may", controlFrame{Type: FrameJoin, Topic: topic}, "not subscribed"},
+		{"group topic", "may", controlFrame{Type: FrameJoin, Topic: GroupTopic(primitive.NewObjectID().Hex())}, "presence is only tracked on events"},
+		{"anonymous", "", controlFrame{Type: FrameTyping, Topic: topic}, "anonymous connection"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := dialAs(t, s, tt.as)
+			ws.WriteJSON(tt.frame)
+			if reply := readReply(t, ws); reply.Type != FrameError || reply.Error != tt.want {
+				t.Errorf("Expected %q error, got %+v", tt.want, reply)
+			}
+		})
+	}
+}
+
+func TestExpirePresence(t *testing.T) {
+	hub := NewHub()
+	client := &Client{hub: hub, send: make(chan []byte, 4), topics: map[string]bool{}}
+	topic := EventTopic(primitive.NewObjectID().Hex())
+	hub.clients[client] = true
+	hub.addToTopic(client, topic)
+
+	now := time.Now()
+	may := realtime.Actor{ID: primitive.NewObjectID(), Name: "Aunt May"}
+	hub.applyPresence(PresenceUpdate{Topic: topic, Conn: "other-1", Member: may, Present: true}, now)
+	<-client.send
+
+	hub.expirePresence(now.Add(presenceTTL / 2))
+	if len(hub.Presence(topic)) != 1 {
+		t.Fatal("Presence expired before its TTL")
+	}
+
+	hub.expirePresence(now.Add(presenceTTL + time.Second))
+	if len(hub.Presence(topic)) != 0 {
+		t.Error("Presence outlived its TTL")
+	}
+	select {
+	case msg := <-client.send:
+		if !strings.Contains(string(msg), `"members":[]`) {
+			t.Errorf("Expected an empty snapshot, got %s", msg)
+		}
+	default:
+		t.Error("Expected subscribers to be told about the expiry")
+	}
+}
+
+func TestPresenceIsSharedBetweenHubs(t *testing.T) {
+	broker := NewMemoryBroker()
+	hubA, hubB := NewHub(), NewHub()
+	hubA.Broker, hubB.Broker = broker, broker
+	go hubA.Run()
+	go hubB.Run()
+
+	may := &models.FamilyMember{ID: primitive.NewObjectID(), Name: "Aunt May"}
+	s := presenceServer(t, hubA, map[string]*models.FamilyMember{"may": may})
+	topic := EventTopic(primitive.NewObjectID().Hex())
+
+	ws := dialAs(t, s, "may")
+	ws.WriteJSON(controlFrame{Type: FrameSubscribe, Topic: topic})
+	readReply(t, ws)
+	ws.WriteJSON(controlFrame{Type: FrameJoin, Topic: topic})
+	readEnvelope(t, ws)
+
+	deadline := time.Now().Add(1 * time.Second)
+	for memberNames(hubB.Presence(topic)) != "Aunt May" {
+		if time.Now().After(deadline) {
+			t.Fatal("Other hub never saw the join")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
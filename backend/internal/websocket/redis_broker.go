@@ -0,0 +1,258 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RedisBroker relays messages through a Redis PUBLISH/SUBSCRIBE channel, so
+// any server speaking the Redis protocol (Redis, Valkey, KeyDB, ...) can
+// connect the instances. It talks RESP directly over TCP.
+type RedisBroker struct {
+	Addr     string
+	Password string
+	Channel  string
+
+	// DialTimeout bounds connecting and each command on the publish connection.
+	DialTimeout time.Duration
+	// MaxBackoff caps the wait between attempts to resubscribe.
+	MaxBackoff time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+const defaultRedisChannel = "family-potluck:realtime"
+
+func NewRedisBroker(addr, password, channel string) *RedisBroker {
+	if channel == "" {
+		channel = defaultRedisChannel
+	}
+	return &RedisBroker{
+		Addr:        addr,
+		Password:    password,
+		Channel:     channel,
+		DialTimeout: 5 * time.Second,
+		MaxBackoff:  30 * time.Second,
+	}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, msg BrokerMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A pooled connection may have gone stale since the last publish, so a
+	// failure on it is retried once on a fresh one.
+	for attempt := 0; ; attempt++ {
+		err = b.publishLocked(ctx, payload)
+		if err == nil || attempt == 1 {
+			return err
+		}
+	}
+}
+
+// publishLocked must be called with b.mu held.
+func (b *RedisBroker) publishLocked(ctx context.Context, payload []byte) error {
+	if b.conn == nil {
+		conn, reader, err := b.dial(ctx)
+		if err != nil {
+			return err
+		}
+		b.conn, b.reader = conn, reader
+	}
+
+	deadline := time.Now().Add(b.DialTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	b.conn.SetDeadline(deadline)
+
+	_, err := b.conn.Write(encodeCommand("PUBLISH", b.Channel, string(payload)))
+	if err == nil {
+		_, err = readRESP(b.reader)
+	}
+	if err != nil {
+		b.conn.Close()
+		b.conn, b.reader = nil, nil
+	}
+	return err
+}
+
+// Subscribe keeps a subscription open until ctx is done, reconnecting with
+// backoff when the connection drops. Messages published while disconnected
+// never reach this instance's clients.
+func (b *RedisBroker) Subscribe(ctx context.Context) (<-chan BrokerMessage, error) {
+	out := make(chan BrokerMessage, sendBufferSize)
+	go func() {
+		defer close(out)
+		backoff := time.Second
+		for ctx.Err() == nil {
+			start := time.Now()
+			err := b.subscribeOnce(ctx, out)
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(start) > b.MaxBackoff {
+				backoff = time.Second
+			}
+			log.Printf("websocket: redis subscription to %s lost: %v; retrying in %s", b.Addr, err, backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = min(backoff*2, b.MaxBackoff)
+		}
+	}()
+	return out, nil
+}
+
+func (b *RedisBroker) subscribeOnce(ctx context.Context, out chan<- BrokerMessage) error {
+	conn, reader, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Reads block indefinitely, so closing the connection is how ctx stops them.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn.SetDeadline(time.Now().Add(b.DialTimeout))
+	if _, err := conn.Write(encodeCommand("SUBSCRIBE", b.Channel)); err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	for {
+		reply, err := readRESP(reader)
+		if err != nil {
+			return err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 3 {
+			continue
+		}
+		if kind, _ := parts[0].(string); kind != "message" {
+			continue
+		}
+		payload, _ := parts[2].(string)
+
+		var msg BrokerMessage
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			log.Printf("websocket: ignoring malformed broker message: %v", err)
+			continue
+		}
+		select {
+		case out <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *RedisBroker) dial(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	dialer := net.Dialer{Timeout: b.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", b.Addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(conn)
+
+	if b.Password != "" {
+		conn.SetDeadline(time.Now().Add(b.DialTimeout))
+		_, err = conn.Write(encodeCommand("AUTH", b.Password))
+		if err == nil {
+			_, err = readRESP(reader)
+		}
+		conn.SetDeadline(time.Time{})
+		if err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("redis auth: %w", err)
+		}
+	}
+	return conn, reader, nil
+}
+
+// encodeCommand renders args as a RESP array of bulk strings.
+func encodeCommand(args ...string) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// redisError is an error reply ("-ERR ...") from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// readRESP reads one RESP value: simple and bulk strings become string,
+// integers int64, arrays []interface{} and nulls nil. Error replies are
+// returned as a redisError.
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply")
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply type %q", kind)
+}
@@ -0,0 +1,111 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Request frames let a client make changes over the socket instead of REST:
+//
+//	{"type": "request", "request_id": "7", "method": "chat.send", "params": {...}}
+//
+// Each is answered, once it has run, with a response carrying the same
+// request_id and either the method's result or an HTTP-style status and error:
+//
+//	{"type": "response", "request_id": "7", "result": {...}}
+//	{"type": "response", "request_id": "7", "status": 403, "error": "Forbidden"}
+//
+// Requests run concurrently, so responses may arrive out of order; the
+// request_id is how a client matches them up.
+const (
+	FrameRequest  = "request"
+	FrameResponse = "response"
+)
+
+// maxPendingRequests bounds how many requests one connection may have
+// running at once.
+const maxPendingRequests = 8
+
+// requestTimeout bounds running a single request.
+const requestTimeout = 10 * time.Second
+
+// RequestHandler runs a request frame's method for the connection it was
+// given to and returns the method's JSON result.
+type RequestHandler func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error)
+
+// RequestError fails a request with an HTTP status code. Any other error from
+// a RequestHandler is reported as an internal error.
+type RequestError struct {
+	Status  int
+	Message string
+}
+
+func (e *RequestError) Error() string { return e.Message }
+
+type responseFrame struct {
+	Type      string          `json:"type"`
+	RequestID string          `json:"request_id"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Status    int             `json:"status,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// directMessage is a reply to one client that isn't a subscription change.
+type directMessage struct {
+	client  *Client
+	message []byte
+}
+
+// handleRequest starts running a request frame, answering straight away if it
+// can't be run. It is called from the client's reader.
+func (c *Client) handleRequest(frame controlFrame) {
+	switch {
+	case frame.RequestID == "":
+		c.respond(responseFrame{Status: http.StatusBadRequest, Error: "request_id is required"})
+		return
+	case c.handle == nil:
+		c.respond(responseFrame{RequestID: frame.RequestID, Status: http.StatusForbidden, Error: "requests are not accepted on this connection"})
+		return
+	}
+
+	select {
+	case c.requests <- struct{}{}:
+	default:
+		c.respond(responseFrame{RequestID: frame.RequestID, Status: http.StatusTooManyRequests, Error: "too many requests in flight"})
+		return
+	}
+
+	go func() {
+		defer func() { <-c.requests }()
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+
+		result, err := c.handle(ctx, frame.Method, frame.Params)
+		reply := responseFrame{RequestID: frame.RequestID, Result: result}
+		var reqErr *RequestError
+		switch {
+		case errors.As(err, &reqErr):
+			reply.Result, reply.Status, reply.Error = nil, reqErr.Status, reqErr.Message
+		case err != nil:
+			log.Printf("websocket: request %s: %v", frame.Method, err)
+			reply.Result, reply.Status, reply.Error = nil, http.StatusInternalServerError, "internal error"
+		}
+		c.respond(reply)
+	}()
+}
+
+// respond hands reply to Run, which delivers it if the client is still
+// connected.
+func (c *Client) respond(reply responseFrame) {
+	reply.Type = FrameResponse
+	message, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("websocket: encoding response: %v", err)
+		return
+	}
+	c.hub.direct <- directMessage{client: c, message: message}
+}
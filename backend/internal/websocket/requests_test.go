@@ -0,0 +1,130 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func readResponse(t *testing.T, ws *websocket.Conn) responseFrame {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(1 * time.Second))
+	_, p, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	var resp responseFrame
+	if err := json.Unmarshal(p, &resp); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", p, err)
+	}
+	return resp
+}
+
+func TestRequests(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	handle := func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		switch method {
+		case "echo":
+			return params, nil
+		case "forbidden":
+			return nil, &RequestError{Status: http.StatusForbidden, Message: "Forbidden"}
+		}
+		return nil, errors.New("database is down")
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{Handle: handle})
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer ws.Close()
+
+	tests := []struct {
+		name  string
+		frame controlFrame
+		want  responseFrame
+	}{
+		{"result", controlFrame{Type: FrameRequest, RequestID: "1", Method: "echo", Params: json.RawMessage(`{"n":1}`)}, responseFrame{RequestID: "1", Result: json.RawMessage(`{"n":1}`)}},
+		{"request error", controlFrame{Type: FrameRequest, RequestID: "2", Method: "forbidden"}, responseFrame{RequestID: "2", Status: http.StatusForbidden, Error: "Forbidden"}},
+		{"other error", controlFrame{Type: FrameRequest, RequestID: "3", Method: "boom"}, responseFrame{RequestID: "3", Status: http.StatusInternalServerError, Error: "internal error"}},
+		{"no request_id", controlFrame{Type: FrameRequest, Method: "echo"}, responseFrame{Status: http.StatusBadRequest, Error: "request_id is required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws.WriteJSON(tt.frame)
+			got := readResponse(t, ws)
+			if got.Type != FrameResponse || got.RequestID != tt.want.RequestID || string(got.Result) != string(tt.want.Result) ||
+				got.Status != tt.want.Status || got.Error != tt.want.Error {
+				t.Errorf("Got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestsRefusedWithoutHandler(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{})
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer ws.Close()
+
+	ws.WriteJSON(controlFrame{Type: FrameRequest, RequestID: "1", Method: "chat.send"})
+	if got := readResponse(t, ws); got.RequestID != "1" || got.Status != http.StatusForbidden {
+		t.Errorf("Expected the request to be refused, got %+v", got)
+	}
+}
+
+func TestRequestsInFlightAreLimited(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	release := make(chan struct{})
+	handle := func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		<-release
+		return nil, nil
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{Handle: handle})
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer ws.Close()
+
+	for i := 0; i <= maxPendingRequests; i++ {
+		ws.WriteJSON(controlFrame{Type: FrameRequest, RequestID: "r" + string(rune('a'+i)), Method: "slow"})
+	}
+	if got := readResponse(t, ws); got.Status != http.StatusTooManyRequests {
+		t.Fatalf("Expected the extra request to be refused, got %+v", got)
+	}
+
+	close(release)
+	for i := 0; i < maxPendingRequests; i++ {
+		if got := readResponse(t, ws); got.Status != 0 || got.Error != "" {
+			t.Errorf("Expected success once released, got %+v", got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package websocket
+
+import "context"
+
+// Stream is a subscription for a consumer that isn't a WebSocket, such as a
+// Server-Sent Events response.
+type Stream struct {
+	// C carries the same envelopes a WebSocket client receives: any replay
+	// (or a "resync_required" frame), the "subscribed" acknowledgement, then
+	// live messages. It is closed when the stream ends.
+	C <-chan []byte
+
+	client *Client
+}
+
+// CloseReason says why the hub ended the stream, e.g. ReasonSlowConsumer. It
+// is only meaningful once C has been closed, and is empty when the stream
+// ended because its context was done.
+func (s *Stream) CloseReason() string {
+	return s.client.closeReason
+}
+
+// Stream subscribes to topic until ctx is done, replaying what was published
+// after since when it is set. The caller must already have authorized the
+// topic.
+func (h *Hub) Stream(ctx context.Context, topic string, since *uint64) *Stream {
+	client := &Client{
+		hub:    h,
+		send:   make(chan []byte, sendBufferSize),
+		config: h.Config.withDefaults(),
+		topics: make(map[string]bool),
+	}
+	h.warmHistory(topic)
+	h.register <- client
+	h.changes <- subscriptionChange{
+		client:    client,
+		topic:     topic,
+		subscribe: true,
+		since:     since,
+		reply:     controlReply{Type: FrameSubscribed, Topic: topic},
+	}
+
+	go func() {
+		<-ctx.Done()
+		h.unregister <- client
+	}()
+	return &Stream{C: client.send, client: client}
+}
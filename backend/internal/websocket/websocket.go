@@ -1,84 +1,461 @@
 package websocket
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"family-potluck/backend/internal/models"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for now
-	},
+// Topics scope who receives a message. A client starts out subscribed to its
+// own user topic and subscribes to group and event topics as the pages it
+// shows need them.
+func GroupTopic(groupID string) string { return "group:" + groupID }
+func EventTopic(eventID string) string { return "event:" + eventID }
+func UserTopic(userID string) string   { return "user:" + userID }
+
+// SplitTopic breaks topic into its kind ("group", "event" or "user") and id.
+func SplitTopic(topic string) (kind, id string, ok bool) {
+	kind, id, found := strings.Cut(topic, ":")
+	if !found || id == "" {
+		return "", "", false
+	}
+	switch kind {
+	case "group", "event", "user":
+		return kind, id, true
+	}
+	return "", "", false
+}
+
+// Authorizer decides whether a connection may subscribe to topic. ServeWs
+// takes one per connection, so it already knows who is on the other end.
+type Authorizer func(ctx context.Context, topic string) (bool, error)
+
+// ConnOptions describes a connection to ServeWs.
+type ConnOptions struct {
+	// Member is who authenticated the connection, or nil for an anonymous one,
+	// which can't join presence.
+	Member *models.FamilyMember
+	// Topics are subscribed to straight away.
+	Topics []string
+	// Authorize checks further subscriptions; when it is nil they are all
+	// refused.
+	Authorize Authorizer
+	// Handle runs "request" frames; when it is nil they are all refused.
+	Handle RequestHandler
+}
+
+// maxTopicsPerClient bounds how many subscriptions one connection can hold.
+const maxTopicsPerClient = 100
+
+// brokerTimeout bounds publishing one message through the Broker.
+const brokerTimeout = 5 * time.Second
+
+// authorizeTimeout bounds the lookups behind a single subscribe frame.
+const authorizeTimeout = 5 * time.Second
+
+// Control frames let a client change its subscriptions:
+//
+//	{"type": "subscribe", "topic": "event:<id>", "request_id": "1"}
+//	{"type": "unsubscribe", "topic": "event:<id>", "request_id": "2"}
+//
+// Each is answered with a "subscribed", "unsubscribed" or "error" frame
+// carrying the same topic and request_id. "subscribed" also carries the
+// topic's current sequence number.
+//
+// Every published message gets a "seq" field mapping each of its topics to a
+// sequence number that increases by one per message on that topic. A client
+// that reconnects subscribes with "since" set to the last seq it saw and is
+// sent the messages it missed before the acknowledgement, or a
+// "resync_required" frame if they are no longer available and it should
+// reload instead.
+const (
+	FrameSubscribe      = "subscribe"
+	FrameUnsubscribe    = "unsubscribe"
+	FrameSubscribed     = "subscribed"
+	FrameUnsubscribed   = "unsubscribed"
+	FrameResyncRequired = "resync_required"
+	FrameError          = "error"
+	FrameDisconnect     = "disconnect"
+)
+
+// Reasons sent in a "disconnect" frame, and the close frame after it, when the
+// server ends a connection.
+const ReasonSlowConsumer = "slow_consumer"
+
+type controlFrame struct {
+	Type      string  `json:"type"`
+	Topic     string  `json:"topic"`
+	RequestID string  `json:"request_id,omitempty"`
+	Since     *uint64 `json:"since,omitempty"`
+	Typing    bool    `json:"typing,omitempty"`
+
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type controlReply struct {
+	Type      string `json:"type"`
+	Topic     string `json:"topic,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Seq       uint64 `json:"seq,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type disconnectFrame struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
 }
 
 type Hub struct {
 	clients    map[*Client]bool
+	topics     map[string]map[*Client]bool
 	broadcast  chan []byte
+	publish    chan publication
 	register   chan *Client
 	unregister chan *Client
+	changes    chan subscriptionChange
+	direct     chan directMessage
+	histories  map[string]*topicHistory
+	persist    chan models.RealtimeMessage
 	mu         sync.Mutex
+
+	// presence maps event topics to the connections, on any instance, that
+	// have the event open. It is guarded by mu.
+	presence        map[string]map[string]presenceEntry
+	presenceUpdates chan PresenceUpdate
+	clientSeq       atomic.Uint64
+
+	// Store, when set before Run, keeps topic histories across restarts.
+	Store Store
+
+	// Broker, when set before Run, connects this hub to those of other
+	// instances. It defaults to a MemoryBroker for a single instance.
+	Broker     Broker
+	id         string
+	listenOnce sync.Once
+
+	// Config applies to connections opened after it is set.
+	Config Config
+
+	// Counters reported by Metrics, guarded by mu.
+	sent         uint64
+	drops        uint64
+	brokerErrors atomic.Uint64
+
+	// CheckOrigin decides whether a browser origin may open a socket. The
+	// socket is authenticated by cookie, so any origin must not be allowed.
+	// When nil, only same-origin requests (or those without an Origin header)
+	// are accepted.
+	CheckOrigin func(r *http.Request) bool
+}
+
+type publication struct {
+	message []byte
+	topics  []string
+	origin  string
+	// ephemeral messages are delivered without being numbered or kept.
+	ephemeral bool
+}
+
+// subscriptionChange is applied by Run so that it is ordered after the
+// client's registration and before its removal.
+type subscriptionChange struct {
+	client    *Client
+	topic     string
+	subscribe bool
+	since     *uint64
+	reply     controlReply
+	// applied, if set, is closed once the change has been made.
+	applied chan struct{}
 }
 
 type Client struct {
-	hub  *Hub
-	conn *websocket.Conn
-	send chan []byte
+	hub       *Hub
+	conn      *websocket.Conn
+	send      chan []byte
+	authorize Authorizer
+	handle    RequestHandler
+	config    Config
+
+	// requests holds a token for each request being handled.
+	requests chan struct{}
+
+	// id is unique across instances; member is nil for anonymous connections.
+	id     string
+	member *models.FamilyMember
+
+	// closeReason is set, under hub.mu, before the hub closes send.
+	closeReason string
+
+	// topics, and present, the topics it has joined, are guarded by hub.mu.
+	topics  map[string]bool
+	present map[string]bool
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		clients:    make(map[*Client]bool),
+		Broker:          NewMemoryBroker(),
+		id:              newHubID(),
+		broadcast:       make(chan []byte),
+		publish:         make(chan publication),
+		register:        make(chan *Client),
+		unregister:      make(chan *Client),
+		changes:         make(chan subscriptionChange),
+		direct:          make(chan directMessage),
+		histories:       make(map[string]*topicHistory),
+		persist:         make(chan models.RealtimeMessage, 1024),
+		presence:        make(map[string]map[string]presenceEntry),
+		presenceUpdates: make(chan PresenceUpdate),
+		Config:          DefaultConfig(),
+		clients:         make(map[*Client]bool),
+		topics:          make(map[string]map[*Client]bool),
 	}
 }
 
 func (h *Hub) Run() {
+	if h.Store != nil {
+		go h.persistHistory()
+	}
+	h.listen()
+	sweep := time.NewTicker(presenceTTL / 4)
+	defer sweep.Stop()
 	for {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client] = true
+			for topic := range client.topics {
+				h.addToTopic(client, topic)
+			}
 			h.mu.Unlock()
-		case client := <-h.unregister:
+		case c := <-h.changes:
+			h.mu.Lock()
+			h.applyChange(c)
+			h.mu.Unlock()
+			if c.applied != nil {
+				close(c.applied)
+			}
+		case d := <-h.direct:
 			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
+			if _, ok := h.clients[d.client]; ok {
+				h.deliver(d.client, d.message)
 			}
 			h.mu.Unlock()
+		case client := <-h.unregister:
+			h.mu.Lock()
+			h.removeClient(client)
+			h.mu.Unlock()
 		case message := <-h.broadcast:
 			h.mu.Lock()
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
+				h.deliver(client, message)
+			}
+			h.mu.Unlock()
+		case u := <-h.presenceUpdates:
+			h.mu.Lock()
+			h.applyPresence(u, time.Now())
+			h.mu.Unlock()
+		case now := <-sweep.C:
+			h.mu.Lock()
+			h.expirePresence(now)
+			h.mu.Unlock()
+		case p := <-h.publish:
+			h.mu.Lock()
+			// Every instance records the message, but only the one it was
+			// published on stores it.
+			message := p.message
+			if !p.ephemeral {
+				message = h.record(p.message, p.topics, p.origin == h.id)
+			}
+			// A client subscribed to several of the topics gets the message once
+			recipients := make(map[*Client]bool)
+			for _, topic := range p.topics {
+				for client := range h.topics[topic] {
+					recipients[client] = true
 				}
 			}
+			for client := range recipients {
+				h.deliver(client, message)
+			}
 			h.mu.Unlock()
 		}
 	}
 }
 
+// addToTopic must be called with h.mu held.
+func (h *Hub) addToTopic(client *Client, topic string) {
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*Client]bool)
+	}
+	h.topics[topic][client] = true
+}
+
+// removeFromTopic must be called with h.mu held.
+func (h *Hub) removeFromTopic(client *Client, topic string) {
+	delete(h.topics[topic], client)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+// applyChange updates the client's subscriptions and acknowledges the frame
+// that asked for it. The caller must hold h.mu.
+func (h *Hub) applyChange(c subscriptionChange) {
+	if _, ok := h.clients[c.client]; !ok {
+		return
+	}
+	reply := c.reply
+	switch {
+	case reply.Type == FrameError:
+		// Rejected by the client's reader; only the reply is delivered
+	case !c.subscribe:
+		delete(c.client.topics, c.topic)
+		h.removeFromTopic(c.client, c.topic)
+	case !c.client.topics[c.topic] && len(c.client.topics) >= maxTopicsPerClient:
+		reply.Type = FrameError
+		reply.Error = "too many subscriptions"
+	default:
+		// Replaying and subscribing under the same lock means nothing
+		// published in between is missed or sent twice.
+		if c.since != nil {
+			h.replay(c.client, c.topic, *c.since)
+		}
+		c.client.topics[c.topic] = true
+		h.addToTopic(c.client, c.topic)
+		reply.Seq = h.history(c.topic).head
+	}
+	h.deliver(c.client, encodeReply(reply))
+}
+
+// deliver queues message for client, dropping the client if it is too slow to
+// keep up. The caller must hold h.mu.
+func (h *Hub) deliver(client *Client, message []byte) {
+	select {
+	case client.send <- message:
+		h.sent++
+	default:
+		h.drops++
+		client.closeReason = ReasonSlowConsumer
+		h.removeClient(client)
+	}
+}
+
+// removeClient must be called with h.mu held.
+func (h *Hub) removeClient(client *Client) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	delete(h.clients, client)
+	for topic := range client.topics {
+		h.removeFromTopic(client, topic)
+	}
+	close(client.send)
+}
+
+// Broadcast sends message to every connected client. Prefer Publish for
+// anything that concerns a particular group, event or user.
 func (h *Hub) Broadcast(message []byte) {
-	h.broadcast <- message
+	h.relay(BrokerMessage{Origin: h.id, Broadcast: true, Message: message})
+}
+
+// Publish sends message to the clients subscribed to any of topics.
+func (h *Hub) Publish(message []byte, topics ...string) {
+	h.relay(BrokerMessage{Origin: h.id, Topics: topics, Message: message})
+}
+
+// relay sends msg through the broker, which hands it back to this hub and the
+// others for delivery. If the broker can't be reached the message still goes
+// to this instance's clients.
+func (h *Hub) relay(msg BrokerMessage) {
+	h.listen()
+	ctx, cancel := context.WithTimeout(context.Background(), brokerTimeout)
+	defer cancel()
+	if err := h.Broker.Publish(ctx, msg); err != nil {
+		h.brokerErrors.Add(1)
+		log.Printf("websocket: publishing through broker: %v", err)
+		h.receive(msg)
+	}
 }
 
-func (h *Hub) ServeWs(w http.ResponseWriter, r *http.Request) {
+// listen subscribes to the broker the first time it is needed, so nothing
+// published before Run starts is lost. If the broker refuses, the hub falls
+// back to serving only this instance.
+func (h *Hub) listen() {
+	h.listenOnce.Do(func() {
+		msgs, err := h.Broker.Subscribe(context.Background())
+		if err != nil {
+			log.Printf("websocket: subscribing to broker: %v; serving this instance only", err)
+			h.Broker = NewMemoryBroker()
+			msgs, _ = h.Broker.Subscribe(context.Background())
+		}
+		go func() {
+			for msg := range msgs {
+				h.receive(msg)
+			}
+		}()
+	})
+}
+
+func (h *Hub) receive(msg BrokerMessage) {
+	switch {
+	case msg.Presence != nil:
+		h.presenceUpdates <- *msg.Presence
+	case msg.Broadcast:
+		h.broadcast <- msg.Message
+	default:
+		if !msg.Ephemeral {
+			h.warmHistory(msg.Topics...)
+		}
+		h.publish <- publication{message: msg.Message, topics: msg.Topics, origin: msg.Origin, ephemeral: msg.Ephemeral}
+	}
+}
+
+func newHubID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ServeWs upgrades an already-authenticated request to a WebSocket described
+// by opts.
+func (h *Hub) ServeWs(w http.ResponseWriter, r *http.Request, opts ConnOptions) {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     h.CheckOrigin,
+	}
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	client := &Client{hub: h, conn: conn, send: make(chan []byte, 256)}
+	client := &Client{
+		hub:       h,
+		conn:      conn,
+		send:      make(chan []byte, sendBufferSize),
+		authorize: opts.Authorize,
+		handle:    opts.Handle,
+		requests:  make(chan struct{}, maxPendingRequests),
+		config:    h.Config.withDefaults(),
+		id:        h.id + "-" + strconv.FormatUint(h.clientSeq.Add(1), 10),
+		member:    memberOf(opts.Member),
+		topics:    make(map[string]bool),
+		present:   make(map[string]bool),
+	}
+	for _, topic := range opts.Topics {
+		client.topics[topic] = true
+	}
 	client.hub.register <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
@@ -89,31 +466,118 @@ func (h *Hub) ServeWs(w http.ResponseWriter, r *http.Request) {
 
 func (c *Client) readPump() {
 	defer func() {
+		c.leave("")
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
+	c.conn.SetReadLimit(c.config.MaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(c.config.PongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(c.config.PongTimeout))
+		return nil
+	})
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("error: %v", err)
 			}
 			break
 		}
-		// We can handle incoming messages here if needed
+		c.handleFrame(message)
+	}
+}
+
+// handleFrame processes one control frame from the client. Authorization runs
+// here rather than in Run so a slow lookup only holds up this connection.
+func (c *Client) handleFrame(message []byte) {
+	var frame controlFrame
+	if err := json.Unmarshal(message, &frame); err != nil {
+		c.hub.changes <- c.rejection(frame, "invalid frame")
+		return
+	}
+
+	if frame.Type == FrameRequest {
+		c.handleRequest(frame)
+		return
+	}
+
+	if _, _, ok := SplitTopic(frame.Topic); !ok {
+		c.hub.changes <- c.rejection(frame, "invalid topic")
+		return
+	}
+
+	switch frame.Type {
+	case FrameSubscribe:
+		allowed := false
+		if c.authorize != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), authorizeTimeout)
+			ok, err := c.authorize(ctx, frame.Topic)
+			cancel()
+			if err != nil {
+				log.Printf("websocket: authorizing %s: %v", frame.Topic, err)
+				c.hub.changes <- c.rejection(frame, "authorization failed")
+				return
+			}
+			allowed = ok
+		}
+		if !allowed {
+			c.hub.changes <- c.rejection(frame, "forbidden")
+			return
+		}
+		c.hub.warmHistory(frame.Topic)
+		// Waiting for the subscription to be made means frames that follow,
+		// such as a join, see it.
+		applied := make(chan struct{})
+		c.hub.changes <- subscriptionChange{
+			client:    c,
+			topic:     frame.Topic,
+			subscribe: true,
+			since:     frame.Since,
+			reply:     controlReply{Type: FrameSubscribed, Topic: frame.Topic, RequestID: frame.RequestID},
+			applied:   applied,
+		}
+		<-applied
+	case FrameUnsubscribe:
+		c.hub.changes <- subscriptionChange{
+			client: c,
+			topic:  frame.Topic,
+			reply:  controlReply{Type: FrameUnsubscribed, Topic: frame.Topic, RequestID: frame.RequestID},
+		}
+		c.leave(frame.Topic)
+	case FrameJoin, FrameHeartbeat, FrameLeave, FrameTyping:
+		c.handlePresenceFrame(frame)
+	default:
+		c.hub.changes <- c.rejection(frame, "unknown frame type")
+	}
+}
+
+// rejection answers frame with an error without changing any subscription.
+func (c *Client) rejection(frame controlFrame, reason string) subscriptionChange {
+	return subscriptionChange{
+		client: c,
+		reply:  controlReply{Type: FrameError, Topic: frame.Topic, RequestID: frame.RequestID, Error: reason},
 	}
 }
 
+func encodeReply(reply controlReply) []byte {
+	msg, _ := json.Marshal(reply)
+	return msg
+}
+
 func (c *Client) writePump() {
+	ticker := time.NewTicker(c.config.PingInterval)
 	defer func() {
+		ticker.Stop()
 		c.conn.Close()
 	}()
 	for {
 		select {
 		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
 			if !ok {
 				// The hub closed the channel.
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.writeClose()
 				return
 			}
 
@@ -126,6 +590,24 @@ func (c *Client) writePump() {
 			if err := w.Close(); err != nil {
 				return
 			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
 		}
 	}
 }
+
+// writeClose tells the client why the hub dropped it, if it did, and closes
+// the connection. Clients that are dropped as slow consumers may try again.
+func (c *Client) writeClose() {
+	if c.closeReason == "" {
+		c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+		return
+	}
+	if frame, err := json.Marshal(disconnectFrame{Type: FrameDisconnect, Reason: c.closeReason}); err == nil {
+		c.conn.WriteMessage(websocket.TextMessage, frame)
+	}
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, c.closeReason))
+}
@@ -1,6 +1,8 @@
 package websocket
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -27,6 +29,12 @@ func TestNewHub(t *testing.T) {
 	if hub.unregister == nil {
 		t.Error("Hub unregister channel is nil")
 	}
+	if hub.topics == nil {
+		t.Error("Hub topics map is nil")
+	}
+	if hub.changes == nil {
+		t.Error("Hub changes channel is nil")
+	}
 }
 
 func TestHubIntegration(t *testing.T) {
@@ -35,7 +43,7 @@ func TestHubIntegration(t *testing.T) {
 
 	// Create a test server that uses the hub
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		hub.ServeWs(w, r)
+		hub.ServeWs(w, r, ConnOptions{})
 	}))
 	defer s.Close()
 
@@ -70,3 +78,280 @@ func TestHubIntegration(t *testing.T) {
 		t.Errorf("Expected message %s, got %s", message, p)
 	}
 }
+
+func TestHubPublishRoutesByTopic(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{Topics: strings.Split(r.URL.Query().Get("topics"), ",")})
+	}))
+	defer s.Close()
+
+	u := "ws" + strings.TrimPrefix(s.URL, "http")
+	member, _, err := websocket.DefaultDialer.Dial(u+"?topics="+GroupTopic("g1")+","+EventTopic("e1"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer member.Close()
+	outsider, _, err := websocket.DefaultDialer.Dial(u+"?topics="+GroupTopic("g2"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer outsider.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	// Published to both of member's topics, but delivered only once
+	hub.Publish([]byte("for g1"), GroupTopic("g1"), EventTopic("e1"))
+	hub.Publish([]byte("for g2"), GroupTopic("g2"))
+
+	member.SetReadDeadline(time.Now().Add(1 * time.Second))
+	_, p, err := member.ReadMessage()
+	if err != nil || string(p) != "for g1" {
+		t.Fatalf("Expected member to receive %q, got %q (%v)", "for g1", p, err)
+	}
+	member.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, p, err := member.ReadMessage(); err == nil {
+		t.Errorf("Expected no further messages for member, got %q", p)
+	}
+
+	outsider.SetReadDeadline(time.Now().Add(1 * time.Second))
+	_, p, err = outsider.ReadMessage()
+	if err != nil || string(p) != "for g2" {
+		t.Fatalf("Expected outsider to receive only %q, got %q (%v)", "for g2", p, err)
+	}
+}
+
+func TestServeWsRejectsCrossOrigin(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{})
+	}))
+	defer s.Close()
+
+	u := "ws" + strings.TrimPrefix(s.URL, "http")
+	_, resp, err := websocket.DefaultDialer.Dial(u, http.Header{"Origin": []string{"https://evil.example.com"}})
+	if err == nil {
+		t.Fatal("Expected cross-origin upgrade to be rejected")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403, got %v", resp)
+	}
+}
+
+func TestSplitTopic(t *testing.T) {
+	tests := []struct {
+		topic    string
+		kind, id string
+		ok       bool
+	}{
+		{GroupTopic("g1"), "group", "g1", true},
+		{EventTopic("e1"), "event", "e1", true},
+		{UserTopic("u1"), "user", "u1", true},
+		{"event:", "", "", false},
+		{"household:h1", "", "", false},
+		{"nonsense", "", "", false},
+	}
+	for _, tt := range tests {
+		kind, id, ok := SplitTopic(tt.topic)
+		if kind != tt.kind || id != tt.id || ok != tt.ok {
+			t.Errorf("SplitTopic(%q) = %q, %q, %v; want %q, %q, %v", tt.topic, kind, id, ok, tt.kind, tt.id, tt.ok)
+		}
+	}
+}
+
+func readReply(t *testing.T, ws *websocket.Conn) controlReply {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(1 * time.Second))
+	_, p, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read reply: %v", err)
+	}
+	var reply controlReply
+	if err := json.Unmarshal(p, &reply); err != nil {
+		t.Fatalf("Failed to decode reply %q: %v", p, err)
+	}
+	return reply
+}
+
+func TestSubscribeProtocol(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	authorize := func(ctx context.Context, topic string) (bool, error) {
+		return topic == EventTopic("e1"), nil
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{Authorize: authorize})
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer ws.Close()
+
+	ws.WriteJSON(controlFrame{Type: FrameSubscribe, Topic: EventTopic("e1"), RequestID: "1"})
+	if reply := readReply(t, ws); reply.Type != FrameSubscribed || reply.Topic != EventTopic("e1") || reply.RequestID != "1" {
+		t.Fatalf("Unexpected subscribe reply: %+v", reply)
+	}
+
+	ws.WriteJSON(controlFrame{Type: FrameSubscribe, Topic: EventTopic("e2"), RequestID: "2"})
+	if reply := readReply(t, ws); reply.Type != FrameError || reply.Error != "forbidden" || reply.RequestID != "2" {
+		t.Fatalf("Expected forbidden reply, got %+v", reply)
+	}
+
+	ws.WriteJSON(controlFrame{Type: FrameSubscribe, Topic: "household:h1", RequestID: "3"})
+	if reply := readReply(t, ws); reply.Type != FrameError || reply.Error != "invalid topic" {
+		t.Fatalf("Expected invalid topic reply, got %+v", reply)
+	}
+
+	// Only the authorized topic's traffic is delivered
+	hub.Publish([]byte("for e2"), EventTopic("e2"))
+	hub.Publish([]byte("for e1"), EventTopic("e1"))
+	ws.SetReadDeadline(time.Now().Add(1 * time.Second))
+	if _, p, err := ws.ReadMessage(); err != nil || string(p) != "for e1" {
+		t.Fatalf("Expected %q, got %q (%v)", "for e1", p, err)
+	}
+
+	ws.WriteJSON(controlFrame{Type: FrameUnsubscribe, Topic: EventTopic("e1"), RequestID: "4"})
+	if reply := readReply(t, ws); reply.Type != FrameUnsubscribed || reply.RequestID != "4" {
+		t.Fatalf("Unexpected unsubscribe reply: %+v", reply)
+	}
+
+	hub.Publish([]byte("for e1 again"), EventTopic("e1"))
+	ws.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, p, err := ws.ReadMessage(); err == nil {
+		t.Errorf("Expected no messages after unsubscribing, got %q", p)
+	}
+}
+
+func TestDeliverDropsSlowConsumer(t *testing.T) {
+	hub := NewHub()
+	client := &Client{hub: hub, send: make(chan []byte, 1), topics: map[string]bool{EventTopic("e1"): true}}
+
+	hub.mu.Lock()
+	hub.clients[client] = true
+	hub.addToTopic(client, EventTopic("e1"))
+	hub.deliver(client, []byte("first"))
+	hub.deliver(client, []byte("second"))
+	hub.mu.Unlock()
+
+	if client.closeReason != ReasonSlowConsumer {
+		t.Errorf("closeReason = %q, want %q", client.closeReason, ReasonSlowConsumer)
+	}
+	if msg := <-client.send; string(msg) != "first" {
+		t.Errorf("Expected queued message to be kept, got %q", msg)
+	}
+	if _, ok := <-client.send; ok {
+		t.Error("Expected send channel to be closed")
+	}
+
+	m := hub.Metrics()
+	if m.ConnectedClients != 0 || m.Topics != 0 || m.SlowConsumerDrops != 1 || m.MessagesSent != 1 {
+		t.Errorf("Unexpected metrics after drop: %+v", m)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	hub := NewHub()
+	hub.Config = Config{PingInterval: 20 * time.Millisecond, PongTimeout: 100 * time.Millisecond}
+	go hub.Run()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{})
+	}))
+	defer s.Close()
+	u := "ws" + strings.TrimPrefix(s.URL, "http")
+
+	// A client that keeps reading answers pings and stays connected
+	alive, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer alive.Close()
+	pinged := make(chan struct{}, 1)
+	alive.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return alive.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// A client that never reads never answers, like a half-open connection
+	silent, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer silent.Close()
+
+	select {
+	case <-pinged:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the server to ping")
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if got := hub.Metrics().ConnectedClients; got != 1 {
+		t.Errorf("ConnectedClients = %d, want only the responsive client", got)
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	hub := NewHub()
+	hub.Config = Config{MaxMessageSize: 64}
+	go hub.Run()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWs(w, r, ConnOptions{})
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer ws.Close()
+
+	ws.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 128)))
+	ws.SetReadDeadline(time.Now().Add(1 * time.Second))
+	_, _, err = ws.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("Expected close with CloseMessageTooBig, got %v", err)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("WS_PING_INTERVAL", "15s")
+	t.Setenv("WS_PONG_TIMEOUT", "bogus")
+	t.Setenv("WS_MAX_MESSAGE_SIZE", "8192")
+
+	cfg := ConfigFromEnv()
+	defaults := DefaultConfig()
+	if cfg.PingInterval != 15*time.Second {
+		t.Errorf("PingInterval = %s, want 15s", cfg.PingInterval)
+	}
+	if cfg.PongTimeout != defaults.PongTimeout {
+		t.Errorf("PongTimeout = %s, want default %s", cfg.PongTimeout, defaults.PongTimeout)
+	}
+	if cfg.MaxMessageSize != 8192 {
+		t.Errorf("MaxMessageSize = %d, want 8192", cfg.MaxMessageSize)
+	}
+
+	t.Setenv("WS_PONG_TIMEOUT", "10s")
+	if cfg := ConfigFromEnv(); cfg.PingInterval != defaults.PingInterval || cfg.PongTimeout != defaults.PongTimeout {
+		t.Errorf("Expected defaults when pong timeout is shorter than the ping interval, got %+v", cfg)
+	}
+}
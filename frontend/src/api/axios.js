@@ -5,12 +5,38 @@ const api = axios.create({
     withCredentials: true,
 });
 
+// Access tokens are short-lived; on a 401 we try once to rotate the refresh
+// token and replay the request. Concurrent 401s share a single refresh call.
+const NO_REFRESH_URLS = ['/auth/refresh', '/auth/google', '/auth/logout'];
+let refreshPromise = null;
+
 api.interceptors.response.use(
     (response) => response,
-    (error) => {
-        // We handle 401s in the AuthContext/ProtectedRoute
-        // rather than doing a hard window.location redirect here
-        return Promise.reject(error);
+    async (error) => {
+        const original = error.config;
+        if (
+            error.response?.status !== 401 ||
+            !original ||
+            original._retry ||
+            NO_REFRESH_URLS.includes(original.url)
+        ) {
+            // Other 401s are handled in the AuthContext/ProtectedRoute
+            // rather than doing a hard window.location redirect here
+            return Promise.reject(error);
+        }
+
+        original._retry = true;
+        try {
+            if (!refreshPromise) {
+                refreshPromise = api.post('/auth/refresh').finally(() => {
+                    refreshPromise = null;
+                });
+            }
+            await refreshPromise;
+        } catch {
+            return Promise.reject(error);
+        }
+        return api(original);
     }
 );
 
@@ -0,0 +1,151 @@
+import React, { useState, useEffect } from 'react';
+import { X, Plus, Trash2 } from 'lucide-react';
+import api from '../api/axios';
+import { useUI } from '../context/UIContext';
+
+// AvailabilityModal edits the days a member or household can't host. Host
+// rotations pass them over for occurrences on those days. It saves the list
+// by patching path with any extra fields the endpoint needs.
+const AvailabilityModal = ({ isOpen, onClose, title, path, unavailable, extra, onSaved }) => {
+    const { showToast } = useUI();
+    const [dates, setDates] = useState([]);
+    const [start, setStart] = useState('');
+    const [end, setEnd] = useState('');
+    const [reason, setReason] = useState('');
+    const [saving, setSaving] = useState(false);
+
+    useEffect(() => {
+        if (!isOpen) return;
+        setDates(unavailable || []);
+        setStart('');
+        setEnd('');
+        setReason('');
+    }, [isOpen, unavailable]);
+
+    const handleAdd = (e) => {
+        e.preventDefault();
+        if (!start) return;
+        const last = end || start;
+        if (last < start) {
+            showToast("The last day can't be before the first", "error");
+            return;
+        }
+        const added = { start, end: last, ...(reason && { reason }) };
+        setDates([...dates, added].sort((a, b) => a.start.localeCompare(b.start)));
+        setStart('');
+        setEnd('');
+        setReason('');
+    };
+
+    const handleSave = async () => {
+        setSaving(true);
+        try {
+            await api.patch(path, { ...extra, unavailable: dates });
+            showToast("Availability saved");
+            onSaved?.(dates);
+            onClose();
+        } catch (error) {
+            console.error("Failed to save availability", error);
+            showToast("Failed to save availability", "error");
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    if (!isOpen) return null;
+
+    return (
+        <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center p-4 z-50 animate-in fade-in duration-200">
+            <div className="bg-white rounded-xl shadow-xl max-w-md w-full p-6 transform transition-all scale-100">
+                <div className="flex justify-between items-center mb-2">
+                    <h3 className="text-xl font-bold text-gray-800">{title}</h3>
+                    <button onClick={onClose} className="text-gray-400 hover:text-gray-600 transition">
+                        <X className="w-6 h-6" />
+                    </button>
+                </div>
+                <p className="text-sm text-gray-500 mb-4">
+                    Recurring events won't rotate to you on these days while someone else can host.
+                </p>
+
+                <ul className="space-y-2 max-h-60 overflow-y-auto pr-2 mb-4">
+                    {dates.length === 0 && (
+                        <li className="text-sm text-gray-400 italic">No days away.</li>
+                    )}
+                    {dates.map((d, i) => (
+                        <li key={`${d.start}-${d.end}-${i}`} className="flex items-center gap-2 p-2 bg-gray-50 rounded-lg">
+                            <span className="flex-1 text-sm text-gray-800">
+                                {d.start === d.end ? d.start : `${d.start} – ${d.end}`}
+                                {d.reason && <span className="text-gray-500"> · {d.reason}</span>}
+                            </span>
+                            <button
+                                onClick={() => setDates(dates.filter((_, j) => j !== i))}
+                                className="text-gray-400 hover:text-red-600"
+                                title={`Remove ${d.start}`}
+                            >
+                                <Trash2 className="w-4 h-4" />
+                            </button>
+                        </li>
+                    ))}
+                </ul>
+
+                <form onSubmit={handleAdd} className="space-y-2 border-t border-gray-100 pt-4">
+                    <div className="flex gap-2">
+                        <div className="flex-1">
+                            <label htmlFor="unavailable-start" className="block text-xs font-medium text-gray-700 mb-1">From</label>
+                            <input
+                                id="unavailable-start"
+                                type="date"
+                                value={start}
+                                onChange={(e) => setStart(e.target.value)}
+                                className="w-full p-2 border border-gray-200 rounded-lg text-sm focus:ring-2 focus:ring-orange-500 outline-none"
+                            />
+                        </div>
+                        <div className="flex-1">
+                            <label htmlFor="unavailable-end" className="block text-xs font-medium text-gray-700 mb-1">To</label>
+                            <input
+                                id="unavailable-end"
+                                type="date"
+                                value={end}
+                                min={start}
+                                onChange={(e) => setEnd(e.target.value)}
+                                className="w-full p-2 border border-gray-200 rounded-lg text-sm focus:ring-2 focus:ring-orange-500 outline-none"
+                            />
+                        </div>
+                    </div>
+                    <div className="flex gap-2">
+                        <input
+                            type="text"
+                            value={reason}
+                            onChange={(e) => setReason(e.target.value)}
+                            className="flex-1 p-2 border border-gray-200 rounded-lg text-sm focus:ring-2 focus:ring-orange-500 outline-none"
+                            placeholder="Reason (optional)"
+                        />
+                        <button
+                            type="submit"
+                            disabled={!start}
+                            className="bg-gray-100 text-gray-700 px-3 py-2 rounded-lg text-sm font-medium hover:bg-gray-200 transition disabled:opacity-50 flex items-center gap-1"
+                        >
+                            <Plus className="w-4 h-4" />
+                            Add
+                        </button>
+                    </div>
+                </form>
+
+                <div className="flex justify-end gap-3 mt-6">
+                    <button onClick={onClose} className="px-4 py-2 text-gray-600 hover:bg-gray-100 rounded-lg transition">
+                        Cancel
+                    </button>
+                    <button
+                        onClick={handleSave}
+                        disabled={saving}
+                        className="px-4 py-2 bg-orange-600 text-white rounded-lg hover:bg-orange-700 transition disabled:opacity-50"
+                    >
+                        Save
+                    </button>
+                </div>
+            </div>
+        </div>
+    );
+};
+
+export default AvailabilityModal;
@@ -0,0 +1,70 @@
+import React from 'react';
+import { render, screen, fireEvent, waitFor } from '@testing-library/react';
+import '@testing-library/jest-dom';
+import AvailabilityModal from './AvailabilityModal';
+import api from '../api/axios';
+import { vi } from 'vitest';
+
+vi.mock('../api/axios');
+
+const mockShowToast = vi.fn();
+
+vi.mock('../context/UIContext', () => ({
+    useUI: () => ({
+        showToast: mockShowToast,
+        confirm: vi.fn()
+    }),
+}));
+
+describe('AvailabilityModal', () => {
+    const unavailable = [{ start: '2025-07-01', end: '2025-07-14', reason: 'Summer trip' }];
+
+    beforeEach(() => {
+        vi.clearAllMocks();
+        api.patch.mockResolvedValue({});
+    });
+
+    test('lists the saved days away', () => {
+        render(<AvailabilityModal isOpen={true} onClose={vi.fn()} title="My Availability" path="/families/ann" unavailable={unavailable} />);
+
+        expect(screen.getByText('2025-07-01 – 2025-07-14')).toBeInTheDocument();
+        expect(screen.getByText(/Summer trip/)).toBeInTheDocument();
+    });
+
+    test('adds a day and saves the list', async () => {
+        const onClose = vi.fn();
+        const onSaved = vi.fn();
+        render(
+            <AvailabilityModal
+                isOpen={true}
+                onClose={onClose}
+                title="Household Days Away"
+                path="/households/h1"
+                unavailable={unavailable}
+                extra={{ group_id: 'group1' }}
+                onSaved={onSaved}
+            />
+        );
+
+        fireEvent.change(screen.getByLabelText('From'), { target: { value: '2025-06-08' } });
+        fireEvent.click(screen.getByText('Add'));
+        fireEvent.click(screen.getByText('Save'));
+
+        const saved = [{ start: '2025-06-08', end: '2025-06-08' }, ...unavailable];
+        await waitFor(() => expect(api.patch).toHaveBeenCalledWith('/households/h1', {
+            group_id: 'group1',
+            unavailable: saved,
+        }));
+        expect(onSaved).toHaveBeenCalledWith(saved);
+        expect(onClose).toHaveBeenCalled();
+    });
+
+    test('removes a day', async () => {
+        render(<AvailabilityModal isOpen={true} onClose={vi.fn()} title="My Availability" path="/families/ann" unavailable={unavailable} />);
+
+        fireEvent.click(screen.getByTitle('Remove 2025-07-01'));
+        fireEvent.click(screen.getByText('Save'));
+
+        await waitFor(() => expect(api.patch).toHaveBeenCalledWith('/families/ann', { unavailable: [] }));
+    });
+});
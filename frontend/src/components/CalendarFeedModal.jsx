@@ -0,0 +1,126 @@
+import React, { useState, useEffect } from 'react';
+import { X, Copy, CheckCircle, Calendar } from 'lucide-react';
+import api from '../api/axios';
+import { useUI } from '../context/UIContext';
+
+// CalendarFeedModal hands out the secret links calendar apps subscribe to for
+// the member's events and each of their groups. The secret is only shown when
+// it's made, so seeing the links again means making new ones.
+const CalendarFeedModal = ({ isOpen, onClose, user, groups, refreshUser }) => {
+    const { showToast, confirm } = useUI();
+    const [token, setToken] = useState(null);
+    const [working, setWorking] = useState(false);
+    const [copied, setCopied] = useState(null);
+
+    useEffect(() => {
+        if (!isOpen) {
+            setToken(null);
+            setCopied(null);
+        }
+    }, [isOpen]);
+
+    if (!isOpen) return null;
+
+    const feedURL = (path) => `${api.defaults.baseURL}/calendar/${token}/${path}`;
+
+    const createLinks = async () => {
+        setWorking(true);
+        try {
+            const response = await api.post('/auth/calendar');
+            setToken(response.data.token);
+            refreshUser?.();
+        } catch (error) {
+            console.error("Failed to create calendar links", error);
+            showToast("Failed to create calendar links", "error");
+        } finally {
+            setWorking(false);
+        }
+    };
+
+    const turnOff = () => {
+        confirm({
+            title: "Turn Off Calendar Links",
+            message: "Calendars subscribed to your links will stop updating.",
+            confirmText: "Turn Off",
+            isDestructive: true,
+            onConfirm: async () => {
+                try {
+                    await api.delete('/auth/calendar');
+                    setToken(null);
+                    showToast("Calendar links turned off");
+                    refreshUser?.();
+                } catch (error) {
+                    console.error("Failed to turn off calendar links", error);
+                    showToast("Failed to turn off calendar links", "error");
+                }
+            }
+        });
+    };
+
+    const copy = (key, url) => {
+        navigator.clipboard.writeText(url);
+        setCopied(key);
+        showToast("Calendar link copied to clipboard!");
+        setTimeout(() => setCopied(null), 2000);
+    };
+
+    const feeds = token ? [
+        { key: 'events', name: 'All my events', url: feedURL('events.ics') },
+        ...(groups || []).map(g => ({ key: g.id, name: g.name, url: feedURL(`groups/${g.id}.ics`) })),
+    ] : [];
+
+    return (
+        <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center p-4 z-50 animate-in fade-in duration-200">
+            <div className="bg-white rounded-xl shadow-xl max-w-md w-full p-6 transform transition-all scale-100">
+                <div className="flex justify-between items-center mb-2">
+                    <h3 className="text-xl font-bold text-gray-800">Calendar Links</h3>
+                    <button onClick={onClose} className="text-gray-400 hover:text-gray-600 transition">
+                        <X className="w-6 h-6" />
+                    </button>
+                </div>
+                <p className="text-sm text-gray-500 mb-4">
+                    Subscribe to these links in Google Calendar, Apple Calendar or Outlook to keep your events up to date there. Anyone with a link can see its events, so keep them to yourself.
+                </p>
+
+                {token ? (
+                    <ul className="space-y-2 mb-4">
+                        {feeds.map(feed => (
+                            <li key={feed.key} className="flex items-center gap-2 p-2 bg-gray-50 rounded-lg">
+                                <Calendar className="w-4 h-4 text-orange-500 shrink-0" />
+                                <span className="flex-1 text-sm text-gray-800 truncate">{feed.name}</span>
+                                <button
+                                    onClick={() => copy(feed.key, feed.url)}
+                                    className="text-gray-400 hover:text-orange-600"
+                                    title={`Copy link for ${feed.name}`}
+                                >
+                                    {copied === feed.key ? <CheckCircle className="w-4 h-4 text-green-600" /> : <Copy className="w-4 h-4" />}
+                                </button>
+                            </li>
+                        ))}
+                    </ul>
+                ) : user?.calendar_feed ? (
+                    <p className="text-sm text-gray-600 mb-4">
+                        Your calendar links are on. They're only shown once, so to see them again make new ones; the old links will stop working.
+                    </p>
+                ) : null}
+
+                <div className="flex justify-end gap-3 mt-6">
+                    {(token || user?.calendar_feed) && (
+                        <button onClick={turnOff} className="px-4 py-2 text-red-600 hover:bg-red-50 rounded-lg transition">
+                            Turn Off
+                        </button>
+                    )}
+                    <button
+                        onClick={createLinks}
+                        disabled={working}
+                        className="px-4 py-2 bg-orange-600 text-white rounded-lg hover:bg-orange-700 transition disabled:opacity-50"
+                    >
+                        {token || user?.calendar_feed ? 'Make New Links' : 'Create Links'}
+                    </button>
+                </div>
+            </div>
+        </div>
+    );
+};
+
+export default CalendarFeedModal;
@@ -0,0 +1,52 @@
+import React from 'react';
+import { render, screen, fireEvent, waitFor } from '@testing-library/react';
+import '@testing-library/jest-dom';
+import CalendarFeedModal from './CalendarFeedModal';
+import api from '../api/axios';
+import { vi } from 'vitest';
+
+vi.mock('../api/axios');
+
+const mockShowToast = vi.fn();
+
+vi.mock('../context/UIContext', () => ({
+    useUI: () => ({
+        showToast: mockShowToast,
+        confirm: ({ onConfirm }) => onConfirm(),
+    }),
+}));
+
+describe('CalendarFeedModal', () => {
+    const groups = [{ id: 'group1', name: 'Smith Family' }];
+
+    beforeEach(() => {
+        vi.clearAllMocks();
+        api.defaults = { baseURL: 'https://api.example.com' };
+        api.post.mockResolvedValue({ data: { token: 'fpcal_secret' } });
+        api.delete.mockResolvedValue({});
+        Object.assign(navigator, { clipboard: { writeText: vi.fn() } });
+    });
+
+    test('creates links for my events and each group', async () => {
+        const refreshUser = vi.fn();
+        render(<CalendarFeedModal isOpen={true} onClose={vi.fn()} user={{ id: 'ann' }} groups={groups} refreshUser={refreshUser} />);
+
+        fireEvent.click(screen.getByText('Create Links'));
+
+        await waitFor(() => expect(screen.getByText('All my events')).toBeInTheDocument());
+        expect(api.post).toHaveBeenCalledWith('/auth/calendar');
+        expect(refreshUser).toHaveBeenCalled();
+
+        fireEvent.click(screen.getByTitle('Copy link for Smith Family'));
+        expect(navigator.clipboard.writeText).toHaveBeenCalledWith('https://api.example.com/calendar/fpcal_secret/groups/group1.ics');
+    });
+
+    test('turns existing links off', async () => {
+        render(<CalendarFeedModal isOpen={true} onClose={vi.fn()} user={{ id: 'ann', calendar_feed: true }} groups={groups} />);
+
+        expect(screen.getByText('Make New Links')).toBeInTheDocument();
+        fireEvent.click(screen.getByText('Turn Off'));
+
+        await waitFor(() => expect(api.delete).toHaveBeenCalledWith('/auth/calendar'));
+    });
+});
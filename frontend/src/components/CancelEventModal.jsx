@@ -0,0 +1,102 @@
+import React, { useState, useEffect } from 'react';
+import { X } from 'lucide-react';
+import api from '../api/axios';
+import { useUI } from '../context/UIContext';
+
+const seriesActions = [
+    { value: 'next', label: 'Just this date; the series carries on' },
+    { value: 'end', label: 'This and every later date; the series ends' },
+];
+
+// CancelEventModal calls an event off for a reason everyone will see. Its
+// dishes, RSVPs and chat are kept, so it can be put back on later.
+const CancelEventModal = ({ isOpen, onClose, event, onCancelled }) => {
+    const { showToast } = useUI();
+    const [reason, setReason] = useState('');
+    const [series, setSeries] = useState('next');
+    const [saving, setSaving] = useState(false);
+
+    useEffect(() => {
+        if (isOpen) {
+            setReason('');
+            setSeries('next');
+        }
+    }, [isOpen]);
+
+    const handleCancel = async () => {
+        setSaving(true);
+        try {
+            const body = { reason: reason.trim() };
+            if (event.recurrence) body.series = series;
+            const response = await api.post(`/events/${event.id}/cancel`, body);
+            showToast("Event cancelled");
+            onCancelled?.(response.data);
+            onClose();
+        } catch (error) {
+            console.error("Failed to cancel event", error);
+            showToast("Failed to cancel event", "error");
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    if (!isOpen || !event) return null;
+
+    return (
+        <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center p-4 z-50 animate-in fade-in duration-200">
+            <div className="bg-white rounded-xl shadow-xl max-w-md w-full p-6 transform transition-all scale-100">
+                <div className="flex justify-between items-center mb-2">
+                    <h3 className="text-xl font-bold text-gray-800">Cancel Event</h3>
+                    <button onClick={onClose} className="text-gray-400 hover:text-gray-600 transition">
+                        <X className="w-6 h-6" />
+                    </button>
+                </div>
+                <p className="text-sm text-gray-500 mb-4">
+                    Everyone will see why {event.name} isn't going ahead. Dishes, RSVPs and chat are kept in case it's back on.
+                </p>
+
+                <label className="block text-sm text-gray-700 mb-1" htmlFor="cancel-reason">Reason</label>
+                <textarea
+                    id="cancel-reason"
+                    className="w-full p-2 border border-gray-200 rounded-lg focus:ring-2 focus:ring-orange-500 outline-none text-sm"
+                    rows={3}
+                    value={reason}
+                    onChange={(e) => setReason(e.target.value)}
+                    placeholder="e.g. Grandma is unwell"
+                />
+
+                {event.recurrence && (
+                    <div className="space-y-2 mt-4">
+                        {seriesActions.map(a => (
+                            <label key={a.value} className="flex items-center gap-2 text-sm text-gray-700 cursor-pointer">
+                                <input
+                                    type="radio"
+                                    name="cancel-series"
+                                    value={a.value}
+                                    checked={series === a.value}
+                                    onChange={() => setSeries(a.value)}
+                                />
+                                {a.label}
+                            </label>
+                        ))}
+                    </div>
+                )}
+
+                <div className="flex justify-end gap-3 mt-6">
+                    <button onClick={onClose} className="px-4 py-2 text-gray-600 hover:bg-gray-100 rounded-lg transition">
+                        Keep Event
+                    </button>
+                    <button
+                        onClick={handleCancel}
+                        disabled={saving || !reason.trim()}
+                        className="px-4 py-2 bg-red-600 text-white rounded-lg hover:bg-red-700 transition disabled:opacity-50"
+                    >
+                        Cancel Event
+                    </button>
+                </div>
+            </div>
+        </div>
+    );
+};
+
+export default CancelEventModal;
@@ -0,0 +1,51 @@
+import React from 'react';
+import { render, screen, fireEvent, waitFor } from '@testing-library/react';
+import '@testing-library/jest-dom';
+import CancelEventModal from './CancelEventModal';
+import api from '../api/axios';
+import { vi } from 'vitest';
+
+vi.mock('../api/axios');
+
+const mockShowToast = vi.fn();
+
+vi.mock('../context/UIContext', () => ({
+    useUI: () => ({
+        showToast: mockShowToast,
+        confirm: vi.fn()
+    }),
+}));
+
+describe('CancelEventModal', () => {
+    beforeEach(() => {
+        vi.clearAllMocks();
+        api.post.mockResolvedValue({ data: { id: 'event1', status: 'cancelled' } });
+    });
+
+    test('needs a reason to cancel', async () => {
+        const onClose = vi.fn();
+        const onCancelled = vi.fn();
+        render(<CancelEventModal isOpen={true} onClose={onClose} event={{ id: 'event1', name: 'Picnic' }} onCancelled={onCancelled} />);
+
+        const button = screen.getByRole('button', { name: 'Cancel Event' });
+        expect(button).toBeDisabled();
+        expect(screen.queryByRole('radio')).not.toBeInTheDocument();
+
+        fireEvent.change(screen.getByLabelText('Reason'), { target: { value: ' Rain forecast ' } });
+        fireEvent.click(button);
+
+        await waitFor(() => expect(api.post).toHaveBeenCalledWith('/events/event1/cancel', { reason: 'Rain forecast' }));
+        expect(onCancelled).toHaveBeenCalledWith({ id: 'event1', status: 'cancelled' });
+        expect(onClose).toHaveBeenCalled();
+    });
+
+    test('asks what happens to a recurring series', async () => {
+        render(<CancelEventModal isOpen={true} onClose={vi.fn()} event={{ id: 'event1', name: 'Sunday Dinner', recurrence: 'FREQ=WEEKLY' }} />);
+
+        fireEvent.change(screen.getByLabelText('Reason'), { target: { value: 'Moving away' } });
+        fireEvent.click(screen.getByLabelText(/the series ends/));
+        fireEvent.click(screen.getByRole('button', { name: 'Cancel Event' }));
+
+        await waitFor(() => expect(api.post).toHaveBeenCalledWith('/events/event1/cancel', { reason: 'Moving away', series: 'end' }));
+    });
+});
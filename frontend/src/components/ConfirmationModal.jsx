@@ -1,4 +1,4 @@
-import React from 'react';
+import React, { useEffect, useState } from 'react';
 import { X } from 'lucide-react';
 
 const ConfirmationModal = ({
@@ -9,8 +9,18 @@ const ConfirmationModal = ({
     message,
     confirmText = "Confirm",
     cancelText = "Cancel",
-    isDestructive = false
+    isDestructive = false,
+    choices = [],
+    defaultChoice
 }) => {
+    // Choices, when given, are offered as radio buttons and the picked one is
+    // passed to onConfirm
+    const initialChoice = defaultChoice ?? choices[0]?.value;
+    const [choice, setChoice] = useState(initialChoice);
+    useEffect(() => {
+        if (isOpen) setChoice(initialChoice);
+    }, [isOpen, initialChoice]);
+
     if (!isOpen) return null;
 
     return (
@@ -23,10 +33,27 @@ const ConfirmationModal = ({
                     </button>
                 </div>
 
-                <p className="text-gray-600 mb-8 leading-relaxed">
+                <p className={`text-gray-600 leading-relaxed ${choices.length ? 'mb-4' : 'mb-8'}`}>
                     {message}
                 </p>
 
+                {choices.length > 0 && (
+                    <div className="space-y-2 mb-8">
+                        {choices.map(c => (
+                            <label key={c.value} className="flex items-center gap-2 text-gray-700 cursor-pointer">
+                                <input
+                                    type="radio"
+                                    name="confirmation-choice"
+                                    value={c.value}
+                                    checked={choice === c.value}
+                                    onChange={() => setChoice(c.value)}
+                                />
+                                {c.label}
+                            </label>
+                        ))}
+                    </div>
+                )}
+
                 <div className="flex gap-3">
                     <button
                         onClick={onClose}
@@ -36,7 +63,7 @@ const ConfirmationModal = ({
                     </button>
                     <button
                         onClick={() => {
-                            onConfirm();
+                            onConfirm(choice);
                             onClose();
                         }}
                         className={`flex-1 py-2.5 rounded-lg text-white font-medium shadow-sm transition focus:ring-2 focus:ring-offset-1 outline-none ${isDestructive
@@ -0,0 +1,25 @@
+import React from 'react';
+import { render, screen, fireEvent } from '@testing-library/react';
+import ConfirmationModal from './ConfirmationModal';
+import { seriesScopes } from './RecurrenceSelect';
+
+describe('ConfirmationModal', () => {
+    it('passes the picked choice to onConfirm', () => {
+        const onConfirm = vi.fn();
+        render(
+            <ConfirmationModal
+                isOpen
+                onClose={() => { }}
+                onConfirm={onConfirm}
+                title="Delete Event"
+                message="Which events do you want to delete?"
+                choices={seriesScopes}
+            />
+        );
+
+        expect(screen.getByLabelText('This event')).toBeChecked();
+        fireEvent.click(screen.getByLabelText('This and following events'));
+        fireEvent.click(screen.getByText('Confirm'));
+        expect(onConfirm).toHaveBeenCalledWith('following');
+    });
+});
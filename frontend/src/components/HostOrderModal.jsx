@@ -0,0 +1,135 @@
+import React, { useState, useEffect } from 'react';
+import { X, ChevronUp, ChevronDown } from 'lucide-react';
+import api from '../api/axios';
+import { useUI } from '../context/UIContext';
+
+// HostOrderModal lets a group's admins set the order members take turns
+// hosting in, which series using the "ordered" rotation follow, and who
+// doesn't host at all.
+const HostOrderModal = ({ isOpen, onClose, group, userId, onSaved }) => {
+    const { showToast } = useUI();
+    const [members, setMembers] = useState([]);
+    const [optedOut, setOptedOut] = useState([]);
+    const [loading, setLoading] = useState(false);
+    const [saving, setSaving] = useState(false);
+
+    useEffect(() => {
+        if (!isOpen || !group) return;
+        const fetchMembers = async () => {
+            setLoading(true);
+            try {
+                const response = await api.get(`/groups/members?group_id=${group.id}`);
+                const families = response.data.families || [];
+                // Members in the saved order first, then anyone who joined since
+                const order = group.host_order || [];
+                const position = (id) => (order.includes(id) ? order.indexOf(id) : order.length);
+                setMembers([...families].sort((a, b) => position(a.id) - position(b.id)));
+                setOptedOut(group.host_opt_out_ids || []);
+            } catch (error) {
+                console.error("Failed to fetch group members", error);
+                showToast("Failed to fetch group members", "error");
+            } finally {
+                setLoading(false);
+            }
+        };
+        fetchMembers();
+    }, [isOpen, group, showToast]);
+
+    const move = (index, by) => {
+        const next = [...members];
+        [next[index], next[index + by]] = [next[index + by], next[index]];
+        setMembers(next);
+    };
+
+    const toggleOptOut = (id) => {
+        setOptedOut(optedOut.includes(id) ? optedOut.filter(o => o !== id) : [...optedOut, id]);
+    };
+
+    const handleSave = async () => {
+        setSaving(true);
+        try {
+            const updates = { host_order: members.map(m => m.id), host_opt_out_ids: optedOut };
+            await api.patch(`/groups/${group.id}`, { ...updates, user_id: userId });
+            showToast("Host order saved");
+            onSaved?.(updates);
+            onClose();
+        } catch (error) {
+            console.error("Failed to save host order", error);
+            showToast("Failed to save host order", "error");
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    if (!isOpen) return null;
+
+    return (
+        <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center p-4 z-50 animate-in fade-in duration-200">
+            <div className="bg-white rounded-xl shadow-xl max-w-md w-full p-6 transform transition-all scale-100">
+                <div className="flex justify-between items-center mb-2">
+                    <h3 className="text-xl font-bold text-gray-800">Host Order</h3>
+                    <button onClick={onClose} className="text-gray-400 hover:text-gray-600 transition">
+                        <X className="w-6 h-6" />
+                    </button>
+                </div>
+                <p className="text-sm text-gray-500 mb-4">
+                    Recurring events that rotate in the group's host order pass from each member to the next. Members who don't host are skipped by every rotation.
+                </p>
+
+                {loading ? (
+                    <div className="text-center py-8 text-gray-500">Loading...</div>
+                ) : (
+                    <ol className="space-y-2 max-h-80 overflow-y-auto pr-2">
+                        {members.map((member, i) => (
+                            <li key={member.id} className="flex items-center gap-2 p-2 bg-gray-50 rounded-lg">
+                                <span className="w-6 text-sm text-gray-400">{i + 1}.</span>
+                                <span className={`flex-1 text-sm ${optedOut.includes(member.id) ? 'text-gray-400 line-through' : 'text-gray-800'}`}>
+                                    {member.name}
+                                </span>
+                                <label className="flex items-center gap-1 text-xs text-gray-500">
+                                    <input
+                                        type="checkbox"
+                                        checked={optedOut.includes(member.id)}
+                                        onChange={() => toggleOptOut(member.id)}
+                                    />
+                                    Doesn't host
+                                </label>
+                                <button
+                                    onClick={() => move(i, -1)}
+                                    disabled={i === 0}
+                                    className="text-gray-400 hover:text-orange-600 disabled:opacity-30"
+                                    title={`Move ${member.name} up`}
+                                >
+                                    <ChevronUp className="w-4 h-4" />
+                                </button>
+                                <button
+                                    onClick={() => move(i, 1)}
+                                    disabled={i === members.length - 1}
+                                    className="text-gray-400 hover:text-orange-600 disabled:opacity-30"
+                                    title={`Move ${member.name} down`}
+                                >
+                                    <ChevronDown className="w-4 h-4" />
+                                </button>
+                            </li>
+                        ))}
+                    </ol>
+                )}
+
+                <div className="flex justify-end gap-3 mt-6">
+                    <button onClick={onClose} className="px-4 py-2 text-gray-600 hover:bg-gray-100 rounded-lg transition">
+                        Cancel
+                    </button>
+                    <button
+                        onClick={handleSave}
+                        disabled={saving || loading}
+                        className="px-4 py-2 bg-orange-600 text-white rounded-lg hover:bg-orange-700 transition disabled:opacity-50"
+                    >
+                        Save
+                    </button>
+                </div>
+            </div>
+        </div>
+    );
+};
+
+export default HostOrderModal;
@@ -0,0 +1,62 @@
+import React from 'react';
+import { render, screen, fireEvent, waitFor } from '@testing-library/react';
+import '@testing-library/jest-dom';
+import HostOrderModal from './HostOrderModal';
+import api from '../api/axios';
+import { vi } from 'vitest';
+
+vi.mock('../api/axios');
+
+const mockShowToast = vi.fn();
+
+vi.mock('../context/UIContext', () => ({
+    useUI: () => ({
+        showToast: mockShowToast,
+        confirm: vi.fn()
+    }),
+}));
+
+describe('HostOrderModal', () => {
+    const group = { id: 'group1', host_order: ['cat', 'ann'], host_opt_out_ids: [] };
+
+    beforeEach(() => {
+        vi.clearAllMocks();
+        api.get.mockResolvedValue({
+            data: {
+                families: [
+                    { id: 'ann', name: 'Ann' },
+                    { id: 'bob', name: 'Bob' },
+                    { id: 'cat', name: 'Cat' },
+                ],
+            },
+        });
+        api.patch.mockResolvedValue({});
+    });
+
+    test('lists members in the saved order with new members last', async () => {
+        render(<HostOrderModal isOpen={true} onClose={vi.fn()} group={group} userId="ann" />);
+
+        await waitFor(() => expect(screen.getByText('Cat')).toBeInTheDocument());
+        const names = screen.getAllByRole('listitem').map(li => li.textContent);
+        expect(names[0]).toContain('Cat');
+        expect(names[1]).toContain('Ann');
+        expect(names[2]).toContain('Bob');
+    });
+
+    test('saves the new order and opt-outs', async () => {
+        const onClose = vi.fn();
+        render(<HostOrderModal isOpen={true} onClose={onClose} group={group} userId="ann" />);
+
+        await waitFor(() => expect(screen.getByText('Bob')).toBeInTheDocument());
+        fireEvent.click(screen.getByTitle('Move Bob up'));
+        fireEvent.click(screen.getAllByRole('checkbox')[0]);
+        fireEvent.click(screen.getByText('Save'));
+
+        await waitFor(() => expect(api.patch).toHaveBeenCalledWith('/groups/group1', {
+            host_order: ['cat', 'bob', 'ann'],
+            host_opt_out_ids: ['cat'],
+            user_id: 'ann',
+        }));
+        expect(onClose).toHaveBeenCalled();
+    });
+});
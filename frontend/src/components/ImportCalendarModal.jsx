@@ -0,0 +1,181 @@
+import React, { useState, useEffect } from 'react';
+import { X, Upload } from 'lucide-react';
+import api from '../api/axios';
+import { useUI } from '../context/UIContext';
+
+const resultLabels = {
+    create: { text: 'New', className: 'bg-green-100 text-green-700' },
+    created: { text: 'Imported', className: 'bg-green-100 text-green-700' },
+    duplicate: { text: 'Duplicate', className: 'bg-yellow-100 text-yellow-700' },
+    skipped: { text: 'Skipped', className: 'bg-gray-100 text-gray-500' },
+};
+
+// ImportCalendarModal lets a group's admins bring in events from an .ics file
+// exported from another calendar. It previews what would be created before
+// anything is, leaving out events the group already has.
+const ImportCalendarModal = ({ isOpen, onClose, group, userId, onImported }) => {
+    const { showToast } = useUI();
+    const [members, setMembers] = useState([]);
+    const [hostId, setHostId] = useState(userId);
+    const [type, setType] = useState('Dinner');
+    const [ics, setIcs] = useState('');
+    const [fileName, setFileName] = useState('');
+    const [preview, setPreview] = useState(null);
+    const [working, setWorking] = useState(false);
+
+    useEffect(() => {
+        if (!isOpen || !group) return;
+        setHostId(userId);
+        setIcs('');
+        setFileName('');
+        setPreview(null);
+        const fetchMembers = async () => {
+            try {
+                const response = await api.get(`/groups/members?group_id=${group.id}`);
+                setMembers(response.data.families || []);
+            } catch (error) {
+                console.error("Failed to fetch group members", error);
+            }
+        };
+        fetchMembers();
+    }, [isOpen, group, userId]);
+
+    const handleFile = async (e) => {
+        const file = e.target.files[0];
+        if (!file) return;
+        setFileName(file.name);
+        setIcs(await file.text());
+        setPreview(null);
+    };
+
+    const send = async (dryRun) => {
+        setWorking(true);
+        try {
+            const response = await api.post('/events/import', {
+                group_id: group.id,
+                host_id: hostId,
+                type,
+                dry_run: dryRun,
+                ics,
+            });
+            if (dryRun) {
+                setPreview(response.data);
+                return;
+            }
+            showToast(`Imported ${response.data.created} event${response.data.created === 1 ? '' : 's'}`);
+            onImported?.(response.data);
+            onClose();
+        } catch (error) {
+            console.error("Failed to import calendar", error);
+            showToast(error.response?.data?.trim() || "Failed to import calendar", "error");
+        } finally {
+            setWorking(false);
+        }
+    };
+
+    if (!isOpen) return null;
+
+    return (
+        <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center p-4 z-50 animate-in fade-in duration-200">
+            <div className="bg-white rounded-xl shadow-xl max-w-lg w-full p-6 transform transition-all scale-100">
+                <div className="flex justify-between items-center mb-2">
+                    <h3 className="text-xl font-bold text-gray-800">Import Calendar</h3>
+                    <button onClick={onClose} className="text-gray-400 hover:text-gray-600 transition">
+                        <X className="w-6 h-6" />
+                    </button>
+                </div>
+                <p className="text-sm text-gray-500 mb-4">
+                    Export your shared calendar from Google or Apple Calendar as an .ics file to add its events to {group?.name}. Events are hosted by the member who organized them, or by the host you choose.
+                </p>
+
+                <div className="space-y-3">
+                    <label className="flex items-center gap-2 p-3 border-2 border-dashed border-gray-200 rounded-lg cursor-pointer hover:border-orange-300 text-sm text-gray-600">
+                        <Upload className="w-4 h-4" />
+                        <span>{fileName || 'Choose an .ics file'}</span>
+                        <input
+                            type="file"
+                            accept=".ics,text/calendar"
+                            className="hidden"
+                            data-testid="ics-file"
+                            onChange={handleFile}
+                        />
+                    </label>
+                    <div className="flex gap-3">
+                        <label className="flex-1 text-sm text-gray-700">
+                            Host
+                            <select
+                                className="mt-1 w-full p-2 border border-gray-200 rounded-lg text-sm"
+                                value={hostId}
+                                onChange={(e) => { setHostId(e.target.value); setPreview(null); }}
+                            >
+                                {members.map(m => (
+                                    <option key={m.id} value={m.id}>{m.name}</option>
+                                ))}
+                            </select>
+                        </label>
+                        <label className="flex-1 text-sm text-gray-700">
+                            Type
+                            <input
+                                type="text"
+                                className="mt-1 w-full p-2 border border-gray-200 rounded-lg text-sm"
+                                value={type}
+                                onChange={(e) => { setType(e.target.value); setPreview(null); }}
+                            />
+                        </label>
+                    </div>
+                </div>
+
+                {preview && (
+                    <div className="mt-4">
+                        <p className="text-sm text-gray-600 mb-2">
+                            {preview.created} to import, {preview.duplicates} already in the group, {preview.skipped} skipped
+                        </p>
+                        <ul className="space-y-2 max-h-60 overflow-y-auto pr-2">
+                            {preview.events.map((event, i) => {
+                                const label = resultLabels[event.result] || resultLabels.skipped;
+                                return (
+                                    <li key={`${event.uid}-${i}`} className="flex items-start gap-2 p-2 bg-gray-50 rounded-lg text-sm">
+                                        <div className="flex-1">
+                                            <p className="text-gray-800">{event.name}</p>
+                                            <p className="text-xs text-gray-500">
+                                                {new Date(event.date).toLocaleDateString()}
+                                                {event.recurrence && ' · repeats'}
+                                                {event.reason && ` · ${event.reason}`}
+                                            </p>
+                                        </div>
+                                        <span className={`text-xs px-2 py-0.5 rounded-full ${label.className}`}>{label.text}</span>
+                                    </li>
+                                );
+                            })}
+                        </ul>
+                    </div>
+                )}
+
+                <div className="flex justify-end gap-3 mt-6">
+                    <button onClick={onClose} className="px-4 py-2 text-gray-600 hover:bg-gray-100 rounded-lg transition">
+                        Cancel
+                    </button>
+                    {preview ? (
+                        <button
+                            onClick={() => send(false)}
+                            disabled={working || preview.created === 0}
+                            className="px-4 py-2 bg-orange-600 text-white rounded-lg hover:bg-orange-700 transition disabled:opacity-50"
+                        >
+                            Import {preview.created} Event{preview.created === 1 ? '' : 's'}
+                        </button>
+                    ) : (
+                        <button
+                            onClick={() => send(true)}
+                            disabled={working || !ics || !hostId}
+                            className="px-4 py-2 bg-orange-600 text-white rounded-lg hover:bg-orange-700 transition disabled:opacity-50"
+                        >
+                            Preview
+                        </button>
+                    )}
+                </div>
+            </div>
+        </div>
+    );
+};
+
+export default ImportCalendarModal;
@@ -0,0 +1,86 @@
+import React from 'react';
+import { render, screen, fireEvent, waitFor } from '@testing-library/react';
+import '@testing-library/jest-dom';
+import ImportCalendarModal from './ImportCalendarModal';
+import api from '../api/axios';
+import { vi } from 'vitest';
+
+vi.mock('../api/axios');
+
+const mockShowToast = vi.fn();
+
+vi.mock('../context/UIContext', () => ({
+    useUI: () => ({
+        showToast: mockShowToast,
+        confirm: vi.fn()
+    }),
+}));
+
+describe('ImportCalendarModal', () => {
+    const group = { id: 'group1', name: 'Smiths' };
+    const ics = 'BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n';
+    const preview = {
+        dry_run: true,
+        created: 1,
+        duplicates: 1,
+        skipped: 0,
+        events: [
+            { uid: 'a', name: 'Sunday Dinner', date: '2030-01-06T18:00:00Z', recurrence: 'FREQ=WEEKLY', result: 'create' },
+            { uid: 'b', name: 'Picnic', date: '2030-07-04T00:00:00Z', result: 'duplicate', reason: 'an event with the same name is on that day' },
+        ],
+    };
+
+    beforeEach(() => {
+        vi.clearAllMocks();
+        api.get.mockResolvedValue({
+            data: { families: [{ id: 'ann', name: 'Ann' }, { id: 'bob', name: 'Bob' }] },
+        });
+    });
+
+    const chooseFile = async () => {
+        const file = new File([ics], 'family.ics', { type: 'text/calendar' });
+        fireEvent.change(screen.getByTestId('ics-file'), { target: { files: [file] } });
+        await waitFor(() => expect(screen.getByText('family.ics')).toBeInTheDocument());
+    };
+
+    test('previews the import before creating anything', async () => {
+        api.post.mockResolvedValue({ data: preview });
+        render(<ImportCalendarModal isOpen={true} onClose={vi.fn()} group={group} userId="ann" />);
+
+        await waitFor(() => expect(screen.getByText('Bob')).toBeInTheDocument());
+        await chooseFile();
+        fireEvent.click(screen.getByText('Preview'));
+
+        await waitFor(() => expect(screen.getByText('Sunday Dinner')).toBeInTheDocument());
+        expect(api.post).toHaveBeenCalledWith('/events/import', {
+            group_id: 'group1',
+            host_id: 'ann',
+            type: 'Dinner',
+            dry_run: true,
+            ics,
+        });
+        expect(screen.getByText('Duplicate')).toBeInTheDocument();
+        expect(screen.getByText(/an event with the same name is on that day/)).toBeInTheDocument();
+        expect(screen.getByText('Import 1 Event')).toBeInTheDocument();
+    });
+
+    test('imports the previewed events', async () => {
+        const onClose = vi.fn();
+        const onImported = vi.fn();
+        api.post
+            .mockResolvedValueOnce({ data: preview })
+            .mockResolvedValueOnce({ data: { ...preview, dry_run: false, events: [{ ...preview.events[0], result: 'created' }] } });
+        render(<ImportCalendarModal isOpen={true} onClose={onClose} group={group} userId="ann" onImported={onImported} />);
+
+        await waitFor(() => expect(screen.getByText('Bob')).toBeInTheDocument());
+        await chooseFile();
+        fireEvent.click(screen.getByText('Preview'));
+        await waitFor(() => expect(screen.getByText('Import 1 Event')).toBeInTheDocument());
+        fireEvent.click(screen.getByText('Import 1 Event'));
+
+        await waitFor(() => expect(onClose).toHaveBeenCalled());
+        expect(api.post).toHaveBeenLastCalledWith('/events/import', expect.objectContaining({ dry_run: false }));
+        expect(mockShowToast).toHaveBeenCalledWith('Imported 1 event');
+        expect(onImported).toHaveBeenCalled();
+    });
+});
@@ -1,7 +1,8 @@
 import React, { useState, useEffect, useCallback } from 'react';
-import { X, Plus, Users, Save, Trash2 } from 'lucide-react';
+import { X, Plus, Users, Save, Trash2, CalendarOff } from 'lucide-react';
 import api from '../api/axios';
 import { useUI } from '../context/UIContext';
+import AvailabilityModal from './AvailabilityModal';
 
 const ManageHouseholdModal = ({ isOpen, onClose, user, refreshUser, householdId, isAdmin, groupId }) => {
     const { showToast, confirm } = useUI();
@@ -15,6 +16,7 @@ const ManageHouseholdModal = ({ isOpen, onClose, user, refreshUser, householdId,
     const [updateName, setUpdateName] = useState('');
     const [updateAddress, setUpdateAddress] = useState('');
     const [updating, setUpdating] = useState(false);
+    const [availabilityOpen, setAvailabilityOpen] = useState(false);
 
     const targetHouseholdId = householdId || user?.household_id;
 
@@ -80,7 +82,8 @@ const ManageHouseholdModal = ({ isOpen, onClose, user, refreshUser, householdId,
         try {
             await api.patch(`/households/${targetHouseholdId}`, {
                 name: updateName,
-                address: updateAddress
+                address: updateAddress,
+                group_id: isAdmin ? groupId : undefined
             });
             showToast("Household updated successfully!");
             fetchHousehold();
@@ -99,7 +102,8 @@ const ManageHouseholdModal = ({ isOpen, onClose, user, refreshUser, householdId,
         try {
             await api.post('/households/add-member', {
                 household_id: targetHouseholdId,
-                email: addEmail
+                email: addEmail,
+                group_id: isAdmin ? groupId : undefined
             });
             setAddEmail('');
             showToast("Member added successfully!");
@@ -227,7 +231,15 @@ const ManageHouseholdModal = ({ isOpen, onClose, user, refreshUser, householdId,
                                             placeholder="Household Address"
                                         />
                                     </div>
-                                    <div className="flex justify-end">
+                                    <div className="flex justify-between">
+                                        <button
+                                            type="button"
+                                            onClick={() => setAvailabilityOpen(true)}
+                                            className="text-purple-600 hover:text-purple-800 text-xs font-medium flex items-center gap-1"
+                                        >
+                                            <CalendarOff className="w-3 h-3" />
+                                            Days Away{household.unavailable?.length ? ` (${household.unavailable.length})` : ''}
+                                        </button>
                                         <button
                                             type="submit"
                                             disabled={updating}
@@ -339,6 +351,16 @@ const ManageHouseholdModal = ({ isOpen, onClose, user, refreshUser, householdId,
                     </div>
                 )}
             </div>
+
+            <AvailabilityModal
+                isOpen={availabilityOpen}
+                onClose={() => setAvailabilityOpen(false)}
+                title="Household Days Away"
+                path={`/households/${targetHouseholdId}`}
+                unavailable={household?.unavailable}
+                extra={{ group_id: isAdmin ? groupId : undefined }}
+                onSaved={fetchHousehold}
+            />
         </div >
     );
 };
@@ -0,0 +1,135 @@
+import React from 'react';
+
+// Recurrence is stored as an RFC 5545 RRULE, e.g. "FREQ=MONTHLY;BYDAY=2SU".
+// Events created before that use the names below, which the server still
+// understands.
+const LEGACY_RULES = {
+    'Daily': 'FREQ=DAILY',
+    'Weekly': 'FREQ=WEEKLY',
+    'Bi-Weekly': 'FREQ=WEEKLY;INTERVAL=2',
+    'Monthly': 'FREQ=MONTHLY',
+};
+
+const DAY_CODES = ['SU', 'MO', 'TU', 'WE', 'TH', 'FR', 'SA'];
+const DAY_NAMES = ['Sunday', 'Monday', 'Tuesday', 'Wednesday', 'Thursday', 'Friday', 'Saturday'];
+const ORDINALS = { 1: '1st', 2: '2nd', 3: '3rd', 4: '4th', 5: '5th', '-1': 'last' };
+const FREQ_UNITS = { DAILY: 'day', WEEKLY: 'week', MONTHLY: 'month', YEARLY: 'year' };
+
+const parseRule = (rule) => {
+    const parts = {};
+    (LEGACY_RULES[rule] || rule).replace(/^RRULE:/i, '').split(';').forEach(part => {
+        const [name, value] = part.split('=');
+        if (name && value) parts[name.toUpperCase()] = value.toUpperCase();
+    });
+    return parts;
+};
+
+// seriesScopes are the occurrences of a recurring event an edit or delete
+// can apply to, sent as the scope query parameter.
+export const seriesScopes = [
+    { value: 'this', label: 'This event' },
+    { value: 'following', label: 'This and following events' },
+    { value: 'all', label: 'All events in the series' },
+];
+
+// hostRotations are the ways a series can pick the host of each new
+// occurrence, sent as host_rotation.
+export const hostRotations = [
+    { value: 'members', label: 'Each member in turn' },
+    { value: 'ordered', label: "In the group's host order" },
+    { value: 'household', label: 'Each household in turn' },
+    { value: 'fewest_hosted', label: 'Whoever has hosted least' },
+];
+
+// recurrenceOptions are the common rules for an event on date, such as
+// "Monthly on the 2nd Sunday" for an event on the second Sunday of a month.
+export const recurrenceOptions = (date) => {
+    const options = [
+        { value: 'FREQ=DAILY', label: 'Daily' },
+        { value: 'FREQ=WEEKLY', label: 'Weekly' },
+        { value: 'FREQ=WEEKLY;INTERVAL=2', label: 'Every 2 weeks' },
+        { value: 'FREQ=MONTHLY', label: 'Monthly on the same day' },
+    ];
+    const d = date ? new Date(date) : null;
+    if (!d || isNaN(d)) return options;
+
+    const day = d.getDay();
+    const nth = Math.ceil(d.getDate() / 7);
+    const daysInMonth = new Date(d.getFullYear(), d.getMonth() + 1, 0).getDate();
+    options[1].label = `Weekly on ${DAY_NAMES[day]}`;
+    options[3].label = `Monthly on day ${d.getDate()}`;
+    if (nth <= 4) {
+        options.push({ value: `FREQ=MONTHLY;BYDAY=${nth}${DAY_CODES[day]}`, label: `Monthly on the ${ORDINALS[nth]} ${DAY_NAMES[day]}` });
+    }
+    if (d.getDate() + 7 > daysInMonth) {
+        options.push({ value: `FREQ=MONTHLY;BYDAY=-1${DAY_CODES[day]}`, label: `Monthly on the last ${DAY_NAMES[day]}` });
+    }
+    return options;
+};
+
+// describeRecurrence turns a rule into a short phrase for badges, falling back
+// to the rule itself for anything unusual.
+export const describeRecurrence = (rule) => {
+    if (!rule) return '';
+    const parts = parseRule(rule);
+    const unit = FREQ_UNITS[parts.FREQ];
+    if (!unit) return rule;
+
+    const interval = parseInt(parts.INTERVAL || '1', 10);
+    let text = interval > 1 ? `Every ${interval} ${unit}s` : { day: 'Daily', week: 'Weekly', month: 'Monthly', year: 'Yearly' }[unit];
+    if (parts.BYDAY) {
+        const days = parts.BYDAY.split(',').map(code => {
+            const match = code.match(/^([+-]?\d+)?([A-Z]{2})$/);
+            if (!match) return code;
+            const name = DAY_NAMES[DAY_CODES.indexOf(match[2])] || match[2];
+            return match[1] ? `the ${ORDINALS[parseInt(match[1], 10)] || match[1]} ${name}` : name;
+        });
+        text += ` on ${days.join(', ')}`;
+    } else if (parts.BYMONTHDAY) {
+        text += parts.BYMONTHDAY === '-1' ? ' on the last day' : ` on day ${parts.BYMONTHDAY}`;
+    }
+    if (parts.COUNT) text += `, ${parts.COUNT} times`;
+    if (parts.UNTIL) {
+        const m = parts.UNTIL.match(/^(\d{4})(\d{2})(\d{2})/);
+        if (m) text += `, until ${new Date(+m[1], +m[2] - 1, +m[3]).toLocaleDateString()}`;
+    }
+    return text;
+};
+
+// RecurrenceSelect picks a recurrence rule for an event on date, offering the
+// common ones and a custom RRULE field for anything else.
+const RecurrenceSelect = ({ value, onChange, date, className }) => {
+    const options = recurrenceOptions(date);
+    const current = LEGACY_RULES[value] || value;
+    const isCustom = !options.some(o => o.value === current);
+
+    return (
+        <div className="space-y-2">
+            <select
+                className={className}
+                value={isCustom ? 'custom' : current}
+                onChange={e => onChange(e.target.value === 'custom' ? (isCustom ? value : 'FREQ=WEEKLY;COUNT=4') : e.target.value)}
+            >
+                {options.map(o => <option key={o.value} value={o.value}>{o.label}</option>)}
+                <option value="custom">Custom rule…</option>
+            </select>
+            {isCustom && (
+                <div>
+                    <input
+                        type="text"
+                        aria-label="Custom recurrence rule"
+                        className={className}
+                        value={value}
+                        placeholder="FREQ=MONTHLY;BYDAY=-1FR;COUNT=6"
+                        onChange={e => onChange(e.target.value)}
+                    />
+                    <p className="text-xs text-gray-500 mt-1">
+                        An iCalendar RRULE{value ? `: ${describeRecurrence(value)}` : ''}
+                    </p>
+                </div>
+            )}
+        </div>
+    );
+};
+
+export default RecurrenceSelect;
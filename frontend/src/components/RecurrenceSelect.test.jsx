@@ -0,0 +1,34 @@
+import React from 'react';
+import { render, screen, fireEvent } from '@testing-library/react';
+import RecurrenceSelect, { describeRecurrence, recurrenceOptions } from './RecurrenceSelect';
+
+describe('RecurrenceSelect', () => {
+    it('offers rules relative to the event date', () => {
+        // The second and last Friday of May 2025
+        const labels = recurrenceOptions('2025-05-30T18:00').map(o => o.label);
+        expect(labels).toContain('Weekly on Friday');
+        expect(labels).toContain('Monthly on the last Friday');
+        expect(recurrenceOptions('2025-05-09T18:00').map(o => o.value)).toContain('FREQ=MONTHLY;BYDAY=2FR');
+    });
+
+    it('describes rules', () => {
+        expect(describeRecurrence('Bi-Weekly')).toBe('Every 2 weeks');
+        expect(describeRecurrence('FREQ=MONTHLY;BYDAY=2SU')).toBe('Monthly on the 2nd Sunday');
+        expect(describeRecurrence('FREQ=MONTHLY;BYDAY=-1FR;COUNT=6')).toBe('Monthly on the last Friday, 6 times');
+    });
+
+    it('shows a custom rule field for rules not in the list', () => {
+        const onChange = vi.fn();
+        render(<RecurrenceSelect value="FREQ=YEARLY;BYMONTH=11;BYDAY=4TH" date="2025-11-27T18:00" onChange={onChange} />);
+
+        const input = screen.getByLabelText('Custom recurrence rule');
+        fireEvent.change(input, { target: { value: 'FREQ=YEARLY' } });
+        expect(onChange).toHaveBeenCalledWith('FREQ=YEARLY');
+    });
+
+    it('selects a legacy rule by its RRULE', () => {
+        render(<RecurrenceSelect value="Weekly" date="2025-05-30T18:00" onChange={() => {}} />);
+        expect(screen.getByRole('combobox')).toHaveValue('FREQ=WEEKLY');
+        expect(screen.queryByLabelText('Custom recurrence rule')).not.toBeInTheDocument();
+    });
+});
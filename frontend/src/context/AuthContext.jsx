@@ -27,9 +27,11 @@ export const AuthProvider = ({ children }) => {
         checkAuth();
     }, []);
 
-    const login = async (idToken) => {
+    const login = async (idToken) => loginWith('google', { id_token: idToken });
+
+    const loginWith = async (provider, credentials) => {
         try {
-            const response = await api.post('/auth/google', { id_token: idToken });
+            const response = await api.post(`/auth/${provider}`, credentials);
             setUser(response.data);
             return response.data;
         } catch (error) {
@@ -52,7 +54,7 @@ export const AuthProvider = ({ children }) => {
     };
 
     return (
-        <AuthContext.Provider value={{ user, login, logout, loading, refreshUser }}>
+        <AuthContext.Provider value={{ user, login, loginWith, logout, loading, refreshUser }}>
             {children}
         </AuthContext.Provider>
     );
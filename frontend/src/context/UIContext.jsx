@@ -28,6 +28,7 @@ export const UIProvider = ({ children }) => {
         confirmText: 'Confirm',
         cancelText: 'Cancel',
         isDestructive: false,
+        choices: [],
         onConfirm: () => { }
     });
 
@@ -43,7 +44,7 @@ export const UIProvider = ({ children }) => {
         setModal(prev => ({ ...prev, isOpen: false }));
     }, []);
 
-    const confirm = useCallback(({ title, message, confirmText, cancelText, isDestructive, onConfirm }) => {
+    const confirm = useCallback(({ title, message, confirmText, cancelText, isDestructive, choices, defaultChoice, onConfirm }) => {
         setModal({
             isOpen: true,
             title,
@@ -51,8 +52,10 @@ export const UIProvider = ({ children }) => {
             confirmText: confirmText || 'Confirm',
             cancelText: cancelText || 'Cancel',
             isDestructive: isDestructive || false,
-            onConfirm: () => {
-                if (onConfirm) onConfirm();
+            choices: choices || [],
+            defaultChoice,
+            onConfirm: (choice) => {
+                if (onConfirm) onConfirm(choice);
                 closeModal();
             }
         });
@@ -76,6 +79,8 @@ export const UIProvider = ({ children }) => {
                 confirmText={modal.confirmText}
                 cancelText={modal.cancelText}
                 isDestructive={modal.isDestructive}
+                choices={modal.choices}
+                defaultChoice={modal.defaultChoice}
             />
         </UIContext.Provider>
     );
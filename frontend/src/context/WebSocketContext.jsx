@@ -1,12 +1,267 @@
-import React, { createContext, useContext, useEffect, useState } from 'react';
+import React, { createContext, useCallback, useContext, useEffect, useRef, useState } from 'react';
+import { useAuth } from './AuthContext';
+import api from '../api/axios';
+import { validateMessage } from '../realtime/messages';
 
 const WebSocketContext = createContext(null);
 
+// sendFrame sends a control frame, such as subscribe or join, if ws is open;
+// subscriptions and joins missed while closed are resent by onopen. fields
+// holds extras like since, which asks the server to replay what was published
+// on the topic after that sequence number.
+const sendFrame = (ws, type, topic, fields = {}) => {
+    if (ws && ws.readyState === WebSocket.OPEN) {
+        ws.send(JSON.stringify({ type, topic, ...fields }));
+    }
+};
+
+// The server forgets a joined page after a minute without a heartbeat.
+const PRESENCE_HEARTBEAT = 25000;
+
+// How long a typing indicator lasts unless it is repeated.
+const TYPING_TIMEOUT = 5000;
+
+// How long to wait for the response to a request frame.
+const REQUEST_TIMEOUT = 15000;
+
+// restRequests are the REST equivalents of the methods the socket accepts,
+// used when it isn't open.
+const restRequests = {
+    'chat.send': (params) => api.post('/chat/messages', params),
+    'dish.pledge': ({ dish_id, ...body }) => api.post(`/dishes/${dish_id}/pledge`, body),
+    'rsvp.set': (params) => api.post('/rsvps', params),
+};
+
+// requestError shapes a failed response frame like an axios error, so callers
+// handle both transports the same way.
+const requestError = (message) => {
+    const error = new Error(message.error);
+    error.response = { status: message.status, data: message.error };
+    return error;
+};
+
+// After this many attempts that never open, we assume something between us and
+// the server blocks WebSockets and fall back to Server-Sent Events.
+const MAX_FAILED_SOCKETS = 2;
+
+// streamUrl is the Server-Sent Events endpoint for a group or event topic.
+const streamUrl = (topic, since) => {
+    const [kind, id] = topic.split(':');
+    if (kind !== 'group' && kind !== 'event') return null;
+    const query = since ? `?last_event_id=${since}` : '';
+    return `${api.defaults.baseURL}/${kind}s/${id}/stream${query}`;
+};
+
 export const WebSocketProvider = ({ children }) => {
     const [socket, setSocket] = useState(null);
     const [lastMessage, setLastMessage] = useState(null);
+    const { user } = useAuth();
+    // Topics the mounted pages want, with how many of them want each one. They
+    // are (re)sent to the server whenever the socket opens.
+    const topicsRef = useRef(new Map());
+    const socketRef = useRef(null);
+    // The last sequence number seen on each subscribed topic, used to resume
+    // after a reconnect and to drop replayed messages we already have.
+    const seqRef = useRef(new Map());
+    // In fallback mode each topic has its own EventSource instead.
+    const streamsRef = useRef(new Map());
+    const useStreamsRef = useRef(false);
+    // Event topics this tab has open, with how many components joined each.
+    // Presence needs the socket; in fallback mode we still see others but
+    // can't announce ourselves.
+    const joinedRef = useRef(new Map());
+    // Who is present on, and typing in, each event topic.
+    const [presence, setPresence] = useState({});
+    const [typing, setTyping] = useState({});
+    // Request frames awaiting their response, by request_id.
+    const pendingRef = useRef(new Map());
+    const nextRequestRef = useRef(1);
+    const userId = user ? user.id : null;
+
+    // A message is new unless every subscribed topic it was published on has
+    // already seen its sequence number.
+    const isNewMessage = useCallback((seqs) => {
+        let isNew = false;
+        let tracked = false;
+        for (const [topic, seq] of Object.entries(seqs)) {
+            if (!topicsRef.current.has(topic)) continue;
+            tracked = true;
+            const seen = seqRef.current.get(topic) || 0;
+            if (seq > seen) {
+                isNew = true;
+                seqRef.current.set(topic, seq);
+            }
+        }
+        return isNew || !tracked;
+    }, []);
+
+    // handleMessage processes an envelope from either transport.
+    const handleMessage = useCallback((message) => {
+        if (message.type === 'error') {
+            console.warn(`WebSocket subscription to ${message.topic} failed: ${message.error}`);
+            return;
+        }
+        if (message.type === 'subscribed') {
+            const seen = seqRef.current.get(message.topic) || 0;
+            seqRef.current.set(message.topic, Math.max(seen, message.seq || 0));
+            return;
+        }
+        if (message.type === 'unsubscribed') return;
+        if (message.type === 'response') {
+            const pending = pendingRef.current.get(message.request_id);
+            if (!pending) return;
+            pendingRef.current.delete(message.request_id);
+            clearTimeout(pending.timer);
+            if (message.error) pending.reject(requestError(message));
+            else pending.resolve({ data: message.result });
+            return;
+        }
+        if (message.type === 'disconnect') {
+            // The server is about to close the connection; we reconnect
+            console.warn(`WebSocket disconnected by server: ${message.reason}`);
+            return;
+        }
+        if (message.type === 'resync_required') {
+            // Too much was missed to replay; pages refetch the topic
+            seqRef.current.delete(message.topic);
+            setLastMessage(message);
+            return;
+        }
+        if (import.meta.env.DEV) {
+            const problem = validateMessage(message);
+            if (problem) console.warn(`Unexpected realtime message: ${problem}`, message);
+        }
+        if (message.type === 'presence_changed') {
+            const topic = `event:${message.data.event_id}`;
+            setPresence(prev => ({ ...prev, [topic]: message.data.members }));
+            return;
+        }
+        if (message.type === 'typing_changed') {
+            const topic = `event:${message.data.event_id}`;
+            const { member } = message.data;
+            setTyping(prev => {
+                const others = (prev[topic] || []).filter(t => t.member.id !== member.id);
+                const typers = message.data.typing ? [...others, { member, until: Date.now() + TYPING_TIMEOUT }] : others;
+                return { ...prev, [topic]: typers };
+            });
+            return;
+        }
+        if (message.seq && !isNewMessage(message.seq)) return;
+        setLastMessage(message);
+    }, [isNewMessage]);
+
+    const openStream = useCallback((topic) => {
+        const url = streamUrl(topic, seqRef.current.get(topic));
+        if (!url || streamsRef.current.has(topic)) return;
+
+        const source = new EventSource(url, { withCredentials: true });
+        source.onmessage = (event) => {
+            try {
+                handleMessage(JSON.parse(event.data));
+            } catch (e) {
+                console.error("Failed to parse stream message", e);
+            }
+        };
+        source.onerror = () => {
+            // EventSource retries by itself unless the server refused the
+            // request, usually because the access token expired.
+            if (source.readyState !== EventSource.CLOSED) return;
+            streamsRef.current.delete(topic);
+            setTimeout(() => {
+                api.get('/auth/me').catch(() => {}).finally(() => {
+                    if (useStreamsRef.current && topicsRef.current.has(topic)) openStream(topic);
+                });
+            }, 3000);
+        };
+        streamsRef.current.set(topic, source);
+    }, [handleMessage]);
+
+    const closeStream = (topic) => {
+        const source = streamsRef.current.get(topic);
+        if (source) source.close();
+        streamsRef.current.delete(topic);
+    };
+
+    // subscribe asks the server for a topic's traffic and returns a function
+    // that releases it again.
+    const subscribe = useCallback((topic) => {
+        const topics = topicsRef.current;
+        const count = topics.get(topic) || 0;
+        topics.set(topic, count + 1);
+        if (count === 0) {
+            if (useStreamsRef.current) openStream(topic);
+            else sendFrame(socketRef.current, 'subscribe', topic);
+        }
+
+        return () => {
+            const remaining = (topics.get(topic) || 1) - 1;
+            if (remaining > 0) {
+                topics.set(topic, remaining);
+                return;
+            }
+            topics.delete(topic);
+            seqRef.current.delete(topic);
+            if (useStreamsRef.current) closeStream(topic);
+            else sendFrame(socketRef.current, 'unsubscribe', topic);
+        };
+    }, [openStream]);
+
+    // joinPresence tells the server this tab has an event topic open, and
+    // returns a function that leaves it again. The topic must be subscribed.
+    const joinPresence = useCallback((topic) => {
+        const joined = joinedRef.current;
+        const count = joined.get(topic) || 0;
+        joined.set(topic, count + 1);
+        if (count === 0) sendFrame(socketRef.current, 'join', topic);
+
+        return () => {
+            const remaining = (joined.get(topic) || 1) - 1;
+            if (remaining > 0) {
+                joined.set(topic, remaining);
+                return;
+            }
+            joined.delete(topic);
+            sendFrame(socketRef.current, 'leave', topic);
+            setPresence(prev => {
+                const { [topic]: _, ...rest } = prev;
+                return rest;
+            });
+        };
+    }, []);
+
+    const sendTyping = useCallback((topic, isTyping) => {
+        sendFrame(socketRef.current, 'typing', topic, { typing: isTyping });
+    }, []);
+
+    // request runs a method such as 'dish.pledge' over the socket, or over REST
+    // if the socket isn't open. Like axios, it resolves to { data } and
+    // rejects with an error carrying response.status.
+    const request = useCallback((method, params = {}) => {
+        const ws = socketRef.current;
+        if (!ws || ws.readyState !== WebSocket.OPEN) {
+            return restRequests[method](params);
+        }
+        const requestId = String(nextRequestRef.current++);
+        return new Promise((resolve, reject) => {
+            const timer = setTimeout(() => {
+                pendingRef.current.delete(requestId);
+                reject(new Error(`${method} timed out`));
+            }, REQUEST_TIMEOUT);
+            pendingRef.current.set(requestId, { resolve, reject, timer });
+            ws.send(JSON.stringify({ type: 'request', request_id: requestId, method, params }));
+        });
+    }, []);
 
     useEffect(() => {
+        if (!userId) {
+            setSocket(null);
+            return;
+        }
+
+        let closedByUs = false;
+        let reconnectTimer = null;
+        let failedAttempts = 0;
+
         // Connect to WebSocket
         let wsProtocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
         let wsHost = `${window.location.hostname}:5000`;
@@ -24,38 +279,138 @@ export const WebSocketProvider = ({ children }) => {
 
         const wsUrl = `${wsProtocol}//${wsHost}/ws`;
 
-        const ws = new WebSocket(wsUrl);
+        let ws;
+        const connect = () => {
+            ws = new WebSocket(wsUrl);
+            let opened = false;
 
-        ws.onopen = () => {
-            console.log('Connected to WebSocket');
-        };
+            ws.onopen = () => {
+                console.log('Connected to WebSocket');
+                opened = true;
+                failedAttempts = 0;
+                for (const topic of topicsRef.current.keys()) {
+                    sendFrame(ws, 'subscribe', topic, { since: seqRef.current.get(topic) });
+                }
+                for (const topic of joinedRef.current.keys()) {
+                    sendFrame(ws, 'join', topic);
+                }
+            };
 
-        ws.onmessage = (event) => {
-            try {
-                const message = JSON.parse(event.data);
-                setLastMessage(message);
-            } catch (e) {
-                console.error("Failed to parse websocket message", e);
-            }
-        };
+            ws.onmessage = (event) => {
+                try {
+                    handleMessage(JSON.parse(event.data));
+                } catch (e) {
+                    console.error("Failed to parse websocket message", e);
+                }
+            };
+
+            ws.onclose = () => {
+                console.log('Disconnected from WebSocket');
+                // Requests in flight may or may not have run; callers decide
+                // whether to retry.
+                for (const pending of pendingRef.current.values()) {
+                    clearTimeout(pending.timer);
+                    pending.reject(new Error('WebSocket closed'));
+                }
+                pendingRef.current.clear();
+                if (closedByUs) return;
+                if (!opened && ++failedAttempts >= MAX_FAILED_SOCKETS) {
+                    console.warn('WebSockets appear to be blocked; falling back to Server-Sent Events');
+                    socketRef.current = null;
+                    useStreamsRef.current = true;
+                    for (const topic of topicsRef.current.keys()) openStream(topic);
+                    return;
+                }
+                // The socket authenticates with the access token cookie, which may
+                // have expired; /auth/me refreshes it before we reconnect.
+                reconnectTimer = setTimeout(() => {
+                    api.get('/auth/me').catch(() => {}).finally(() => {
+                        if (!closedByUs) connect();
+                    });
+                }, 3000);
+            };
 
-        ws.onclose = () => {
-            console.log('Disconnected from WebSocket');
-            // Implement reconnection logic if needed
+            socketRef.current = ws;
+            setSocket(ws);
         };
 
-        setSocket(ws);
+        connect();
+
+        const heartbeat = setInterval(() => {
+            for (const topic of joinedRef.current.keys()) {
+                sendFrame(socketRef.current, 'heartbeat', topic);
+            }
+        }, PRESENCE_HEARTBEAT);
 
         return () => {
+            closedByUs = true;
+            clearTimeout(reconnectTimer);
+            clearInterval(heartbeat);
+            socketRef.current = null;
             ws.close();
+            useStreamsRef.current = false;
+            for (const source of streamsRef.current.values()) source.close();
+            streamsRef.current.clear();
         };
-    }, []);
+    }, [userId, handleMessage, openStream]);
 
     return (
-        <WebSocketContext.Provider value={{ socket, lastMessage }}>
+        <WebSocketContext.Provider value={{ socket, lastMessage, subscribe, request, presence, typing, joinPresence, sendTyping }}>
             {children}
         </WebSocketContext.Provider>
     );
 };
 
 export const useWebSocket = () => useContext(WebSocketContext);
+
+// useTopic keeps the socket subscribed to topic (e.g. `event:${id}`) while the
+// calling component is mounted. A falsy topic subscribes to nothing.
+export const useTopic = (topic) => {
+    const { subscribe } = useWebSocket();
+    useEffect(() => {
+        if (!topic) return;
+        return subscribe(topic);
+    }, [topic, subscribe]);
+};
+
+// usePresence announces that this tab has an event topic open while the
+// calling component is mounted, and returns who has it open, who is typing in
+// its chat, and setTyping to report our own typing.
+export const usePresence = (topic) => {
+    const { presence, typing, joinPresence, sendTyping } = useWebSocket();
+    const [initial, setInitial] = useState(null);
+    const [now, setNow] = useState(() => Date.now());
+    useTopic(topic);
+
+    useEffect(() => {
+        if (!topic) return;
+        return joinPresence(topic);
+    }, [topic, joinPresence]);
+
+    // Whoever was already there before our first presence_changed
+    useEffect(() => {
+        if (!topic) return;
+        const [, eventId] = topic.split(':');
+        api.get(`/events/${eventId}/presence`)
+            .then(response => setInitial({ topic, members: response.data.members || [] }))
+            .catch(() => {});
+    }, [topic]);
+
+    const typers = typing[topic] || [];
+    // Tick while anyone is typing so indicators that weren't repeated go away
+    useEffect(() => {
+        if (typers.length === 0) return;
+        const timer = setInterval(() => setNow(Date.now()), 1000);
+        return () => clearInterval(timer);
+    }, [typers.length]);
+
+    const setTyping = useCallback((isTyping) => {
+        if (topic) sendTyping(topic, isTyping);
+    }, [topic, sendTyping]);
+
+    return {
+        members: presence[topic] || (initial && initial.topic === topic ? initial.members : []),
+        typing: typers.filter(t => t.until > now).map(t => t.member),
+        setTyping,
+    };
+};
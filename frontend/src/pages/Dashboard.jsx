@@ -9,10 +9,12 @@ import {
     LogOut, Settings, Bell, Search, Filter,
     ChefHat, Clock, ArrowRight, SkipForward, CheckCircle, RefreshCw, Trash2
 } from 'lucide-react';
+import RecurrenceSelect, { describeRecurrence, hostRotations, seriesScopes } from '../components/RecurrenceSelect';
+import { browserTimeZone, durationBetween, formatEventDate, formatEventTime, timeZoneOptions, zonedToISO } from '../utils/eventTime';
 
 const Dashboard = () => {
     const { user, logout } = useAuth();
-    const { lastMessage } = useWebSocket();
+    const { lastMessage, subscribe } = useWebSocket();
     const { showToast, confirm } = useUI();
     const navigate = useNavigate();
     const location = useLocation();
@@ -28,7 +30,10 @@ const Dashboard = () => {
         date: '',
         location: '',
         description: '',
-        recurrence: ''
+        recurrence: '',
+        host_rotation: 'members',
+        end_time: '',
+        time_zone: ''
     });
 
     const fetchGuestEvents = useCallback(async () => {
@@ -83,9 +88,23 @@ const Dashboard = () => {
         }
     }, [selectedGroupId, fetchEvents]);
 
+    // Follow the selected group's events and each event we're a guest of
+    const guestEventKey = guestEvents.map(e => e.id).join(',');
+    useEffect(() => {
+        const topics = guestEventKey ? guestEventKey.split(',').map(id => `event:${id}`) : [];
+        if (selectedGroupId) topics.push(`group:${selectedGroupId}`);
+        const releases = topics.map(topic => subscribe(topic));
+        return () => releases.forEach(release => release());
+    }, [selectedGroupId, guestEventKey, subscribe]);
+
     useEffect(() => {
         if (lastMessage) {
-            if (lastMessage.type === 'event_created' || lastMessage.type === 'event_updated' || lastMessage.type === 'event_deleted') {
+            if (lastMessage.type === 'resync_required') {
+                if (lastMessage.topic === `group:${selectedGroupId}`) fetchEvents();
+                else fetchGuestEvents();
+                return;
+            }
+            if (['event_created', 'event_updated', 'event_deleted', 'event_cancelled'].includes(lastMessage.type)) {
                 // Refresh events if the event belongs to the current group
                 if (selectedGroupId && lastMessage.data.group_id === selectedGroupId) {
                     fetchEvents();
@@ -102,18 +121,27 @@ const Dashboard = () => {
         }
     }, [lastMessage, selectedGroupId, guestEvents, fetchEvents, fetchGuestEvents]);
 
+    // New events default to the group's time zone, or failing that ours
+    const selectedGroup = userGroups.find(g => g.id === selectedGroupId);
+    const newEventTimeZone = newEvent.time_zone || selectedGroup?.time_zone || browserTimeZone();
+
     const handleCreateEvent = async (e) => {
         e.preventDefault();
         try {
+            // The date and end time are wall-clock times where the event happens
+            const { end_time, ...fields } = newEvent;
+            const [day, time] = newEvent.date.split('T');
             await api.post('/events', {
-                ...newEvent,
+                ...fields,
                 group_id: selectedGroupId,
                 host_id: user.id,
-                date: new Date(newEvent.date).toISOString()
+                time_zone: newEventTimeZone,
+                duration_minutes: durationBetween(time, end_time),
+                date: zonedToISO(day, time, newEventTimeZone)
             });
             setShowCreateModal(false);
             fetchEvents();
-            setNewEvent({ name: '', date: '', type: 'Dinner', location: '', description: '', recurrence: '' });
+            setNewEvent({ name: '', date: '', type: 'Dinner', location: '', description: '', recurrence: '', host_rotation: 'members', end_time: '', time_zone: '' });
             setIsCustomType(false);
             showToast("Event created successfully!");
         } catch (error) {
@@ -129,9 +157,10 @@ const Dashboard = () => {
             confirmText: "Complete",
             onConfirm: async () => {
                 try {
-                    await api.post(`/events/${eventId}/finish?admin_id=${user.id}`);
+                    const response = await api.post(`/events/${eventId}/finish?admin_id=${user.id}`);
                     fetchEvents();
-                    showToast("Event completed and next occurrence created");
+                    // The response is the completed event itself once the series has ended
+                    showToast(response.data?.id === eventId ? "Event completed; the series has ended" : "Event completed and next occurrence created");
                 } catch (error) {
                     console.error("Failed to complete event", error);
                     showToast("Failed to complete event", "error");
@@ -158,15 +187,18 @@ const Dashboard = () => {
         });
     };
 
-    const handleDeleteEvent = async (eventId) => {
+    const handleDeleteEvent = async (event) => {
         confirm({
             title: "Delete Event",
-            message: "Are you sure you want to delete this event? This action cannot be undone.",
+            message: event.recurrence
+                ? "This is a recurring event. Which events do you want to delete? This action cannot be undone."
+                : "Are you sure you want to delete this event? This action cannot be undone.",
             confirmText: "Delete Event",
             isDestructive: true,
-            onConfirm: async () => {
+            choices: event.recurrence ? seriesScopes : [],
+            onConfirm: async (scope) => {
                 try {
-                    await api.delete(`/events/${eventId}?user_id=${user.id}`);
+                    await api.delete(`/events/${event.id}?user_id=${user.id}${scope ? `&scope=${scope}` : ''}`);
                     fetchEvents();
                     showToast("Event deleted successfully");
                 } catch (error) {
@@ -267,11 +299,11 @@ const Dashboard = () => {
                                                     {event.type}
                                                 </span>
                                             </div>
-                                            <h3 className="text-lg font-bold text-gray-800 mb-1 group-hover:text-orange-600 transition">{event.name || new Date(event.date).toLocaleDateString(undefined, { weekday: 'long', month: 'long', day: 'numeric' })}</h3>
+                                            <h3 className="text-lg font-bold text-gray-800 mb-1 group-hover:text-orange-600 transition">{event.name || formatEventDate(event, { weekday: 'long', month: 'long', day: 'numeric' })}</h3>
                                             <div className="text-gray-500 text-sm flex items-center gap-4 mb-4">
                                                 <span className="flex items-center gap-1">
                                                     <Clock className="w-4 h-4" />
-                                                    {new Date(event.date).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' })}
+                                                    {formatEventTime(event)}
                                                 </span>
                                                 <span className="flex items-center gap-1">
                                                     <MapPin className="w-4 h-4" />
@@ -340,6 +372,27 @@ const Dashboard = () => {
                                             onChange={e => setNewEvent({ ...newEvent, date: e.target.value })}
                                         />
                                     </div>
+                                    <div>
+                                        <label className="block text-sm font-medium text-gray-700 mb-1">Ends at (optional)</label>
+                                        <input
+                                            type="time"
+                                            className="w-full p-2 border border-gray-200 rounded-lg focus:ring-2 focus:ring-orange-500 outline-none"
+                                            value={newEvent.end_time}
+                                            onChange={e => setNewEvent({ ...newEvent, end_time: e.target.value })}
+                                        />
+                                    </div>
+                                    <div>
+                                        <label className="block text-sm font-medium text-gray-700 mb-1">Time Zone</label>
+                                        <select
+                                            className="w-full p-2 border border-gray-200 rounded-lg focus:ring-2 focus:ring-orange-500 outline-none"
+                                            value={newEventTimeZone}
+                                            onChange={e => setNewEvent({ ...newEvent, time_zone: e.target.value })}
+                                        >
+                                            {[...new Set([newEventTimeZone, ...timeZoneOptions()])].map(zone => (
+                                                <option key={zone} value={zone}>{zone.replace(/_/g, ' ')}</option>
+                                            ))}
+                                        </select>
+                                    </div>
                                     <div>
                                         <label className="block text-sm font-medium text-gray-700 mb-1">Type</label>
                                         <select
@@ -379,7 +432,7 @@ const Dashboard = () => {
                                             id="isRecurring"
                                             className="w-4 h-4 text-orange-600 border-gray-300 rounded focus:ring-orange-500"
                                             checked={!!newEvent.recurrence}
-                                            onChange={e => setNewEvent({ ...newEvent, recurrence: e.target.checked ? 'Weekly' : '' })}
+                                            onChange={e => setNewEvent({ ...newEvent, recurrence: e.target.checked ? 'FREQ=WEEKLY' : '' })}
                                         />
                                         <label htmlFor="isRecurring" className="text-sm font-medium text-gray-700">Recurring Event</label>
                                     </div>
@@ -387,15 +440,24 @@ const Dashboard = () => {
                                     {newEvent.recurrence && (
                                         <div>
                                             <label className="block text-sm font-medium text-gray-700 mb-1">Frequency</label>
-                                            <select
+                                            <RecurrenceSelect
                                                 className="w-full p-2 border border-gray-200 rounded-lg focus:ring-2 focus:ring-orange-500 outline-none"
                                                 value={newEvent.recurrence}
-                                                onChange={e => setNewEvent({ ...newEvent, recurrence: e.target.value })}
+                                                date={newEvent.date}
+                                                onChange={recurrence => setNewEvent({ ...newEvent, recurrence })}
+                                            />
+                                        </div>
+                                    )}
+
+                                    {newEvent.recurrence && (
+                                        <div>
+                                            <label className="block text-sm font-medium text-gray-700 mb-1">Hosts</label>
+                                            <select
+                                                className="w-full p-2 border border-gray-200 rounded-lg focus:ring-2 focus:ring-orange-500 outline-none"
+                                                value={newEvent.host_rotation}
+                                                onChange={e => setNewEvent({ ...newEvent, host_rotation: e.target.value })}
                                             >
-                                                <option value="Daily">Daily</option>
-                                                <option value="Weekly">Weekly</option>
-                                                <option value="Bi-Weekly">Bi-Weekly</option>
-                                                <option value="Monthly">Monthly</option>
+                                                {hostRotations.map(r => <option key={r.value} value={r.value}>{r.label}</option>)}
                                             </select>
                                         </div>
                                     )}
@@ -445,19 +507,19 @@ const Dashboard = () => {
                                                 {event.recurrence && (
                                                     <div className="flex items-center gap-1 text-xs font-medium text-blue-600 bg-blue-50 px-2 py-1 rounded-full">
                                                         <RefreshCw className="w-3 h-3" />
-                                                        {event.recurrence}
+                                                        {describeRecurrence(event.recurrence)}
                                                     </div>
                                                 )}
                                             </div>
 
                                             <h3 className="text-xl font-bold text-gray-800 mb-2 group-hover:text-orange-600 transition">
-                                                {event.name || new Date(event.date).toLocaleDateString(undefined, { weekday: 'long', month: 'long', day: 'numeric' })}
+                                                {event.name || formatEventDate(event, { weekday: 'long', month: 'long', day: 'numeric' })}
                                             </h3>
 
                                             <div className="space-y-2 mb-6">
                                                 <div className="flex items-center gap-2 text-gray-500 text-sm">
                                                     <Clock className="w-4 h-4 text-orange-400" />
-                                                    {new Date(event.date).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' })}
+                                                    {formatEventTime(event)}
                                                 </div>
                                                 <div className="flex items-center gap-2 text-gray-500 text-sm">
                                                     <MapPin className="w-4 h-4 text-orange-400" />
@@ -510,7 +572,7 @@ const Dashboard = () => {
                                                         )}
                                                         {(userGroups.find(g => g.id === selectedGroupId)?.admin_ids?.includes(user?.id) || userGroups.find(g => g.id === selectedGroupId)?.admin_id === user?.id) && (
                                                             <button
-                                                                onClick={() => handleDeleteEvent(event.id)}
+                                                                onClick={() => handleDeleteEvent(event)}
                                                                 className="p-2 text-red-600 hover:bg-red-50 rounded-lg transition"
                                                                 title="Delete Event"
                                                             >
@@ -1,12 +1,12 @@
 import React, { useState, useEffect, useCallback } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
-import { useWebSocket } from '../context/WebSocketContext';
+import { useWebSocket, useTopic, usePresence } from '../context/WebSocketContext';
 import { toast } from 'sonner';
 import api from '../api/axios';
 import {
     Calendar, MapPin, ChefHat, ArrowLeft, CheckCircle,
-    HelpCircle, XCircle, Trash2, Plus, User, RefreshCw, Share2, Copy, BarChart2, Edit, Sparkles
+    HelpCircle, XCircle, Trash2, Plus, User, RefreshCw, Share2, Copy, BarChart2, Edit, Sparkles, AlertTriangle, Ban
 } from 'lucide-react';
 
 import { useUI } from '../context/UIContext';
@@ -14,13 +14,18 @@ import EventStatsModal from '../components/EventStatsModal';
 import EventRSVPModal from '../components/EventRSVPModal';
 import HostSummary from '../components/HostSummary';
 import DietaryPreferencesModal from '../components/DietaryPreferencesModal';
+import CancelEventModal from '../components/CancelEventModal';
+import RecurrenceSelect, { describeRecurrence, hostRotations, seriesScopes } from '../components/RecurrenceSelect';
+import { browserTimeZone, durationBetween, endTime, formatEventDate, formatEventTime, isoToZoned, timeZoneOptions, zonedToISO } from '../utils/eventTime';
 
 const DIETARY_TAGS = ["Vegan", "Vegetarian", "Gluten-Free", "Dairy-Free", "Nut-Free", "Spicy", "Halal", "Kosher"];
 
 const EventDetails = () => {
     const { eventId } = useParams();
     const { user } = useAuth();
-    const { lastMessage } = useWebSocket();
+    const { lastMessage, request } = useWebSocket();
+    useTopic(eventId ? `event:${eventId}` : null);
+    const { members: viewers } = usePresence(eventId ? `event:${eventId}` : null);
     const { showToast, confirm } = useUI();
     const navigate = useNavigate();
 
@@ -34,6 +39,7 @@ const EventDetails = () => {
     const [showRSVPModal, setShowRSVPModal] = useState(false);
     const [showHostAcceptModal, setShowHostAcceptModal] = useState(false);
     const [showStatsModal, setShowStatsModal] = useState(false);
+    const [showCancelModal, setShowCancelModal] = useState(false);
     const [showRSVPListModal, setShowRSVPListModal] = useState(false);
     const [showDietaryModal, setShowDietaryModal] = useState(false);
     const [rsvpStatus, setRsvpStatus] = useState(null);
@@ -44,6 +50,8 @@ const EventDetails = () => {
     const [rsvpData, setRsvpData] = useState({ count: 1, kidsCount: 0 });
     const [hostUpdateData, setHostUpdateData] = useState({ date: '', time: '', location: '' });
     const [isAiThinking, setIsAiThinking] = useState(false);
+    const [occurrences, setOccurrences] = useState([]);
+    const [hostPreview, setHostPreview] = useState([]);
 
 
 
@@ -95,6 +103,28 @@ const EventDetails = () => {
         }
     }, [eventId]);
 
+    // The other scheduled dates of a recurring series, which members can
+    // RSVP and bring dishes to ahead of time
+    const fetchOccurrences = useCallback(async () => {
+        try {
+            const response = await api.get(`/events/${eventId}/occurrences`);
+            setOccurrences(response.data || []);
+        } catch (error) {
+            console.error("Failed to fetch upcoming dates", error);
+        }
+    }, [eventId]);
+
+    // Who hosts the series' next occurrences, including those not scheduled
+    // yet, with hosts who are away on their date flagged
+    const fetchHostPreview = useCallback(async () => {
+        try {
+            const response = await api.get(`/events/${eventId}/hosts`);
+            setHostPreview(Array.isArray(response.data) ? response.data : []);
+        } catch (error) {
+            console.error("Failed to fetch upcoming hosts", error);
+        }
+    }, [eventId]);
+
     const fetchGroupMembers = useCallback(async (groupId) => {
         try {
             const response = await api.get(`/groups/members?group_id=${groupId}`);
@@ -115,7 +145,7 @@ const EventDetails = () => {
 
     const submitRSVP = async (status, count, kidsCount) => {
         try {
-            await api.post('/rsvps', {
+            await request('rsvp.set', {
                 event_id: eventId,
                 family_id: user.id,
                 status: status,
@@ -214,8 +244,8 @@ const EventDetails = () => {
 
     const handleAcceptHostSwapClick = (requestId) => {
         setSelectedSwapRequestId(requestId);
-        // Pre-fill with current event data
-        const eventDate = new Date(event.date);
+        // Pre-fill with current event data, in the event's own time zone
+        const { date, time } = isoToZoned(event.date, event.time_zone);
 
         // Find user's household address to overwrite location
         let userAddress = event.location;
@@ -227,8 +257,8 @@ const EventDetails = () => {
         }
 
         setHostUpdateData({
-            date: eventDate.toISOString().split('T')[0],
-            time: eventDate.toTimeString().slice(0, 5),
+            date,
+            time,
             location: userAddress
         });
         setShowHostAcceptModal(true);
@@ -241,14 +271,11 @@ const EventDetails = () => {
         // Let's just proceed.
 
         try {
-            // Combine date and time
-            const dateTime = new Date(`${hostUpdateData.date}T${hostUpdateData.time}`);
-
             await api.patch(`/swaps/${selectedSwapRequestId}`, {
                 status: 'approved',
                 target_family_id: user.id,
                 event_updates: {
-                    date: dateTime.toISOString(),
+                    date: zonedToISO(hostUpdateData.date, hostUpdateData.time, event.time_zone),
                     location: hostUpdateData.location
                 }
             });
@@ -264,18 +291,22 @@ const EventDetails = () => {
     };
 
     const [showEditEventModal, setShowEditEventModal] = useState(false);
-    const [editEventData, setEditEventData] = useState({ name: '', type: '', date: '', time: '', location: '', description: '', recurrence: '' });
+    const [editEventData, setEditEventData] = useState({ name: '', type: '', date: '', time: '', end_time: '', time_zone: '', location: '', description: '', recurrence: '', host_rotation: '' });
     const [isEditCustomType, setIsEditCustomType] = useState(false);
 
     const handleEditEventClick = () => {
-        const eventDate = new Date(event.date);
+        const timeZone = event.time_zone || browserTimeZone();
+        const { date, time } = isoToZoned(event.date, timeZone);
         setEditEventData({
             name: event.name || '',
-            date: eventDate.toISOString().split('T')[0],
-            time: eventDate.toTimeString().slice(0, 5),
+            date,
+            time,
+            end_time: endTime(time, event.duration_minutes),
+            time_zone: timeZone,
             location: event.location,
             description: event.description || '',
             recurrence: event.recurrence || '',
+            host_rotation: event.host_rotation || 'members',
             type: event.type || ''
         });
         const standardTypes = ['Dinner', 'Lunch', 'Coffee Meet', 'Picnic'];
@@ -283,17 +314,36 @@ const EventDetails = () => {
         setShowEditEventModal(true);
     };
 
-    const handleUpdateEvent = async (e) => {
+    const handleUpdateEvent = (e) => {
         e.preventDefault();
+        if (!event.recurrence) {
+            saveEventDetails();
+            return;
+        }
+        // A new rule or host rotation can't apply to one occurrence alone
+        const ruleChanged = editEventData.recurrence !== event.recurrence
+            || editEventData.host_rotation !== (event.host_rotation || 'members');
+        confirm({
+            title: "Edit Recurring Event",
+            message: "Which events should these changes apply to?",
+            confirmText: "Save",
+            choices: ruleChanged ? seriesScopes.filter(s => s.value !== 'this') : seriesScopes,
+            onConfirm: saveEventDetails
+        });
+    };
+
+    const saveEventDetails = async (scope) => {
         try {
-            const dateTime = new Date(`${editEventData.date}T${editEventData.time}`);
-            await api.patch(`/events/${eventId}`, {
+            await api.patch(`/events/${eventId}${scope ? `?scope=${scope}` : ''}`, {
                 name: editEventData.name,
                 type: editEventData.type,
-                date: dateTime.toISOString(),
+                date: zonedToISO(editEventData.date, editEventData.time, editEventData.time_zone),
+                time_zone: editEventData.time_zone,
+                duration_minutes: durationBetween(editEventData.time, editEventData.end_time),
                 location: editEventData.location,
                 description: editEventData.description,
                 recurrence: editEventData.recurrence,
+                host_rotation: editEventData.host_rotation,
                 user_id: user.id
             });
             setShowEditEventModal(false);
@@ -320,7 +370,7 @@ const EventDetails = () => {
 
     const handlePledgeDish = async (dishId) => {
         try {
-            await api.post(`/dishes/${dishId}/pledge`, { family_id: user.id });
+            await request('dish.pledge', { dish_id: dishId, family_id: user.id });
             fetchDishes();
         } catch (error) {
             console.error("Failed to pledge dish", error);
@@ -336,6 +386,27 @@ const EventDetails = () => {
         });
     };
 
+    const handleUncancelEvent = () => {
+        confirm({
+            title: "Reinstate Event",
+            message: "Put this event back on? Everyone will see it again.",
+            confirmText: "Reinstate",
+            onConfirm: executeUncancelEvent,
+            isDestructive: false
+        });
+    };
+
+    const executeUncancelEvent = async () => {
+        try {
+            await api.post(`/events/${eventId}/uncancel`);
+            fetchEventDetails();
+            showToast("Event is back on!", "success");
+        } catch (error) {
+            console.error("Failed to reinstate event", error);
+            showToast("Failed to reinstate event", "error");
+        }
+    };
+
     const executeUnpledgeDish = async (dishId) => {
         try {
             await api.post(`/dishes/${dishId}/unpledge`);
@@ -407,9 +478,28 @@ const EventDetails = () => {
         }
     }, [event, fetchGroupMembers]);
 
+    useEffect(() => {
+        if (event?.recurrence_id) {
+            fetchOccurrences();
+            fetchHostPreview();
+        } else {
+            setOccurrences([]);
+            setHostPreview([]);
+        }
+    }, [event?.recurrence_id, event?.date, event?.host_id, fetchOccurrences, fetchHostPreview]);
+
     useEffect(() => {
         if (lastMessage) {
             console.log("WebSocket Message Received:", lastMessage);
+            if (lastMessage.type === 'resync_required' && lastMessage.topic === `event:${eventId}`) {
+                // Missed too much while disconnected to catch up message by message
+                fetchEventDetails();
+                fetchDishes();
+                fetchSwapRequests();
+                fetchRSVPs();
+                fetchEventStats();
+                return;
+            }
             if (lastMessage.type === 'rsvp_updated') {
                 const msgEventId = String(lastMessage.data.event_id);
                 const currentEventId = String(eventId);
@@ -491,8 +581,12 @@ const EventDetails = () => {
                     toast.info("Event details updated.");
                 }
             }
+            if (lastMessage.type === 'event_cancelled' && String(lastMessage.data.id) === String(eventId)) {
+                fetchEventDetails();
+                toast.warning(`This event was cancelled: ${lastMessage.data.cancel_reason}`);
+            }
         }
-    }, [lastMessage, eventId, fetchRSVPs, fetchDishes, fetchSwapRequests, fetchEventDetails]);
+    }, [lastMessage, eventId, fetchRSVPs, fetchDishes, fetchSwapRequests, fetchEventDetails, fetchEventStats]);
 
     useEffect(() => {
         fetchGroups();
@@ -522,6 +616,7 @@ const EventDetails = () => {
     const requestedDishes = dishes.filter(d => !d.bringer_id && !d.is_suggested);
     const pledgedDishes = dishes.filter(d => d.bringer_id);
     const suggestedDishes = dishes.filter(d => d.is_suggested && !d.bringer_id);
+    const otherViewers = viewers.filter(v => !user || v.id !== user.id);
 
     // Filter for active (pending) swap requests relevant to the current user
     const activeSwapRequests = swapRequests.filter(req =>
@@ -559,14 +654,31 @@ const EventDetails = () => {
             <main className="max-w-3xl mx-auto px-4 py-8">
                 {/* Event Details Card ... */}
                 <div className="bg-white rounded-xl shadow-sm border border-gray-100 overflow-hidden mb-8">
-                    <div className="h-4 bg-orange-500"></div>
+                    <div className={`h-4 ${event.status === 'cancelled' ? 'bg-gray-400' : 'bg-orange-500'}`}></div>
                     <div className="p-6">
+                        {event.status === 'cancelled' && (
+                            <div className="mb-6 flex items-start gap-3 bg-red-50 border border-red-100 text-red-800 rounded-lg p-4">
+                                <Ban className="w-5 h-5 mt-0.5 shrink-0" />
+                                <div className="flex-1">
+                                    <p className="font-semibold">This event is cancelled</p>
+                                    {event.cancel_reason && <p className="text-sm mt-1">{event.cancel_reason}</p>}
+                                </div>
+                                {(isAdmin || event.host_id === user.id || isHostHousehold) && (
+                                    <button
+                                        onClick={handleUncancelEvent}
+                                        className="text-sm bg-white text-red-700 border border-red-200 px-3 py-1 rounded-lg hover:bg-red-100 transition"
+                                    >
+                                        Reinstate
+                                    </button>
+                                )}
+                            </div>
+                        )}
                         <div className="flex justify-between items-start mb-6">
                             <div>
                                 <h2 className="text-2xl font-bold text-gray-800 mb-2">{event.name || event.type}</h2>
                                 <div className="flex items-center gap-2 text-gray-600 mb-1">
                                     <Calendar className="w-5 h-5 text-orange-500" />
-                                    <span>{new Date(event.date).toLocaleDateString()} at {new Date(event.date).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' })}</span>
+                                    <span>{formatEventDate(event)} at {formatEventTime(event)}</span>
                                 </div>
                                 <div className="flex items-center gap-2 text-gray-600">
                                     <span>{event.location}</span>
@@ -588,7 +700,7 @@ const EventDetails = () => {
                             <div className="flex flex-col items-end gap-2">
                                 {event.recurrence && (
                                     <span className="bg-blue-50 text-blue-600 text-xs px-3 py-1 rounded-full font-medium h-fit">
-                                        {event.recurrence}
+                                        {describeRecurrence(event.recurrence)}
                                     </span>
                                 )}
                                 {(isAdmin || event.host_id === user.id || isHostHousehold) && (
@@ -600,6 +712,15 @@ const EventDetails = () => {
                                         <Edit className="w-5 h-5" />
                                     </button>
                                 )}
+                                {(isAdmin || event.host_id === user.id || isHostHousehold) && event.status !== 'cancelled' && event.status !== 'completed' && (
+                                    <button
+                                        onClick={() => setShowCancelModal(true)}
+                                        className="text-gray-400 hover:text-red-600 p-1 rounded-full hover:bg-red-50 transition"
+                                        title="Cancel Event"
+                                    >
+                                        <Ban className="w-5 h-5" />
+                                    </button>
+                                )}
                             </div>
                         </div>
 
@@ -618,6 +739,47 @@ const EventDetails = () => {
                             </div>
                         )}
 
+                        {occurrences.some(o => o.id !== event.id) && (
+                            <div className="mb-6">
+                                <h3 className="text-sm font-semibold text-gray-700 mb-2">Upcoming Dates</h3>
+                                <div className="flex flex-wrap gap-2">
+                                    {occurrences.filter(o => o.id !== event.id).map(o => (
+                                        <button
+                                            key={o.id}
+                                            onClick={() => navigate(`/events/${o.id}`)}
+                                            className="text-sm text-gray-700 bg-gray-50 hover:bg-orange-50 hover:text-orange-700 px-3 py-1.5 rounded-lg transition"
+                                        >
+                                            {formatEventDate(o, { weekday: 'short', month: 'short', day: 'numeric' })}
+                                            {o.host_name && <span className="text-gray-500"> · {o.host_name}</span>}
+                                        </button>
+                                    ))}
+                                </div>
+                            </div>
+                        )}
+
+                        {hostPreview.length > 0 && (
+                            <div className="mb-6">
+                                <h3 className="text-sm font-semibold text-gray-700 mb-2">Upcoming Hosts</h3>
+                                <ul className="space-y-1">
+                                    {hostPreview.map(p => (
+                                        <li key={p.date} className="flex items-center gap-2 text-sm">
+                                            <span className="w-28 text-gray-500">
+                                                {formatEventDate({ ...event, date: p.date }, { weekday: 'short', month: 'short', day: 'numeric' })}
+                                            </span>
+                                            <span className="text-gray-800">{p.host_name || 'Former member'}</span>
+                                            {!p.event_id && <span className="text-xs text-gray-400">(not scheduled yet)</span>}
+                                            {p.conflict && (
+                                                <span className="flex items-center gap-1 text-xs text-amber-700 bg-amber-50 px-2 py-0.5 rounded-full">
+                                                    <AlertTriangle className="w-3 h-3" />
+                                                    Away that day
+                                                </span>
+                                            )}
+                                        </li>
+                                    ))}
+                                </ul>
+                            </div>
+                        )}
+
                         {event.description && (
                             <div className="bg-gray-50 p-4 rounded-lg mb-8">
                                 <p className="text-gray-700 italic">"{event.description}"</p>
@@ -779,6 +941,13 @@ const EventDetails = () => {
                             <ChefHat className="w-6 h-6 text-orange-600" />
                         </div>
                         <h3 className="text-lg font-bold text-gray-800">Potluck Dishes</h3>
+                        {otherViewers.length > 0 && (
+                            <span className="ml-auto text-sm text-gray-500" title={otherViewers.map(v => v.name).join(', ')}>
+                                {otherViewers.length === 1
+                                    ? `${otherViewers[0].name} is viewing`
+                                    : `${otherViewers[0].name} and ${otherViewers.length - 1} more are viewing`}
+                            </span>
+                        )}
                     </div>
 
                     {/* AI Suggested Dishes */}
@@ -1264,6 +1433,27 @@ const EventDetails = () => {
                                     required
                                 />
                             </div>
+                            <div>
+                                <label className="block text-sm font-medium text-gray-700 mb-1">Ends at</label>
+                                <input
+                                    type="time"
+                                    value={editEventData.end_time}
+                                    onChange={(e) => setEditEventData({ ...editEventData, end_time: e.target.value })}
+                                    className="w-full px-4 py-2 border border-gray-200 rounded-lg focus:ring-2 focus:ring-orange-500 outline-none"
+                                />
+                            </div>
+                            <div>
+                                <label className="block text-sm font-medium text-gray-700 mb-1">Time Zone</label>
+                                <select
+                                    value={editEventData.time_zone}
+                                    onChange={(e) => setEditEventData({ ...editEventData, time_zone: e.target.value })}
+                                    className="w-full px-4 py-2 border border-gray-200 rounded-lg focus:ring-2 focus:ring-orange-500 outline-none"
+                                >
+                                    {[...new Set([editEventData.time_zone, ...timeZoneOptions()])].filter(Boolean).map(zone => (
+                                        <option key={zone} value={zone}>{zone.replace(/_/g, ' ')}</option>
+                                    ))}
+                                </select>
+                            </div>
                             <div>
                                 <label className="block text-sm font-medium text-gray-700 mb-1">Location</label>
                                 <input
@@ -1280,22 +1470,30 @@ const EventDetails = () => {
                                     id="editIsRecurring"
                                     className="w-4 h-4 text-orange-600 border-gray-300 rounded focus:ring-orange-500"
                                     checked={!!editEventData.recurrence}
-                                    onChange={e => setEditEventData({ ...editEventData, recurrence: e.target.checked ? 'Weekly' : '' })}
+                                    onChange={e => setEditEventData({ ...editEventData, recurrence: e.target.checked ? 'FREQ=WEEKLY' : '' })}
                                 />
                                 <label htmlFor="editIsRecurring" className="text-sm font-medium text-gray-700">Recurring Event</label>
                             </div>
                             {editEventData.recurrence && (
                                 <div>
                                     <label className="block text-sm font-medium text-gray-700 mb-1">Frequency</label>
-                                    <select
+                                    <RecurrenceSelect
                                         className="w-full px-4 py-2 border border-gray-200 rounded-lg focus:ring-2 focus:ring-orange-500 outline-none"
                                         value={editEventData.recurrence}
-                                        onChange={e => setEditEventData({ ...editEventData, recurrence: e.target.value })}
+                                        date={editEventData.date && `${editEventData.date}T${editEventData.time || '00:00'}`}
+                                        onChange={recurrence => setEditEventData({ ...editEventData, recurrence })}
+                                    />
+                                </div>
+                            )}
+                            {editEventData.recurrence && (
+                                <div>
+                                    <label className="block text-sm font-medium text-gray-700 mb-1">Hosts</label>
+                                    <select
+                                        className="w-full px-4 py-2 border border-gray-200 rounded-lg focus:ring-2 focus:ring-orange-500 outline-none"
+                                        value={editEventData.host_rotation}
+                                        onChange={e => setEditEventData({ ...editEventData, host_rotation: e.target.value })}
                                     >
-                                        <option value="Daily">Daily</option>
-                                        <option value="Weekly">Weekly</option>
-                                        <option value="Bi-Weekly">Bi-Weekly</option>
-                                        <option value="Monthly">Monthly</option>
+                                        {hostRotations.map(r => <option key={r.value} value={r.value}>{r.label}</option>)}
                                     </select>
                                 </div>
                             )}
@@ -1328,6 +1526,13 @@ const EventDetails = () => {
                 </div>
             )}
 
+            <CancelEventModal
+                isOpen={showCancelModal}
+                onClose={() => setShowCancelModal(false)}
+                event={event}
+                onCancelled={fetchEventDetails}
+            />
+
             <EventStatsModal
                 isOpen={showStatsModal}
                 onClose={() => setShowStatsModal(false)}
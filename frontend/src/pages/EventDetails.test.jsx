@@ -24,7 +24,9 @@ vi.mock('../context/AuthContext', async () => {
 
 vi.mock('../context/WebSocketContext', () => ({
     WebSocketProvider: ({ children }) => <div>{children}</div>,
-    useWebSocket: () => ({ lastMessage: null }),
+    useWebSocket: () => ({ lastMessage: null, request: vi.fn(() => Promise.resolve({ data: {} })) }),
+    useTopic: () => {},
+    usePresence: () => ({ members: [], typing: [], setTyping: () => {} }),
 }));
 
 vi.mock('../context/UIContext', () => ({
@@ -69,6 +71,8 @@ describe('EventDetails Component', () => {
     beforeEach(() => {
         vi.clearAllMocks();
         api.get.mockImplementation((url) => {
+            if (url.includes('/occurrences')) return Promise.resolve({ data: [] });
+            if (url.includes('/hosts')) return Promise.resolve({ data: [] });
             if (url.includes('/events/event1')) return Promise.resolve({ data: mockEvent });
             if (url.includes('/dishes')) return Promise.resolve({ data: [] });
             if (url.includes('/rsvps')) return Promise.resolve({ data: [] });
@@ -109,4 +113,63 @@ describe('EventDetails Component', () => {
             expect(locationInput).toBeInTheDocument();
         });
     });
+
+    test('lists the other upcoming dates of a recurring event', async () => {
+        const recurring = { ...mockEvent, recurrence: 'FREQ=MONTHLY', recurrence_id: 'series1' };
+        api.get.mockImplementation((url) => {
+            if (url.includes('/occurrences')) {
+                return Promise.resolve({
+                    data: [recurring, { ...recurring, id: 'event2', date: '2026-01-25T18:00:00.000Z', host_name: 'Host 2' }],
+                });
+            }
+            if (url.includes('/hosts')) return Promise.resolve({ data: [] });
+            if (url.includes('/events/event1')) return Promise.resolve({ data: recurring });
+            if (url.includes('/groups/members')) return Promise.resolve({ data: mockGroupMembers });
+            return Promise.resolve({ data: [] });
+        });
+
+        render(
+            <MemoryRouter initialEntries={['/events/event1']}>
+                <Routes>
+                    <Route path="/events/:eventId" element={<EventDetails />} />
+                </Routes>
+            </MemoryRouter>
+        );
+
+        await waitFor(() => expect(screen.getByText('Upcoming Dates')).toBeInTheDocument());
+        expect(api.get).toHaveBeenCalledWith('/events/event1/occurrences');
+        expect(screen.getByText(/Host 2/)).toBeInTheDocument();
+    });
+
+    test('flags upcoming hosts who are away on their date', async () => {
+        const recurring = { ...mockEvent, recurrence: 'FREQ=WEEKLY', recurrence_id: 'series1' };
+        api.get.mockImplementation((url) => {
+            if (url.includes('/occurrences')) return Promise.resolve({ data: [recurring] });
+            if (url.includes('/hosts')) {
+                return Promise.resolve({
+                    data: [
+                        { date: '2026-01-18T18:00:00.000Z', event_id: 'event1', host_id: 'host1', host_name: 'Ann', conflict: false },
+                        { date: '2026-01-25T18:00:00.000Z', host_id: 'host2', host_name: 'Bob', conflict: true },
+                    ],
+                });
+            }
+            if (url.includes('/events/event1')) return Promise.resolve({ data: recurring });
+            if (url.includes('/groups/members')) return Promise.resolve({ data: mockGroupMembers });
+            return Promise.resolve({ data: [] });
+        });
+
+        render(
+            <MemoryRouter initialEntries={['/events/event1']}>
+                <Routes>
+                    <Route path="/events/:eventId" element={<EventDetails />} />
+                </Routes>
+            </MemoryRouter>
+        );
+
+        await waitFor(() => expect(screen.getByText('Upcoming Hosts')).toBeInTheDocument());
+        expect(api.get).toHaveBeenCalledWith('/events/event1/hosts');
+        expect(screen.getByText('Bob')).toBeInTheDocument();
+        expect(screen.getByText('(not scheduled yet)')).toBeInTheDocument();
+        expect(screen.getAllByText('Away that day')).toHaveLength(1);
+    });
 });
@@ -3,8 +3,13 @@ import { useAuth } from '../context/AuthContext';
 import { useUI } from '../context/UIContext';
 import api from '../api/axios';
 import { useNavigate } from 'react-router-dom';
-import { Plus, Trash2, Share2, Copy, LogOut, Users, Pencil } from 'lucide-react';
+import { Plus, Trash2, Share2, Copy, LogOut, Users, Pencil, CalendarOff, Calendar } from 'lucide-react';
 import ManageHouseholdModal from '../components/ManageHouseholdModal';
+import HostOrderModal from '../components/HostOrderModal';
+import AvailabilityModal from '../components/AvailabilityModal';
+import CalendarFeedModal from '../components/CalendarFeedModal';
+import ImportCalendarModal from '../components/ImportCalendarModal';
+import { browserTimeZone } from '../utils/eventTime';
 
 const Groups = () => {
     const { user, refreshUser } = useAuth();
@@ -23,6 +28,10 @@ const Groups = () => {
     const [viewMembersModalOpen, setViewMembersModalOpen] = useState(false);
     const [currentGroupMembers, setCurrentGroupMembers] = useState([]);
     const [viewingGroup, setViewingGroup] = useState(null);
+    const [hostOrderModalOpen, setHostOrderModalOpen] = useState(false);
+    const [availabilityModalOpen, setAvailabilityModalOpen] = useState(false);
+    const [calendarModalOpen, setCalendarModalOpen] = useState(false);
+    const [importModalOpen, setImportModalOpen] = useState(false);
 
     const fetchGroups = useCallback(async () => {
         try {
@@ -47,7 +56,9 @@ const Groups = () => {
         try {
             await api.post('/groups', {
                 name: newGroupName,
-                admin_id: user.id
+                admin_id: user.id,
+                // The group's events default to its creator's time zone
+                time_zone: browserTimeZone()
             });
             fetchGroups();
             setNewGroupName('');
@@ -218,13 +229,29 @@ const Groups = () => {
                 <div className="text-center mb-12">
                     <h1 className="text-3xl font-bold text-gray-800 mb-2">Welcome, {user?.name}!</h1>
                     <p className="text-gray-600 mb-4">Manage your gathering circles.</p>
-                    <button
-                        onClick={() => setManageHouseholdModalOpen(true)}
-                        className="text-sm text-purple-600 font-medium hover:text-purple-800 flex items-center justify-center gap-1 mx-auto"
-                    >
-                        <Users className="w-4 h-4" />
-                        Manage Household
-                    </button>
+                    <div className="flex items-center justify-center gap-6">
+                        <button
+                            onClick={() => setManageHouseholdModalOpen(true)}
+                            className="text-sm text-purple-600 font-medium hover:text-purple-800 flex items-center justify-center gap-1"
+                        >
+                            <Users className="w-4 h-4" />
+                            Manage Household
+                        </button>
+                        <button
+                            onClick={() => setAvailabilityModalOpen(true)}
+                            className="text-sm text-orange-600 font-medium hover:text-orange-800 flex items-center justify-center gap-1"
+                        >
+                            <CalendarOff className="w-4 h-4" />
+                            My Availability
+                        </button>
+                        <button
+                            onClick={() => setCalendarModalOpen(true)}
+                            className="text-sm text-blue-600 font-medium hover:text-blue-800 flex items-center justify-center gap-1"
+                        >
+                            <Calendar className="w-4 h-4" />
+                            Calendar Links
+                        </button>
+                    </div>
                 </div>
 
                 <div className="grid md:grid-cols-2 gap-8">
@@ -393,9 +420,27 @@ const Groups = () => {
                     <div className="bg-white rounded-xl shadow-xl max-w-md w-full p-6 transform transition-all scale-100">
                         <div className="flex justify-between items-center mb-6">
                             <h3 className="text-xl font-bold text-gray-800">Members of {viewingGroup?.name}</h3>
-                            <button onClick={() => setViewMembersModalOpen(false)} className="text-gray-400 hover:text-gray-600 transition">
-                                <Plus className="w-6 h-6 rotate-45" />
-                            </button>
+                            <div className="flex items-center gap-3">
+                                {(viewingGroup?.admin_ids?.includes(user.id) || viewingGroup?.admin_id === user.id) && (
+                                    <button
+                                        onClick={() => setHostOrderModalOpen(true)}
+                                        className="text-xs bg-orange-50 text-orange-700 px-2 py-1 rounded-full font-medium hover:bg-orange-100 transition"
+                                    >
+                                        Host Order
+                                    </button>
+                                )}
+                                {(viewingGroup?.admin_ids?.includes(user.id) || viewingGroup?.admin_id === user.id) && (
+                                    <button
+                                        onClick={() => setImportModalOpen(true)}
+                                        className="text-xs bg-blue-50 text-blue-700 px-2 py-1 rounded-full font-medium hover:bg-blue-100 transition"
+                                    >
+                                        Import Calendar
+                                    </button>
+                                )}
+                                <button onClick={() => setViewMembersModalOpen(false)} className="text-gray-400 hover:text-gray-600 transition">
+                                    <Plus className="w-6 h-6 rotate-45" />
+                                </button>
+                            </div>
                         </div>
 
                         <div className="space-y-3 max-h-80 overflow-y-auto pr-2">
@@ -491,6 +536,41 @@ const Groups = () => {
                 </div>
             )}
 
+            <HostOrderModal
+                isOpen={hostOrderModalOpen}
+                onClose={() => setHostOrderModalOpen(false)}
+                group={viewingGroup}
+                userId={user.id}
+                onSaved={(updates) => {
+                    setViewingGroup({ ...viewingGroup, ...updates });
+                    setGroups(groups.map(g => g.id === viewingGroup.id ? { ...g, ...updates } : g));
+                }}
+            />
+
+            <AvailabilityModal
+                isOpen={availabilityModalOpen}
+                onClose={() => setAvailabilityModalOpen(false)}
+                title="My Availability"
+                path={`/families/${user.id}`}
+                unavailable={user.unavailable}
+                onSaved={refreshUser}
+            />
+
+            <ImportCalendarModal
+                isOpen={importModalOpen}
+                onClose={() => setImportModalOpen(false)}
+                group={viewingGroup}
+                userId={user.id}
+            />
+
+            <CalendarFeedModal
+                isOpen={calendarModalOpen}
+                onClose={() => setCalendarModalOpen(false)}
+                user={user}
+                groups={groups}
+                refreshUser={refreshUser}
+            />
+
             <ManageHouseholdModal
                 isOpen={manageHouseholdModalOpen}
                 onClose={() => {
@@ -64,7 +64,8 @@ describe('Groups Page', () => {
         await waitFor(() => {
             expect(api.post).toHaveBeenCalledWith('/groups', {
                 name: 'New Group',
-                admin_id: 'user1'
+                admin_id: 'user1',
+                time_zone: expect.any(String)
             });
             expect(mockShowToast).toHaveBeenCalledWith('Group created successfully!');
             expect(mockRefreshUser).toHaveBeenCalled();
@@ -2,6 +2,7 @@ import React, { useState, useEffect } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
 import api from '../api/axios';
+import { formatEventDate, formatEventTime } from '../utils/eventTime';
 import { Calendar, MapPin, User, ArrowRight, CheckCircle, Clock } from 'lucide-react';
 
 const JoinEvent = () => {
@@ -106,11 +107,11 @@ const JoinEvent = () => {
                     <div className="space-y-2 text-sm text-gray-600">
                         <div className="flex items-center gap-2">
                             <Calendar className="w-4 h-4 text-orange-500" />
-                            <span>{new Date(event.date).toLocaleDateString()}</span>
+                            <span>{formatEventDate(event)}</span>
                         </div>
                         <div className="flex items-center gap-2">
                             <Clock className="w-4 h-4 text-orange-500" />
-                            <span>{new Date(event.date).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' })}</span>
+                            <span>{formatEventTime(event)}</span>
                         </div>
                         <div className="flex items-center gap-2">
                             <MapPin className="w-4 h-4 text-orange-500" />
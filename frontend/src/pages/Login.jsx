@@ -1,12 +1,23 @@
 import React from 'react';
 import { GoogleLogin } from '@react-oauth/google';
 import { useAuth } from '../context/AuthContext';
+import api from '../api/axios';
 import { useNavigate } from 'react-router-dom';
 import { UtensilsCrossed } from 'lucide-react';
 
 const Login = () => {
-    const { user, login } = useAuth();
+    const { user, login, loginWith } = useAuth();
     const navigate = useNavigate();
+    const [devUsers, setDevUsers] = React.useState([]);
+
+    React.useEffect(() => {
+        api.get('/auth/providers')
+            .then((response) => {
+                const dev = response.data.find((p) => p.name === 'dev');
+                setDevUsers(dev?.users || []);
+            })
+            .catch(() => setDevUsers([]));
+    }, []);
 
     React.useEffect(() => {
         if (user) {
@@ -23,6 +34,15 @@ const Login = () => {
         }
     };
 
+    const handleDevLogin = async (email) => {
+        try {
+            await loginWith('dev', { email });
+            navigate('/groups');
+        } catch (error) {
+            console.error("Login Failed", error);
+        }
+    };
+
     return (
         <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-orange-100 to-amber-50">
             <div className="bg-white p-8 rounded-2xl shadow-xl w-full max-w-md text-center">
@@ -48,6 +68,23 @@ const Login = () => {
                 <p className="mt-6 text-sm text-gray-400">
                     Sign in with Google to join your family group.
                 </p>
+
+                {devUsers.length > 0 && (
+                    <div className="mt-6 pt-6 border-t border-gray-100">
+                        <p className="text-xs uppercase tracking-wide text-gray-400 mb-3">Development sign-in</p>
+                        <div className="flex flex-col gap-2">
+                            {devUsers.map((u) => (
+                                <button
+                                    key={u.email}
+                                    onClick={() => handleDevLogin(u.email)}
+                                    className="px-4 py-2 rounded-lg border border-gray-200 text-gray-700 hover:bg-orange-50 transition-colors"
+                                >
+                                    {u.name} <span className="text-gray-400">({u.email})</span>
+                                </button>
+                            ))}
+                        </div>
+                    </div>
+                )}
             </div>
         </div>
     );
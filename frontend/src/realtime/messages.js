@@ -0,0 +1,29 @@
+import schema from './schema.json';
+
+// schema.json is generated from the backend's realtime package; see
+// backend/internal/realtime. It describes every message type the server
+// publishes.
+
+export const MESSAGE_TYPES = schema.oneOf.map((ref) => ref.$ref.split('/').pop());
+
+const definitions = schema.$defs;
+
+// validateMessage checks a published message's envelope and the top-level
+// fields of its data against the schema, returning a description of the first
+// problem found or null. It is deliberately shallow; it catches a frontend and
+// backend that disagree about a message, not every malformed value.
+export const validateMessage = (message) => {
+    const definition = definitions[message?.type];
+    if (!definition) return `unknown message type ${message?.type}`;
+
+    const { properties, required } = definition;
+    if (message.version !== properties.version.const) {
+        return `${message.type}: version ${message.version}, expected ${properties.version.const}`;
+    }
+    const missing = required.find((field) => !(field in message));
+    if (missing) return `${message.type}: missing ${missing}`;
+
+    const missingData = (properties.data.required || []).find((field) => !(field in message.data));
+    if (missingData) return `${message.type}: data missing ${missingData}`;
+    return null;
+};
@@ -0,0 +1,83 @@
+// Events happen at a wall-clock time in their own IANA time zone, which may
+// not be the browser's. These helpers convert between that wall-clock time
+// and the UTC instants the API uses.
+
+export const browserTimeZone = () => Intl.DateTimeFormat().resolvedOptions().timeZone || 'UTC';
+
+// timeZoneOptions lists the zones to offer, with the browser's first.
+export const timeZoneOptions = () => {
+    const zones = typeof Intl.supportedValuesOf === 'function' ? Intl.supportedValuesOf('timeZone') : [];
+    const own = browserTimeZone();
+    return [own, ...zones.filter(z => z !== own)];
+};
+
+// zoneParts returns the wall-clock fields of instant in timeZone.
+const zoneParts = (instant, timeZone) => {
+    const parts = {};
+    new Intl.DateTimeFormat('en-US', {
+        timeZone, hourCycle: 'h23',
+        year: 'numeric', month: '2-digit', day: '2-digit', hour: '2-digit', minute: '2-digit', second: '2-digit',
+    }).formatToParts(instant).forEach(({ type, value }) => { parts[type] = value; });
+    return parts;
+};
+
+// offsetAt is how far timeZone is ahead of UTC at instant, in milliseconds.
+const offsetAt = (instant, timeZone) => {
+    const p = zoneParts(instant, timeZone);
+    const asUTC = Date.UTC(+p.year, +p.month - 1, +p.day, +p.hour, +p.minute, +p.second);
+    return asUTC - Math.floor(instant.getTime() / 1000) * 1000;
+};
+
+// zonedToISO turns a date ("2025-03-09") and time ("18:00") in timeZone into
+// an ISO instant. Times skipped by a daylight saving change move forward.
+export const zonedToISO = (date, time, timeZone) => {
+    const [y, mo, d] = date.split('-').map(Number);
+    const [h, mi] = (time || '00:00').split(':').map(Number);
+    const wall = Date.UTC(y, mo - 1, d, h, mi);
+    const zone = timeZone || browserTimeZone();
+    let instant = new Date(wall - offsetAt(new Date(wall), zone));
+    // The offset may differ either side of a change; a second pass settles it
+    instant = new Date(wall - offsetAt(instant, zone));
+    return instant.toISOString();
+};
+
+// isoToZoned is the inverse of zonedToISO, for filling in forms.
+export const isoToZoned = (iso, timeZone) => {
+    const p = zoneParts(new Date(iso), timeZone || browserTimeZone());
+    return { date: `${p.year}-${p.month}-${p.day}`, time: `${p.hour}:${p.minute}` };
+};
+
+// formatEventDate and formatEventTime show an event's date and time as they
+// are where it happens; the time carries the zone's abbreviation when that
+// differs from the browser's.
+export const formatEventDate = (event, options = {}) =>
+    new Date(event.date).toLocaleDateString(undefined, { timeZone: event.time_zone || undefined, ...options });
+
+export const formatEventTime = (event) => {
+    const elsewhere = event.time_zone && event.time_zone !== browserTimeZone();
+    return new Date(event.date).toLocaleTimeString([], {
+        hour: '2-digit', minute: '2-digit',
+        timeZone: event.time_zone || undefined,
+        timeZoneName: elsewhere ? 'short' : undefined,
+    });
+};
+
+// durationBetween returns the minutes from start to end ("HH:mm" times on the
+// same evening), treating an end before the start as past midnight.
+export const durationBetween = (start, end) => {
+    if (!start || !end) return 0;
+    const toMinutes = (t) => {
+        const [h, m] = t.split(':').map(Number);
+        return h * 60 + m;
+    };
+    const minutes = toMinutes(end) - toMinutes(start);
+    return minutes > 0 ? minutes : minutes + 24 * 60;
+};
+
+// endTime is the "HH:mm" an event starting at start lasting minutes ends.
+export const endTime = (start, minutes) => {
+    if (!start || !minutes) return '';
+    const [h, m] = start.split(':').map(Number);
+    const total = (h * 60 + m + minutes) % (24 * 60);
+    return `${String(Math.floor(total / 60)).padStart(2, '0')}:${String(total % 60).padStart(2, '0')}`;
+};
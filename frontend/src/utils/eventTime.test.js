@@ -0,0 +1,19 @@
+import { durationBetween, endTime, isoToZoned, zonedToISO } from './eventTime';
+
+describe('eventTime', () => {
+    it('converts wall-clock times in a zone to instants and back', () => {
+        expect(zonedToISO('2025-03-02', '18:00', 'America/New_York')).toBe('2025-03-02T23:00:00.000Z');
+        // After clocks go forward, 6pm is an hour earlier in UTC
+        expect(zonedToISO('2025-03-09', '18:00', 'America/New_York')).toBe('2025-03-09T22:00:00.000Z');
+        expect(isoToZoned('2025-03-09T22:00:00.000Z', 'America/New_York')).toEqual({ date: '2025-03-09', time: '18:00' });
+        expect(isoToZoned('2025-03-09T22:00:00.000Z', 'Asia/Tokyo')).toEqual({ date: '2025-03-10', time: '07:00' });
+    });
+
+    it('works out durations from end times', () => {
+        expect(durationBetween('18:00', '21:30')).toBe(210);
+        expect(durationBetween('22:00', '01:00')).toBe(180);
+        expect(durationBetween('18:00', '')).toBe(0);
+        expect(endTime('22:00', 180)).toBe('01:00');
+        expect(endTime('18:00', 0)).toBe('');
+    });
+});